@@ -11,14 +11,23 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"gorm.io/gorm"
 
 	"lovable-backend/internal/config"
 	"lovable-backend/internal/database"
 	"lovable-backend/internal/handlers"
+	"lovable-backend/internal/metrics"
 	"lovable-backend/internal/middleware"
 	"lovable-backend/internal/redis"
 	"lovable-backend/internal/services"
+	"lovable-backend/internal/shutdown"
+	"lovable-backend/internal/storage"
+	"lovable-backend/internal/telemetry"
+	"lovable-backend/internal/worker"
+	"lovable-backend/internal/ws"
 	"lovable-backend/pkg/logger"
 )
 
@@ -34,16 +43,46 @@ func main() {
 	// Initialize logger
 	logger := logger.New(cfg.Environment)
 
+	// Fields that can change at runtime are read through the config store
+	configStore := config.NewConfigStore(cfg)
+	configReloader := config.NewConfigReloader(configStore, logger)
+
+	// Initialize tracing
+	shutdownTracing, err := telemetry.Init(context.Background(), cfg.Telemetry)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", "error", err)
+	}
+
 	// Initialize database
 	db, err := database.Connect(cfg.Database)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", "error", err)
 	}
 
-	// Run migrations
-	if err := database.Migrate(db); err != nil {
-		logger.Fatal("Failed to run migrations", "error", err)
+	// Run migrations only when the schema is behind this build
+	upToDate, err := database.IsUpToDate(db)
+	if err != nil {
+		logger.Warn("Failed to check schema version, running migrations", "error", err)
+	}
+	if !upToDate {
+		pending, _ := database.PendingMigrations(db)
+		logger.Info("Applying database migrations", "pending", pending)
+		if err := database.Migrate(db); err != nil {
+			logger.Fatal("Failed to run migrations", "error", err)
+		}
+	}
+
+	// Connect read replicas; unreachable ones are skipped and reads fall back to the primary
+	var replicas []*gorm.DB
+	for i, dsn := range cfg.Database.ReadReplicaDSNs {
+		replica, err := database.ConnectReadReplica(dsn)
+		if err != nil {
+			logger.Warn("Read replica unavailable", "replica", i, "error", err)
+			continue
+		}
+		replicas = append(replicas, replica)
 	}
+	dbRouter := database.NewRouter(db, replicas)
 
 	// Initialize Redis
 	redisClient := redis.Connect(cfg.Redis)
@@ -51,17 +90,96 @@ func main() {
 		logger.Warn("Redis connection failed, continuing without cache")
 	}
 
+	// Initialize project code storage
+	storageBackend, err := storage.New(context.Background(), cfg, db)
+	if err != nil {
+		logger.Fatal("Failed to initialize storage backend", "error", err)
+	}
+
+	// WebSocket connections, shared by the AI handlers and notifications
+	wsHub := ws.NewWSHub()
+	if cfg.Redis.PubSubEnabled && redisClient != nil {
+		if err := wsHub.UsePubSub(redis.NewRedisPubSub(redisClient)); err != nil {
+			logger.Error("Failed to subscribe to WebSocket pub/sub, broadcasting locally", "error", err)
+		}
+	}
+
 	// Initialize services
-	authService := services.NewAuthService(db, redisClient, cfg.JWT)
-	aiService := services.NewAIService(cfg.AI, redisClient)
-	projectService := services.NewProjectService(db, redisClient)
-	exportService := services.NewExportService(db)
+	emailService := services.NewEmailService(cfg.Email, logger)
+	notificationService := services.NewNotificationService(dbRouter, emailService, wsHub, logger)
+	authService := services.NewAuthService(dbRouter, redisClient, cfg.JWT, cfg.Subscription, cfg.Security, emailService, logger)
+	aiService := services.NewAIService(configStore, redisClient, dbRouter)
+	thumbnailService := services.NewThumbnailService(db, redisClient, storageBackend, cfg.Thumbnail, logger)
+	projectService := services.NewProjectService(dbRouter, redisClient, aiService, authService, storageBackend, cfg.StorageMode, cfg.URLImport, cfg.Versions, thumbnailService, logger)
+	exportService := services.NewExportService(dbRouter, storageBackend, redisClient, cfg.Export)
+	billingService := services.NewBillingService(db, emailService, cfg.Subscription, logger)
+	auditService := services.NewAuditService(db)
+	accountService := services.NewAccountService(dbRouter, storageBackend, authService, notificationService, logger)
+	samlService, err := services.NewSAMLService(context.Background(), cfg.SAML, db, redisClient, authService)
+	if err != nil {
+		logger.Fatal("Failed to initialize SAML SSO", "error", err)
+	}
+	oauthService := services.NewOAuthService(cfg.GoogleOAuth, db, redisClient, authService)
+	collaborationService := services.NewCollaborationService(db, emailService, logger)
+	moderationService := services.NewModerationService(db, aiService, cfg.Moderation, logger)
+	conversationAnalyticsService := services.NewConversationAnalyticsService(db, redisClient)
+	searchIndexWorker := services.NewSearchIndexWorker(db, redisClient, storageBackend, logger)
+	notificationBatcher := services.NewNotificationBatcher(db, redisClient, emailService, logger)
+	thumbnailWorker := services.NewThumbnailWorker(db, redisClient, storageBackend, exportService, logger)
+	webhookDispatcher := services.NewWebhookDispatcher(db, redisClient, emailService, logger)
+	preferenceService := services.NewPreferenceService(dbRouter, redisClient, cfg.Preferences)
+	adminService := services.NewAdminService(dbRouter, authService, accountService, auditService, logger)
+	promptTemplateService := services.NewPromptTemplateService(dbRouter)
+	promptVariantService := services.NewPromptVariantService(dbRouter, cfg.AI.PromptVariantMinSamples)
+	collectionService := services.NewCollectionService(dbRouter, redisClient)
+	templateService := services.NewTemplateService(dbRouter, storageBackend, aiService)
+
+	shutdownMonitor := shutdown.NewShutdownMonitor(logger)
+	aiJobQueue := services.NewAIJobQueue(db, aiService, projectService, authService, wsHub, notificationService, cfg.AI.WorkerPoolSize, logger)
+	jobPool := worker.NewPool(db, cfg.Jobs.Concurrency, logger)
+	jobPool.Register(services.JobTypeEmail, emailService.HandleEmailJob)
+	jobPool.Register(services.JobTypeThumbnail, thumbnailService.HandleThumbnailJob)
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService, logger)
+	authHandler := handlers.NewAuthHandler(authService, auditService, accountService, notificationService, logger)
 	projectHandler := handlers.NewProjectHandler(projectService, logger)
-	aiHandler := handlers.NewAIHandler(aiService, projectService, logger)
+	aiHandler := handlers.NewAIHandler(aiService, projectService, authService, moderationService, shutdownMonitor, notificationBatcher, aiJobQueue, promptTemplateService, wsHub, cfg.MaxAIRequestBodyBytes, logger)
 	exportHandler := handlers.NewExportHandler(exportService, logger)
+	webhookHandler := handlers.NewWebhookHandler(webhookDispatcher, logger)
+	billingHandler := handlers.NewBillingHandler(billingService, logger)
+	adminHandler := handlers.NewAdminHandler(adminService, moderationService, projectService, auditService, templateService, promptVariantService, webhookDispatcher, logger)
+	samlHandler := handlers.NewSAMLHandler(samlService, authService, cfg.FrontendURL, logger)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, authService, auditService, logger)
+	analyticsHandler := handlers.NewAnalyticsHandler(conversationAnalyticsService, logger)
+	collaborationHandler := handlers.NewCollaborationHandler(collaborationService, logger)
+	preferenceHandler := handlers.NewPreferenceHandler(preferenceService, logger)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, logger)
+	promptTemplateHandler := handlers.NewPromptTemplateHandler(promptTemplateService, logger)
+	collectionHandler := handlers.NewCollectionHandler(collectionService, logger)
+	templateHandler := handlers.NewTemplateHandler(templateService, logger)
+	healthHandler := handlers.NewHealthHandler(db, redisClient, aiService, cfg.Environment)
+
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	// The job pool has its own context so it can be drained before the
+	// workers its jobs depend on are stopped
+	jobPoolCtx, stopJobPool := context.WithCancel(context.Background())
+	go billingService.StartDowngradeWorker(workerCtx)
+	go authService.StartGuestPurgeWorker(workerCtx, logger)
+	go projectService.StartVersionPruneWorker(workerCtx, logger)
+	go projectService.StartBatchImportSweepWorker(workerCtx, logger)
+	go projectService.StartReplaySweepWorker(workerCtx, logger)
+	go promptVariantService.StartPromotionWorker(workerCtx, logger)
+	go moderationService.WatchBlocklist(workerCtx)
+	go searchIndexWorker.StartWorker(workerCtx)
+	go dbRouter.StartHealthCheck(workerCtx, logger)
+	go notificationBatcher.StartWorker(workerCtx)
+	go thumbnailWorker.StartWorker(workerCtx)
+	go aiJobQueue.StartWorkers(workerCtx)
+	go jobPool.Start(jobPoolCtx)
+	go webhookDispatcher.StartWorker(workerCtx)
+	go webhookDispatcher.StartDeadLetterPurgeWorker(workerCtx)
+	go aiService.StartPerformanceReportWorker(workerCtx, time.Duration(cfg.AI.PerformanceReportIntervalHours)*time.Hour)
 
 	// Setup Gin router
 	if cfg.Environment == "production" {
@@ -72,30 +190,50 @@ func main() {
 
 	// Middleware
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
+	router.Use(middleware.Metrics())
+	router.Use(otelgin.Middleware(cfg.Telemetry.ServiceName))
 	router.Use(middleware.Security())
+	router.Use(middleware.BodySizeLimit(cfg.MaxRequestBodyBytes))
+	router.Use(middleware.Compress(cfg.Compression.Level, cfg.Compression.MinBytes))
 
 	// CORS configuration
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = []string{cfg.FrontendURL}
+	corsConfig.AllowOriginFunc = func(origin string) bool {
+		for _, allowed := range configStore.AllowOrigins() {
+			if allowed == "*" || allowed == origin {
+				return true
+			}
+		}
+		return false
+	}
 	corsConfig.AllowCredentials = true
 	corsConfig.AllowHeaders = []string{"*"}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	router.Use(cors.New(corsConfig))
 
+	// Health and probe endpoints are registered ahead of rate limiting so
+	// they are never throttled
+	router.GET("/health", healthHandler.Health)
+	router.GET("/readyz", healthHandler.Ready)
+	router.GET("/livez", healthHandler.Live)
+
 	// Rate limiting
-	rateLimiter := middleware.NewRateLimiter(redisClient)
+	rateLimiter := middleware.NewRateLimiter(redisClient, configStore)
 	router.Use(rateLimiter.GlobalLimit())
+	rateLimitHandler := handlers.NewRateLimitHandler(rateLimiter, logger)
 
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":      "healthy",
-			"timestamp":   time.Now().Format(time.RFC3339),
-			"version":     "1.0.0",
-			"environment": cfg.Environment,
-		})
-	})
+	// Replays responses to retried requests that create things
+	idempotency := middleware.Idempotency(redisClient, time.Duration(cfg.Idempotency.TTLHours)*time.Hour)
+
+	// Tighter body limits than the global one. ZIP uploads allow the largest
+	// importable archive plus multipart overhead.
+	aiBodyLimit := middleware.BodySizeLimit(cfg.MaxAIRequestBodyBytes)
+	zipUploadLimit := middleware.BodySizeLimit(services.MaxZIPImportSize + 1<<20)
+
+	// Prometheus scrape endpoint
+	router.GET("/metrics", middleware.MetricsAuth(cfg.Metrics.Token), gin.WrapH(metrics.Handler()))
 
 	// API routes
 	api := router.Group("/api")
@@ -106,13 +244,45 @@ func main() {
 			auth.POST("/register", rateLimiter.AuthLimit(), authHandler.Register)
 			auth.POST("/login", rateLimiter.AuthLimit(), authHandler.Login)
 			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/verify-email", rateLimiter.AuthLimit(), authHandler.VerifyEmail)
+			auth.POST("/resend-verification", middleware.Auth(authService), rateLimiter.AuthLimit(), authHandler.ResendVerification)
 			auth.POST("/logout", middleware.Auth(authService), authHandler.Logout)
 			auth.GET("/me", middleware.Auth(authService), authHandler.GetProfile)
 			auth.PUT("/me", middleware.Auth(authService), authHandler.UpdateProfile)
 			auth.PUT("/password", middleware.Auth(authService), authHandler.ChangePassword)
+			auth.POST("/forgot-password", rateLimiter.AuthLimit(), authHandler.ForgotPassword)
+			auth.POST("/reset-password", rateLimiter.AuthLimit(), authHandler.ResetPassword)
+			auth.POST("/mfa/setup", middleware.Auth(authService), authHandler.SetupMFA)
+			auth.POST("/mfa/enable", middleware.Auth(authService), rateLimiter.AuthLimit(), authHandler.EnableMFA)
+			auth.POST("/mfa/disable", middleware.Auth(authService), rateLimiter.AuthLimit(), authHandler.DisableMFA)
+			auth.POST("/mfa/verify", rateLimiter.AuthLimit(), authHandler.VerifyMFA)
+			auth.POST("/claim-guest", middleware.Auth(authService), authHandler.ClaimGuest)
+			auth.GET("/audit-log/export", middleware.Auth(authService), authHandler.ExportAuditLog)
+			auth.GET("/sessions", middleware.Auth(authService), authHandler.ListSessions)
+			auth.DELETE("/sessions", middleware.Auth(authService), authHandler.RevokeOtherSessions)
+			auth.DELETE("/sessions/:sessionID", middleware.Auth(authService), authHandler.RevokeSession)
+			auth.POST("/export-data", middleware.Auth(authService), authHandler.ExportUserData)
+			auth.GET("/export-data/:jobId", middleware.Auth(authService), authHandler.GetDataExport)
+			auth.DELETE("/account", middleware.Auth(authService), rateLimiter.AuthLimit(), authHandler.DeleteAccount)
+			auth.POST("/api-keys", middleware.Auth(authService), authHandler.CreateAPIKey)
+			auth.GET("/api-keys", middleware.Auth(authService), authHandler.ListAPIKeys)
+			auth.DELETE("/api-keys/:id", middleware.Auth(authService), authHandler.RevokeAPIKey)
+			auth.GET("/saml/metadata", samlHandler.Metadata)
+			auth.GET("/saml/login", rateLimiter.AuthLimit(), samlHandler.Login)
+			auth.POST("/saml/acs", samlHandler.AssertionConsumerService)
+			auth.GET("/google", rateLimiter.AuthLimit(), oauthHandler.GoogleLogin)
+			auth.GET("/google/callback", oauthHandler.GoogleCallback)
+			auth.GET("/providers", middleware.Auth(authService), oauthHandler.ListProviders)
+			auth.DELETE("/providers/:provider", middleware.Auth(authService), oauthHandler.UnlinkProvider)
 			auth.GET("/health", authHandler.HealthCheck)
 		}
 
+		// Anonymous generation for visitors without an account
+		api.POST("/ai/generate/anonymous", rateLimiter.GuestLimit(), aiHandler.GenerateAnonymous)
+
+		// Billing provider webhooks
+		api.POST("/billing/webhook", middleware.VerifyHMACSignature("Stripe-Signature", cfg.Webhooks.StripeSecret), billingHandler.Webhook)
+
 		// Protected routes
 		protected := api.Group("")
 		protected.Use(middleware.Auth(authService))
@@ -121,42 +291,196 @@ func main() {
 			projects := protected.Group("/projects")
 			{
 				projects.GET("", rateLimiter.ProjectLimit(), projectHandler.GetProjects)
-				projects.POST("", rateLimiter.ProjectLimit(), projectHandler.CreateProject)
-				projects.GET("/:id", projectHandler.GetProject)
+				projects.GET("/search", rateLimiter.ProjectLimit(), projectHandler.SearchProjects)
+				projects.POST("", rateLimiter.ProjectLimit(), idempotency, projectHandler.CreateProject)
+				projects.POST("/batch-import", rateLimiter.ProjectLimit(), projectHandler.BatchImport)
+				projects.POST("/import-url", rateLimiter.ProjectLimit(), projectHandler.ImportFromURL)
+				projects.POST("/import-zip", rateLimiter.ProjectLimit(), zipUploadLimit, projectHandler.ImportFromZIP)
+				projects.GET("/batch-import/:jobId", projectHandler.GetBatchImportJob)
+				projects.GET("/replay/:jobId", projectHandler.GetReplayJob)
+				projects.GET("/compare", exportHandler.CompareProjects)
+				projects.DELETE("/bulk", rateLimiter.BulkLimit(), projectHandler.BulkDeleteProjects)
+				projects.PUT("/bulk/status", rateLimiter.BulkLimit(), projectHandler.BulkUpdateStatus)
+				projects.GET("/:id", middleware.CountProjectView(projectService), middleware.CacheResponse(redisClient, 30*time.Second, projectCacheKey), projectHandler.GetProject)
 				projects.PUT("/:id", projectHandler.UpdateProject)
 				projects.DELETE("/:id", projectHandler.DeleteProject)
 				projects.POST("/:id/duplicate", projectHandler.DuplicateProject)
+				projects.POST("/:id/fork", projectHandler.ForkProject)
+				projects.POST("/:id/view", projectHandler.RecordView)
+				projects.POST("/:id/validate", projectHandler.ValidateProject)
+				projects.POST("/:id/thumbnail/regenerate", projectHandler.RegenerateThumbnail)
+				projects.GET("/:id/forks", projectHandler.GetForks)
+				projects.GET("/:id/collaborators", collaborationHandler.ListCollaborators)
+				projects.POST("/:id/collaborators", collaborationHandler.InviteCollaborator)
+				projects.POST("/:id/collaborators/accept", collaborationHandler.AcceptInvitation)
+				projects.DELETE("/:id/collaborators/:userId", collaborationHandler.RemoveCollaborator)
+				projects.GET("/:id/versions", projectHandler.GetVersions)
+				projects.GET("/:id/versions/:versionNumber", projectHandler.GetVersion)
+				projects.POST("/:id/versions/:versionNumber/restore", projectHandler.RestoreVersion)
+				projects.GET("/:id/diff", projectHandler.DiffVersions)
 				projects.GET("/:id/conversations", projectHandler.GetConversations)
+				projects.GET("/:id/conversations/search", rateLimiter.ProjectLimit(), projectHandler.SearchProjectConversations)
+				projects.GET("/:id/conversations/export", rateLimiter.ExportLimit(), projectHandler.ExportConversations)
+				projects.POST("/:id/conversations/import", projectHandler.ImportConversations)
+				projects.PATCH("/:id/conversations/:conversationId/rating", projectHandler.RateConversation)
+				projects.POST("/:id/conversations/replay", rateLimiter.AILimit(), middleware.UsageLimit(authService), projectHandler.ReplayConversations)
+				projects.POST("/:id/conversations/:conversationId/branch", rateLimiter.AILimit(), middleware.UsageLimit(authService), aiHandler.BranchConversation)
+				projects.GET("/:id/stats", projectHandler.GetProjectStats)
+				projects.GET("/:id/heatmap", projectHandler.GetActivityHeatmap)
+				projects.GET("/:id/activity", projectHandler.GetActivityTimeline)
+				projects.GET("/:id/palette", exportHandler.GetColorPalette)
 				projects.GET("/health", projectHandler.HealthCheck)
 			}
 
+			// Open WebSocket connections per user
+			protected.GET("/ws/connections", middleware.AdminAuth(authService), aiHandler.GetWSConnections)
+
+			// Overview of the caller's projects, AI usage and subscription
+			protected.GET("/me/dashboard", authHandler.GetDashboard)
+
+			// The caller's own actions across all projects
+			protected.GET("/me/activity", projectHandler.GetMyActivity)
+			protected.GET("/me/conversations/export", rateLimiter.ExportLimit(), projectHandler.ExportMyConversations)
+
+			// Frontend UI settings
+			protected.GET("/me/preferences", preferenceHandler.GetPreferences)
+			protected.PUT("/me/preferences", preferenceHandler.UpdatePreferences)
+			protected.DELETE("/me/preferences/:key", preferenceHandler.DeletePreference)
+
+			// In-app notifications
+			protected.GET("/me/notifications", notificationHandler.ListNotifications)
+			protected.PUT("/me/notifications/read-all", notificationHandler.MarkAllRead)
+			protected.PUT("/me/notifications/:id/read", notificationHandler.MarkRead)
+			protected.DELETE("/me/notifications/:id", notificationHandler.DeleteNotification)
+
+			// Rate limit status for client-side backoff
+			protected.GET("/rate-limit/status", rateLimitHandler.GetStatus)
+			protected.GET("/rate-limits", rateLimitHandler.GetStatus)
+
+			// Analytics routes
+			analytics := protected.Group("/analytics")
+			{
+				analytics.GET("/overview", projectHandler.GetAnalyticsOverview)
+				analytics.GET("/conversations", analyticsHandler.GetConversationAnalytics)
+			}
+
+			// Async job status stays readable once the usage limit is reached
+			protected.GET("/ai/jobs/:jobId", aiHandler.GetJob)
+
+			// Claude API circuit breaker, outside the usage limit so admins
+			// can always reach it
+			protected.GET("/ai/circuit-breaker", middleware.AdminAuth(authService), aiHandler.GetCircuitBreaker)
+			protected.POST("/ai/circuit-breaker/reset", middleware.AdminAuth(authService), aiHandler.ResetCircuitBreaker)
+
 			// AI routes
 			ai := protected.Group("/ai")
 			ai.Use(middleware.UsageLimit(authService))
 			{
-				ai.POST("/generate", rateLimiter.AILimit(), aiHandler.Generate)
-				ai.POST("/refine", rateLimiter.AILimit(), aiHandler.Refine)
+				ai.POST("/generate", rateLimiter.AILimit(), aiBodyLimit, idempotency, aiHandler.Generate)
+				ai.POST("/generate/async", rateLimiter.AILimit(), aiHandler.GenerateAsync)
+				ai.GET("/generate/stream", rateLimiter.AILimit(), aiHandler.GenerateStream)
+				ai.POST("/refine", rateLimiter.AILimit(), idempotency, aiHandler.Refine)
+				ai.POST("/recolor", rateLimiter.AILimit(), aiHandler.Recolor)
 				ai.POST("/template", rateLimiter.AILimit(), aiHandler.GenerateTemplate)
-				ai.GET("/templates", aiHandler.GetTemplates)
-				ai.GET("/templates/:id", aiHandler.GetTemplate)
+				ai.GET("/templates", templateHandler.GetTemplates)
+				ai.POST("/templates", templateHandler.CreateTemplate)
+				ai.GET("/templates/:id", templateHandler.GetTemplate)
+				ai.PUT("/templates/:id", templateHandler.UpdateTemplate)
+				ai.DELETE("/templates/:id", templateHandler.DeleteTemplate)
+				ai.GET("/templates/:id/reviews", templateHandler.GetReviews)
+				ai.POST("/templates/:id/reviews", templateHandler.CreateReview)
 				ai.GET("/status", aiHandler.GetStatus)
 				ai.GET("/usage", aiHandler.GetUsage)
+				ai.GET("/performance", aiHandler.GetPerformance)
 				ai.GET("/health", aiHandler.HealthCheck)
 			}
 
 			// Export routes
 			export := protected.Group("/export")
 			{
-				export.GET("/:projectId/html", rateLimiter.ExportLimit(), exportHandler.ExportHTML)
-				export.GET("/:projectId/zip", rateLimiter.ExportLimit(), exportHandler.ExportZIP)
-				export.POST("/batch", rateLimiter.ExportLimit(), exportHandler.BatchExport)
+				export.GET("/:projectId/html", rateLimiter.ExportLimit(), middleware.ExportFormatGate("html", authService), exportHandler.ExportHTML)
+				export.GET("/:projectId/zip", rateLimiter.ExportLimit(), middleware.ExportFormatGate("zip", authService), exportHandler.ExportZIP)
+				export.GET("/:projectId/pdf", rateLimiter.ExportLimit(), middleware.ExportFormatGate("pdf", authService), exportHandler.ExportPDF)
+				export.POST("/batch", rateLimiter.ExportLimit(), middleware.ExportFormatGate("zip", authService), idempotency, exportHandler.BatchExport)
 				export.GET("/history", exportHandler.GetExportHistory)
+				export.DELETE("/history/:id", exportHandler.DeleteExportRecord)
 				export.GET("/health", exportHandler.HealthCheck)
 			}
+
+			// Webhook routes
+			webhooks := protected.Group("/webhooks")
+			{
+				webhooks.GET("", webhookHandler.ListWebhooks)
+				webhooks.POST("", webhookHandler.CreateWebhook)
+				webhooks.GET("/:id", webhookHandler.GetWebhook)
+				webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+				webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+				webhooks.POST("/:id/test", webhookHandler.TestWebhook)
+			}
+
+			// Conversation routes
+			conversations := protected.Group("/conversations")
+			{
+				conversations.GET("/search", rateLimiter.ProjectLimit(), projectHandler.SearchConversations)
+			}
+
+			// Prompt template routes
+			promptTemplates := protected.Group("/prompt-templates")
+			{
+				promptTemplates.GET("", promptTemplateHandler.ListTemplates)
+				promptTemplates.POST("", promptTemplateHandler.CreateTemplate)
+				promptTemplates.PUT("/:id", promptTemplateHandler.UpdateTemplate)
+				promptTemplates.DELETE("/:id", promptTemplateHandler.DeleteTemplate)
+				promptTemplates.POST("/:id/render", promptTemplateHandler.RenderTemplate)
+			}
+
+			// Project collection routes
+			collections := protected.Group("/collections")
+			{
+				collections.GET("", collectionHandler.ListCollections)
+				collections.POST("", collectionHandler.CreateCollection)
+				collections.PUT("/:id", collectionHandler.UpdateCollection)
+				collections.DELETE("/:id", collectionHandler.DeleteCollection)
+				collections.PUT("/:id/projects", collectionHandler.AssignProjects)
+				collections.POST("/:id/reorder", collectionHandler.ReorderProjects)
+			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(middleware.AdminAuth(authService))
+			{
+				admin.GET("/users", adminHandler.ListUsers)
+				admin.GET("/users/:id", adminHandler.GetUser)
+				admin.PUT("/users/:id/disable", adminHandler.DisableUser)
+				admin.PUT("/users/:id/enable", adminHandler.EnableUser)
+				admin.PUT("/users/:id/plan", adminHandler.ChangePlan)
+				admin.DELETE("/users/:id", adminHandler.DeleteUser)
+				admin.GET("/stats", adminHandler.GetStats)
+				admin.GET("/jobs", adminHandler.ListJobs)
+				admin.POST("/webhooks/retry-failed", adminHandler.RetryFailedWebhooks)
+				admin.GET("/webhooks/stats", adminHandler.GetWebhookStats)
+				admin.POST("/moderation/mode", adminHandler.SetModerationMode)
+				admin.POST("/accounts/unlock", authHandler.UnlockAccount)
+				admin.PUT("/projects/:id/featured", adminHandler.SetFeatured)
+				admin.POST("/templates/:id/approve", adminHandler.ApproveTemplate)
+				admin.GET("/prompt-variants", adminHandler.ListPromptVariants)
+				admin.POST("/prompt-variants", adminHandler.CreatePromptVariant)
+				admin.POST("/prompt-variants/analyse", adminHandler.AnalysePromptVariants)
+				admin.PUT("/prompt-variants/:id", adminHandler.UpdatePromptVariant)
+				admin.DELETE("/prompt-variants/:id", adminHandler.DeletePromptVariant)
+			}
 		}
 
 		// Public preview route
 		api.GET("/export/:projectId/preview", middleware.OptionalAuth(authService), exportHandler.Preview)
+
+		// Public project gallery
+		discover := api.Group("/discover")
+		discover.Use(middleware.OptionalAuth(authService))
+		{
+			discover.GET("", projectHandler.Discover)
+			discover.GET("/featured", projectHandler.GetFeatured)
+		}
 	}
 
 	// WebSocket endpoint for real-time AI generation
@@ -182,6 +506,7 @@ func main() {
 	}
 
 	// Start server in goroutine
+	healthHandler.SetReady(true)
 	go func() {
 		logger.Info("🚀 Server starting", "port", cfg.Port, "environment", cfg.Environment)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -189,30 +514,83 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown
+	// Reload configuration on SIGHUP, shut down gracefully on interrupt
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range quit {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		logger.Info("🔄 Reloading configuration...")
+		configReloader.Reload()
+	}
 
 	logger.Info("🛑 Shutting down server...")
+	healthHandler.SetReady(false)
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Graceful shutdown. Each phase has its own deadline so a slow one can't
+	// use up the time of the next, and cleanup below always runs.
 
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", "error", err)
+	// Let WebSocket generations finish before the server stops accepting requests
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 15*time.Second)
+	shutdownMonitor.Drain(drainCtx)
+	cancelDrain()
+	wsHub.Shutdown()
+
+	// Let running background jobs finish; unstarted ones stay queued
+	stopJobPool()
+	jobsCtx, cancelJobs := context.WithTimeout(context.Background(), 10*time.Second)
+	jobPool.Drain(jobsCtx)
+	cancelJobs()
+
+	// Stop the other background workers once nothing in flight still needs them
+	stopWorkers()
+
+	serverCtx, cancelServer := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := server.Shutdown(serverCtx); err != nil {
+		logger.Error("Server forced to shutdown", "error", err)
 	}
+	cancelServer()
 
 	// Close database connections
 	if sqlDB, err := db.DB(); err == nil {
 		sqlDB.Close()
 	}
+	for _, replica := range replicas {
+		if sqlDB, err := replica.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
 
 	// Close Redis connection
 	if redisClient != nil {
 		redisClient.Close()
 	}
 
+	// Flush pending spans
+	traceCtx, cancelTrace := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTrace()
+	if err := shutdownTracing(traceCtx); err != nil {
+		logger.Warn("Failed to flush traces", "error", err)
+	}
+
 	logger.Info("✅ Server shutdown complete")
 }
+
+// projectCacheKey keys cached project responses by caller and project, so
+// users never see each other's view of a project
+func projectCacheKey(c *gin.Context) string {
+	userID, ok := c.Get("userID")
+	if !ok {
+		return ""
+	}
+	id, ok := userID.(uuid.UUID)
+	if !ok {
+		return ""
+	}
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return ""
+	}
+	return services.ProjectCacheKey(id, projectID)
+}
@@ -11,7 +11,7 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 -- Create enum types
 CREATE TYPE subscription_plan AS ENUM ('free', 'pro', 'premium');
 CREATE TYPE project_status AS ENUM ('draft', 'published', 'archived');
-CREATE TYPE message_type AS ENUM ('generation', 'refinement', 'question');
+CREATE TYPE message_type AS ENUM ('generation', 'refinement', 'question', 'imported');
 
 -- Users table
 CREATE TABLE IF NOT EXISTS users (
@@ -25,6 +25,8 @@ CREATE TABLE IF NOT EXISTS users (
     api_usage_limit INTEGER DEFAULT 100,
     is_active BOOLEAN DEFAULT true,
     email_verified BOOLEAN DEFAULT false,
+    is_admin BOOLEAN DEFAULT false,
+    password_set BOOLEAN DEFAULT true,
     last_login_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
@@ -41,13 +43,16 @@ CREATE TABLE IF NOT EXISTS projects (
     css_code TEXT,
     js_code TEXT,
     preview_url VARCHAR(500),
-    thumbnail_url VARCHAR(500),
+    thumbnail_url TEXT,
+    thumbnail_code_hash VARCHAR(64),
+    thumbnail_refreshed_at TIMESTAMP WITH TIME ZONE,
     status project_status DEFAULT 'draft',
     tags TEXT[],
     is_public BOOLEAN DEFAULT false,
     view_count INTEGER DEFAULT 0,
     like_count INTEGER DEFAULT 0,
     published_at TIMESTAMP WITH TIME ZONE,
+    search_vector TSVECTOR,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     deleted_at TIMESTAMP WITH TIME ZONE
@@ -66,6 +71,7 @@ CREATE TABLE IF NOT EXISTS conversations (
     model_used VARCHAR(100),
     message_type message_type DEFAULT 'generation',
     satisfaction_rating INTEGER CHECK (satisfaction_rating >= 1 AND satisfaction_rating <= 5),
+    from_cache BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
@@ -132,7 +138,7 @@ CREATE INDEX IF NOT EXISTS idx_api_usage_user_id ON api_usage(user_id);
 CREATE INDEX IF NOT EXISTS idx_api_usage_created_at ON api_usage(created_at);
 
 -- Full-text search index for projects
-CREATE INDEX IF NOT EXISTS idx_projects_search ON projects USING GIN(to_tsvector('english', name || ' ' || COALESCE(description, '')));
+CREATE INDEX IF NOT EXISTS idx_projects_search_vector ON projects USING GIN(search_vector);
 
 -- Insert some sample templates
 INSERT INTO templates (name, description, category, html_code, tags, is_premium) VALUES 
@@ -299,8 +305,8 @@ ARRAY['modern', 'portfolio', 'developer'], false),
 ARRAY['saas', 'landing', 'conversion'], false);
 
 -- Create a default admin user (password: admin123)
-INSERT INTO users (email, password_hash, name, subscription_plan, api_usage_limit, is_active, email_verified) VALUES 
-('admin@lovable.dev', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Admin User', 'premium', 1000, true, true);
+INSERT INTO users (email, password_hash, name, subscription_plan, api_usage_limit, is_active, email_verified, is_admin) VALUES 
+('admin@lovable.dev', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Admin User', 'premium', 1000, true, true, true);
 
 -- Create update trigger for updated_at
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -4,18 +4,53 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	Environment string
-	Port        string
-	FrontendURL string
-	Database    DatabaseConfig
-	Redis       RedisConfig
-	JWT         JWTConfig
-	AI          AIConfig
+	Environment  string
+	Port         string
+	FrontendURL  string
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	JWT          JWTConfig
+	AI           AIConfig
+	Subscription SubscriptionConfig
+	Email        EmailConfig
+	Webhooks     WebhookConfig
+	Moderation   ModerationConfig
+	RateLimits   RateLimitConfig
+	CORSConfig   CORSConfig
+	StorageMode  string // postgres or s3
+	S3           S3Config
+	SAML         SAMLConfig
+	Security     SecurityConfig
+	GoogleOAuth  GoogleOAuthConfig
+	Metrics      MetricsConfig
+	Telemetry    TelemetryConfig
+	Preferences  PreferencesConfig
+	Jobs         JobsConfig
+	Export       ExportConfig
+	URLImport    URLImportConfig
+	Thumbnail    ThumbnailConfig
+	Compression  CompressionConfig
+	Idempotency  IdempotencyConfig
+	Versions     VersionsConfig
+	// Largest request body accepted by any route, and by AI generation and
+	// WebSocket messages
+	MaxRequestBodyBytes   int64
+	MaxAIRequestBodyBytes int64
+	// token_bucket allows a window's requests in a burst; leaky_bucket spreads
+	// them evenly over the window
+	RateLimitStrategy string
 }
 
+// Rate limiting strategies
+const (
+	RateLimitTokenBucket = "token_bucket"
+	RateLimitLeakyBucket = "leaky_bucket"
+)
+
 type DatabaseConfig struct {
 	Host     string
 	Port     int
@@ -23,18 +58,22 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+
+	ReadReplicaDSNs []string
 }
 
 type RedisConfig struct {
-	URL      string
-	Password string
+	URL           string
+	Password      string
+	PubSubEnabled bool // Fan out WebSocket messages across server instances
 }
 
 type JWTConfig struct {
-	Secret           string
-	RefreshSecret    string
-	ExpirationHours  int
+	Secret                string
+	RefreshSecret         string
+	ExpirationHours       int
 	RefreshExpirationDays int
+	GuestSecret           string
 }
 
 type AIConfig struct {
@@ -43,13 +82,183 @@ type AIConfig struct {
 	Model        string
 	MaxTokens    int
 	Timeout      int
+	// Size of the model's context window used to budget conversation history
+	MaxContextTokens int
+	OpenAIModel      string
+	GeminiAPIKey     string
+	GeminiModel      string
+	// Models tried in order when a call is rate limited, times out or fails
+	// with a server error
+	FallbackChain []FallbackModel
+	// Number of workers processing queued async generations
+	WorkerPoolSize int
+	// Hours between logged AI performance summaries; 0 disables them
+	PerformanceReportIntervalHours int
+	// YAML file of regex rules that reject prompts before generation
+	ContentFilterRules string
+	// Rated generations a prompt variant needs before it can be promoted
+	PromptVariantMinSamples int
+}
+
+// FallbackModel is one step of the AI fallback chain. An empty Model uses the
+// provider's configured model; zero MaxTokens and Timeout use the AI defaults.
+type FallbackModel struct {
+	Provider  string // claude, openai or gemini
+	Model     string
+	MaxTokens int
+	Timeout   int // seconds
+}
+
+type SubscriptionConfig struct {
+	ExportLimits       ExportLimits
+	DowngradeGraceDays int
+}
+
+type ExportLimits struct {
+	AllowedFormats map[string][]string
+}
+
+type EmailConfig struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FromAddress  string
+	AppURL       string // base URL for links in emails
+}
+
+type WebhookConfig struct {
+	StripeSecret string
+}
+
+type ModerationConfig struct {
+	Mode          string
+	BlocklistPath string
+}
+
+// RateLimitConfig holds the rate limits for each subscription plan, keyed by
+// plan name. Plans or limiters missing from it fall back to the free tier.
+type RateLimitConfig map[string]PlanRateLimits
+
+// PlanRateLimits holds the request limit per window for each rate limiter,
+// keyed by its Redis key prefix (global, auth, project, ai, guest, export)
+type PlanRateLimits map[string]int64
+
+type URLImportConfig struct {
+	// Seconds to wait for the imported page
+	Timeout int
+	// Third-party hosts whose scripts are kept in imported pages
+	AllowedScriptHosts []string
+	// Hosts that may never be fetched, on top of private addresses
+	DisallowedHosts []string
+}
+
+type ExportConfig struct {
+	// PDF export renders pages with an external headless browser service
+	EnablePDFExport bool
+	// Gotenberg-compatible HTML conversion endpoint, e.g.
+	// http://gotenberg:3000/forms/chromium/convert/html
+	PDFServiceURL string
+	// Seconds to wait for the PDF service
+	PDFServiceTimeout int
+}
+
+type ThumbnailConfig struct {
+	// Screenshot thumbnails are captured by an external headless browser
+	// service; without it thumbnails are drawn from the page's palette
+	ServiceEnabled bool
+	// Endpoint receiving the page as a data URL and returning the image URL
+	ServiceURL string
+	// Seconds to wait for the thumbnail service
+	ServiceTimeout int
+}
+
+type CompressionConfig struct {
+	// compress/gzip level, from 1 (fastest) to 9 (smallest)
+	Level int
+	// Responses smaller than this are sent uncompressed
+	MinBytes int
+}
+
+type IdempotencyConfig struct {
+	// Hours a response is replayed for retries with the same idempotency key
+	TTLHours int
+}
+
+type VersionsConfig struct {
+	// Version snapshots kept per project, newest first
+	MaxPerProject int
+	// Days after which snapshots are pruned; a project's latest is always kept
+	RetentionDays int
+}
+
+type JobsConfig struct {
+	// Number of workers running background jobs
+	Concurrency int
+}
+
+type PreferencesConfig struct {
+	// Keys users may store UI preferences under
+	AllowedKeys []string
+}
+
+type MetricsConfig struct {
+	Token string // bearer token required by /metrics; open when empty
+}
+
+type TelemetryConfig struct {
+	OTLPEndpoint string // OTLP/HTTP collector URL; tracing is off when empty
+	ServiceName  string
+}
+
+type CORSConfig struct {
+	AllowOrigins []string
+}
+
+type SAMLConfig struct {
+	EntityID                    string
+	MetadataURL                 string // identity provider metadata
+	AssertionConsumerServiceURL string
+	PrivateKey                  string // PEM encoded
+	Certificate                 string // PEM encoded
+}
+
+type GoogleOAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // must match the callback registered with Google
+}
+
+type SecurityConfig struct {
+	BCryptCost int
+	// Block AI endpoints until the account's email address is verified
+	RequireEmailVerification bool
+	// Key used to encrypt TOTP secrets at rest; MFA is unavailable when empty
+	MFAEncryptionKey string
+	// Failed logins within the window that lock an email address
+	MaxLoginAttempts     int
+	LockoutWindowMinutes int
+}
+
+// Bounds for the configurable bcrypt cost; values outside are clamped
+const (
+	MinBCryptCost = 10
+	MaxBCryptCost = 14
+)
+
+type S3Config struct {
+	Bucket   string
+	Region   string
+	Endpoint string
 }
 
 func Load() *Config {
+	frontendURL := getEnv("FRONTEND_URL", "http://localhost:3000")
+
 	return &Config{
 		Environment: getEnv("NODE_ENV", "development"),
 		Port:        getEnv("PORT", "3001"),
-		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+		FrontendURL: frontendURL,
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnvInt("DB_PORT", 5432),
@@ -57,23 +266,166 @@ func Load() *Config {
 			Password: getEnv("DB_PASSWORD", "password"),
 			Name:     getEnv("DB_NAME", "ai_website_builder"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+
+			ReadReplicaDSNs: getEnvList("DB_READ_REPLICA_DSNS", nil),
 		},
 		Redis: RedisConfig{
-			URL:      getEnv("REDIS_URL", "redis://localhost:6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
+			URL:           getEnv("REDIS_URL", "redis://localhost:6379"),
+			Password:      getEnv("REDIS_PASSWORD", ""),
+			PubSubEnabled: getEnvBool("REDIS_PUBSUB_ENABLED", false),
 		},
 		JWT: JWTConfig{
 			Secret:                getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
 			RefreshSecret:         getEnv("JWT_REFRESH_SECRET", "your-refresh-secret"),
 			ExpirationHours:       getEnvInt("JWT_EXPIRES_HOURS", 24),
 			RefreshExpirationDays: getEnvInt("JWT_REFRESH_EXPIRES_DAYS", 30),
+			GuestSecret:           getEnv("JWT_GUEST_SECRET", "your-guest-secret"),
 		},
 		AI: AIConfig{
-			ClaudeAPIKey: getEnv("CLAUDE_API_KEY", ""),
-			OpenAIAPIKey: getEnv("OPENAI_API_KEY", ""),
-			Model:        getEnv("AI_MODEL", "claude-sonnet-4-20250514"),
-			MaxTokens:    getEnvInt("AI_MAX_TOKENS", 4000),
-			Timeout:      getEnvInt("AI_TIMEOUT_SECONDS", 30),
+			ClaudeAPIKey:     getEnv("CLAUDE_API_KEY", ""),
+			OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
+			Model:            getEnv("AI_MODEL", "claude-sonnet-4-20250514"),
+			MaxTokens:        getEnvInt("AI_MAX_TOKENS", 4000),
+			Timeout:          getEnvInt("AI_TIMEOUT_SECONDS", 30),
+			MaxContextTokens: getEnvInt("AI_MAX_CONTEXT_TOKENS", 200000),
+			OpenAIModel:      getEnv("OPENAI_MODEL", "gpt-4o"),
+			GeminiAPIKey:     getEnv("GEMINI_API_KEY", ""),
+			GeminiModel:      getEnv("GEMINI_MODEL", "gemini-1.5-pro"),
+			FallbackChain:    getFallbackChain("AI_FALLBACK_CHAIN", getEnvList("AI_FALLBACK_PROVIDERS", []string{"claude", "openai"})),
+			WorkerPoolSize:   getEnvInt("AI_WORKER_POOL_SIZE", 4),
+			// Weekly by default
+			PerformanceReportIntervalHours: getEnvInt("AI_PERFORMANCE_REPORT_INTERVAL_HOURS", 168),
+			ContentFilterRules:             getEnv("CONTENT_FILTER_RULES", "config/content_filter.yaml"),
+			PromptVariantMinSamples:        getEnvInt("PROMPT_VARIANT_MIN_SAMPLES", 50),
+		},
+		Subscription: SubscriptionConfig{
+			ExportLimits: ExportLimits{
+				AllowedFormats: map[string][]string{
+					"free":    {"html"},
+					"pro":     {"html", "zip"},
+					"premium": {"html", "zip", "pdf", "react", "nextjs"},
+				},
+			},
+			DowngradeGraceDays: getEnvInt("DOWNGRADE_GRACE_DAYS", 7),
+		},
+		Email: EmailConfig{
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			FromAddress:  getEnv("EMAIL_FROM", "noreply@lovable.dev"),
+			AppURL:       frontendURL,
+		},
+		Webhooks: WebhookConfig{
+			StripeSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		},
+		Moderation: ModerationConfig{
+			Mode:          getEnv("MODERATION_MODE", "blocklist"),
+			BlocklistPath: getEnv("MODERATION_BLOCKLIST_PATH", "config/moderation.yaml"),
+		},
+		RateLimits: RateLimitConfig{
+			"free": {
+				"global":  int64(getEnvInt("RATE_LIMIT_GLOBAL", 100)),
+				"auth":    int64(getEnvInt("RATE_LIMIT_AUTH", 5)),
+				"project": int64(getEnvInt("RATE_LIMIT_PROJECT", 30)),
+				"ai":      int64(getEnvInt("RATE_LIMIT_AI", 10)),
+				"guest":   int64(getEnvInt("RATE_LIMIT_GUEST", 3)),
+				"export":  int64(getEnvInt("RATE_LIMIT_EXPORT", 10)),
+				"bulk":    int64(getEnvInt("RATE_LIMIT_BULK", 5)),
+			},
+			"pro": {
+				"global":  int64(getEnvInt("RATE_LIMIT_PRO_GLOBAL", 300)),
+				"auth":    int64(getEnvInt("RATE_LIMIT_PRO_AUTH", 10)),
+				"project": int64(getEnvInt("RATE_LIMIT_PRO_PROJECT", 120)),
+				"ai":      int64(getEnvInt("RATE_LIMIT_PRO_AI", 30)),
+				"export":  int64(getEnvInt("RATE_LIMIT_PRO_EXPORT", 30)),
+				"bulk":    int64(getEnvInt("RATE_LIMIT_PRO_BULK", 15)),
+			},
+			"premium": {
+				"global":  int64(getEnvInt("RATE_LIMIT_PREMIUM_GLOBAL", 1000)),
+				"auth":    int64(getEnvInt("RATE_LIMIT_PREMIUM_AUTH", 20)),
+				"project": int64(getEnvInt("RATE_LIMIT_PREMIUM_PROJECT", 300)),
+				"ai":      int64(getEnvInt("RATE_LIMIT_PREMIUM_AI", 100)),
+				"export":  int64(getEnvInt("RATE_LIMIT_PREMIUM_EXPORT", 100)),
+				"bulk":    int64(getEnvInt("RATE_LIMIT_PREMIUM_BULK", 30)),
+			},
+		},
+		CORSConfig: CORSConfig{
+			AllowOrigins: getEnvList("CORS_ALLOW_ORIGINS", []string{frontendURL}),
+		},
+		SAML: SAMLConfig{
+			EntityID:                    getEnv("SAML_ENTITY_ID", ""),
+			MetadataURL:                 getEnv("SAML_IDP_METADATA_URL", ""),
+			AssertionConsumerServiceURL: getEnv("SAML_ACS_URL", ""),
+			PrivateKey:                  getEnv("SAML_PRIVATE_KEY", ""),
+			Certificate:                 getEnv("SAML_CERTIFICATE", ""),
+		},
+		StorageMode: getEnv("STORAGE_MODE", "postgres"),
+		S3: S3Config{
+			Bucket:   getEnv("S3_BUCKET", ""),
+			Region:   getEnv("S3_REGION", "us-east-1"),
+			Endpoint: getEnv("S3_ENDPOINT", ""),
+		},
+		Security: SecurityConfig{
+			BCryptCost:               clampInt(getEnvInt("BCRYPT_COST", 12), MinBCryptCost, MaxBCryptCost),
+			RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+			MFAEncryptionKey:         getEnv("MFA_ENCRYPTION_KEY", ""),
+			MaxLoginAttempts:         getEnvInt("MAX_LOGIN_ATTEMPTS", 5),
+			LockoutWindowMinutes:     getEnvInt("LOCKOUT_WINDOW_MINUTES", 15),
+		},
+		GoogleOAuth: GoogleOAuthConfig{
+			ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+			ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("GOOGLE_REDIRECT_URL", ""),
+		},
+		Metrics: MetricsConfig{
+			Token: getEnv("METRICS_TOKEN", ""),
+		},
+		Telemetry: TelemetryConfig{
+			OTLPEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			ServiceName:  getEnv("OTEL_SERVICE_NAME", "lovable-backend"),
+		},
+		RateLimitStrategy: getEnv("RATE_LIMIT_STRATEGY", RateLimitTokenBucket),
+		URLImport: URLImportConfig{
+			Timeout: getEnvInt("URL_IMPORT_TIMEOUT_SECONDS", 10),
+			AllowedScriptHosts: getEnvList("URL_IMPORT_ALLOWED_SCRIPT_HOSTS", []string{
+				"cdn.jsdelivr.net", "unpkg.com", "cdnjs.cloudflare.com", "cdn.tailwindcss.com",
+			}),
+			DisallowedHosts: getEnvList("DISALLOW_URL_IMPORT_HOSTS", []string{
+				"localhost", "metadata.google.internal",
+			}),
+		},
+		Export: ExportConfig{
+			EnablePDFExport:   getEnvBool("ENABLE_PDF_EXPORT", false),
+			PDFServiceURL:     getEnv("PDF_SERVICE_URL", ""),
+			PDFServiceTimeout: getEnvInt("PDF_SERVICE_TIMEOUT_SECONDS", 30),
+		},
+		Thumbnail: ThumbnailConfig{
+			ServiceEnabled: getEnvBool("THUMBNAIL_SERVICE_ENABLED", false),
+			ServiceURL:     getEnv("THUMBNAIL_SERVICE_URL", ""),
+			ServiceTimeout: getEnvInt("THUMBNAIL_SERVICE_TIMEOUT_SECONDS", 30),
+		},
+		Compression: CompressionConfig{
+			Level:    clampInt(getEnvInt("COMPRESS_LEVEL", 6), 1, 9),
+			MinBytes: getEnvInt("MIN_COMPRESS_BYTES", 1024),
+		},
+		Idempotency: IdempotencyConfig{
+			TTLHours: getEnvInt("IDEMPOTENCY_TTL_HOURS", 24),
+		},
+		Versions: VersionsConfig{
+			MaxPerProject: getEnvInt("MAX_SNAPSHOTS_PER_PROJECT", 50),
+			RetentionDays: clampInt(getEnvInt("SNAPSHOT_RETENTION_DAYS", 90), 1, 3650),
+		},
+		Jobs: JobsConfig{
+			Concurrency: getEnvInt("JOB_WORKER_CONCURRENCY", 4),
+		},
+		MaxRequestBodyBytes:   int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 10<<20)),
+		MaxAIRequestBodyBytes: int64(getEnvInt("MAX_AI_REQUEST_BODY_BYTES", 5<<20)),
+		Preferences: PreferencesConfig{
+			AllowedKeys: getEnvList("ALLOWED_PREFERENCE_KEYS", []string{
+				"theme", "language", "editor_font_size", "editor_theme", "editor_word_wrap",
+				"autosave_interval", "preview_device", "sidebar_collapsed",
+			}),
 		},
 	}
 }
@@ -94,3 +446,57 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func clampInt(val, min, max int) int {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if boolVal, err := strconv.ParseBool(val); err == nil {
+			return boolVal
+		}
+	}
+	return defaultVal
+}
+
+// getFallbackChain parses a comma-separated list of
+// provider[:model[:maxTokens[:timeoutSeconds]]] entries, e.g.
+// "claude,openai:gpt-4o-mini:2000:20". Without it each default provider is
+// tried with its configured model.
+func getFallbackChain(key string, defaultProviders []string) []FallbackModel {
+	var chain []FallbackModel
+	for _, entry := range getEnvList(key, defaultProviders) {
+		parts := strings.Split(entry, ":")
+		model := FallbackModel{Provider: strings.TrimSpace(parts[0])}
+		if len(parts) > 1 {
+			model.Model = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			model.MaxTokens, _ = strconv.Atoi(strings.TrimSpace(parts[2]))
+		}
+		if len(parts) > 3 {
+			model.Timeout, _ = strconv.Atoi(strings.TrimSpace(parts[3]))
+		}
+		chain = append(chain, model)
+	}
+	return chain
+}
+
+func getEnvList(key string, defaultVal []string) []string {
+	if val := os.Getenv(key); val != "" {
+		var items []string
+		for _, item := range strings.Split(val, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return defaultVal
+}
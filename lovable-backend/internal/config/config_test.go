@@ -0,0 +1,28 @@
+// internal/config/config_test.go
+package config
+
+import "testing"
+
+func TestLoadBCryptCost(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{"default", "", 12},
+		{"configured", "13", 13},
+		{"below minimum", "8", MinBCryptCost},
+		{"above maximum", "20", MaxBCryptCost},
+		{"not a number", "high", 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BCRYPT_COST", tt.value)
+
+			if got := Load().Security.BCryptCost; got != tt.want {
+				t.Errorf("BCryptCost = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// internal/config/store.go
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"sync"
+
+	"github.com/joho/godotenv"
+
+	"lovable-backend/pkg/logger"
+)
+
+// ConfigStore holds the running configuration and allows the fields that are
+// safe to change at runtime to be swapped without a restart.
+type ConfigStore struct {
+	mu     sync.RWMutex
+	config *Config
+}
+
+func NewConfigStore(config *Config) *ConfigStore {
+	return &ConfigStore{config: config}
+}
+
+// Get returns a copy of the current configuration
+func (s *ConfigStore) Get() Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return *s.config
+}
+
+func (s *ConfigStore) AI() AIConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.AI
+}
+
+// RateLimit returns the plan's limit for the rate limiter with the given
+// prefix, falling back to the free tier
+func (s *ConfigStore) RateLimit(plan, prefix string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if limit, ok := s.config.RateLimits[plan][prefix]; ok {
+		return limit
+	}
+	return s.config.RateLimits["free"][prefix]
+}
+
+// RateLimitStrategy returns the configured rate limiting strategy, treating
+// unknown values as the token bucket
+func (s *ConfigStore) RateLimitStrategy() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.RateLimitStrategy == RateLimitLeakyBucket {
+		return RateLimitLeakyBucket
+	}
+	return RateLimitTokenBucket
+}
+
+func (s *ConfigStore) AllowOrigins() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.CORSConfig.AllowOrigins
+}
+
+// ConfigReloader re-reads the .env file and the environment and applies
+// reloadable changes to a ConfigStore
+type ConfigReloader struct {
+	store   *ConfigStore
+	envFile string
+	logger  *logger.Logger
+}
+
+func NewConfigReloader(store *ConfigStore, logger *logger.Logger) *ConfigReloader {
+	return &ConfigReloader{
+		store:   store,
+		envFile: ".env",
+		logger:  logger,
+	}
+}
+
+// Reload loads the configuration again and updates the AI model settings,
+// rate limits and strategy, and CORS origins. Other differences are logged
+// but only take effect after a restart. Values in the .env file replace those
+// loaded from it at startup, so edits to it take effect.
+func (r *ConfigReloader) Reload() {
+	if err := godotenv.Overload(r.envFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		r.logger.Error("Failed to read env file, reloading from the environment", "file", r.envFile, "error", err)
+	}
+
+	next := Load()
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	current := r.store.config
+	updated := *current
+
+	if next.AI.Model != current.AI.Model {
+		r.logger.Info("Config reloaded", "field", "AI.Model", "old", current.AI.Model, "new", next.AI.Model)
+		updated.AI.Model = next.AI.Model
+	}
+	if next.AI.OpenAIModel != current.AI.OpenAIModel {
+		r.logger.Info("Config reloaded", "field", "AI.OpenAIModel", "old", current.AI.OpenAIModel, "new", next.AI.OpenAIModel)
+		updated.AI.OpenAIModel = next.AI.OpenAIModel
+	}
+	if next.AI.MaxTokens != current.AI.MaxTokens {
+		r.logger.Info("Config reloaded", "field", "AI.MaxTokens", "old", current.AI.MaxTokens, "new", next.AI.MaxTokens)
+		updated.AI.MaxTokens = next.AI.MaxTokens
+	}
+	if next.AI.MaxContextTokens != current.AI.MaxContextTokens {
+		r.logger.Info("Config reloaded", "field", "AI.MaxContextTokens", "old", current.AI.MaxContextTokens, "new", next.AI.MaxContextTokens)
+		updated.AI.MaxContextTokens = next.AI.MaxContextTokens
+	}
+	if next.AI.Timeout != current.AI.Timeout {
+		r.logger.Info("Config reloaded", "field", "AI.Timeout", "old", current.AI.Timeout, "new", next.AI.Timeout)
+		updated.AI.Timeout = next.AI.Timeout
+	}
+	if !reflect.DeepEqual(next.RateLimits, current.RateLimits) {
+		r.logger.Info("Config reloaded", "field", "RateLimits", "old", current.RateLimits, "new", next.RateLimits)
+		updated.RateLimits = next.RateLimits
+	}
+	if next.RateLimitStrategy != current.RateLimitStrategy {
+		r.logger.Info("Config reloaded", "field", "RateLimitStrategy", "old", current.RateLimitStrategy, "new", next.RateLimitStrategy)
+		updated.RateLimitStrategy = next.RateLimitStrategy
+	}
+	if !reflect.DeepEqual(next.CORSConfig.AllowOrigins, current.CORSConfig.AllowOrigins) {
+		r.logger.Info("Config reloaded", "field", "CORSConfig.AllowOrigins", "old", current.CORSConfig.AllowOrigins, "new", next.CORSConfig.AllowOrigins)
+		updated.CORSConfig.AllowOrigins = next.CORSConfig.AllowOrigins
+	}
+
+	// These are bound at startup and cannot be swapped safely
+	if !reflect.DeepEqual(next.Database, current.Database) {
+		r.logger.Warn("Config change requires restart", "field", "Database")
+	}
+	if !reflect.DeepEqual(next.Redis, current.Redis) {
+		r.logger.Warn("Config change requires restart", "field", "Redis")
+	}
+	if !reflect.DeepEqual(next.JWT, current.JWT) {
+		r.logger.Warn("Config change requires restart", "field", "JWT")
+	}
+	if next.AI.ClaudeAPIKey != current.AI.ClaudeAPIKey || next.AI.OpenAIAPIKey != current.AI.OpenAIAPIKey {
+		r.logger.Warn("Config change requires restart", "field", "AI API keys")
+	}
+	if next.Port != current.Port {
+		r.logger.Warn("Config change requires restart", "field", "Port")
+	}
+
+	r.store.config = &updated
+}
@@ -0,0 +1,57 @@
+// internal/config/store_test.go
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lovable-backend/pkg/logger"
+)
+
+func TestReloadReadsEditedEnvFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	writeEnvFile := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(envFile, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Registered so the variables are restored when the test ends
+	t.Setenv("AI_MODEL", "")
+	t.Setenv("RATE_LIMIT_STRATEGY", "")
+
+	writeEnvFile("AI_MODEL=claude-original\nRATE_LIMIT_STRATEGY=token_bucket\n")
+	store := NewConfigStore(&Config{})
+	reloader := NewConfigReloader(store, logger.New("test"))
+	reloader.envFile = envFile
+	reloader.Reload()
+
+	if got := store.AI().Model; got != "claude-original" {
+		t.Fatalf("AI.Model = %q after the first reload, want claude-original", got)
+	}
+
+	writeEnvFile("AI_MODEL=claude-edited\nRATE_LIMIT_STRATEGY=leaky_bucket\n")
+	reloader.Reload()
+
+	if got := store.AI().Model; got != "claude-edited" {
+		t.Errorf("AI.Model = %q, want the edited claude-edited", got)
+	}
+	if got := store.RateLimitStrategy(); got != RateLimitLeakyBucket {
+		t.Errorf("RateLimitStrategy = %q, want the edited %q", got, RateLimitLeakyBucket)
+	}
+}
+
+func TestReloadWithoutEnvFile(t *testing.T) {
+	t.Setenv("AI_MODEL", "claude-from-environment")
+
+	store := NewConfigStore(&Config{})
+	reloader := NewConfigReloader(store, logger.New("test"))
+	reloader.envFile = filepath.Join(t.TempDir(), "missing.env")
+	reloader.Reload()
+
+	if got := store.AI().Model; got != "claude-from-environment" {
+		t.Errorf("AI.Model = %q, want claude-from-environment", got)
+	}
+}
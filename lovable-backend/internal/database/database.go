@@ -42,19 +42,56 @@ func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
+	fromVersion, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+
 	// Enable UUID extension
 	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error; err != nil {
 		return fmt.Errorf("failed to create uuid extension: %w", err)
 	}
 
+	if err := hashEmailedTokens(db, fromVersion); err != nil {
+		return fmt.Errorf("failed to hash emailed tokens: %w", err)
+	}
+
 	// Auto migrate all models
-	err := db.AutoMigrate(
+	err = db.AutoMigrate(
 		&models.User{},
+		&models.ProjectCollection{},
 		&models.Project{},
+		&models.ProjectVersion{},
+		&models.ProjectCollaborator{},
 		&models.Conversation{},
 		&models.Template{},
+		&models.TemplateReview{},
+		&models.PromptTemplate{},
+		&models.PromptVariant{},
 		&models.UserSession{},
 		&models.APIUsage{},
+		&models.SAMLConfig{},
+		&models.AuditLog{},
+		&models.AdminAuditLog{},
+		&models.ProjectView{},
+		&models.ProjectActivity{},
+		&models.BatchImportJob{},
+		&models.ReplayJob{},
+		&models.AIJob{},
+		&models.AsyncJob{},
+		&models.Job{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.ModerationEvent{},
+		&models.VerificationToken{},
+		&models.PasswordResetToken{},
+		&models.OAuthProvider{},
+		&models.UserAPIKey{},
+		&models.UserMFA{},
+		&models.UserPreference{},
+		&models.LoginAttempt{},
+		&models.ExportRecord{},
+		&models.Notification{},
 	)
 
 	if err != nil {
@@ -66,6 +103,14 @@ func Migrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to create indexes: %w", err)
 	}
 
+	if err := backfillSearchVectors(db, fromVersion); err != nil {
+		return fmt.Errorf("failed to backfill search vectors: %w", err)
+	}
+
+	if err := recordMigrations(db); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
 	return nil
 }
 
@@ -74,6 +119,8 @@ func createIndexes(db *gorm.DB) error {
 		// Users indexes
 		"CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)",
 		"CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at)",
+		"CREATE EXTENSION IF NOT EXISTS btree_gin",
+		"CREATE INDEX IF NOT EXISTS idx_users_is_guest ON users USING GIN(is_guest)",
 
 		// Projects indexes
 		"CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id)",
@@ -81,19 +128,50 @@ func createIndexes(db *gorm.DB) error {
 		"CREATE INDEX IF NOT EXISTS idx_projects_created_at ON projects(created_at)",
 		"CREATE INDEX IF NOT EXISTS idx_projects_is_public ON projects(is_public)",
 		"CREATE INDEX IF NOT EXISTS idx_projects_tags ON projects USING GIN(tags)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_forked_from_id ON projects(forked_from_id)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_collection_id_sort_order ON projects(collection_id, sort_order)",
 
-		// Full-text search index for projects
-		"CREATE INDEX IF NOT EXISTS idx_projects_search ON projects USING GIN(to_tsvector('english', name || ' ' || COALESCE(description, '')))",
+		// Keyset pagination indexes for project listings
+		"CREATE INDEX IF NOT EXISTS idx_projects_user_id_updated_at_id ON projects(user_id, updated_at, id)",
+		"CREATE INDEX IF NOT EXISTS idx_projects_user_id_created_at_id ON projects(user_id, created_at, id)",
+
+		// Public gallery indexes
+		"CREATE INDEX IF NOT EXISTS idx_projects_public_created_at_id ON projects(created_at, id) WHERE is_public",
+		"CREATE INDEX IF NOT EXISTS idx_projects_public_view_count_id ON projects(view_count, id) WHERE is_public",
+		"CREATE INDEX IF NOT EXISTS idx_projects_public_like_count_id ON projects(like_count, id) WHERE is_public",
+		"CREATE INDEX IF NOT EXISTS idx_projects_featured ON projects(updated_at) WHERE is_public AND is_featured",
+
+		// Full-text search index for projects, on the vector kept by the search index worker
+		"DROP INDEX IF EXISTS idx_projects_search",
+		"CREATE INDEX IF NOT EXISTS idx_projects_search_vector ON projects USING GIN(search_vector)",
+
+		// Project collections indexes
+		"CREATE INDEX IF NOT EXISTS idx_project_collections_user_id_sort_order ON project_collections(user_id, sort_order)",
+
+		// Project versions indexes
+		"CREATE INDEX IF NOT EXISTS idx_project_versions_created_at ON project_versions(created_at)",
+
+		// Project collaborators indexes
+		"CREATE INDEX IF NOT EXISTS idx_project_collaborators_user_id ON project_collaborators(user_id)",
 
 		// Conversations indexes
 		"CREATE INDEX IF NOT EXISTS idx_conversations_project_id ON conversations(project_id)",
 		"CREATE INDEX IF NOT EXISTS idx_conversations_user_id ON conversations(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_conversations_created_at ON conversations(created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_conversations_parent_conversation_id ON conversations(parent_conversation_id)",
+		"CREATE INDEX IF NOT EXISTS idx_conversations_prompt_variant ON conversations(prompt_variant) WHERE prompt_variant IS NOT NULL",
+		"CREATE INDEX IF NOT EXISTS idx_conversations_search ON conversations USING GIN(to_tsvector('english', user_message || ' ' || ai_response))",
 
 		// Templates indexes
 		"CREATE INDEX IF NOT EXISTS idx_templates_category ON templates(category)",
 		"CREATE INDEX IF NOT EXISTS idx_templates_tags ON templates USING GIN(tags)",
 		"CREATE INDEX IF NOT EXISTS idx_templates_rating ON templates(rating)",
+		"CREATE INDEX IF NOT EXISTS idx_templates_created_by ON templates(created_by)",
+		"CREATE INDEX IF NOT EXISTS idx_templates_approved_usage ON templates(usage_count DESC) WHERE publish_status = 'approved'",
+
+		// Template reviews indexes
+		"CREATE INDEX IF NOT EXISTS idx_template_reviews_template_created_at ON template_reviews(template_id, created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_template_reviews_template_rating ON template_reviews(template_id, rating DESC)",
 
 		// Sessions indexes
 		"CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON user_sessions(user_id)",
@@ -102,6 +180,73 @@ func createIndexes(db *gorm.DB) error {
 		// API usage indexes
 		"CREATE INDEX IF NOT EXISTS idx_api_usage_user_id ON api_usage(user_id)",
 		"CREATE INDEX IF NOT EXISTS idx_api_usage_created_at ON api_usage(created_at)",
+
+		// Audit log indexes
+		"CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id_created_at ON audit_logs(user_id, created_at)",
+		"CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at)",
+
+		// Admin audit log indexes
+		"CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_admin_user_id ON admin_audit_logs(admin_user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_target_id ON admin_audit_logs(target_id)",
+		"CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_created_at ON admin_audit_logs(created_at)",
+
+		// Project views indexes
+		"CREATE INDEX IF NOT EXISTS idx_project_views_project_id_created_at ON project_views(project_id, created_at)",
+
+		// Batch import jobs indexes
+		"CREATE INDEX IF NOT EXISTS idx_batch_import_jobs_user_id ON batch_import_jobs(user_id)",
+
+		// Replay jobs indexes
+		"CREATE INDEX IF NOT EXISTS idx_replay_jobs_user_id ON replay_jobs(user_id)",
+
+		// AI jobs indexes
+		"CREATE INDEX IF NOT EXISTS idx_ai_jobs_queued_created_at ON ai_jobs(created_at) WHERE status = 'queued'",
+		"CREATE INDEX IF NOT EXISTS idx_ai_jobs_user_id ON ai_jobs(user_id)",
+
+		// Async jobs indexes
+		"CREATE INDEX IF NOT EXISTS idx_async_jobs_user_id ON async_jobs(user_id)",
+
+		// Project activities indexes
+		"CREATE INDEX IF NOT EXISTS idx_project_activities_project_id_created_at ON project_activities(project_id, created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_project_activities_user_id_created_at ON project_activities(user_id, created_at DESC)",
+
+		// Prompt templates indexes
+		"CREATE INDEX IF NOT EXISTS idx_prompt_templates_user_id ON prompt_templates(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_prompt_templates_public_usage ON prompt_templates(usage_count DESC) WHERE is_public = true",
+
+		// Prompt variants indexes
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_variants_name ON prompt_variants(name)",
+
+		// Jobs indexes
+		"CREATE INDEX IF NOT EXISTS idx_jobs_queued_run_after ON jobs(run_after) WHERE status = 'queued'",
+		"CREATE INDEX IF NOT EXISTS idx_jobs_status_created_at ON jobs(status, created_at)",
+
+		// Webhooks indexes
+		"CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id_created_at ON webhook_deliveries(webhook_id, created_at)",
+
+		// Moderation events indexes
+		"CREATE INDEX IF NOT EXISTS idx_moderation_events_user_id ON moderation_events(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_moderation_events_created_at ON moderation_events(created_at)",
+
+		// Verification tokens indexes
+		"CREATE INDEX IF NOT EXISTS idx_verification_tokens_user_id ON verification_tokens(user_id)",
+
+		// Password reset tokens indexes
+		"CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id)",
+
+		// Login attempts indexes
+		"CREATE INDEX IF NOT EXISTS idx_login_attempts_email_created_at ON login_attempts(email, created_at)",
+
+		// API keys indexes
+		"CREATE INDEX IF NOT EXISTS idx_user_api_keys_user_id ON user_api_keys(user_id)",
+
+		// Export records indexes
+		"CREATE INDEX IF NOT EXISTS idx_export_records_user_id_created_at ON export_records(user_id, created_at DESC)",
+
+		// Notifications indexes
+		"CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at ON notifications(user_id, created_at DESC)",
+		"CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE is_read = false",
 	}
 
 	for _, indexSQL := range indexes {
@@ -0,0 +1,186 @@
+// internal/database/migrations.go
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// schemaMigrations lists schema versions in the order they were introduced.
+// Append an entry whenever a model or index change needs Migrate to run again
+// on existing databases.
+var schemaMigrations = []schemaMigration{
+	{Version: 1, Description: "initial schema"},
+	{Version: 2, Description: "moderation events and admin flag"},
+	{Version: 3, Description: "audit logs"},
+	{Version: 4, Description: "project views"},
+	{Version: 5, Description: "batch import jobs"},
+	{Version: 6, Description: "project HTML storage keys"},
+	{Version: 7, Description: "SAML SSO"},
+	{Version: 8, Description: "conversation replay jobs"},
+	{Version: 9, Description: "conversation cache flag"},
+	{Version: 10, Description: "project search vectors"},
+	{Version: 11, Description: "project thumbnail refresh tracking"},
+	{Version: 12, Description: "email verification tokens"},
+	{Version: 13, Description: "password reset tokens"},
+	{Version: 14, Description: "OAuth provider links"},
+	{Version: 15, Description: "user API keys"},
+	{Version: 16, Description: "project version history"},
+	{Version: 17, Description: "project keyset pagination indexes"},
+	{Version: 18, Description: "project collaborators"},
+	{Version: 19, Description: "TOTP two-factor authentication"},
+	{Version: 20, Description: "login attempts"},
+	{Version: 21, Description: "export history"},
+	{Version: 22, Description: "project search vectors include generated HTML"},
+	{Version: 23, Description: "public project gallery"},
+	{Version: 24, Description: "conversation branching"},
+	{Version: 25, Description: "async AI generation jobs"},
+	{Version: 26, Description: "async jobs for account data exports"},
+	{Version: 27, Description: "user webhooks"},
+	{Version: 28, Description: "project activity feed"},
+	{Version: 29, Description: "user preferences"},
+	{Version: 30, Description: "admin audit log"},
+	{Version: 31, Description: "prompt templates"},
+	{Version: 32, Description: "background job queue"},
+	{Version: 33, Description: "project collections"},
+	{Version: 34, Description: "conversation full-text search index"},
+	{Version: 35, Description: "user-submitted templates"},
+	{Version: 36, Description: "template reviews"},
+	{Version: 37, Description: "project activity source"},
+	{Version: 38, Description: "project version conversation link, size and pruning"},
+	{Version: 39, Description: "prompt variants"},
+	{Version: 40, Description: "user subscription renewal date"},
+	{Version: 41, Description: "notifications"},
+	{Version: 42, Description: "hashed email verification tokens"},
+	{Version: 43, Description: "hashed password reset tokens"},
+}
+
+type schemaMigration struct {
+	Version     int64 `gorm:"primaryKey;autoIncrement:false"`
+	Description string
+	AppliedAt   time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// IsUpToDate reports whether the database has every compiled schema version
+// applied, so startup can skip AutoMigrate.
+func IsUpToDate(db *gorm.DB) (bool, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return false, err
+	}
+	return pending == 0, nil
+}
+
+// PendingMigrations returns how many compiled schema versions are newer than
+// the highest version recorded in schema_migrations.
+func PendingMigrations(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return len(schemaMigrations), nil
+	}
+
+	current, err := currentVersion(db)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, migration := range schemaMigrations {
+		if migration.Version > current {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// currentVersion returns the highest version recorded in schema_migrations,
+// or 0 on a fresh database
+func currentVersion(db *gorm.DB) (int64, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return 0, nil
+	}
+
+	var current int64
+	if err := db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return current, nil
+}
+
+// SearchVectorSQL builds projects.search_vector from a project's name,
+// description and generated HTML. Markup is skipped by the text search parser.
+const SearchVectorSQL = "to_tsvector('english', name || ' ' || COALESCE(description,'') || ' ' || COALESCE(html_code,''))"
+
+// ExternalHTMLSearchVectorSQL builds the same vector for a project whose HTML
+// is kept in external storage, taking the HTML as a query parameter.
+const ExternalHTMLSearchVectorSQL = "to_tsvector('english', name || ' ' || COALESCE(description,'') || ' ' || ?)"
+
+// backfillSearchVectors rebuilds every project's search vector once when
+// upgrading past the version that added HTML to it.
+func backfillSearchVectors(db *gorm.DB, fromVersion int64) error {
+	if fromVersion >= 22 {
+		return nil
+	}
+	return db.Exec("UPDATE projects SET search_vector = " + SearchVectorSQL).Error
+}
+
+// hashEmailedTokens replaces the plaintext tokens stored before versions 42
+// (verification) and 43 (password reset) with their SHA-256 hashes, so links
+// already emailed keep working. It runs before AutoMigrate, which can't add
+// the NOT NULL token_hash column to a table that has rows.
+func hashEmailedTokens(db *gorm.DB, fromVersion int64) error {
+	if fromVersion < 42 {
+		if err := hashTokenColumn(db, "verification_tokens"); err != nil {
+			return err
+		}
+	}
+	if fromVersion < 43 {
+		if err := hashTokenColumn(db, "password_reset_tokens"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashTokenColumn renames table's plaintext token column to token_hash and
+// hashes its values in place
+func hashTokenColumn(db *gorm.DB, table string) error {
+	if !db.Migrator().HasColumn(table, "token") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			"DROP INDEX IF EXISTS idx_" + table + "_token",
+			"ALTER TABLE " + table + " RENAME COLUMN token TO token_hash",
+			"UPDATE " + table + " SET token_hash = encode(sha256(token_hash::bytea), 'hex')",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func recordMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	records := make([]schemaMigration, len(schemaMigrations))
+	for i, migration := range schemaMigrations {
+		records[i] = migration
+		records[i].AppliedAt = now
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&records).Error
+}
@@ -0,0 +1,144 @@
+// internal/database/router.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"lovable-backend/pkg/logger"
+)
+
+const (
+	replicaHealthCheckInterval = 30 * time.Second
+	maxReplicationLag          = 10 * time.Second
+)
+
+// DBRouter sends writes to the primary and spreads reads across healthy read
+// replicas in round-robin order, falling back to the primary when none are
+// available.
+type DBRouter struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+
+	mu      sync.RWMutex
+	healthy []*gorm.DB
+	next    uint64
+}
+
+func NewRouter(primary *gorm.DB, replicas []*gorm.DB) *DBRouter {
+	return &DBRouter{
+		primary:  primary,
+		replicas: replicas,
+		healthy:  append([]*gorm.DB{}, replicas...),
+	}
+}
+
+// ConnectReadReplica opens a connection to a read replica
+func ConnectReadReplica(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Warn),
+		NowFunc: func() time.Time {
+			return time.Now().UTC()
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to read replica: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure read replica: %w", err)
+	}
+
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	return db, nil
+}
+
+// Writer returns the primary database
+func (r *DBRouter) Writer() *gorm.DB {
+	return r.primary
+}
+
+// Reader returns the next healthy replica, or the primary when no replica is
+// healthy. Reads that must observe a preceding write should use Writer.
+func (r *DBRouter) Reader() *gorm.DB {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.healthy) == 0 {
+		return r.primary
+	}
+
+	n := atomic.AddUint64(&r.next, 1)
+	return r.healthy[n%uint64(len(r.healthy))]
+}
+
+// StartHealthCheck periodically removes unreachable or lagging replicas from
+// rotation and restores them once they catch up.
+func (r *DBRouter) StartHealthCheck(ctx context.Context, logger *logger.Logger) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(replicaHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkReplicas(ctx, logger)
+		}
+	}
+}
+
+func (r *DBRouter) checkReplicas(ctx context.Context, logger *logger.Logger) {
+	healthy := make([]*gorm.DB, 0, len(r.replicas))
+	for i, replica := range r.replicas {
+		lag, err := replicationLag(ctx, replica)
+		if err != nil {
+			logger.Warn("Read replica health check failed", "replica", i, "error", err)
+			continue
+		}
+		if lag > maxReplicationLag {
+			logger.Warn("Read replica lagging, removed from rotation", "replica", i, "lag", lag.String())
+			continue
+		}
+		healthy = append(healthy, replica)
+	}
+
+	r.mu.Lock()
+	r.healthy = healthy
+	r.mu.Unlock()
+}
+
+// replicationLag measures how far a replica's replay is behind the primary.
+// An idle primary produces no new transactions to replay, so a replica that
+// has received everything the primary sent is treated as caught up.
+func replicationLag(ctx context.Context, replica *gorm.DB) (time.Duration, error) {
+	var seconds *float64
+	err := replica.WithContext(ctx).Raw(`
+		SELECT CASE
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END`).Scan(&seconds).Error
+	if err != nil {
+		return 0, err
+	}
+	if seconds == nil {
+		return 0, nil
+	}
+
+	return time.Duration(*seconds * float64(time.Second)), nil
+}
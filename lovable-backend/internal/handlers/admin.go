@@ -0,0 +1,574 @@
+// internal/handlers/admin.go
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type AdminHandler struct {
+	adminService      *services.AdminService
+	moderationService *services.ModerationService
+	projectService    *services.ProjectService
+	auditService      *services.AuditService
+	templateService   *services.TemplateService
+	promptVariants    *services.PromptVariantService
+	webhookDispatcher *services.WebhookDispatcher
+	logger            *logger.Logger
+}
+
+func NewAdminHandler(adminService *services.AdminService, moderationService *services.ModerationService, projectService *services.ProjectService, auditService *services.AuditService, templateService *services.TemplateService, promptVariants *services.PromptVariantService, webhookDispatcher *services.WebhookDispatcher, logger *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		adminService:      adminService,
+		moderationService: moderationService,
+		projectService:    projectService,
+		auditService:      auditService,
+		templateService:   templateService,
+		promptVariants:    promptVariants,
+		webhookDispatcher: webhookDispatcher,
+		logger:            logger,
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.adminService.ListUsers(c.Request.Context(), c.Query("search"), page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list users", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list users",
+			"code":  "USER_LIST_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	user, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		h.respondAdminError(c, err, "USER_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AdminHandler) DisableUser(c *gin.Context) {
+	h.setUserActive(c, false)
+}
+
+func (h *AdminHandler) EnableUser(c *gin.Context) {
+	h.setUserActive(c, true)
+}
+
+func (h *AdminHandler) setUserActive(c *gin.Context, active bool) {
+	adminIDStr := c.GetString("userID")
+	adminID, err := uuid.Parse(adminIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	if err := h.adminService.SetUserActive(c.Request.Context(), adminID, userID, active); err != nil {
+		h.respondAdminError(c, err, "USER_UPDATE_ERROR")
+		return
+	}
+
+	h.logger.Info("User active status changed", "userId", userID, "active", active, "adminId", adminID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "User updated successfully",
+		"is_active": active,
+	})
+}
+
+func (h *AdminHandler) ChangePlan(c *gin.Context) {
+	adminIDStr := c.GetString("userID")
+	adminID, err := uuid.Parse(adminIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req models.ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.adminService.ChangePlan(c.Request.Context(), adminID, userID, req.Plan); err != nil {
+		h.respondAdminError(c, err, "PLAN_CHANGE_ERROR")
+		return
+	}
+
+	h.logger.Info("User plan changed", "userId", userID, "plan", req.Plan, "adminId", adminID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Plan changed successfully",
+		"subscription_plan": req.Plan,
+	})
+}
+
+func (h *AdminHandler) DeleteUser(c *gin.Context) {
+	adminIDStr := c.GetString("userID")
+	adminID, err := uuid.Parse(adminIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	if err := h.adminService.DeleteUser(c.Request.Context(), adminID, userID); err != nil {
+		h.logger.Error("Failed to delete user", "userId", userID, "adminId", adminID, "error", err)
+		h.respondAdminError(c, err, "USER_DELETE_ERROR")
+		return
+	}
+
+	h.logger.Info("User deleted by admin", "userId", userID, "adminId", adminID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User deleted successfully",
+	})
+}
+
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.adminService.GetStats(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get admin stats", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get stats",
+			"code":  "STATS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListJobs returns background jobs for queue monitoring; ?status= limits the
+// list to queued, running, completed or dead jobs
+func (h *AdminHandler) ListJobs(c *gin.Context) {
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.adminService.ListJobs(c.Request.Context(), c.Query("status"), page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list jobs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list jobs",
+			"code":  "JOB_LIST_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RetryFailedWebhooks requeues up to 100 dead-lettered webhook events, all
+// webhooks' or only those of the optional body's webhook_id
+func (h *AdminHandler) RetryFailedWebhooks(c *gin.Context) {
+	var req models.RetryWebhookDeadLettersRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	requeued, err := h.webhookDispatcher.RetryDeadLetters(c.Request.Context(), req.WebhookID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "webhook queue unavailable" {
+			status = http.StatusServiceUnavailable
+		}
+		h.logger.Error("Failed to retry webhook dead letters", "error", err)
+		c.JSON(status, gin.H{
+			"error":    "Failed to retry failed webhooks",
+			"code":     "WEBHOOK_RETRY_ERROR",
+			"requeued": requeued,
+		})
+		return
+	}
+
+	h.recordAction(c, "webhooks_retried", req.WebhookID, map[string]interface{}{"requeued": requeued})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Failed webhook deliveries requeued",
+		"requeued": requeued,
+	})
+}
+
+func (h *AdminHandler) GetWebhookStats(c *gin.Context) {
+	stats, err := h.webhookDispatcher.GetStats(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get webhook stats", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get webhook stats",
+			"code":  "STATS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *AdminHandler) SetModerationMode(c *gin.Context) {
+	var req models.ModerationModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.moderationService.SetMode(req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_MODERATION_MODE",
+		})
+		return
+	}
+
+	h.recordAction(c, "moderation_mode_changed", nil, map[string]interface{}{"mode": req.Mode})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Moderation mode updated",
+		"mode":    h.moderationService.Mode(),
+	})
+}
+
+func (h *AdminHandler) SetFeatured(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID format",
+			"code":  "INVALID_PROJECT_ID",
+		})
+		return
+	}
+
+	var req models.SetFeaturedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.projectService.SetFeatured(c.Request.Context(), projectID, *req.Featured); err != nil {
+		status := http.StatusInternalServerError
+		code := "FEATURE_ERROR"
+
+		switch err.Error() {
+		case "project not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case "only public projects can be featured":
+			status = http.StatusBadRequest
+			code = "PROJECT_NOT_PUBLIC"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	h.logger.Info("Project featured status changed", "projectId", projectID, "featured", *req.Featured, "adminId", c.GetString("userID"))
+	h.recordAction(c, "project_featured", &projectID, map[string]interface{}{"featured": *req.Featured})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Featured status updated",
+		"featured": *req.Featured,
+	})
+}
+
+// ApproveTemplate publishes a user-submitted template that is pending review
+func (h *AdminHandler) ApproveTemplate(c *gin.Context) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid template ID format",
+			"code":  "INVALID_TEMPLATE_ID",
+		})
+		return
+	}
+
+	if err := h.templateService.ApproveTemplate(c.Request.Context(), templateID); err != nil {
+		status := http.StatusInternalServerError
+		code := "TEMPLATE_APPROVE_ERROR"
+
+		switch err.Error() {
+		case "template not found":
+			status = http.StatusNotFound
+			code = "TEMPLATE_NOT_FOUND"
+		case "template is not pending review":
+			status = http.StatusConflict
+			code = "TEMPLATE_NOT_PENDING"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	h.logger.Info("Template approved", "templateId", templateID, "adminId", c.GetString("userID"))
+	h.recordAction(c, "template_approved", &templateID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Template approved",
+	})
+}
+
+func (h *AdminHandler) ListPromptVariants(c *gin.Context) {
+	variants, err := h.promptVariants.ListVariants(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list prompt variants", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list prompt variants",
+			"code":  "PROMPT_VARIANT_LIST_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"variants": variants,
+	})
+}
+
+func (h *AdminHandler) CreatePromptVariant(c *gin.Context) {
+	var req models.CreatePromptVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	variant, err := h.promptVariants.CreateVariant(c.Request.Context(), &req)
+	if err != nil {
+		h.respondPromptVariantError(c, err, "PROMPT_VARIANT_CREATE_ERROR")
+		return
+	}
+
+	h.recordAction(c, "prompt_variant_created", &variant.ID, map[string]interface{}{
+		"name":      variant.Name,
+		"weight":    variant.Weight,
+		"is_active": variant.IsActive,
+	})
+
+	c.JSON(http.StatusCreated, variant)
+}
+
+func (h *AdminHandler) UpdatePromptVariant(c *gin.Context) {
+	variantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt variant ID format",
+			"code":  "INVALID_PROMPT_VARIANT_ID",
+		})
+		return
+	}
+
+	var req models.UpdatePromptVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	variant, err := h.promptVariants.UpdateVariant(c.Request.Context(), variantID, &req)
+	if err != nil {
+		h.respondPromptVariantError(c, err, "PROMPT_VARIANT_UPDATE_ERROR")
+		return
+	}
+
+	h.recordAction(c, "prompt_variant_updated", &variant.ID, map[string]interface{}{
+		"name":      variant.Name,
+		"weight":    variant.Weight,
+		"is_active": variant.IsActive,
+	})
+
+	c.JSON(http.StatusOK, variant)
+}
+
+func (h *AdminHandler) DeletePromptVariant(c *gin.Context) {
+	variantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt variant ID format",
+			"code":  "INVALID_PROMPT_VARIANT_ID",
+		})
+		return
+	}
+
+	if err := h.promptVariants.DeleteVariant(c.Request.Context(), variantID); err != nil {
+		h.respondPromptVariantError(c, err, "PROMPT_VARIANT_DELETE_ERROR")
+		return
+	}
+
+	h.recordAction(c, "prompt_variant_deleted", &variantID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prompt variant deleted successfully",
+	})
+}
+
+// AnalysePromptVariants compares the satisfaction ratings of generations
+// made with each prompt variant and refreshes their win rates
+func (h *AdminHandler) AnalysePromptVariants(c *gin.Context) {
+	analysis, err := h.promptVariants.AnalyseVariants(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to analyse prompt variants", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to analyse prompt variants",
+			"code":  "PROMPT_VARIANT_ANALYSIS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysis)
+}
+
+func (h *AdminHandler) respondPromptVariantError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch err.Error() {
+	case "prompt variant not found":
+		status = http.StatusNotFound
+		code = "PROMPT_VARIANT_NOT_FOUND"
+	case "prompt variant name already exists":
+		status = http.StatusConflict
+		code = "PROMPT_VARIANT_NAME_TAKEN"
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
+
+// recordAction adds an action taken through this handler to the admin audit
+// log without failing the request
+func (h *AdminHandler) recordAction(c *gin.Context, action string, targetID *uuid.UUID, details map[string]interface{}) {
+	adminID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		return
+	}
+
+	if err := h.auditService.RecordAdminAction(adminID, action, targetID, details); err != nil {
+		h.logger.Error("Failed to record admin action", "adminId", adminID, "action", action, "error", err)
+	}
+}
+
+func (h *AdminHandler) respondAdminError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch err.Error() {
+	case "record not found":
+		status = http.StatusNotFound
+		code = "USER_NOT_FOUND"
+	case "cannot modify your own account":
+		status = http.StatusBadRequest
+		code = "CANNOT_MODIFY_SELF"
+	case "invalid plan":
+		status = http.StatusBadRequest
+		code = "INVALID_PLAN"
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
@@ -2,8 +2,12 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,18 +16,38 @@ import (
 
 	"lovable-backend/internal/models"
 	"lovable-backend/internal/services"
+	"lovable-backend/internal/shutdown"
+	"lovable-backend/internal/ws"
 	"lovable-backend/pkg/logger"
 )
 
 type AIHandler struct {
-	aiService      *services.AIService
-	projectService *services.ProjectService
-	authService    *services.AuthService
-	logger         *logger.Logger
-	upgrader       websocket.Upgrader
+	aiService         *services.AIService
+	projectService    *services.ProjectService
+	authService       *services.AuthService
+	moderationService *services.ModerationService
+	shutdownMonitor   *shutdown.ShutdownMonitor
+	notifications     *services.NotificationBatcher
+	aiJobs            *services.AIJobQueue
+	promptTemplates   *services.PromptTemplateService
+	wsHub             *ws.WSHub
+	maxMessageBytes   int64
+	logger            *logger.Logger
+	upgrader          websocket.Upgrader
+
+	// In-flight WebSocket generations by project ID, for cancel_generation
+	generations sync.Map
 }
 
-func NewAIHandler(aiService *services.AIService, projectService *services.ProjectService, logger *logger.Logger) *AIHandler {
+type wsGeneration struct {
+	userID uuid.UUID
+	cancel context.CancelFunc
+}
+
+// Deadline for a single generation, including retries within the AI service
+const generationTimeout = 45 * time.Second
+
+func NewAIHandler(aiService *services.AIService, projectService *services.ProjectService, authService *services.AuthService, moderationService *services.ModerationService, shutdownMonitor *shutdown.ShutdownMonitor, notifications *services.NotificationBatcher, aiJobs *services.AIJobQueue, promptTemplates *services.PromptTemplateService, wsHub *ws.WSHub, maxMessageBytes int64, logger *logger.Logger) *AIHandler {
 	upgrader := websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
 			// Allow all origins for development - restrict in production
@@ -32,10 +56,18 @@ func NewAIHandler(aiService *services.AIService, projectService *services.Projec
 	}
 
 	return &AIHandler{
-		aiService:      aiService,
-		projectService: projectService,
-		logger:         logger,
-		upgrader:       upgrader,
+		aiService:         aiService,
+		projectService:    projectService,
+		authService:       authService,
+		moderationService: moderationService,
+		shutdownMonitor:   shutdownMonitor,
+		notifications:     notifications,
+		aiJobs:            aiJobs,
+		promptTemplates:   promptTemplates,
+		wsHub:             wsHub,
+		maxMessageBytes:   maxMessageBytes,
+		logger:            logger,
+		upgrader:          upgrader,
 	}
 }
 
@@ -44,8 +76,9 @@ func (h *AIHandler) Generate(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -53,27 +86,67 @@ func (h *AIHandler) Generate(c *gin.Context) {
 	var req models.GenerateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
+	if !h.applyPromptTemplate(c, userID, &req) {
+		return
+	}
+
 	startTime := time.Now()
 
 	// Verify project ownership and get project details
-	project, err := h.projectService.GetProject(userID, req.ProjectID)
+	project, err := h.projectService.GetProject(c.Request.Context(), userID, req.ProjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Project not found or access denied",
-			"code":  "PROJECT_NOT_FOUND",
+			"error":     "Project not found or access denied",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// Screen the prompt before it reaches the model
+	allowed, reason, err := h.moderationService.CheckPrompt(c.Request.Context(), userID, req.Message)
+	if err != nil {
+		// Fail open so a moderation outage does not block generation
+		h.logger.Error("Prompt moderation failed", "userId", userID, "error", err)
+	} else if !allowed {
+		h.logger.Info("Prompt blocked by moderation", "projectId", req.ProjectID, "userId", userID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Your request was blocked by our content policy",
+			"code":      "CONTENT_MODERATED",
+			"reason":    reason,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// One generation per project at a time, across tabs and instances
+	unlock, acquired, err := h.aiService.LockProjectGeneration(req.ProjectID)
+	if err != nil {
+		h.logger.Warn("Generation lock unavailable, continuing without it", "projectId", req.ProjectID, "error", err)
+	}
+	if !acquired {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "A generation is already in progress for this project",
+			"code":      "GENERATION_IN_PROGRESS",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
+	defer unlock()
 
-	// Generate website code
-	result, err := h.aiService.GenerateWebsite(req.Message, req.ConversationHistory, nil)
+	// Generate website code; cancelled if the client disconnects
+	ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+	defer cancel()
+
+	result, err := h.aiService.GenerateWebsite(ctx, req.Message, req.ConversationHistory, nil)
 	if err != nil {
 		status := http.StatusInternalServerError
 		code := "GENERATION_ERROR"
@@ -81,11 +154,18 @@ func (h *AIHandler) Generate(c *gin.Context) {
 		if err.Error() == "rate limit exceeded" {
 			status = http.StatusTooManyRequests
 			code = "AI_RATE_LIMIT"
+		} else if errors.Is(err, services.ErrContentFiltered) {
+			status = http.StatusUnprocessableEntity
+			code = "CONTENT_FILTERED"
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+			code = "GENERATION_TIMEOUT"
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -94,9 +174,9 @@ func (h *AIHandler) Generate(c *gin.Context) {
 
 	// Save conversation and update project
 	conversation, err := h.projectService.SaveConversation(
-		req.ProjectID, userID, req.Message,
+		c.Request.Context(), req.ProjectID, userID, req.Message,
 		result.ConversationalResponse, result.HTMLCode,
-		result.TokensUsed, responseTime, "claude-sonnet-4", "generation",
+		result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, "generation", result.FromCache,
 	)
 	if err != nil {
 		h.logger.Error("Failed to save conversation", "error", err)
@@ -105,13 +185,18 @@ func (h *AIHandler) Generate(c *gin.Context) {
 	// Update project with new code if generated
 	if result.HTMLCode != "" {
 		updateReq := &models.UpdateProjectRequest{
-			HTMLCode: &result.HTMLCode,
+			HTMLCode:     &result.HTMLCode,
+			ChangeSource: services.ChangeSourceAIGeneration,
+		}
+		if conversation != nil {
+			updateReq.ConversationID = &conversation.ID
 		}
-		h.projectService.UpdateProject(userID, req.ProjectID, updateReq)
+		h.projectService.UpdateProject(c.Request.Context(), userID, req.ProjectID, updateReq)
 	}
 
 	// Increment user usage
 	h.authService.IncrementUsage(userID)
+	h.notifications.Queue(userID, "generation", req.ProjectID.String())
 
 	// Use the project variable in response
 	response := models.GenerateResponse{
@@ -123,6 +208,9 @@ func (h *AIHandler) Generate(c *gin.Context) {
 			TokensUsed:             result.TokensUsed,
 			ResponseTime:           int(responseTime),
 			FromCache:              result.FromCache,
+			ContextUtilization:     result.ContextUtilization,
+			Warnings:               result.Warnings,
+			Partial:                result.Partial,
 			GeneratedAt:            conversation.CreatedAt,
 		},
 		Project: &models.ProjectBasicInfo{
@@ -134,13 +222,558 @@ func (h *AIHandler) Generate(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// applyPromptTemplate renders the prompt template a generation request refers
+// to and prepends it to the message. It responds with an error and returns
+// false when the template can't be rendered.
+func (h *AIHandler) applyPromptTemplate(c *gin.Context, userID uuid.UUID, req *models.GenerateRequest) bool {
+	if req.PromptTemplateID == nil {
+		return true
+	}
+
+	rendered, err := h.promptTemplates.RenderTemplate(c.Request.Context(), userID, *req.PromptTemplateID, req.TemplateVariables)
+	if err != nil {
+		status, code := promptTemplateErrorStatus(err, "PROMPT_TEMPLATE_RENDER_ERROR")
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return false
+	}
+
+	req.Message = rendered + "\n\n" + req.Message
+	return true
+}
+
+// GenerateAsync queues a generation and returns immediately. The result is
+// polled from GetJob or pushed over the user's WebSocket connections.
+func (h *AIHandler) GenerateAsync(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if !h.applyPromptTemplate(c, userID, &req) {
+		return
+	}
+
+	allowed, reason, err := h.moderationService.CheckPrompt(c.Request.Context(), userID, req.Message)
+	if err != nil {
+		h.logger.Error("Prompt moderation failed", "userId", userID, "error", err)
+	} else if !allowed {
+		h.logger.Info("Prompt blocked by moderation", "projectId", req.ProjectID, "userId", userID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Your request was blocked by our content policy",
+			"code":      "CONTENT_MODERATED",
+			"reason":    reason,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.aiJobs.Enqueue(c.Request.Context(), userID, &req)
+	if err != nil {
+		if err.Error() == "project not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "Project not found or access denied",
+				"code":      "PROJECT_NOT_FOUND",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		h.logger.Error("Failed to queue AI job", "projectId", req.ProjectID, "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to queue generation",
+			"code":      "QUEUE_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"jobId":  job.ID,
+		"status": job.Status,
+	})
+}
+
+func (h *AIHandler) GetJob(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid job ID format",
+			"code":      "INVALID_JOB_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.aiJobs.GetJob(c.Request.Context(), userID, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Job not found",
+			"code":      "JOB_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+// BranchConversation regenerates from an earlier point of a conversation,
+// keeping the turns after it on the original branch.
+func (h *AIHandler) BranchConversation(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	conversationID, err := uuid.Parse(c.Param("conversationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid conversation ID format",
+			"code":      "INVALID_CONVERSATION_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.BranchConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	allowed, reason, err := h.moderationService.CheckPrompt(c.Request.Context(), userID, req.NewMessage)
+	if err != nil {
+		h.logger.Error("Prompt moderation failed", "userId", userID, "error", err)
+	} else if !allowed {
+		h.logger.Info("Prompt blocked by moderation", "projectId", projectID, "userId", userID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Your request was blocked by our content policy",
+			"code":      "CONTENT_MODERATED",
+			"reason":    reason,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+	defer cancel()
+
+	result, err := h.projectService.BranchConversation(ctx, userID, projectID, conversationID, *req.FromIndex, req.NewMessage)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "BRANCH_ERROR"
+
+		switch {
+		case err.Error() == "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case err.Error() == "conversation not found":
+			status = http.StatusNotFound
+			code = "CONVERSATION_NOT_FOUND"
+		case err.Error() == "branch point out of range":
+			status = http.StatusBadRequest
+			code = "INVALID_BRANCH_POINT"
+		case err.Error() == "rate limit exceeded":
+			status = http.StatusTooManyRequests
+			code = "AI_RATE_LIMIT"
+		case errors.Is(err, services.ErrContentFiltered):
+			status = http.StatusUnprocessableEntity
+			code = "CONTENT_FILTERED"
+		case errors.Is(err, context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+			code = "GENERATION_TIMEOUT"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.authService.IncrementUsage(userID)
+	h.notifications.Queue(userID, "generation", projectID.String())
+
+	h.logger.Info("Conversation branched", "projectId", projectID, "fromConversationId", conversationID, "fromIndex", *req.FromIndex, "conversationId", result.ConversationID, "userId", userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Conversation branched successfully",
+		"result":  result,
+	})
+}
+
+// GenerateStream runs a generation and reports progress as Server-Sent Events,
+// mirroring the WebSocket event sequence for clients that cannot upgrade.
+func (h *AIHandler) GenerateStream(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.GenerateStreamQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var history []models.ConversationEntry
+	if req.ConversationHistory != "" {
+		if err := json.Unmarshal([]byte(req.ConversationHistory), &history); err != nil || len(history) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "conversationHistory must be a JSON array of at most 50 entries",
+				"code":      "VALIDATION_ERROR",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+	}
+
+	project, err := h.projectService.GetProject(c.Request.Context(), userID, req.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Project not found or access denied",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	allowed, reason, err := h.moderationService.CheckPrompt(c.Request.Context(), userID, req.Message)
+	if err != nil {
+		h.logger.Error("Prompt moderation failed", "userId", userID, "error", err)
+	} else if !allowed {
+		h.logger.Info("Prompt blocked by moderation", "projectId", req.ProjectID, "userId", userID)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Your request was blocked by our content policy",
+			"code":      "CONTENT_MODERATED",
+			"reason":    reason,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	done, ok := h.shutdownMonitor.BeginGeneration()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":     "Server is shutting down",
+			"code":      "SHUTTING_DOWN",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+	defer done()
+
+	// Cancel the generation as soon as the stream ends, including when the
+	// client disconnects mid-stream
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, generationTimeout)
+	defer cancelTimeout()
+
+	type generationOutcome struct {
+		result *services.GenerationResult
+		err    error
+	}
+
+	progress := make(chan int, 8)
+	outcome := make(chan generationOutcome, 1)
+	startTime := time.Now()
+
+	go func() {
+		result, err := h.aiService.GenerateWebsite(ctx, req.Message, history, func(percent int) {
+			select {
+			case progress <- percent:
+			case <-ctx.Done():
+			}
+		})
+		outcome <- generationOutcome{result: result, err: err}
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("generation_started", gin.H{"projectId": project.ID})
+	c.Writer.Flush()
+
+	sendProgress := func(percent int) {
+		c.SSEvent("generation_progress", gin.H{
+			"projectId": project.ID,
+			"progress":  percent,
+			"stage":     "generating",
+		})
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			h.logger.Info("SSE client disconnected", "projectId", project.ID, "userId", userID)
+			return false
+		case percent := <-progress:
+			sendProgress(percent)
+			return true
+		case out := <-outcome:
+			// Flush progress reported just before the result arrived
+			for len(progress) > 0 {
+				sendProgress(<-progress)
+			}
+
+			if out.err != nil {
+				code := "GENERATION_ERROR"
+				if errors.Is(out.err, services.ErrContentFiltered) {
+					code = "CONTENT_FILTERED"
+				} else if errors.Is(out.err, context.DeadlineExceeded) {
+					code = "GENERATION_TIMEOUT"
+				}
+				c.SSEvent("generation_error", gin.H{
+					"projectId": project.ID,
+					"error":     out.err.Error(),
+					"code":      code,
+					"requestId": c.GetString("requestID"),
+				})
+				return false
+			}
+
+			c.SSEvent("generation_complete", gin.H{
+				"projectId": project.ID,
+				"result":    h.completeStreamedGeneration(c.Request.Context(), userID, project.ID, req.Message, out.result, startTime),
+			})
+			return false
+		}
+	})
+}
+
+// completeStreamedGeneration persists a finished streamed generation the same
+// way Generate does and returns the result to report to the client.
+func (h *AIHandler) completeStreamedGeneration(ctx context.Context, userID, projectID uuid.UUID, message string, result *services.GenerationResult, startTime time.Time) models.GenerationResult {
+	responseTime := time.Since(startTime).Milliseconds()
+
+	response := models.GenerationResult{
+		ConversationalResponse: result.ConversationalResponse,
+		HTMLCode:               result.HTMLCode,
+		TokensUsed:             result.TokensUsed,
+		ResponseTime:           int(responseTime),
+		FromCache:              result.FromCache,
+		ContextUtilization:     result.ContextUtilization,
+		Warnings:               result.Warnings,
+		Partial:                result.Partial,
+		GeneratedAt:            time.Now(),
+	}
+
+	conversation, err := h.projectService.SaveConversation(
+		ctx, projectID, userID, message,
+		result.ConversationalResponse, result.HTMLCode,
+		result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, "generation", result.FromCache,
+	)
+	if err != nil {
+		h.logger.Error("Failed to save conversation", "error", err)
+	} else {
+		response.ConversationID = conversation.ID
+		response.GeneratedAt = conversation.CreatedAt
+	}
+
+	if result.HTMLCode != "" {
+		updateReq := &models.UpdateProjectRequest{HTMLCode: &result.HTMLCode, ChangeSource: services.ChangeSourceAIGeneration}
+		if conversation != nil {
+			updateReq.ConversationID = &conversation.ID
+		}
+		h.projectService.UpdateProject(ctx, userID, projectID, updateReq)
+	}
+
+	h.authService.IncrementUsage(userID)
+	h.notifications.Queue(userID, "generation", projectID.String())
+
+	return response
+}
+
+// GenerateAnonymous lets visitors generate a website without an account. A
+// guest user owns the resulting project until it is claimed with the
+// returned guest token.
+func (h *AIHandler) GenerateAnonymous(c *gin.Context) {
+	var req models.AnonymousGenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	startTime := time.Now()
+
+	guest, guestToken, err := h.authService.CreateGuestUser()
+	if err != nil {
+		h.logger.Error("Failed to create guest user", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to start guest session",
+			"code":      "GUEST_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectName := req.ProjectName
+	if projectName == "" {
+		projectName = "Untitled Website"
+	}
+
+	project, err := h.projectService.CreateProject(c.Request.Context(), guest.ID, &models.CreateProjectRequest{Name: projectName})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to create project",
+			"code":      "CREATE_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+	defer cancel()
+
+	result, err := h.aiService.GenerateWebsite(ctx, req.Message, nil, nil)
+	if err != nil {
+		if errors.Is(err, services.ErrContentFiltered) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":     err.Error(),
+				"code":      "CONTENT_FILTERED",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     err.Error(),
+			"code":      "GENERATION_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	responseTime := time.Since(startTime).Milliseconds()
+
+	conversation, err := h.projectService.SaveConversation(
+		c.Request.Context(), project.ID, guest.ID, req.Message,
+		result.ConversationalResponse, result.HTMLCode,
+		result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, "generation", result.FromCache,
+	)
+	if err != nil {
+		h.logger.Error("Failed to save conversation", "error", err)
+	}
+
+	if result.HTMLCode != "" {
+		updateReq := &models.UpdateProjectRequest{
+			HTMLCode:     &result.HTMLCode,
+			ChangeSource: services.ChangeSourceAIGeneration,
+		}
+		if conversation != nil {
+			updateReq.ConversationID = &conversation.ID
+		}
+		h.projectService.UpdateProject(c.Request.Context(), guest.ID, project.ID, updateReq)
+	}
+
+	h.logger.Info("Anonymous website generated", "projectId", project.ID, "ip", c.ClientIP())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Website generated successfully",
+		"guest_token": guestToken,
+		"expiresIn":   "24h",
+		"result": models.GenerationResult{
+			ConversationID:         conversation.ID,
+			ConversationalResponse: result.ConversationalResponse,
+			HTMLCode:               result.HTMLCode,
+			TokensUsed:             result.TokensUsed,
+			ResponseTime:           int(responseTime),
+			FromCache:              result.FromCache,
+			ContextUtilization:     result.ContextUtilization,
+			Warnings:               result.Warnings,
+			Partial:                result.Partial,
+			GeneratedAt:            conversation.CreatedAt,
+		},
+		"project": &models.ProjectBasicInfo{
+			ID:   project.ID,
+			Name: project.Name,
+		},
+	})
+}
+
 func (h *AIHandler) Refine(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -148,9 +781,10 @@ func (h *AIHandler) Refine(c *gin.Context) {
 	var req models.RefineRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -158,21 +792,35 @@ func (h *AIHandler) Refine(c *gin.Context) {
 	startTime := time.Now()
 
 	// Verify project ownership
-	_, err = h.projectService.GetProject(userID, req.ProjectID)
+	_, err = h.projectService.GetProject(c.Request.Context(), userID, req.ProjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Project not found or access denied",
-			"code":  "PROJECT_NOT_FOUND",
+			"error":     "Project not found or access denied",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
 	// Refine website code
-	result, err := h.aiService.RefineWebsite(req.CurrentCode, req.RefinementRequest)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+	defer cancel()
+
+	result, err := h.aiService.RefineWebsite(ctx, req.CurrentCode, req.RefinementRequest)
 	if err != nil {
+		if errors.Is(err, services.ErrContentFiltered) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":     err.Error(),
+				"code":      "CONTENT_FILTERED",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Website refinement failed",
-			"code":  "REFINEMENT_ERROR",
+			"error":     "Website refinement failed",
+			"code":      "REFINEMENT_ERROR",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -181,9 +829,9 @@ func (h *AIHandler) Refine(c *gin.Context) {
 
 	// Save conversation and update project
 	conversation, err := h.projectService.SaveConversation(
-		req.ProjectID, userID, req.RefinementRequest,
+		c.Request.Context(), req.ProjectID, userID, req.RefinementRequest,
 		result.ConversationalResponse, result.HTMLCode,
-		result.TokensUsed, responseTime, "claude-sonnet-4", "refinement",
+		result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, "refinement", result.FromCache,
 	)
 	if err != nil {
 		h.logger.Error("Failed to save conversation", "error", err)
@@ -192,13 +840,18 @@ func (h *AIHandler) Refine(c *gin.Context) {
 	// Update project with refined code
 	if result.HTMLCode != "" {
 		updateReq := &models.UpdateProjectRequest{
-			HTMLCode: &result.HTMLCode,
+			HTMLCode:     &result.HTMLCode,
+			ChangeSource: services.ChangeSourceAIGeneration,
 		}
-		h.projectService.UpdateProject(userID, req.ProjectID, updateReq)
+		if conversation != nil {
+			updateReq.ConversationID = &conversation.ID
+		}
+		h.projectService.UpdateProject(c.Request.Context(), userID, req.ProjectID, updateReq)
 	}
 
 	// Increment user usage
 	h.authService.IncrementUsage(userID)
+	h.notifications.Queue(userID, "refinement", req.ProjectID.String())
 
 	response := gin.H{
 		"message": "Website refined successfully",
@@ -215,160 +868,187 @@ func (h *AIHandler) Refine(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *AIHandler) GenerateTemplate(c *gin.Context) {
+func (h *AIHandler) Recolor(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	var req models.TemplateRequest
+	var req models.RecolorRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	style := ""
-	if req.Style != nil {
-		style = *req.Style
+	startTime := time.Now()
+
+	// Verify project ownership
+	project, err := h.projectService.GetProject(c.Request.Context(), userID, req.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Project not found or access denied",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
 	}
 
-	colorScheme := ""
-	if req.ColorScheme != nil {
-		colorScheme = *req.ColorScheme
+	if project.HTMLCode == nil || *project.HTMLCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "No HTML code available for this project",
+			"code":      "NO_HTML_CODE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
 	}
 
-	// Generate template
-	result, err := h.aiService.GenerateFromTemplate(req.Category, style, colorScheme)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+	defer cancel()
+
+	result, err := h.aiService.RecolorWebsite(ctx, *project.HTMLCode, req.NewPalette)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Template generation failed",
-			"code":  "TEMPLATE_ERROR",
+			"error":     "Website recolor failed",
+			"code":      "RECOLOR_ERROR",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	// Increment user usage
-	h.authService.IncrementUsage(userID)
+	responseTime := time.Since(startTime).Milliseconds()
+
+	// Save conversation and update project
+	conversation, err := h.projectService.SaveConversation(
+		c.Request.Context(), req.ProjectID, userID, "Recolor website palette",
+		result.ConversationalResponse, result.HTMLCode,
+		result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, "refinement", result.FromCache,
+	)
+	if err != nil {
+		h.logger.Error("Failed to save conversation", "error", err)
+	}
+
+	if result.HTMLCode != "" {
+		updateReq := &models.UpdateProjectRequest{
+			HTMLCode:     &result.HTMLCode,
+			ChangeSource: services.ChangeSourceAIGeneration,
+		}
+		if conversation != nil {
+			updateReq.ConversationID = &conversation.ID
+		}
+		h.projectService.UpdateProject(c.Request.Context(), userID, req.ProjectID, updateReq)
+	}
+
+	// Only recolors made by the model count towards usage
+	if result.ModelUsed != "" {
+		h.authService.IncrementUsage(userID)
+	}
 
 	response := gin.H{
-		"message": "Template generated successfully",
-		"template": gin.H{
-			"category":    req.Category,
-			"style":       style,
-			"colorScheme": colorScheme,
-			"htmlCode":    result.HTMLCode,
-			"description": result.ConversationalResponse,
+		"message": "Website recolored successfully",
+		"result": models.GenerationResult{
+			ConversationID:         conversation.ID,
+			ConversationalResponse: result.ConversationalResponse,
+			HTMLCode:               result.HTMLCode,
+			TokensUsed:             result.TokensUsed,
+			ResponseTime:           int(responseTime),
+			GeneratedAt:            conversation.CreatedAt,
 		},
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *AIHandler) GetTemplates(c *gin.Context) {
-	category := c.Query("category")
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	if err != nil || limit <= 0 {
-		limit = 20
+func (h *AIHandler) GenerateTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
 	}
 
-	// This would typically fetch from database
-	// For now, return the available template categories
-	templates := []gin.H{
-		{
-			"id":          uuid.New(),
-			"name":        "Modern Portfolio",
-			"description": "A clean, modern portfolio website perfect for developers and designers",
-			"category":    "portfolio",
-			"tags":        []string{"modern", "portfolio", "developer"},
-			"usage_count": 145,
-			"rating":      4.8,
-			"is_premium":  false,
-			"created_at":  time.Now().Add(-30 * 24 * time.Hour),
-		},
-		{
-			"id":          uuid.New(),
-			"name":        "SaaS Landing Page",
-			"description": "A high-converting landing page template for SaaS products",
-			"category":    "landing",
-			"tags":        []string{"saas", "landing", "conversion"},
-			"usage_count": 98,
-			"rating":      4.6,
-			"is_premium":  false,
-			"created_at":  time.Now().Add(-15 * 24 * time.Hour),
-		},
-		{
-			"id":          uuid.New(),
-			"name":        "Restaurant Website",
-			"description": "Appetizing restaurant website with menu and contact information",
-			"category":    "restaurant",
-			"tags":        []string{"restaurant", "food", "menu"},
-			"usage_count": 67,
-			"rating":      4.4,
-			"is_premium":  false,
-			"created_at":  time.Now().Add(-7 * 24 * time.Hour),
-		},
+	var req models.TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
 	}
 
-	// Filter by category if specified
-	if category != "" {
-		filtered := []gin.H{}
-		for _, template := range templates {
-			if template["category"] == category {
-				filtered = append(filtered, template)
-			}
-		}
-		templates = filtered
+	style := ""
+	if req.Style != nil {
+		style = *req.Style
 	}
 
-	categories := []string{
-		"portfolio", "landing", "blog", "ecommerce", "restaurant",
-		"business", "personal", "dashboard", "documentation",
+	colorScheme := ""
+	if req.ColorScheme != nil {
+		colorScheme = *req.ColorScheme
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"templates":  templates,
-		"categories": categories,
-	})
-}
+	if err := h.aiService.ValidateTemplateVariables(req.Category, req.TemplateVariables); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     err.Error(),
+			"code":      "INVALID_TEMPLATE_VARIABLES",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// Generate template
+	ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+	defer cancel()
 
-func (h *AIHandler) GetTemplate(c *gin.Context) {
-	templateIDStr := c.Param("id")
-	templateID, err := uuid.Parse(templateIDStr)
+	result, err := h.aiService.GenerateFromTemplate(ctx, req.Category, style, colorScheme, req.TemplateVariables)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid template ID format",
-			"code":  "INVALID_TEMPLATE_ID",
+		if errors.Is(err, services.ErrContentFiltered) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":     err.Error(),
+				"code":      "CONTENT_FILTERED",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Template generation failed",
+			"code":      "TEMPLATE_ERROR",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	// This would typically fetch from database
-	// For demo purposes, return a sample template
-	template := gin.H{
-		"id":          templateID,
-		"name":        "Modern Portfolio",
-		"description": "A clean, modern portfolio website perfect for developers and designers",
-		"category":    "portfolio",
-		"html_code":   "<!DOCTYPE html>...", // Full HTML would be here
-		"tags":        []string{"modern", "portfolio", "developer"},
-		"usage_count": 146, // Incremented
-		"rating":      4.8,
-		"is_premium":  false,
-		"created_at":  time.Now().Add(-30 * 24 * time.Hour),
+	// Increment user usage
+	h.authService.IncrementUsage(userID)
+
+	response := gin.H{
+		"message": "Template generated successfully",
+		"template": gin.H{
+			"category":    req.Category,
+			"style":       style,
+			"colorScheme": colorScheme,
+			"htmlCode":    result.HTMLCode,
+			"description": result.ConversationalResponse,
+		},
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"template": template,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *AIHandler) GetStatus(c *gin.Context) {
@@ -387,13 +1067,40 @@ func (h *AIHandler) GetStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+func (h *AIHandler) GetPerformance(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	stats, err := h.aiService.GetModelPerformanceStats(userID)
+	if err != nil {
+		h.logger.Error("Failed to load model performance stats", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to load performance stats",
+			"code":      "PERFORMANCE_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
 func (h *AIHandler) GetUsage(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -401,8 +1108,9 @@ func (h *AIHandler) GetUsage(c *gin.Context) {
 	user, err := h.authService.GetUserByID(userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-			"code":  "USER_NOT_FOUND",
+			"error":     "User not found",
+			"code":      "USER_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -451,6 +1159,15 @@ func (h *AIHandler) HealthCheck(c *gin.Context) {
 
 // WebSocket handler for real-time AI generation
 func (h *AIHandler) HandleWebSocket(c *gin.Context) {
+	if h.shutdownMonitor.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":     "Server is shutting down",
+			"code":      "SHUTTING_DOWN",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
 	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.logger.Error("WebSocket upgrade failed", "error", err)
@@ -458,6 +1175,12 @@ func (h *AIHandler) HandleWebSocket(c *gin.Context) {
 	}
 	defer conn.Close()
 
+	release, ok := h.shutdownMonitor.TrackConnection(conn)
+	if !ok {
+		return
+	}
+	defer release()
+
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
@@ -465,6 +1188,14 @@ func (h *AIHandler) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	conn.SetReadLimit(h.maxMessageBytes)
+
+	wsConn := ws.NewConn(conn)
+	if err := h.wsHub.Register(userID, wsConn); err != nil {
+		return
+	}
+	defer h.wsHub.Unregister(userID, wsConn)
+
 	h.logger.Info("WebSocket connection established", "userID", userID)
 
 	for {
@@ -481,10 +1212,11 @@ func (h *AIHandler) HandleWebSocket(c *gin.Context) {
 			break
 		}
 
-		if msg.Type == "generate_website" {
+		switch msg.Type {
+		case "generate_website":
 			projectID, err := uuid.Parse(msg.ProjectID)
 			if err != nil {
-				conn.WriteJSON(gin.H{
+				wsConn.WriteJSON(gin.H{
 					"type":      "error",
 					"projectId": msg.ProjectID,
 					"error":     "Invalid project ID",
@@ -492,64 +1224,200 @@ func (h *AIHandler) HandleWebSocket(c *gin.Context) {
 				continue
 			}
 
-			// Send generation started
-			conn.WriteJSON(gin.H{
-				"type":      "generation_started",
-				"projectId": msg.ProjectID,
-			})
+			done, ok := h.shutdownMonitor.BeginGeneration()
+			if !ok {
+				wsConn.WriteJSON(gin.H{
+					"type":      "generation_error",
+					"projectId": msg.ProjectID,
+					"error":     "Server is shutting down",
+				})
+				continue
+			}
 
-			// Generate with progress callbacks
-			result, err := h.aiService.GenerateWebsite(msg.Message, msg.ConversationHistory, func(progress int) {
-				conn.WriteJSON(gin.H{
-					"type":      "generation_progress",
+			// The generation stops when cancelled, when it times out or when
+			// this connection's request ends
+			ctx, cancel := context.WithTimeout(c.Request.Context(), generationTimeout)
+			generation := &wsGeneration{userID: userID, cancel: cancel}
+			if _, running := h.generations.LoadOrStore(projectID, generation); running {
+				cancel()
+				done()
+				wsConn.WriteJSON(gin.H{
+					"type":      "generation_error",
 					"projectId": msg.ProjectID,
-					"progress":  progress,
-					"stage":     "generating",
+					"error":     "A generation is already in progress for this project",
 				})
-			})
+				continue
+			}
 
+			// Generations started from other connections or instances hold the project lock
+			unlock, acquired, err := h.aiService.LockProjectGeneration(projectID)
 			if err != nil {
-				conn.WriteJSON(gin.H{
-					"type":      "generation_error",
+				h.logger.Warn("Generation lock unavailable, continuing without it", "projectId", projectID, "error", err)
+			}
+			if !acquired {
+				h.generations.CompareAndDelete(projectID, generation)
+				cancel()
+				done()
+				wsConn.WriteJSON(gin.H{
+					"type":      "generation_busy",
 					"projectId": msg.ProjectID,
-					"error":     err.Error(),
+					"error":     "A generation is already in progress for this project",
 				})
 				continue
 			}
 
-			// Save conversation
-			conversation, _ := h.projectService.SaveConversation(
-				projectID, userID, msg.Message,
-				result.ConversationalResponse, result.HTMLCode,
-				result.TokensUsed, result.ResponseTime, "claude-sonnet-4", "generation",
-			)
-
-			// Update project
-			if result.HTMLCode != "" {
-				updateReq := &models.UpdateProjectRequest{
-					HTMLCode: &result.HTMLCode,
-				}
-				h.projectService.UpdateProject(userID, projectID, updateReq)
+			// Run in the background so the loop can still read cancel requests
+			go func() {
+				defer done()
+				defer h.generations.CompareAndDelete(projectID, generation)
+				defer cancel()
+				defer unlock()
+
+				h.runWSGeneration(ctx, c.Request.Context(), userID, projectID, msg.Message, msg.ConversationHistory)
+			}()
+
+		case "cancel_generation":
+			projectID, err := uuid.Parse(msg.ProjectID)
+			if err != nil {
+				wsConn.WriteJSON(gin.H{
+					"type":      "error",
+					"projectId": msg.ProjectID,
+					"error":     "Invalid project ID",
+				})
+				continue
 			}
 
-			// Send completion
-			conn.WriteJSON(gin.H{
-				"type":      "generation_complete",
-				"projectId": msg.ProjectID,
-				"result": gin.H{
-					"conversationId":         conversation.ID,
-					"conversationalResponse": result.ConversationalResponse,
-					"htmlCode":               result.HTMLCode,
-					"tokensUsed":             result.TokensUsed,
-					"responseTime":           result.ResponseTime,
-					"fromCache":              result.FromCache,
-				},
-			})
+			value, ok := h.generations.Load(projectID)
+			if !ok || value.(*wsGeneration).userID != userID {
+				wsConn.WriteJSON(gin.H{
+					"type":      "error",
+					"projectId": msg.ProjectID,
+					"error":     "No generation in progress for this project",
+				})
+				continue
+			}
 
-			// Increment usage
-			h.authService.IncrementUsage(userID)
+			value.(*wsGeneration).cancel()
 		}
 	}
 
 	h.logger.Info("WebSocket connection closed", "userID", userID)
 }
+
+// runWSGeneration generates a website for a WebSocket request and broadcasts
+// its progress and outcome to all of the user's connections. A cancelled
+// generation is reported as such and its output, if any, is discarded.
+func (h *AIHandler) runWSGeneration(ctx, requestCtx context.Context, userID, projectID uuid.UUID, message string, history []models.ConversationEntry) {
+	h.wsHub.Broadcast(userID, gin.H{
+		"type":      "generation_started",
+		"projectId": projectID,
+	})
+
+	result, err := h.aiService.GenerateWebsite(ctx, message, history, func(progress int) {
+		h.wsHub.Broadcast(userID, gin.H{
+			"type":      "generation_progress",
+			"projectId": projectID,
+			"progress":  progress,
+			"stage":     "generating",
+		})
+	})
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		h.logger.Info("WebSocket generation cancelled", "projectId", projectID, "userId", userID)
+		h.wsHub.Broadcast(userID, gin.H{
+			"type":      "generation_cancelled",
+			"projectId": projectID,
+		})
+		return
+	}
+
+	if err != nil {
+		h.wsHub.Broadcast(userID, gin.H{
+			"type":      "generation_error",
+			"projectId": projectID,
+			"error":     err.Error(),
+		})
+		return
+	}
+
+	conversation, err := h.projectService.SaveConversation(
+		requestCtx, projectID, userID, message,
+		result.ConversationalResponse, result.HTMLCode,
+		result.TokensUsed, result.ResponseTime, result.ModelUsed, result.PromptVariant, "generation", result.FromCache,
+	)
+	if err != nil {
+		h.logger.Error("Failed to save conversation", "error", err)
+	}
+
+	if result.HTMLCode != "" {
+		updateReq := &models.UpdateProjectRequest{
+			HTMLCode:     &result.HTMLCode,
+			ChangeSource: services.ChangeSourceAIGeneration,
+		}
+		if conversation != nil {
+			updateReq.ConversationID = &conversation.ID
+		}
+		h.projectService.UpdateProject(requestCtx, userID, projectID, updateReq)
+	}
+
+	response := gin.H{
+		"conversationalResponse": result.ConversationalResponse,
+		"htmlCode":               result.HTMLCode,
+		"tokensUsed":             result.TokensUsed,
+		"responseTime":           result.ResponseTime,
+		"fromCache":              result.FromCache,
+		"contextUtilization":     result.ContextUtilization,
+		"warnings":               result.Warnings,
+	}
+	if conversation != nil {
+		response["conversationId"] = conversation.ID
+	}
+
+	h.wsHub.Broadcast(userID, gin.H{
+		"type":      "generation_complete",
+		"projectId": projectID,
+		"result":    response,
+	})
+
+	h.authService.IncrementUsage(userID)
+	h.notifications.Queue(userID, "generation", projectID.String())
+}
+
+// GetWSConnections reports the number of open WebSocket connections per user
+// GetCircuitBreaker reports the state of the Claude API circuit breaker
+func (h *AIHandler) GetCircuitBreaker(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"circuitBreaker": h.aiService.ClaudeCircuitBreaker().Status(),
+	})
+}
+
+// ResetCircuitBreaker closes the Claude API circuit breaker, for use once
+// an outage is known to be over
+func (h *AIHandler) ResetCircuitBreaker(c *gin.Context) {
+	breaker := h.aiService.ClaudeCircuitBreaker()
+	breaker.Reset()
+
+	h.logger.Info("Circuit breaker reset", "breaker", "claude", "userId", c.GetString("userID"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Circuit breaker reset",
+		"circuitBreaker": breaker.Status(),
+	})
+}
+
+func (h *AIHandler) GetWSConnections(c *gin.Context) {
+	counts := h.wsHub.ConnectionCounts()
+
+	users := make(map[string]int, len(counts))
+	total := 0
+	for userID, count := range counts {
+		users[userID.String()] = count
+		total += count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":             users,
+		"total_users":       len(users),
+		"total_connections": total,
+	})
+}
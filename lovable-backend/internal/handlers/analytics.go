@@ -0,0 +1,60 @@
+// internal/handlers/analytics.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type AnalyticsHandler struct {
+	conversationAnalyticsService *services.ConversationAnalyticsService
+	logger                       *logger.Logger
+}
+
+func NewAnalyticsHandler(conversationAnalyticsService *services.ConversationAnalyticsService, logger *logger.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		conversationAnalyticsService: conversationAnalyticsService,
+		logger:                       logger,
+	}
+}
+
+func (h *AnalyticsHandler) GetConversationAnalytics(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	period := c.DefaultQuery("period", "30d")
+	days, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Period must be between 1d and 365d",
+			"code":  "INVALID_PERIOD",
+		})
+		return
+	}
+
+	analytics, err := h.conversationAnalyticsService.Compute(userID, days)
+	if err != nil {
+		h.logger.Error("Failed to compute conversation analytics", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load conversation analytics",
+			"code":  "ANALYTICS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
@@ -2,7 +2,11 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,14 +18,20 @@ import (
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
-	logger      *logger.Logger
+	authService         *services.AuthService
+	auditService        *services.AuditService
+	accountService      *services.AccountService
+	notificationService *services.NotificationService
+	logger              *logger.Logger
 }
 
-func NewAuthHandler(authService *services.AuthService, logger *logger.Logger) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, auditService *services.AuditService, accountService *services.AccountService, notificationService *services.NotificationService, logger *logger.Logger) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		logger:      logger,
+		authService:         authService,
+		auditService:        auditService,
+		accountService:      accountService,
+		notificationService: notificationService,
+		logger:              logger,
 	}
 }
 
@@ -29,9 +39,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -50,14 +61,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
 	// Set session
-	h.authService.SetSession(response.User.ID, &services.SessionData{
+	h.authService.SetSession(response.AccessToken, &services.SessionData{
 		UserID:    response.User.ID,
 		Email:     response.User.Email,
 		Name:      response.User.Name,
@@ -73,15 +85,29 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	response, err := h.authService.Login(&req)
+	response, err := h.authService.Login(&req, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
+		var lockErr *services.AccountLockedError
+		if errors.As(err, &lockErr) {
+			retryAfter := int64(math.Ceil(lockErr.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":      err.Error(),
+				"code":       "ACCOUNT_LOCKED",
+				"retryAfter": retryAfter,
+				"requestId":  c.GetString("requestID"),
+			})
+			return
+		}
+
 		status := http.StatusInternalServerError
 		code := "LOGIN_ERROR"
 
@@ -94,14 +120,21 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
+	// The session starts once the second factor is verified
+	if response.MFARequired {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
 	// Set session
-	h.authService.SetSession(response.User.ID, &services.SessionData{
+	h.authService.SetSession(response.AccessToken, &services.SessionData{
 		UserID:    response.User.ID,
 		Email:     response.User.Email,
 		Name:      response.User.Name,
@@ -110,6 +143,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		UserAgent: c.GetHeader("User-Agent"),
 	})
 
+	h.recordAudit(response.User.ID, "login", c)
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -117,9 +152,10 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req models.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -127,20 +163,31 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	response, err := h.authService.RefreshToken(&req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error(),
-			"code":  "REFRESH_ERROR",
+			"error":     err.Error(),
+			"code":      "REFRESH_ERROR",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
+	// The refreshed pair replaces its old session under a new jti
+	h.authService.SetSession(response.AccessToken, &services.SessionData{
+		LoginTime: time.Now(),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+
 	c.JSON(http.StatusOK, response)
 }
 
 func (h *AuthHandler) Logout(c *gin.Context) {
+	h.revokeCurrentToken(c)
+
 	userID := c.GetString("userID")
 	if userID != "" {
 		if uid, err := uuid.Parse(userID); err == nil {
-			h.authService.DeleteSession(uid)
+			h.authService.RemoveSession(uid, c.GetString("tokenID"))
+			h.recordAudit(uid, "logout", c)
 		}
 	}
 
@@ -154,8 +201,9 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -163,20 +211,28 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	user, err := h.authService.GetUserByID(userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "User not found",
-			"code":  "USER_NOT_FOUND",
+			"error":     "User not found",
+			"code":      "USER_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
+	unreadNotifications, err := h.notificationService.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to count unread notifications", "userId", userID, "error", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"user": models.UserInfo{
-			ID:               user.ID,
-			Email:            user.Email,
-			Name:             user.Name,
-			AvatarURL:        user.AvatarURL,
-			SubscriptionPlan: user.SubscriptionPlan,
-			EmailVerified:    user.EmailVerified,
+			ID:                       user.ID,
+			Email:                    user.Email,
+			Name:                     user.Name,
+			AvatarURL:                user.AvatarURL,
+			SubscriptionPlan:         user.SubscriptionPlan,
+			EmailVerified:            user.EmailVerified,
+			Timezone:                 user.Timezone,
+			NotificationEmailEnabled: &user.NotificationEmailEnabled,
 			APIUsageInfo: models.APIUsageInfo{
 				Used:      user.APIUsageCount,
 				Limit:     user.APIUsageLimit,
@@ -186,16 +242,55 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 			CreatedAt:   user.CreatedAt,
 			LastLoginAt: user.LastLoginAt,
 		},
+		"unreadNotifications": unreadNotifications,
 	})
 }
 
+// GetDashboard returns the overview for the user's dashboard. Sections that
+// failed to load are reported in the summary, which is still returned.
+func (h *AuthHandler) GetDashboard(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	summary, err := h.authService.GetDashboardSummary(userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "User not found",
+				"code":      "USER_NOT_FOUND",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		h.logger.Error("Failed to load dashboard", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to load dashboard",
+			"code":      "DASHBOARD_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -203,30 +298,45 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	var req models.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
 	user, err := h.authService.UpdateProfile(userID, &req)
 	if err != nil {
+		if err.Error() == "invalid timezone" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid timezone",
+				"code":      "INVALID_TIMEZONE",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Profile update failed",
-			"code":  "UPDATE_ERROR",
+			"error":     "Profile update failed",
+			"code":      "UPDATE_ERROR",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
+	h.recordAudit(userID, "profile_update", c)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Profile updated successfully",
 		"user": models.UserInfo{
-			ID:               user.ID,
-			Email:            user.Email,
-			Name:             user.Name,
-			AvatarURL:        user.AvatarURL,
-			SubscriptionPlan: user.SubscriptionPlan,
+			ID:                       user.ID,
+			Email:                    user.Email,
+			Name:                     user.Name,
+			AvatarURL:                user.AvatarURL,
+			SubscriptionPlan:         user.SubscriptionPlan,
+			Timezone:                 user.Timezone,
+			NotificationEmailEnabled: &user.NotificationEmailEnabled,
 		},
 	})
 }
@@ -236,8 +346,9 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -245,9 +356,10 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req models.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -266,20 +378,957 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	// Clear session after password change
+	// Clear session and revoke the token used for the change
 	h.authService.DeleteSession(userID)
+	h.revokeCurrentToken(c)
+	h.recordAudit(userID, "password_change", c)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Password changed successfully. Please log in again.",
 	})
 }
 
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(req.Token); err != nil {
+		status := http.StatusInternalServerError
+		code := "VERIFICATION_ERROR"
+
+		switch err.Error() {
+		case "invalid verification token":
+			status = http.StatusBadRequest
+			code = "INVALID_TOKEN"
+		case "verification token already used":
+			status = http.StatusBadRequest
+			code = "TOKEN_USED"
+		case "verification token expired":
+			status = http.StatusBadRequest
+			code = "TOKEN_EXPIRED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+	})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.ResendVerification(userID); err != nil {
+		status := http.StatusInternalServerError
+		code := "VERIFICATION_ERROR"
+
+		switch err.Error() {
+		case "user not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		case "email already verified":
+			status = http.StatusConflict
+			code = "ALREADY_VERIFIED"
+		case "guest accounts cannot be verified":
+			status = http.StatusForbidden
+			code = "GUEST_ACCOUNT"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Verification email sent",
+	})
+}
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(req.Email); err != nil {
+		h.logger.Error("Failed to start password reset", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to start password reset",
+			"code":      "PASSWORD_RESET_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// Same response whether or not the account exists
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account exists for that email, a reset link has been sent.",
+	})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	userID, err := h.authService.ResetPassword(&req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "PASSWORD_RESET_ERROR"
+
+		switch err.Error() {
+		case "new passwords do not match":
+			status = http.StatusBadRequest
+			code = "PASSWORD_MISMATCH"
+		case "invalid reset token":
+			status = http.StatusBadRequest
+			code = "INVALID_TOKEN"
+		case "reset token already used":
+			status = http.StatusBadRequest
+			code = "TOKEN_USED"
+		case "reset token expired":
+			status = http.StatusBadRequest
+			code = "TOKEN_EXPIRED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "password_reset", c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully. Please log in with your new password.",
+	})
+}
+
+func (h *AuthHandler) ClaimGuest(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.ClaimGuestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	claimed, err := h.authService.ClaimGuest(userID, req.GuestToken)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "CLAIM_ERROR"
+
+		if err.Error() == "invalid guest token" {
+			status = http.StatusBadRequest
+			code = "INVALID_GUEST_TOKEN"
+		} else if err.Error() == "guest account not found" {
+			status = http.StatusNotFound
+			code = "GUEST_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Guest projects claimed successfully",
+		"claimedProjects": claimed,
+	})
+}
+
+func (h *AuthHandler) ExportAuditLog(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Format must be one of: csv, json",
+			"code":      "INVALID_FORMAT",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// Dates are inclusive calendar days in UTC, defaulting to the last 30 days
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid to date, expected YYYY-MM-DD",
+				"code":      "INVALID_DATE",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid from date, expected YYYY-MM-DD",
+				"code":      "INVALID_DATE",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+	}
+
+	// Admins export every user's entries unless they filter by user_id
+	user, err := h.authService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "User not found",
+			"code":      "USER_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	scope := &userID
+	if user.IsAdmin {
+		scope = nil
+		if filterStr := c.Query("user_id"); filterStr != "" {
+			filterID, err := uuid.Parse(filterStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":     "Invalid user ID filter",
+					"code":      "INVALID_USER_ID",
+					"requestId": c.GetString("requestID"),
+				})
+				return
+			}
+			scope = &filterID
+		}
+	}
+
+	data, filename, err := h.auditService.ExportLogs(scope, from, to.AddDate(0, 0, 1), format)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "AUDIT_EXPORT_ERROR"
+
+		switch err.Error() {
+		case "invalid date range", "date range exceeds 90 days":
+			status = http.StatusBadRequest
+			code = "INVALID_DATE_RANGE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Audit log exported", "userId", userID, "format", format, "allUsers", scope == nil)
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/gzip", data)
+}
+
+func (h *AuthHandler) ExportUserData(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	data, filename, job, err := h.accountService.ExportUserData(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to export user data", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to export account data",
+			"code":      "DATA_EXPORT_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "data_export", c)
+
+	if job != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Export started",
+			"job":     job,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+func (h *AuthHandler) GetDataExport(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid job ID format",
+			"code":      "INVALID_JOB_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.accountService.GetDataExportJob(c.Request.Context(), userID, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Export not found",
+			"code":      "JOB_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// Completed exports are downloaded from here; otherwise report progress
+	if job.Status != "completed" {
+		c.JSON(http.StatusOK, job)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+job.FileName+"\"")
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/zip", job.Result)
+}
+
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.accountService.DeleteAccount(c.Request.Context(), userID, &req); err != nil {
+		status := http.StatusInternalServerError
+		code := "ACCOUNT_DELETE_ERROR"
+
+		switch err.Error() {
+		case "confirmation phrase does not match":
+			status = http.StatusBadRequest
+			code = "INVALID_CONFIRMATION"
+		case "password is incorrect":
+			status = http.StatusUnauthorized
+			code = "INVALID_PASSWORD"
+		case "record not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Account deleted", "userId", userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deleted successfully",
+	})
+}
+
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(userID, c.GetString("tokenID"))
+	if err != nil {
+		h.logger.Error("Failed to list sessions", "userId", userID, "error", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":     "Failed to list sessions",
+			"code":      "SESSIONS_UNAVAILABLE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.RevokeSession(userID, c.Param("sessionID")); err != nil {
+		status := http.StatusInternalServerError
+		code := "SESSION_REVOKE_ERROR"
+
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+			code = "SESSION_NOT_FOUND"
+		case "session ID is ambiguous":
+			status = http.StatusConflict
+			code = "SESSION_AMBIGUOUS"
+		case "session tracking is unavailable":
+			status = http.StatusServiceUnavailable
+			code = "SESSIONS_UNAVAILABLE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "session_revoke", c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeOtherSessions signs the user out of every device but this one
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	revoked, err := h.authService.RevokeOtherSessions(userID, c.GetString("tokenID"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "SESSION_REVOKE_ERROR"
+
+		if err.Error() == "session tracking is unavailable" {
+			status = http.StatusServiceUnavailable
+			code = "SESSIONS_UNAVAILABLE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "session_revoke_others", c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Other sessions revoked successfully",
+		"revoked": revoked,
+	})
+}
+
+func (h *AuthHandler) CreateAPIKey(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	response, err := h.authService.CreateAPIKey(userID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "API_KEY_CREATE_ERROR"
+
+		if strings.HasPrefix(err.Error(), "invalid scope") {
+			status = http.StatusBadRequest
+			code = "INVALID_SCOPE"
+		} else if err.Error() == "API key limit reached" {
+			status = http.StatusConflict
+			code = "API_KEY_LIMIT_REACHED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "api_key_create", c)
+	h.logger.Info("API key created", "apiKeyId", response.APIKey.ID, "userId", userID)
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *AuthHandler) ListAPIKeys(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	keys, err := h.authService.ListAPIKeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to load API keys",
+			"code":      "FETCH_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": keys,
+	})
+}
+
+func (h *AuthHandler) RevokeAPIKey(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid API key ID format",
+			"code":      "INVALID_API_KEY_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.RevokeAPIKey(userID, keyID); err != nil {
+		status := http.StatusInternalServerError
+		code := "API_KEY_REVOKE_ERROR"
+
+		if err.Error() == "API key not found" {
+			status = http.StatusNotFound
+			code = "API_KEY_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "api_key_revoke", c)
+	h.logger.Info("API key revoked", "apiKeyId", keyID, "userId", userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked successfully",
+	})
+}
+
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	response, err := h.authService.SetupMFA(userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "MFA_SETUP_ERROR"
+
+		switch {
+		case err == services.ErrMFANotConfigured:
+			status = http.StatusServiceUnavailable
+			code = "MFA_NOT_CONFIGURED"
+		case err.Error() == "user not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		case err.Error() == "two-factor authentication already enabled":
+			status = http.StatusConflict
+			code = "MFA_ALREADY_ENABLED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) EnableMFA(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	backupCodes, err := h.authService.EnableMFA(userID, req.Code)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "MFA_ENABLE_ERROR"
+
+		switch {
+		case err == services.ErrMFANotConfigured:
+			status = http.StatusServiceUnavailable
+			code = "MFA_NOT_CONFIGURED"
+		case err.Error() == "two-factor authentication not set up":
+			status = http.StatusBadRequest
+			code = "MFA_NOT_SET_UP"
+		case err.Error() == "two-factor authentication already enabled":
+			status = http.StatusConflict
+			code = "MFA_ALREADY_ENABLED"
+		case err.Error() == "invalid verification code":
+			status = http.StatusBadRequest
+			code = "INVALID_MFA_CODE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "mfa_enable", c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Two-factor authentication enabled",
+		"backup_codes": backupCodes,
+	})
+}
+
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.DisableMFA(userID, req.Password); err != nil {
+		status := http.StatusInternalServerError
+		code := "MFA_DISABLE_ERROR"
+
+		switch err.Error() {
+		case "user not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		case "current password is incorrect":
+			status = http.StatusBadRequest
+			code = "INVALID_CURRENT_PASSWORD"
+		case "two-factor authentication not enabled":
+			status = http.StatusBadRequest
+			code = "MFA_NOT_ENABLED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.recordAudit(userID, "mfa_disable", c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	response, err := h.authService.VerifyMFA(&req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "MFA_VERIFY_ERROR"
+
+		switch err.Error() {
+		case "invalid or expired MFA token":
+			status = http.StatusUnauthorized
+			code = "INVALID_MFA_TOKEN"
+		case "invalid verification code":
+			status = http.StatusUnauthorized
+			code = "INVALID_MFA_CODE"
+		case "account is disabled":
+			status = http.StatusForbidden
+			code = "ACCOUNT_DISABLED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// Set session
+	h.authService.SetSession(response.AccessToken, &services.SessionData{
+		UserID:    response.User.ID,
+		Email:     response.User.Email,
+		Name:      response.User.Name,
+		LoginTime: time.Now(),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+
+	h.recordAudit(response.User.ID, "login", c)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var req models.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.authService.UnlockAccount(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to unlock account",
+			"code":      "UNLOCK_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Account unlocked", "email", req.Email, "adminId", c.GetString("userID"))
+
+	if adminID, err := uuid.Parse(c.GetString("userID")); err == nil {
+		if err := h.auditService.RecordAdminAction(adminID, "account_unlocked", nil, map[string]interface{}{"email": req.Email}); err != nil {
+			h.logger.Error("Failed to record admin action", "adminId", adminID, "action", "account_unlocked", "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account unlocked successfully",
+	})
+}
+
+// revokeCurrentToken blacklists the session of the access token that
+// authenticated the request, including its refresh token.
+func (h *AuthHandler) revokeCurrentToken(c *gin.Context) {
+	tokenID := c.GetString("tokenID")
+	if tokenID == "" {
+		return
+	}
+
+	if err := h.authService.RevokeSessionToken(tokenID); err != nil {
+		h.logger.Error("Failed to blacklist token", "tokenId", tokenID, "error", err)
+	}
+}
+
+func (h *AuthHandler) recordAudit(userID uuid.UUID, action string, c *gin.Context) {
+	if err := h.auditService.Record(userID, action, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.logger.Error("Failed to record audit log", "userId", userID, "action", action, "error", err)
+	}
+}
+
 func (h *AuthHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"service":   "Authentication",
@@ -0,0 +1,58 @@
+// internal/handlers/billing.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type BillingHandler struct {
+	billingService *services.BillingService
+	logger         *logger.Logger
+}
+
+func NewBillingHandler(billingService *services.BillingService, logger *logger.Logger) *BillingHandler {
+	return &BillingHandler{
+		billingService: billingService,
+		logger:         logger,
+	}
+}
+
+func (h *BillingHandler) Webhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to read request body",
+			"code":  "INVALID_PAYLOAD",
+		})
+		return
+	}
+
+	if err := h.billingService.HandleWebhook(payload); err != nil {
+		status := http.StatusInternalServerError
+		code := "WEBHOOK_ERROR"
+
+		if err.Error() == "invalid webhook payload" || err.Error() == "subscription event missing user_id metadata" {
+			status = http.StatusBadRequest
+			code = "INVALID_PAYLOAD"
+		} else if err.Error() == "record not found" {
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		}
+
+		h.logger.Error("Billing webhook failed", "error", err)
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"received": true,
+	})
+}
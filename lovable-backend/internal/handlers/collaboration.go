@@ -0,0 +1,226 @@
+// internal/handlers/collaboration.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type CollaborationHandler struct {
+	collaborationService *services.CollaborationService
+	logger               *logger.Logger
+}
+
+func NewCollaborationHandler(collaborationService *services.CollaborationService, logger *logger.Logger) *CollaborationHandler {
+	return &CollaborationHandler{
+		collaborationService: collaborationService,
+		logger:               logger,
+	}
+}
+
+func (h *CollaborationHandler) InviteCollaborator(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID format",
+			"code":  "INVALID_PROJECT_ID",
+		})
+		return
+	}
+
+	var req models.InviteCollaboratorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	collaborator, err := h.collaborationService.InviteCollaborator(userID, projectID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "INVITE_ERROR"
+
+		switch err.Error() {
+		case "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case "user not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		case "cannot invite the project owner":
+			status = http.StatusBadRequest
+			code = "INVALID_COLLABORATOR"
+		case "user is already a collaborator":
+			status = http.StatusConflict
+			code = "ALREADY_COLLABORATOR"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	h.logger.Info("Collaborator invited", "projectId", projectID, "collaboratorId", collaborator.UserID, "role", collaborator.Role, "userId", userID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Invitation sent successfully",
+		"collaborator": collaborator,
+	})
+}
+
+func (h *CollaborationHandler) AcceptInvitation(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID format",
+			"code":  "INVALID_PROJECT_ID",
+		})
+		return
+	}
+
+	if err := h.collaborationService.AcceptInvitation(userID, projectID); err != nil {
+		status := http.StatusInternalServerError
+		code := "ACCEPT_ERROR"
+
+		if err.Error() == "invitation not found" {
+			status = http.StatusNotFound
+			code = "INVITATION_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitation accepted",
+	})
+}
+
+func (h *CollaborationHandler) ListCollaborators(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID format",
+			"code":  "INVALID_PROJECT_ID",
+		})
+		return
+	}
+
+	collaborators, err := h.collaborationService.ListCollaborators(userID, projectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Project not found",
+			"code":  "PROJECT_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collaborators": collaborators,
+		"total":         len(collaborators),
+	})
+}
+
+func (h *CollaborationHandler) RemoveCollaborator(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid project ID format",
+			"code":  "INVALID_PROJECT_ID",
+		})
+		return
+	}
+
+	collaboratorID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collaborator ID format",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	if err := h.collaborationService.RemoveCollaborator(userID, projectID, collaboratorID); err != nil {
+		status := http.StatusInternalServerError
+		code := "REMOVE_COLLABORATOR_ERROR"
+
+		switch err.Error() {
+		case "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case "collaborator not found":
+			status = http.StatusNotFound
+			code = "COLLABORATOR_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	h.logger.Info("Collaborator removed", "projectId", projectID, "collaboratorId", collaboratorID, "userId", userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collaborator removed successfully",
+	})
+}
@@ -0,0 +1,258 @@
+// internal/handlers/collections.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type CollectionHandler struct {
+	collectionService *services.CollectionService
+	logger            *logger.Logger
+}
+
+func NewCollectionHandler(collectionService *services.CollectionService, logger *logger.Logger) *CollectionHandler {
+	return &CollectionHandler{
+		collectionService: collectionService,
+		logger:            logger,
+	}
+}
+
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req models.CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	collection, err := h.collectionService.CreateCollection(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create collection", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create collection",
+			"code":  "COLLECTION_CREATE_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h *CollectionHandler) ListCollections(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	collections, err := h.collectionService.ListCollections(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list collections", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list collections",
+			"code":  "COLLECTION_LIST_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collections": collections,
+	})
+}
+
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collection ID format",
+			"code":  "INVALID_COLLECTION_ID",
+		})
+		return
+	}
+
+	var req models.UpdateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	collection, err := h.collectionService.UpdateCollection(c.Request.Context(), userID, collectionID, &req)
+	if err != nil {
+		h.respondCollectionError(c, err, "COLLECTION_UPDATE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collection ID format",
+			"code":  "INVALID_COLLECTION_ID",
+		})
+		return
+	}
+
+	if err := h.collectionService.DeleteCollection(c.Request.Context(), userID, collectionID); err != nil {
+		h.respondCollectionError(c, err, "COLLECTION_DELETE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection deleted successfully",
+	})
+}
+
+func (h *CollectionHandler) AssignProjects(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collection ID format",
+			"code":  "INVALID_COLLECTION_ID",
+		})
+		return
+	}
+
+	var req models.CollectionProjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.collectionService.AssignProjects(c.Request.Context(), userID, collectionID, req.ProjectIDs); err != nil {
+		h.respondCollectionError(c, err, "COLLECTION_ASSIGN_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Projects added to collection",
+	})
+}
+
+func (h *CollectionHandler) ReorderProjects(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	collectionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid collection ID format",
+			"code":  "INVALID_COLLECTION_ID",
+		})
+		return
+	}
+
+	var req models.CollectionProjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.collectionService.ReorderProjects(c.Request.Context(), userID, collectionID, req.ProjectIDs); err != nil {
+		h.respondCollectionError(c, err, "COLLECTION_REORDER_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection reordered",
+	})
+}
+
+func (h *CollectionHandler) respondCollectionError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch err.Error() {
+	case "collection not found":
+		status = http.StatusNotFound
+		code = "COLLECTION_NOT_FOUND"
+	case "project not found":
+		status = http.StatusNotFound
+		code = "PROJECT_NOT_FOUND"
+	case "project not in collection":
+		status = http.StatusBadRequest
+		code = "PROJECT_NOT_IN_COLLECTION"
+	default:
+		h.logger.Error("Collection operation failed", "code", defaultCode, "error", err)
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
@@ -2,7 +2,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,8 +32,9 @@ func (h *ExportHandler) ExportHTML(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	if _, err := uuid.Parse(userIDStr); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -39,24 +43,26 @@ func (h *ExportHandler) ExportHTML(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	minify := c.Query("minify") == "true"
+	minify := services.ParseMinifyMode(c.Query("minify"))
 
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID format",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID format",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	htmlContent, filename, err := h.exportService.ExportHTML(userID, projectID, minify)
+	htmlContent, filename, err := h.exportService.ExportHTML(c.Request.Context(), userID, projectID, minify)
 	if err != nil {
 		status := http.StatusInternalServerError
 		code := "EXPORT_ERROR"
@@ -70,8 +76,9 @@ func (h *ExportHandler) ExportHTML(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -90,8 +97,9 @@ func (h *ExportHandler) ExportZIP(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -100,15 +108,41 @@ func (h *ExportHandler) ExportZIP(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	includeAssets := c.Query("includeAssets") == "true"
+	preset, err := services.ParseExportPreset(c.Query("preset"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Unknown export preset; use generic, github-pages, netlify or vercel",
+			"code":      "INVALID_PRESET",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	domain, err := services.NormalizeExportDomain(c.Query("domain"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid custom domain",
+			"code":      "INVALID_DOMAIN",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
 
-	zipContent, filename, err := h.exportService.ExportZIP(userID, projectID, includeAssets)
+	opts := services.ZIPExportOptions{
+		IncludeAssets: c.Query("includeAssets") == "true",
+		Minify:        services.ParseMinifyMode(c.Query("minify")),
+		Preset:        preset,
+		Domain:        domain,
+	}
+
+	project, filename, err := h.exportService.PrepareZIPExport(c.Request.Context(), userID, projectID)
 	if err != nil {
 		status := http.StatusInternalServerError
 		code := "EXPORT_ERROR"
@@ -122,19 +156,87 @@ func (h *ExportHandler) ExportZIP(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
+	// Stream the archive without a Content-Length so it is sent chunked
 	c.Header("Content-Type", "application/zip")
 	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
 	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
 
-	h.logger.Info("ZIP exported", "projectId", projectID, "userId", userID)
+	if err := h.exportService.StreamZIP(c.Request.Context(), c.Writer, project, filename, opts); err != nil {
+		h.logger.Error("ZIP export failed mid-stream", "projectId", projectID, "userId", userID, "error", err)
+		return
+	}
 
-	c.Data(http.StatusOK, "application/zip", zipContent)
+	h.logger.Info("ZIP exported", "projectId", projectID, "userId", userID, "preset", preset.String())
+}
+
+// ExportPDF renders the project to PDF through the external PDF service
+func (h *ExportHandler) ExportPDF(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("projectId")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	pdf, filename, err := h.exportService.ExportPDF(c.Request.Context(), userID, projectID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "EXPORT_ERROR"
+
+		switch {
+		case errors.Is(err, services.ErrPDFExportDisabled):
+			status = http.StatusNotImplemented
+			code = "PDF_EXPORT_DISABLED"
+		case errors.Is(err, services.ErrPDFServiceUnavailable):
+			h.logger.Error("PDF service unavailable", "projectId", projectID, "error", err)
+			status = http.StatusServiceUnavailable
+			code = "PDF_SERVICE_UNAVAILABLE"
+		case err.Error() == "project not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case err.Error() == "no HTML code available for this project":
+			status = http.StatusBadRequest
+			code = "NO_HTML_CODE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Cache-Control", "no-cache")
+
+	h.logger.Info("PDF exported", "projectId", projectID, "userId", userID)
+
+	c.Data(http.StatusOK, "application/pdf", pdf)
 }
 
 func (h *ExportHandler) BatchExport(c *gin.Context) {
@@ -142,8 +244,9 @@ func (h *ExportHandler) BatchExport(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -151,14 +254,15 @@ func (h *ExportHandler) BatchExport(c *gin.Context) {
 	var req models.BatchExportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	zipContent, filename, err := h.exportService.BatchExport(userID, req.ProjectIDs, req.IncludeAssets)
+	zipContent, filename, err := h.exportService.BatchExport(c.Request.Context(), userID, req.ProjectIDs, req.IncludeAssets)
 	if err != nil {
 		status := http.StatusInternalServerError
 		code := "BATCH_EXPORT_ERROR"
@@ -169,8 +273,9 @@ func (h *ExportHandler) BatchExport(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -186,49 +291,201 @@ func (h *ExportHandler) BatchExport(c *gin.Context) {
 
 func (h *ExportHandler) GetExportHistory(c *gin.Context) {
 	userIDStr := c.GetString("userID")
-	if _, err := uuid.Parse(userIDStr); err != nil {
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	// In a real implementation, this would fetch from Redis or database
-	// For now, return mock data
-	exportHistory := []gin.H{
-		{
-			"type":  "HTML Exports",
-			"count": 12,
-		},
-		{
-			"type":  "ZIP Exports",
-			"count": 8,
-		},
-		{
-			"type":  "Batch Exports",
-			"count": 2,
-		},
-		{
-			"type":  "Total Projects Exported",
-			"count": 15,
-		},
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
 	}
 
-	totalExports := 0
-	for _, stat := range exportHistory {
-		totalExports += stat["count"].(int)
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	format := c.Query("format")
+	if format != "" && format != "html" && format != "zip" && format != "batch" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Format must be one of: html, zip, batch",
+			"code":      "INVALID_FORMAT",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	response, err := h.exportService.GetExportHistory(c.Request.Context(), userID, format, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to get export history", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to get export history",
+			"code":      "EXPORT_HISTORY_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ExportHandler) DeleteExportRecord(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid export record ID format",
+			"code":      "INVALID_EXPORT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.exportService.DeleteExportRecord(c.Request.Context(), userID, recordID); err != nil {
+		status := http.StatusInternalServerError
+		code := "DELETE_EXPORT_ERROR"
+
+		if err.Error() == "export record not found" {
+			status = http.StatusNotFound
+			code = "EXPORT_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"exportStats": exportHistory,
-		"summary": gin.H{
-			"totalExports": totalExports,
-			"lastUpdated":  time.Now().Format(time.RFC3339),
-		},
+		"message": "Export record deleted successfully",
 	})
 }
 
+func (h *ExportHandler) GetColorPalette(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	palette, err := h.exportService.GetProjectPalette(c.Request.Context(), userID, projectID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "PALETTE_ERROR"
+
+		if err.Error() == "project not found" {
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		} else if err.Error() == "no HTML code available for this project" {
+			status = http.StatusBadRequest
+			code = "NO_HTML_CODE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"palette": palette,
+		"total":   len(palette),
+	})
+}
+
+func (h *ExportHandler) CompareProjects(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	ids := strings.Split(c.Query("ids"), ",")
+	if len(ids) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Exactly two project IDs are required",
+			"code":      "INVALID_PROJECT_IDS",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var projectIDs [2]uuid.UUID
+	for i, id := range ids {
+		projectID, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid project ID format",
+				"code":      "INVALID_PROJECT_ID",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+		projectIDs[i] = projectID
+	}
+
+	comparison, err := h.exportService.CompareProjects(c.Request.Context(), userID, projectIDs)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "COMPARISON_ERROR"
+
+		if err.Error() == "project not found" {
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
 func (h *ExportHandler) Preview(c *gin.Context) {
 	projectIDStr := c.Param("projectId")
 	projectID, err := uuid.Parse(projectIDStr)
@@ -252,6 +509,26 @@ func (h *ExportHandler) Preview(c *gin.Context) {
 		return
 	}
 
+	// Explicit mode wins over the remembered cookie preference
+	mode := c.Query("mode")
+	if mode != "" && !services.IsValidPreviewMode(mode) {
+		c.String(http.StatusBadRequest, "Invalid preview mode. Use dark, light or auto.")
+		return
+	}
+	if mode == "" {
+		if cookieMode, err := c.Cookie("preview_mode"); err == nil && cookieMode != "auto" && services.IsValidPreviewMode(cookieMode) {
+			mode = cookieMode
+		}
+	}
+
+	// Detect the viewer's preference client-side before rendering the project
+	if mode == "auto" {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Header("X-Frame-Options", "SAMEORIGIN")
+		c.String(http.StatusOK, h.exportService.ColorSchemeDetectionPage())
+		return
+	}
+
 	var userID *uuid.UUID
 	if userIDStr := c.GetString("userID"); userIDStr != "" {
 		if uid, err := uuid.Parse(userIDStr); err == nil {
@@ -259,7 +536,7 @@ func (h *ExportHandler) Preview(c *gin.Context) {
 		}
 	}
 
-	project, err := h.exportService.GetProjectForPreview(projectID, userID)
+	project, err := h.exportService.GetProjectForPreview(c.Request.Context(), projectID, userID)
 	if err != nil {
 		c.Header("Content-Type", "text/html; charset=utf-8")
 		c.String(http.StatusNotFound, `
@@ -302,16 +579,25 @@ func (h *ExportHandler) Preview(c *gin.Context) {
 		return
 	}
 
+	if c.Query("mode") != "" {
+		c.SetCookie("preview_mode", mode, 365*24*60*60, "/", "", false, false)
+	}
+
 	c.Header("Content-Type", "text/html; charset=utf-8")
 	c.Header("X-Frame-Options", "SAMEORIGIN")
-	c.String(http.StatusOK, *project.HTMLCode)
+	c.String(http.StatusOK, h.exportService.InjectThemeOverride(*project.HTMLCode, mode))
 }
 
 func (h *ExportHandler) HealthCheck(c *gin.Context) {
+	formats := []string{"html", "zip"}
+	if h.exportService.PDFExportEnabled() {
+		formats = append(formats, "pdf")
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"service":          "Export",
 		"status":           "healthy",
 		"timestamp":        time.Now().Format(time.RFC3339),
-		"supportedFormats": []string{"html", "zip"},
+		"supportedFormats": formats,
 	})
 }
@@ -0,0 +1,158 @@
+// internal/handlers/health.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/redis"
+	"lovable-backend/internal/services"
+)
+
+// Deadline for each dependency probe
+const healthProbeTimeout = 2 * time.Second
+
+// Health statuses
+const (
+	HealthHealthy   = "healthy"
+	HealthDegraded  = "degraded"
+	HealthUnhealthy = "unhealthy"
+)
+
+type HealthHandler struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	aiService   *services.AIService
+	environment string
+	ready       atomic.Bool
+}
+
+func NewHealthHandler(db *gorm.DB, redisClient *redis.Client, aiService *services.AIService, environment string) *HealthHandler {
+	return &HealthHandler{
+		db:          db,
+		redisClient: redisClient,
+		aiService:   aiService,
+		environment: environment,
+	}
+}
+
+// SetReady marks whether the server has finished starting up and is not
+// shutting down
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Health probes the database, Redis and the AI configuration. The database
+// is required, so the server is unhealthy without it; Redis and AI are
+// optional and only degrade it.
+func (h *HealthHandler) Health(c *gin.Context) {
+	database := h.probe(c.Request.Context(), h.pingDatabase)
+	cache := h.probe(c.Request.Context(), h.pingRedis)
+
+	ai := gin.H{"status": HealthHealthy}
+	model, available := h.aiService.PrimaryModel()
+	ai["model"] = model
+	breaker := h.aiService.ClaudeCircuitBreaker().Status()
+	ai["circuitBreaker"] = breaker.State
+	if breaker.State == services.CircuitOpen {
+		available = false
+	}
+	if !available {
+		ai["status"] = HealthDegraded
+	}
+
+	status := HealthHealthy
+	httpStatus := http.StatusOK
+	switch {
+	case database.Status != HealthHealthy:
+		status = HealthUnhealthy
+		httpStatus = http.StatusServiceUnavailable
+	case cache.Status != HealthHealthy || !available:
+		status = HealthDegraded
+	}
+
+	c.JSON(httpStatus, gin.H{
+		"status":      status,
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"version":     "1.0.0",
+		"environment": h.environment,
+		"dependencies": gin.H{
+			"database": database,
+			"redis":    cache,
+			"ai":       ai,
+		},
+	})
+}
+
+// Ready reports whether startup, including database migrations, has
+// completed. It fails again once shutdown begins so load balancers stop
+// routing to the instance.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": HealthUnhealthy})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": HealthHealthy})
+}
+
+// Live reports whether the database can be reached
+func (h *HealthHandler) Live(c *gin.Context) {
+	database := h.probe(c.Request.Context(), h.pingDatabase)
+	if database.Status != HealthHealthy {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":   HealthUnhealthy,
+			"database": database,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   HealthHealthy,
+		"database": database,
+	})
+}
+
+func (h *HealthHandler) probe(ctx context.Context, ping func(context.Context) error) dependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	result := dependencyStatus{
+		Status:    HealthHealthy,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = HealthUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (h *HealthHandler) pingDatabase(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (h *HealthHandler) pingRedis(ctx context.Context) error {
+	if h.redisClient == nil || h.redisClient.Client == nil {
+		return errors.New("redis not connected")
+	}
+	return h.redisClient.Client.Ping(ctx).Err()
+}
@@ -0,0 +1,189 @@
+// internal/handlers/notifications.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	logger              *logger.Logger
+}
+
+func NewNotificationHandler(notificationService *services.NotificationService, logger *logger.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// ListNotifications returns the caller's notifications, newest first.
+// ?is_read=true or false limits the list to read or unread ones.
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	var isRead *bool
+	if value := c.Query("is_read"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "is_read must be true or false",
+				"code":  "VALIDATION_ERROR",
+			})
+			return
+		}
+		isRead = &parsed
+	}
+
+	response, err := h.notificationService.ListNotifications(c.Request.Context(), userID, isRead, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list notifications", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load notifications",
+			"code":  "NOTIFICATIONS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID format",
+			"code":  "INVALID_NOTIFICATION_ID",
+		})
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), userID, notificationID); err != nil {
+		status := http.StatusInternalServerError
+		code := "NOTIFICATION_UPDATE_ERROR"
+
+		switch err.Error() {
+		case "notification not found":
+			status = http.StatusNotFound
+			code = "NOTIFICATION_NOT_FOUND"
+		default:
+			h.logger.Error("Failed to mark notification read", "userId", userID, "notificationId", notificationID, "error", err)
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification marked as read",
+	})
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	updated, err := h.notificationService.MarkAllRead(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to mark notifications read", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to mark notifications as read",
+			"code":  "NOTIFICATION_UPDATE_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notifications marked as read",
+		"updated": updated,
+	})
+}
+
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	notificationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid notification ID format",
+			"code":  "INVALID_NOTIFICATION_ID",
+		})
+		return
+	}
+
+	if err := h.notificationService.DeleteNotification(c.Request.Context(), userID, notificationID); err != nil {
+		status := http.StatusInternalServerError
+		code := "NOTIFICATION_DELETE_ERROR"
+
+		switch err.Error() {
+		case "notification not found":
+			status = http.StatusNotFound
+			code = "NOTIFICATION_NOT_FOUND"
+		default:
+			h.logger.Error("Failed to delete notification", "userId", userID, "notificationId", notificationID, "error", err)
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification deleted",
+	})
+}
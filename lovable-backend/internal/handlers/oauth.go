@@ -0,0 +1,184 @@
+// internal/handlers/oauth.go
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type OAuthHandler struct {
+	oauthService *services.OAuthService
+	authService  *services.AuthService
+	auditService *services.AuditService
+	logger       *logger.Logger
+}
+
+func NewOAuthHandler(oauthService *services.OAuthService, authService *services.AuthService, auditService *services.AuditService, logger *logger.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		authService:  authService,
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+func (h *OAuthHandler) GoogleLogin(c *gin.Context) {
+	redirectURL, err := h.oauthService.GoogleLoginURL()
+	if err != nil {
+		if err == services.ErrOAuthNotConfigured {
+			h.notConfigured(c)
+			return
+		}
+
+		h.logger.Error("Failed to start Google login", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start Google login",
+			"code":  "OAUTH_LOGIN_ERROR",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+func (h *OAuthHandler) GoogleCallback(c *gin.Context) {
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Google sign-in was cancelled",
+			"code":  "OAUTH_DENIED",
+		})
+		return
+	}
+
+	response, err := h.oauthService.HandleGoogleCallback(c.Request.Context(), c.Query("state"), c.Query("code"))
+	if err != nil {
+		if err == services.ErrOAuthNotConfigured {
+			h.notConfigured(c)
+			return
+		}
+
+		status := http.StatusUnauthorized
+		code := "OAUTH_LOGIN_ERROR"
+
+		switch err.Error() {
+		case "invalid OAuth state":
+			status = http.StatusBadRequest
+			code = "INVALID_OAUTH_STATE"
+		case "account is disabled":
+			status = http.StatusForbidden
+			code = "ACCOUNT_DISABLED"
+		case "Google account email is not verified":
+			code = "EMAIL_NOT_VERIFIED"
+		}
+
+		h.logger.LogSecurityEvent("oauth_login_failed", "", c.ClientIP(), map[string]any{"provider": "google", "error": err.Error()})
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	// The session starts once the second factor is verified
+	if response.MFARequired {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	h.authService.SetSession(response.AccessToken, &services.SessionData{
+		UserID:    response.User.ID,
+		Email:     response.User.Email,
+		Name:      response.User.Name,
+		LoginTime: time.Now(),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	})
+
+	if err := h.auditService.Record(response.User.ID, "login", c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.logger.Error("Failed to record audit log", "userId", response.User.ID, "action", "login", "error", err)
+	}
+
+	h.logger.Info("Google login", "userId", response.User.ID)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	providers, err := h.oauthService.ListProviders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load linked providers",
+			"code":  "FETCH_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"providers": providers,
+	})
+}
+
+func (h *OAuthHandler) UnlinkProvider(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	provider := c.Param("provider")
+	if err := h.oauthService.UnlinkProvider(userID, provider); err != nil {
+		status := http.StatusInternalServerError
+		code := "UNLINK_ERROR"
+
+		switch err.Error() {
+		case "user not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		case "provider not linked":
+			status = http.StatusNotFound
+			code = "PROVIDER_NOT_LINKED"
+		case "set a password before unlinking":
+			status = http.StatusConflict
+			code = "PASSWORD_REQUIRED"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	h.logger.Info("OAuth provider unlinked", "provider", provider, "userId", userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Provider unlinked successfully",
+	})
+}
+
+func (h *OAuthHandler) notConfigured(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": "Google sign-in is not configured",
+		"code":  "OAUTH_NOT_CONFIGURED",
+	})
+}
@@ -0,0 +1,130 @@
+// internal/handlers/preferences.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type PreferenceHandler struct {
+	preferenceService *services.PreferenceService
+	logger            *logger.Logger
+}
+
+func NewPreferenceHandler(preferenceService *services.PreferenceService, logger *logger.Logger) *PreferenceHandler {
+	return &PreferenceHandler{
+		preferenceService: preferenceService,
+		logger:            logger,
+	}
+}
+
+func (h *PreferenceHandler) GetPreferences(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	preferences, err := h.preferenceService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get preferences", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get preferences",
+			"code":  "PREFERENCES_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences merges a JSON object of string values into the caller's
+// preferences
+func (h *PreferenceHandler) UpdatePreferences(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req map[string]string
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	preferences, err := h.preferenceService.UpdatePreferences(c.Request.Context(), userID, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "PREFERENCES_UPDATE_ERROR"
+
+		switch err.Error() {
+		case "unknown preference key":
+			status = http.StatusBadRequest
+			code = "INVALID_PREFERENCE_KEY"
+		case "preferences too large":
+			status = http.StatusRequestEntityTooLarge
+			code = "PREFERENCES_TOO_LARGE"
+		case "record not found":
+			status = http.StatusNotFound
+			code = "USER_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (h *PreferenceHandler) DeletePreference(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	if err := h.preferenceService.DeletePreference(c.Request.Context(), userID, c.Param("key")); err != nil {
+		status := http.StatusInternalServerError
+		code := "PREFERENCES_DELETE_ERROR"
+
+		if err.Error() == "preference not found" {
+			status = http.StatusNotFound
+			code = "PREFERENCE_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preference deleted successfully",
+	})
+}
@@ -2,6 +2,8 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -32,14 +34,17 @@ func (h *ProjectHandler) GetProjects(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
 	// Parse query parameters
 	query := &services.ProjectQuery{
+		Mode:   services.PaginationModeCursor,
+		Cursor: c.Query("cursor"),
 		Page:   1,
 		Limit:  20,
 		Sort:   "updated_at",
@@ -57,7 +62,7 @@ func (h *ProjectHandler) GetProjects(c *gin.Context) {
 	}
 
 	if sort := c.Query("sort"); sort != "" {
-		if sort == "created_at" || sort == "updated_at" || sort == "name" || sort == "view_count" {
+		if sort == "created_at" || sort == "updated_at" || sort == "name" || sort == "view_count" || sort == "fork_count" || sort == "sort_order" {
 			query.Sort = sort
 		}
 	}
@@ -70,11 +75,39 @@ func (h *ProjectHandler) GetProjects(c *gin.Context) {
 		query.Tags = strings.Split(tags, ",")
 	}
 
-	response, err := h.projectService.GetProjects(userID, query)
+	if collection := c.Query("collection_id"); collection != "" {
+		collectionID, err := uuid.Parse(collection)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid collection ID format",
+				"code":      "INVALID_COLLECTION_ID",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+		query.CollectionID = &collectionID
+	}
+
+	// Page numbers are only available in offset mode
+	if c.Query("mode") == services.PaginationModeOffset {
+		query.Mode = services.PaginationModeOffset
+	}
+
+	response, err := h.projectService.GetProjects(c.Request.Context(), userID, query)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid cursor",
+				"code":      "INVALID_CURSOR",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch projects",
-			"code":  "FETCH_ERROR",
+			"error":     "Failed to fetch projects",
+			"code":      "FETCH_ERROR",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -82,13 +115,53 @@ func (h *ProjectHandler) GetProjects(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *ProjectHandler) SearchProjects(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Search query is required",
+			"code":      "MISSING_QUERY",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	results, err := h.projectService.SearchProjects(c.Request.Context(), userID, q)
+	if err != nil {
+		h.logger.Error("Failed to search projects", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to search projects",
+			"code":      "SEARCH_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"total":   len(results),
+	})
+}
+
 func (h *ProjectHandler) GetProject(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -97,17 +170,19 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	project, err := h.projectService.GetProject(userID, projectID)
+	project, err := h.projectService.GetProject(c.Request.Context(), userID, projectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Project not found",
-			"code":  "PROJECT_NOT_FOUND",
+			"error":     "Project not found",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -122,8 +197,9 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -131,14 +207,15 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	var req models.CreateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	project, err := h.projectService.CreateProject(userID, &req)
+	project, err := h.projectService.CreateProject(c.Request.Context(), userID, &req)
 	if err != nil {
 		status := http.StatusInternalServerError
 		code := "CREATE_ERROR"
@@ -149,8 +226,9 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -166,8 +244,9 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -176,8 +255,9 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -185,18 +265,20 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	var req models.UpdateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request data",
-			"code":    "VALIDATION_ERROR",
-			"details": err.Error(),
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	project, err := h.projectService.UpdateProject(userID, projectID, &req)
+	project, err := h.projectService.UpdateProject(c.Request.Context(), userID, projectID, &req)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Project not found",
-			"code":  "PROJECT_NOT_FOUND",
+			"error":     "Project not found",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -212,8 +294,9 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -222,17 +305,19 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	err = h.projectService.DeleteProject(userID, projectID)
+	err = h.projectService.DeleteProject(c.Request.Context(), userID, projectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Project not found",
-			"code":  "PROJECT_NOT_FOUND",
+			"error":     "Project not found",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -242,13 +327,96 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	})
 }
 
+func (h *ProjectHandler) BulkDeleteProjects(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.BulkProjectsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	deleted, err := h.projectService.BulkDeleteProjects(c.Request.Context(), userID, req.ProjectIDs)
+	if err != nil {
+		h.logger.Error("Failed to bulk delete projects", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to delete projects",
+			"code":      "BULK_DELETE_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Projects bulk deleted", "userId", userID, "deleted", deleted)
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": deleted,
+		"skipped": len(req.ProjectIDs) - deleted,
+	})
+}
+
+func (h *ProjectHandler) BulkUpdateStatus(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.BulkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	updated, err := h.projectService.BulkUpdateStatus(c.Request.Context(), userID, req.ProjectIDs, req.Status)
+	if err != nil {
+		h.logger.Error("Failed to bulk update project status", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to update projects",
+			"code":      "BULK_UPDATE_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"updated": updated,
+		"skipped": len(req.ProjectIDs) - updated,
+	})
+}
+
 func (h *ProjectHandler) DuplicateProject(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -257,13 +425,14 @@ func (h *ProjectHandler) DuplicateProject(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	project, err := h.projectService.DuplicateProject(userID, projectID)
+	project, err := h.projectService.DuplicateProject(c.Request.Context(), userID, projectID)
 	if err != nil {
 		status := http.StatusInternalServerError
 		code := "DUPLICATE_ERROR"
@@ -274,8 +443,9 @@ func (h *ProjectHandler) DuplicateProject(c *gin.Context) {
 		}
 
 		c.JSON(status, gin.H{
-			"error": err.Error(),
-			"code":  code,
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -286,13 +456,66 @@ func (h *ProjectHandler) DuplicateProject(c *gin.Context) {
 	})
 }
 
-func (h *ProjectHandler) GetConversations(c *gin.Context) {
+func (h *ProjectHandler) ForkProject(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	project, err := h.projectService.ForkProject(c.Request.Context(), userID, projectID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "FORK_ERROR"
+
+		if err.Error() == "record not found" {
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		} else if strings.Contains(err.Error(), "project limit reached") {
+			status = http.StatusForbidden
+			code = "PROJECT_LIMIT_EXCEEDED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Project forked", "projectId", projectID, "forkId", project.ID, "userId", userID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project forked successfully",
+		"project": project,
+	})
+}
+
+func (h *ProjectHandler) GetForks(c *gin.Context) {
 	userIDStr := c.GetString("userID")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-			"code":  "INVALID_USER_ID",
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
@@ -301,27 +524,1530 @@ func (h *ProjectHandler) GetConversations(c *gin.Context) {
 	projectID, err := uuid.Parse(projectIDStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid project ID format",
-			"code":  "INVALID_PROJECT_ID",
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
-	conversations, err := h.projectService.GetConversations(userID, projectID)
+	forks, err := h.projectService.GetForks(c.Request.Context(), userID, projectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Project not found",
-			"code":  "PROJECT_NOT_FOUND",
+			"error":     "Project not found",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"conversations": conversations,
-		"total":         len(conversations),
+		"forks": forks,
+		"total": len(forks),
+	})
+}
+
+func (h *ProjectHandler) ValidateProject(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	warnings, err := h.projectService.ValidateProject(c.Request.Context(), userID, projectID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "VALIDATION_FAILED"
+
+		switch {
+		case err.Error() == "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case err.Error() == "project has no code":
+			status = http.StatusBadRequest
+			code = "NO_CODE"
+		case errors.Is(err, services.ErrMalformedHTML):
+			// A malformed document is a validation result, not a failure
+			c.JSON(http.StatusOK, gin.H{
+				"valid":    false,
+				"error":    err.Error(),
+				"warnings": []services.ValidationWarning{},
+			})
+			return
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":    true,
+		"warnings": warnings,
+	})
+}
+
+func (h *ProjectHandler) RegenerateThumbnail(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.projectService.RegenerateThumbnail(c.Request.Context(), userID, projectID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "THUMBNAIL_FAILED"
+
+		switch {
+		case errors.Is(err, services.ErrThumbnailServiceDisabled):
+			status = http.StatusNotImplemented
+			code = "THUMBNAIL_SERVICE_DISABLED"
+		case err.Error() == "project not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case err.Error() == "no HTML code available for this project":
+			status = http.StatusBadRequest
+			code = "NO_CODE"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Thumbnail regeneration queued", "projectId", projectID, "userId", userID, "jobId", job.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Thumbnail regeneration queued",
+		"job":     job,
+	})
+}
+
+func (h *ProjectHandler) GetVersions(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.projectService.GetProjectVersions(c.Request.Context(), userID, projectID, page, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "FETCH_ERROR"
+
+		if err.Error() == "record not found" {
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ProjectHandler) GetVersion(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(c.Param("versionNumber"))
+	if err != nil || versionNumber < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid version number",
+			"code":      "INVALID_VERSION_NUMBER",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	version, err := h.projectService.GetProjectVersion(c.Request.Context(), userID, projectID, versionNumber)
+	if err != nil {
+		h.versionError(c, err, "FETCH_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version": version,
 	})
 }
 
+func (h *ProjectHandler) RestoreVersion(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(c.Param("versionNumber"))
+	if err != nil || versionNumber < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid version number",
+			"code":      "INVALID_VERSION_NUMBER",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	project, err := h.projectService.RestoreProjectVersion(c.Request.Context(), userID, projectID, versionNumber)
+	if err != nil {
+		h.versionError(c, err, "RESTORE_ERROR")
+		return
+	}
+
+	h.logger.Info("Project version restored", "projectId", projectID, "version", versionNumber, "userId", userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Version restored successfully",
+		"project": project,
+	})
+}
+
+// DiffVersions compares the HTML of two versions, given as the from and to
+// query parameters. With format=html the diff is returned as an HTML
+// fragment instead of JSON.
+func (h *ProjectHandler) DiffVersions(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	fromVersion, fromErr := strconv.Atoi(c.Query("from"))
+	toVersion, toErr := strconv.Atoi(c.Query("to"))
+	if fromErr != nil || toErr != nil || fromVersion < 1 || toVersion < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "from and to must be version numbers",
+			"code":      "INVALID_VERSION_NUMBER",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	result, err := h.projectService.DiffProjectVersions(c.Request.Context(), userID, projectID, fromVersion, toVersion)
+	if err != nil {
+		h.versionError(c, err, "DIFF_ERROR")
+		return
+	}
+
+	if c.Query("format") == "html" {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(result.HTML()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": fromVersion,
+		"to":   toVersion,
+		"diff": result,
+	})
+}
+
+func (h *ProjectHandler) versionError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch err.Error() {
+	case "record not found":
+		status = http.StatusNotFound
+		code = "PROJECT_NOT_FOUND"
+	case "version not found":
+		status = http.StatusNotFound
+		code = "VERSION_NOT_FOUND"
+	}
+
+	c.JSON(status, gin.H{
+		"error":     err.Error(),
+		"code":      code,
+		"requestId": c.GetString("requestID"),
+	})
+}
+
+func (h *ProjectHandler) GetConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if c.Query("tree") == "true" {
+		tree, err := h.projectService.GetConversationTree(c.Request.Context(), userID, projectID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "Project not found",
+				"code":      "PROJECT_NOT_FOUND",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"conversations": tree,
+		})
+		return
+	}
+
+	conversations, err := h.projectService.GetConversations(c.Request.Context(), userID, projectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Project not found",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"conversations": conversations,
+		"total":         len(conversations),
+	})
+}
+
+// SearchConversations searches every project the user can access, or only the
+// one given by the projectId query parameter
+func (h *ProjectHandler) SearchConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var projectID *uuid.UUID
+	if projectIDStr := c.Query("projectId"); projectIDStr != "" {
+		id, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid project ID format",
+				"code":      "INVALID_PROJECT_ID",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+		projectID = &id
+	}
+
+	h.searchConversations(c, userID, projectID)
+}
+
+func (h *ProjectHandler) SearchProjectConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.searchConversations(c, userID, &projectID)
+}
+
+func (h *ProjectHandler) searchConversations(c *gin.Context, userID uuid.UUID, projectID *uuid.UUID) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Search query is required",
+			"code":      "MISSING_QUERY",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	query := &services.ConversationSearchQuery{
+		Q:           q,
+		ProjectID:   projectID,
+		MessageType: c.Query("type"),
+		Page:        1,
+		Limit:       20,
+	}
+
+	if page, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && page > 0 {
+		query.Page = page
+	}
+
+	if limit, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && limit > 0 && limit <= 100 {
+		query.Limit = limit
+	}
+
+	response, err := h.projectService.SearchConversations(c.Request.Context(), userID, query)
+	if err != nil {
+		if err.Error() == "project not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "Project not found",
+				"code":      "PROJECT_NOT_FOUND",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		h.logger.Error("Failed to search conversations", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to search conversations",
+			"code":      "SEARCH_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ProjectHandler) RateConversation(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	conversationID, err := uuid.Parse(c.Param("conversationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid conversation ID format",
+			"code":      "INVALID_CONVERSATION_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.RateConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.projectService.RateConversation(c.Request.Context(), userID, projectID, conversationID, req.Rating); err != nil {
+		status := http.StatusInternalServerError
+		code := "RATING_ERROR"
+
+		switch err.Error() {
+		case "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case "conversation not found":
+			status = http.StatusNotFound
+			code = "CONVERSATION_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rating saved",
+		"rating":  req.Rating,
+	})
+}
+
+func (h *ProjectHandler) ImportConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "No import file provided",
+			"code":      "NO_FILE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if fileHeader.Size > 5<<20 {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     "Import file must be under 5 MB",
+			"code":      "FILE_TOO_LARGE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Failed to read import file",
+			"code":      "FILE_READ_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, 5<<20+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Failed to read import file",
+			"code":      "FILE_READ_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format != "" && format != "chatgpt" && format != "claude" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Format must be one of: chatgpt, claude",
+			"code":      "INVALID_FORMAT",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	conversations, err := h.projectService.ImportConversationHistory(c.Request.Context(), userID, projectID, data, format)
+	if err != nil {
+		status := http.StatusBadRequest
+		code := "IMPORT_ERROR"
+
+		switch {
+		case err.Error() == "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case err.Error() == "import file exceeds 5 MB limit":
+			status = http.StatusRequestEntityTooLarge
+			code = "FILE_TOO_LARGE"
+		case err.Error() == "import file is not valid JSON":
+			code = "INVALID_JSON"
+		case err.Error() == "unsupported conversation format":
+			code = "UNSUPPORTED_FORMAT"
+		case strings.HasPrefix(err.Error(), "invalid "):
+			code = "INVALID_EXPORT"
+		case err.Error() == "no messages found in import file":
+			code = "NO_MESSAGES"
+		default:
+			status = http.StatusInternalServerError
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Conversations imported", "projectId", projectID, "userId", userID, "count", len(conversations))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Conversations imported successfully",
+		"conversations": conversations,
+		"total":         len(conversations),
+	})
+}
+
+func (h *ProjectHandler) GetProjectStats(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	stats, err := h.projectService.GetProjectStats(c.Request.Context(), userID, projectID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "FETCH_ERROR"
+
+		if err.Error() == "record not found" {
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		} else {
+			h.logger.Error("Failed to load project stats", "projectId", projectID, "error", err)
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *ProjectHandler) GetActivityHeatmap(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	period := c.DefaultQuery("period", "30d")
+	days, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Period must be between 1d and 365d",
+			"code":      "INVALID_PERIOD",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	matrix, err := h.projectService.GetActivityHeatmap(c.Request.Context(), userID, projectID, days, h.projectService.GetUserLocation(c.Request.Context(), userID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Project not found",
+			"code":      "PROJECT_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	response := models.ActivityHeatmapResponse{Matrix: matrix}
+	for _, row := range matrix {
+		for _, count := range row {
+			response.TotalEvents += count
+			if count > response.MaxValue {
+				response.MaxValue = count
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ProjectHandler) GetActivityTimeline(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.projectService.GetActivityTimeline(c.Request.Context(), userID, projectID, page, limit)
+	if err != nil {
+		if err.Error() == "record not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "Project not found",
+				"code":      "PROJECT_NOT_FOUND",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		h.logger.Error("Failed to get activity timeline", "projectId", projectID, "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to load activity",
+			"code":      "ACTIVITY_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportConversations downloads a project's conversation history as JSON
+func (h *ProjectHandler) ExportConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	offset := 0
+	if o, err := strconv.Atoi(c.DefaultQuery("offset", "0")); err == nil && o > 0 {
+		offset = o
+	}
+
+	export, filename, err := h.projectService.ExportProjectConversations(c.Request.Context(), userID, projectID, offset)
+	if err != nil {
+		if err.Error() == "project not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":     "Project not found",
+				"code":      "PROJECT_NOT_FOUND",
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+
+		h.logger.Error("Failed to export conversations", "projectId", projectID, "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to export conversations",
+			"code":      "CONVERSATION_EXPORT_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Cache-Control", "no-cache")
+	c.JSON(http.StatusOK, export)
+}
+
+// ExportMyConversations downloads the conversation history of all the
+// caller's projects as JSON
+func (h *ProjectHandler) ExportMyConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	offset := 0
+	if o, err := strconv.Atoi(c.DefaultQuery("offset", "0")); err == nil && o > 0 {
+		offset = o
+	}
+
+	export, filename, err := h.projectService.ExportAllConversations(c.Request.Context(), userID, offset)
+	if err != nil {
+		h.logger.Error("Failed to export conversations", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to export conversations",
+			"code":      "CONVERSATION_EXPORT_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Cache-Control", "no-cache")
+	c.JSON(http.StatusOK, export)
+}
+
+// GetMyActivity returns the caller's own actions across all projects
+func (h *ProjectHandler) GetMyActivity(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.projectService.GetUserActivity(c.Request.Context(), userID, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to get user activity", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to load activity",
+			"code":      "ACTIVITY_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ImportFromURL creates a project from an existing web page
+func (h *ProjectHandler) ImportFromURL(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.ImportURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	project, err := h.projectService.ImportFromURL(c.Request.Context(), userID, req.URL, req.Name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "IMPORT_ERROR"
+
+		switch {
+		case strings.Contains(err.Error(), "project limit reached"):
+			status = http.StatusForbidden
+			code = "PROJECT_LIMIT_EXCEEDED"
+		case err.Error() == "invalid import URL":
+			status = http.StatusBadRequest
+			code = "INVALID_IMPORT_URL"
+		case err.Error() == "import host not allowed":
+			status = http.StatusBadRequest
+			code = "IMPORT_HOST_NOT_ALLOWED"
+		case err.Error() == "page is not HTML":
+			status = http.StatusUnprocessableEntity
+			code = "NOT_HTML"
+		case err.Error() == "page too large":
+			status = http.StatusRequestEntityTooLarge
+			code = "PAGE_TOO_LARGE"
+		case strings.HasPrefix(err.Error(), "failed to fetch page"):
+			status = http.StatusBadGateway
+			code = "FETCH_FAILED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Project imported from URL", "projectId", project.ID, "userId", userID, "url", req.URL)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project imported successfully",
+		"project": project,
+	})
+}
+
+// ImportFromZIP creates a project from an uploaded ZIP archive, such as one
+// produced by ZIP export
+func (h *ProjectHandler) ImportFromZIP(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "A ZIP file is required",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(c.PostForm("name"))
+	if len([]rune(name)) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Name must be at most 255 characters",
+			"code":      "VALIDATION_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if fileHeader.Size > services.MaxZIPImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     "archive too large",
+			"code":      "ARCHIVE_TOO_LARGE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Failed to read uploaded file",
+			"code":      "INVALID_UPLOAD",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+	defer file.Close()
+
+	zipData, err := io.ReadAll(io.LimitReader(file, services.MaxZIPImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Failed to read uploaded file",
+			"code":      "INVALID_UPLOAD",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	project, err := h.projectService.ImportFromZIP(c.Request.Context(), userID, zipData, name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "IMPORT_ERROR"
+
+		switch {
+		case strings.Contains(err.Error(), "project limit reached"):
+			status = http.StatusForbidden
+			code = "PROJECT_LIMIT_EXCEEDED"
+		case err.Error() == "invalid zip archive":
+			status = http.StatusBadRequest
+			code = "INVALID_ZIP"
+		case err.Error() == "archive too large":
+			status = http.StatusRequestEntityTooLarge
+			code = "ARCHIVE_TOO_LARGE"
+		case err.Error() == "index.html not found in archive":
+			status = http.StatusUnprocessableEntity
+			code = "INDEX_HTML_MISSING"
+		default:
+			h.logger.Error("Failed to import ZIP archive", "userId", userID, "error", err)
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Project imported from ZIP", "projectId", project.ID, "userId", userID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project imported successfully",
+		"project": project,
+	})
+}
+
+func (h *ProjectHandler) BatchImport(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "No import file provided",
+			"code":      "NO_FILE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if fileHeader.Size > 100<<20 {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     "Import file must be under 100 MB",
+			"code":      "FILE_TOO_LARGE",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Failed to read import file",
+			"code":      "FILE_READ_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, 100<<20+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Failed to read import file",
+			"code":      "FILE_READ_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.projectService.BatchImport(c.Request.Context(), userID, data)
+	if err != nil {
+		status := http.StatusBadRequest
+		code := "IMPORT_ERROR"
+
+		switch {
+		case err.Error() == "import file exceeds 100 MB limit", err.Error() == "import file exceeds 200 MB uncompressed":
+			status = http.StatusRequestEntityTooLarge
+			code = "FILE_TOO_LARGE"
+		case err.Error() == "import file is not a valid ZIP archive":
+			code = "INVALID_ZIP"
+		case err.Error() == "no projects found in import file":
+			code = "NO_PROJECTS"
+		case strings.HasPrefix(err.Error(), "invalid archive entry"):
+			code = "INVALID_ARCHIVE"
+		default:
+			status = http.StatusInternalServerError
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Batch import started", "jobId", job.ID, "userId", userID, "total", job.TotalCount)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Batch import started",
+		"job":     job,
+	})
+}
+
+func (h *ProjectHandler) GetBatchImportJob(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid job ID format",
+			"code":      "INVALID_JOB_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.projectService.GetBatchImportJob(c.Request.Context(), userID, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Import job not found",
+			"code":      "JOB_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+func (h *ProjectHandler) ReplayConversations(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectIDStr := c.Param("id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	// The body is optional; without it every conversation is replayed
+	var req models.ReplayConversationsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid request data",
+				"code":      "VALIDATION_ERROR",
+				"details":   err.Error(),
+				"requestId": c.GetString("requestID"),
+			})
+			return
+		}
+	}
+
+	job, err := h.projectService.ReplayConversations(c.Request.Context(), userID, projectID, req.UpToConversationID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := "REPLAY_ERROR"
+
+		switch {
+		case err.Error() == "record not found":
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		case err.Error() == "conversation not found":
+			status = http.StatusNotFound
+			code = "CONVERSATION_NOT_FOUND"
+		case err.Error() == "no conversations to replay":
+			status = http.StatusBadRequest
+			code = "NO_CONVERSATIONS"
+		case strings.Contains(err.Error(), "project limit reached"):
+			status = http.StatusForbidden
+			code = "PROJECT_LIMIT_REACHED"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	h.logger.Info("Conversation replay started", "projectId", projectID, "userId", userID, "jobId", job.ID, "steps", job.TotalSteps)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":   "Conversation replay started",
+		"job":       job,
+		"projectId": job.ProjectID,
+	})
+}
+
+func (h *ProjectHandler) GetReplayJob(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid job ID format",
+			"code":      "INVALID_JOB_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	job, err := h.projectService.GetReplayJob(c.Request.Context(), userID, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":     "Replay job not found",
+			"code":      "JOB_NOT_FOUND",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+func (h *ProjectHandler) GetAnalyticsOverview(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	period := c.DefaultQuery("period", "30d")
+	days, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+	if err != nil || days <= 0 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Period must be between 1d and 365d",
+			"code":      "INVALID_PERIOD",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	analytics, err := h.projectService.GetUserAnalytics(c.Request.Context(), userID, days)
+	if err != nil {
+		h.logger.Error("Failed to get user analytics", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to load analytics",
+			"code":      "ANALYTICS_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+func (h *ProjectHandler) Discover(c *gin.Context) {
+	sort := c.DefaultQuery("sort", services.DiscoverSortNewest)
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.projectService.DiscoverProjects(c.Request.Context(), sort, c.Query("cursor"), limit)
+	if err != nil {
+		switch err.Error() {
+		case "invalid sort":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Sort must be one of newest, most_viewed, most_liked or trending",
+				"code":      "INVALID_SORT",
+				"requestId": c.GetString("requestID"),
+			})
+		case "invalid cursor":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "Invalid cursor",
+				"code":      "INVALID_CURSOR",
+				"requestId": c.GetString("requestID"),
+			})
+		default:
+			h.logger.Error("Failed to list public projects", "sort", sort, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":     "Failed to fetch projects",
+				"code":      "FETCH_ERROR",
+				"requestId": c.GetString("requestID"),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *ProjectHandler) GetFeatured(c *gin.Context) {
+	projects, err := h.projectService.GetFeaturedProjects(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list featured projects", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to fetch featured projects",
+			"code":      "FETCH_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projects": projects,
+		"total":    len(projects),
+	})
+}
+
+func (h *ProjectHandler) RecordView(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid project ID format",
+			"code":      "INVALID_PROJECT_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.projectService.RecordProjectView(c.Request.Context(), userID, projectID); err != nil {
+		status := http.StatusInternalServerError
+		code := "VIEW_ERROR"
+
+		if err.Error() == "project not found" {
+			status = http.StatusNotFound
+			code = "PROJECT_NOT_FOUND"
+		}
+
+		c.JSON(status, gin.H{
+			"error":     err.Error(),
+			"code":      code,
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 func (h *ProjectHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"service":   "Projects",
@@ -0,0 +1,225 @@
+// internal/handlers/prompt_templates.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type PromptTemplateHandler struct {
+	promptTemplateService *services.PromptTemplateService
+	logger                *logger.Logger
+}
+
+func NewPromptTemplateHandler(promptTemplateService *services.PromptTemplateService, logger *logger.Logger) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		promptTemplateService: promptTemplateService,
+		logger:                logger,
+	}
+}
+
+func (h *PromptTemplateHandler) CreateTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req models.CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := h.promptTemplateService.CreateTemplate(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.logger.Error("Failed to create prompt template", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create prompt template",
+			"code":  "PROMPT_TEMPLATE_CREATE_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *PromptTemplateHandler) ListTemplates(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	response, err := h.promptTemplateService.ListTemplates(c.Request.Context(), userID, page, limit)
+	if err != nil {
+		h.logger.Error("Failed to list prompt templates", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list prompt templates",
+			"code":  "PROMPT_TEMPLATE_LIST_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *PromptTemplateHandler) UpdateTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt template ID format",
+			"code":  "INVALID_PROMPT_TEMPLATE_ID",
+		})
+		return
+	}
+
+	var req models.UpdatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := h.promptTemplateService.UpdateTemplate(c.Request.Context(), userID, templateID, &req)
+	if err != nil {
+		h.respondPromptTemplateError(c, err, "PROMPT_TEMPLATE_UPDATE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *PromptTemplateHandler) DeleteTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt template ID format",
+			"code":  "INVALID_PROMPT_TEMPLATE_ID",
+		})
+		return
+	}
+
+	if err := h.promptTemplateService.DeleteTemplate(c.Request.Context(), userID, templateID); err != nil {
+		h.respondPromptTemplateError(c, err, "PROMPT_TEMPLATE_DELETE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prompt template deleted successfully",
+	})
+}
+
+func (h *PromptTemplateHandler) RenderTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid prompt template ID format",
+			"code":  "INVALID_PROMPT_TEMPLATE_ID",
+		})
+		return
+	}
+
+	var req models.RenderPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	rendered, err := h.promptTemplateService.RenderTemplate(c.Request.Context(), userID, templateID, req.Variables)
+	if err != nil {
+		h.respondPromptTemplateError(c, err, "PROMPT_TEMPLATE_RENDER_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"text": rendered,
+	})
+}
+
+func (h *PromptTemplateHandler) respondPromptTemplateError(c *gin.Context, err error, defaultCode string) {
+	status, code := promptTemplateErrorStatus(err, defaultCode)
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
+
+// promptTemplateErrorStatus maps prompt template errors to a status and code;
+// AIHandler shares it for templates referenced by generation requests
+func promptTemplateErrorStatus(err error, defaultCode string) (int, string) {
+	switch err.Error() {
+	case "prompt template not found":
+		return http.StatusNotFound, "PROMPT_TEMPLATE_NOT_FOUND"
+	case "missing template variable":
+		return http.StatusBadRequest, "MISSING_TEMPLATE_VARIABLE"
+	}
+	return http.StatusInternalServerError, defaultCode
+}
@@ -0,0 +1,53 @@
+// internal/handlers/ratelimit.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/middleware"
+	"lovable-backend/pkg/logger"
+)
+
+type RateLimitHandler struct {
+	rateLimiter *middleware.RateLimiter
+	logger      *logger.Logger
+}
+
+func NewRateLimitHandler(rateLimiter *middleware.RateLimiter, logger *logger.Logger) *RateLimitHandler {
+	return &RateLimitHandler{
+		rateLimiter: rateLimiter,
+		logger:      logger,
+	}
+}
+
+func (h *RateLimitHandler) GetStatus(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	plan := c.GetString("subscriptionPlan")
+	if plan == "" {
+		plan = "free"
+	}
+
+	statuses, err := h.rateLimiter.GetStatus(userID, plan, c.ClientIP())
+	if err != nil {
+		h.logger.Error("Failed to get rate limit status", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get rate limit status",
+			"code":  "RATE_LIMIT_STATUS_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, statuses)
+}
@@ -0,0 +1,136 @@
+// internal/handlers/saml.go
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type SAMLHandler struct {
+	samlService *services.SAMLService
+	authService *services.AuthService
+	frontendURL string
+	logger      *logger.Logger
+}
+
+func NewSAMLHandler(samlService *services.SAMLService, authService *services.AuthService, frontendURL string, logger *logger.Logger) *SAMLHandler {
+	return &SAMLHandler{
+		samlService: samlService,
+		authService: authService,
+		frontendURL: frontendURL,
+		logger:      logger,
+	}
+}
+
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+	metadata, err := h.samlService.Metadata()
+	if err != nil {
+		h.notConfigured(c)
+		return
+	}
+
+	data, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate metadata",
+			"code":  "SAML_METADATA_ERROR",
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", data)
+}
+
+func (h *SAMLHandler) Login(c *gin.Context) {
+	redirectURL, err := h.samlService.LoginURL()
+	if err != nil {
+		if err == services.ErrSAMLNotConfigured {
+			h.notConfigured(c)
+			return
+		}
+
+		h.logger.Error("Failed to start SAML login", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start SSO login",
+			"code":  "SAML_LOGIN_ERROR",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// AssertionConsumerService receives the identity provider's POSTed response,
+// signs the user in and redirects to the frontend with the token pair in the
+// URL fragment so it is never sent to a server. Accounts with two-factor
+// authentication get an MFA token instead, to finish at /auth/mfa/verify.
+func (h *SAMLHandler) AssertionConsumerService(c *gin.Context) {
+	assertion, err := h.samlService.ParseResponse(c.Request)
+	if err != nil {
+		if err == services.ErrSAMLNotConfigured {
+			h.notConfigured(c)
+			return
+		}
+
+		h.logger.LogSecurityEvent("saml_assertion_rejected", "", c.ClientIP(), map[string]any{"error": err.Error()})
+		c.Redirect(http.StatusFound, h.frontendURL+"/login?error=sso_failed")
+		return
+	}
+
+	response, err := h.samlService.HandleAssertion(assertion)
+	if err != nil {
+		h.logger.Error("SAML login failed", "error", err)
+
+		reason := "sso_failed"
+		if err.Error() == "account is disabled" {
+			reason = "account_disabled"
+		}
+		c.Redirect(http.StatusFound, h.frontendURL+"/login?error="+reason)
+		return
+	}
+
+	// The session starts once the second factor is verified
+	if response.MFARequired {
+		fragment := url.Values{}
+		fragment.Set("mfa_required", "true")
+		fragment.Set("mfa_token", response.MFAToken)
+		fragment.Set("expires_in", response.ExpiresIn)
+
+		c.Redirect(http.StatusFound, h.frontendURL+"/auth/sso/callback#"+fragment.Encode())
+		return
+	}
+
+	if err := h.authService.SetSession(response.AccessToken, &services.SessionData{
+		UserID:    response.User.ID,
+		Email:     response.User.Email,
+		Name:      response.User.Name,
+		LoginTime: time.Now(),
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}); err != nil {
+		h.logger.Error("Failed to record SAML session", "userId", response.User.ID, "error", err)
+	}
+
+	h.logger.Info("SAML login", "userId", response.User.ID)
+
+	fragment := url.Values{}
+	fragment.Set("access_token", response.AccessToken)
+	fragment.Set("refresh_token", response.RefreshToken)
+	fragment.Set("expires_in", response.ExpiresIn)
+
+	c.Redirect(http.StatusFound, h.frontendURL+"/auth/sso/callback#"+fragment.Encode())
+}
+
+func (h *SAMLHandler) notConfigured(c *gin.Context) {
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": "SAML SSO is not configured",
+		"code":  "SAML_NOT_CONFIGURED",
+	})
+}
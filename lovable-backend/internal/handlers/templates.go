@@ -0,0 +1,347 @@
+// internal/handlers/templates.go
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type TemplateHandler struct {
+	templateService *services.TemplateService
+	logger          *logger.Logger
+}
+
+func NewTemplateHandler(templateService *services.TemplateService, logger *logger.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	page := 1
+	if p, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && p > 0 {
+		page = p
+	}
+
+	limit := 20
+	if l, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+
+	query := &services.TemplateQuery{
+		Category: c.Query("category"),
+		Mine:     c.Query("mine") == "true",
+		Page:     page,
+		Limit:    limit,
+	}
+
+	if minRating, err := strconv.ParseFloat(c.Query("min_rating"), 64); err == nil && minRating > 0 && minRating <= 5 {
+		query.MinRating = minRating
+	}
+
+	response, err := h.templateService.GetTemplates(c.Request.Context(), userID, query)
+	if err != nil {
+		h.logger.Error("Failed to list templates", "userId", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "Failed to list templates",
+			"code":      "TEMPLATE_LIST_ERROR",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid template ID format",
+			"code":      "INVALID_TEMPLATE_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Request.Context(), userID, templateID)
+	if err != nil {
+		h.respondTemplateError(c, err, "TEMPLATE_FETCH_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondTemplateError(c, err, "TEMPLATE_CREATE_ERROR")
+		return
+	}
+
+	h.logger.Info("Template created", "templateId", template.ID, "userId", userID, "status", template.PublishStatus)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"template": template,
+	})
+}
+
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid template ID format",
+			"code":      "INVALID_TEMPLATE_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(c.Request.Context(), userID, templateID, &req)
+	if err != nil {
+		h.respondTemplateError(c, err, "TEMPLATE_UPDATE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid template ID format",
+			"code":      "INVALID_TEMPLATE_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(c.Request.Context(), userID, templateID); err != nil {
+		h.respondTemplateError(c, err, "TEMPLATE_DELETE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Template deleted successfully",
+	})
+}
+
+func (h *TemplateHandler) CreateReview(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid template ID format",
+			"code":      "INVALID_TEMPLATE_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	var req models.CreateTemplateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid request data",
+			"code":      "VALIDATION_ERROR",
+			"details":   err.Error(),
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	review, err := h.templateService.CreateReview(c.Request.Context(), userID, templateID, &req)
+	if err != nil {
+		h.respondTemplateError(c, err, "TEMPLATE_REVIEW_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"review": review,
+	})
+}
+
+func (h *TemplateHandler) GetReviews(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid user ID",
+			"code":      "INVALID_USER_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "Invalid template ID format",
+			"code":      "INVALID_TEMPLATE_ID",
+			"requestId": c.GetString("requestID"),
+		})
+		return
+	}
+
+	query := &services.TemplateReviewQuery{
+		Sort:  "created_at",
+		Order: "desc",
+		Page:  1,
+		Limit: 20,
+	}
+
+	if page, err := strconv.Atoi(c.DefaultQuery("page", "1")); err == nil && page > 0 {
+		query.Page = page
+	}
+
+	if limit, err := strconv.Atoi(c.DefaultQuery("limit", "20")); err == nil && limit > 0 && limit <= 100 {
+		query.Limit = limit
+	}
+
+	if sort := c.Query("sort"); sort == "created_at" || sort == "rating" {
+		query.Sort = sort
+	}
+
+	if order := c.Query("order"); order == "asc" {
+		query.Order = "asc"
+	}
+
+	response, err := h.templateService.GetReviews(c.Request.Context(), userID, templateID, query)
+	if err != nil {
+		h.respondTemplateError(c, err, "TEMPLATE_REVIEWS_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TemplateHandler) respondTemplateError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch err.Error() {
+	case "template not found":
+		status = http.StatusNotFound
+		code = "TEMPLATE_NOT_FOUND"
+	case "project not found":
+		status = http.StatusNotFound
+		code = "PROJECT_NOT_FOUND"
+	case "project has no code":
+		status = http.StatusBadRequest
+		code = "PROJECT_HAS_NO_CODE"
+	case "cannot review own template":
+		status = http.StatusForbidden
+		code = "OWN_TEMPLATE"
+	case "template already reviewed":
+		status = http.StatusConflict
+		code = "ALREADY_REVIEWED"
+	default:
+		h.logger.Error("Template operation failed", "code", defaultCode, "error", err)
+	}
+
+	c.JSON(status, gin.H{
+		"error":     err.Error(),
+		"code":      code,
+		"requestId": c.GetString("requestID"),
+	})
+}
@@ -0,0 +1,237 @@
+// internal/handlers/webhook.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/services"
+	"lovable-backend/pkg/logger"
+)
+
+type WebhookHandler struct {
+	webhookDispatcher *services.WebhookDispatcher
+	logger            *logger.Logger
+}
+
+func NewWebhookHandler(webhookDispatcher *services.WebhookDispatcher, logger *logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookDispatcher: webhookDispatcher,
+		logger:            logger,
+	}
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	webhooks, err := h.webhookDispatcher.ListWebhooks(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list webhooks",
+			"code":  "WEBHOOK_LIST_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": webhooks,
+	})
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.webhookDispatcher.CreateWebhook(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.respondWebhookError(c, err, "WEBHOOK_CREATE_ERROR")
+		return
+	}
+
+	h.logger.Info("Webhook created", "userId", userID, "webhookId", response.Webhook.ID)
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID format",
+			"code":  "INVALID_WEBHOOK_ID",
+		})
+		return
+	}
+
+	webhook, err := h.webhookDispatcher.GetWebhook(c.Request.Context(), userID, webhookID)
+	if err != nil {
+		h.respondWebhookError(c, err, "WEBHOOK_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID format",
+			"code":  "INVALID_WEBHOOK_ID",
+		})
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request data",
+			"code":    "VALIDATION_ERROR",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	webhook, err := h.webhookDispatcher.UpdateWebhook(c.Request.Context(), userID, webhookID, &req)
+	if err != nil {
+		h.respondWebhookError(c, err, "WEBHOOK_UPDATE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID format",
+			"code":  "INVALID_WEBHOOK_ID",
+		})
+		return
+	}
+
+	if err := h.webhookDispatcher.DeleteWebhook(c.Request.Context(), userID, webhookID); err != nil {
+		h.respondWebhookError(c, err, "WEBHOOK_DELETE_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	userIDStr := c.GetString("userID")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid user ID",
+			"code":  "INVALID_USER_ID",
+		})
+		return
+	}
+
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook ID format",
+			"code":  "INVALID_WEBHOOK_ID",
+		})
+		return
+	}
+
+	delivery, err := h.webhookDispatcher.TestWebhook(c.Request.Context(), userID, webhookID)
+	if err != nil {
+		h.respondWebhookError(c, err, "WEBHOOK_TEST_ERROR")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  delivery.Success,
+		"delivery": delivery,
+	})
+}
+
+func (h *WebhookHandler) respondWebhookError(c *gin.Context, err error, defaultCode string) {
+	status := http.StatusInternalServerError
+	code := defaultCode
+
+	switch err.Error() {
+	case "webhook not found":
+		status = http.StatusNotFound
+		code = "WEBHOOK_NOT_FOUND"
+	case "webhook URL must be an http or https URL", "webhook host could not be resolved", "webhook host not allowed":
+		status = http.StatusBadRequest
+		code = "INVALID_WEBHOOK_URL"
+	case "unknown webhook event":
+		status = http.StatusBadRequest
+		code = "INVALID_WEBHOOK_EVENT"
+	case "webhook limit reached":
+		status = http.StatusForbidden
+		code = "WEBHOOK_LIMIT_REACHED"
+	}
+
+	c.JSON(status, gin.H{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
@@ -0,0 +1,86 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric the service exports. It is kept separate from
+// the default registry so tests can inspect metrics in isolation.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// AI generation results
+const (
+	ResultSuccess  = "success"
+	ResultCache    = "cache"
+	ResultFallback = "fallback"
+	ResultError    = "error"
+)
+
+var (
+	// SuspiciousLoginsTotal counts logins from IP addresses not seen for the user recently
+	SuspiciousLoginsTotal = factory.NewCounter(prometheus.CounterOpts{
+		Name: "suspicious_logins_total",
+		Help: "Total number of logins from a new device or IP address",
+	})
+
+	// HTTPRequestsTotal is labelled by the matched route pattern rather than
+	// the raw path to keep cardinality bounded
+	HTTPRequestsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests",
+	}, []string{"method", "path", "status"})
+
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path"})
+
+	HTTPRequestsInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served",
+	})
+
+	AIGenerationsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_generations_total",
+		Help: "Total number of AI website generations by model and result",
+	}, []string{"model", "result"})
+
+	// AIProviderCallsTotal counts calls to each AI provider, including those
+	// that failed over to the next model in the fallback chain
+	AIProviderCallsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_provider_calls_total",
+		Help: "Total number of AI provider calls by provider and result",
+	}, []string{"provider", "result"})
+
+	ExportsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "exports_total",
+		Help: "Total number of completed project exports by format",
+	}, []string{"format"})
+
+	RedisOperationDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_operation_duration_seconds",
+		Help:    "Redis command latency in seconds",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,54 @@
+// internal/middleware/body_limit.go
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodySizeLimit rejects requests whose body is larger than maxBytes with 413
+// before any handler reads it. The body is buffered up to the limit, so the
+// check holds even when Content-Length is missing or understated.
+func BodySizeLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			abortTooLarge(c, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(&io.LimitedReader{R: c.Request.Body, N: maxBytes + 1})
+		c.Request.Body.Close()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to read request body",
+				"code":  "INVALID_BODY",
+			})
+			c.Abort()
+			return
+		}
+		if int64(len(body)) > maxBytes {
+			abortTooLarge(c, maxBytes)
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func abortTooLarge(c *gin.Context, maxBytes int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":    "Request body too large",
+		"code":     "REQUEST_TOO_LARGE",
+		"maxBytes": maxBytes,
+	})
+	c.Abort()
+}
@@ -0,0 +1,80 @@
+// internal/middleware/cache.go
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lovable-backend/internal/redis"
+	"lovable-backend/internal/services"
+)
+
+// cachedResponse is a successful GET response stored in Redis
+type cachedResponse struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// bodyRecorder passes writes through to the client while keeping a copy
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// CacheResponse serves GET requests from Redis (cache-aside) under the key
+// returned by keyFn, storing 200 responses for ttl. An empty key skips the
+// cache. Services drop entries through services.ResponseCacheKey when the
+// underlying data changes.
+func CacheResponse(redisClient *redis.Client, ttl time.Duration, keyFn func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if redisClient == nil || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		key := keyFn(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		key = services.ResponseCacheKey(key)
+
+		cache := redisClient.WithContext(c.Request.Context())
+
+		var cached cachedResponse
+		if err := cache.Get(key, &cached); err == nil {
+			c.Header("X-Cache", "HIT")
+			c.Data(http.StatusOK, cached.ContentType, cached.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Header("X-Cache", "MISS")
+
+		c.Next()
+
+		if recorder.Status() != http.StatusOK {
+			return
+		}
+
+		cache.Set(key, cachedResponse{
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}, ttl)
+	}
+}
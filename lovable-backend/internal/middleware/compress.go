@@ -0,0 +1,184 @@
+// internal/middleware/compress.go
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Content types that are already compressed, or streamed to the client as
+// they are produced, and so are sent as they are
+var uncompressedContentTypes = []string{
+	"application/zip",
+	"application/gzip",
+	"application/octet-stream",
+	"image/",
+	"video/",
+	"audio/",
+	"text/event-stream",
+}
+
+// gzipWriter holds back the start of the body until it reaches minBytes, then
+// decides whether to compress: smaller responses aren't worth the overhead
+type gzipWriter struct {
+	gin.ResponseWriter
+	pool     *sync.Pool
+	minBytes int
+
+	buf      bytes.Buffer
+	gz       *gzip.Writer
+	decided  bool
+	compress bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.compress {
+			return w.gz.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	if !w.compressible() {
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minBytes {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends what has been written so far. A response flushed before it is
+// large enough to compress is a stream, and is left uncompressed.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.compress {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// compressible reports whether the response, as the handler has set it up so
+// far, may be compressed
+func (w *gzipWriter) compressible() bool {
+	if w.ResponseWriter.Written() {
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	status := w.Status()
+	if status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	for _, skipped := range uncompressedContentTypes {
+		if strings.HasPrefix(contentType, skipped) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decide starts sending the response, compressed or not, beginning with the
+// part held back so far
+func (w *gzipWriter) decide(compress bool) error {
+	w.decided = true
+	w.compress = compress
+
+	if compress {
+		header := w.Header()
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if compress {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// close sends any part of the body still held back and finishes the gzip
+// stream
+func (w *gzipWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.compress {
+		w.gz.Close()
+		w.gz.Reset(io.Discard)
+		w.pool.Put(w.gz)
+	}
+}
+
+// Compress gzips responses of at least minBytes for clients that accept it,
+// at the given compress/gzip level. Binary downloads, event streams and
+// WebSocket upgrades are passed through unchanged.
+func Compress(level, minBytes int) gin.HandlerFunc {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+
+	pool := &sync.Pool{
+		New: func() interface{} {
+			gz, _ := gzip.NewWriterLevel(io.Discard, level)
+			return gz
+		},
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead ||
+			c.GetHeader("Upgrade") != "" ||
+			!strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{
+			ResponseWriter: c.Writer,
+			pool:           pool,
+			minBytes:       minBytes,
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		writer.close()
+	}
+}
@@ -0,0 +1,124 @@
+// internal/middleware/idempotency.go
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/redis"
+)
+
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+
+	// How long a key stays claimed by a request that hasn't finished. A
+	// request running longer can be repeated by a retry.
+	idempotencyLockTTL = 30 * time.Second
+
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyRecord is the state of an idempotency key stored in Redis
+type idempotencyRecord struct {
+	Status      string `json:"status"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency makes retries of a request carrying an X-Idempotency-Key header
+// safe: the first request with a key runs and its response is kept for ttl,
+// later ones get that response replayed with X-Idempotency-Replay: true.
+// Keys are scoped to the user, so it must run after authentication. Server
+// errors aren't kept, letting the client retry them. Without Redis requests
+// run as usual.
+func Idempotency(redisClient *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader("X-Idempotency-Key")
+		if idempotencyKey == "" || redisClient == nil {
+			c.Next()
+			return
+		}
+
+		// The auth middleware stores the user ID as a uuid.UUID. Without
+		// one the key can't be scoped, so nothing is kept.
+		value, _ := c.Get("userID")
+		userID, ok := value.(uuid.UUID)
+		if !ok || userID == uuid.Nil {
+			c.Next()
+			return
+		}
+
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Idempotency key must be at most 255 characters",
+				"code":  "INVALID_IDEMPOTENCY_KEY",
+			})
+			c.Abort()
+			return
+		}
+
+		hash := sha256.Sum256([]byte(userID.String() + ":" + idempotencyKey))
+		key := "idempotency:" + hex.EncodeToString(hash[:])
+
+		store := redisClient.WithContext(c.Request.Context())
+
+		claimed, err := store.SetNX(key, idempotencyRecord{Status: idempotencyProcessing}, idempotencyLockTTL)
+		if err != nil {
+			// Fail open, as rate limiting does
+			c.Next()
+			return
+		}
+
+		if !claimed {
+			var record idempotencyRecord
+			if err := store.Get(key, &record); err != nil {
+				// Expired since the claim attempt
+				c.Next()
+				return
+			}
+
+			if record.Status == idempotencyProcessing {
+				c.JSON(http.StatusConflict, gin.H{
+					"error": "A request with this idempotency key is still being processed",
+					"code":  "IDEMPOTENCY_IN_FLIGHT",
+				})
+				c.Abort()
+				return
+			}
+
+			c.Header("X-Idempotency-Replay", "true")
+			c.Data(record.StatusCode, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// A client that timed out and disconnected has canceled the request's
+		// context, but its retry still needs the outcome recorded
+		store = redisClient.WithContext(context.WithoutCancel(c.Request.Context()))
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			store.Del(key)
+			return
+		}
+
+		store.Set(key, idempotencyRecord{
+			Status:      idempotencyCompleted,
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}, ttl)
+	}
+}
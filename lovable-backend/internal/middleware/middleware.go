@@ -2,13 +2,24 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
 
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/metrics"
 	"lovable-backend/internal/redis"
 	"lovable-backend/internal/services"
 	"lovable-backend/pkg/logger"
@@ -16,17 +27,24 @@ import (
 
 type RateLimiter struct {
 	redisClient *redis.Client
+	configStore *config.ConfigStore
 }
 
-func NewRateLimiter(redisClient *redis.Client) *RateLimiter {
+func NewRateLimiter(redisClient *redis.Client, configStore *config.ConfigStore) *RateLimiter {
 	return &RateLimiter{
 		redisClient: redisClient,
+		configStore: configStore,
 	}
 }
 
-// Auth middleware for JWT token validation
+// Auth middleware for JWT token or API key validation
 func Auth(authService *services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			authenticateAPIKey(c, authService, rawKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -58,16 +76,101 @@ func Auth(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		if authService.IsTokenRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Token has been revoked",
+				"code":  "TOKEN_REVOKED",
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("name", claims.Name)
 		c.Set("subscriptionPlan", claims.SubscriptionPlan)
+		c.Set("tokenID", claims.ID)
+		c.Set("role", claims.Role)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
+		authService.TouchSession(claims.UserID)
 
 		c.Next()
 	}
 }
 
+// authenticateAPIKey validates an X-API-Key header and checks that one of the
+// key's scopes covers the route being called.
+func authenticateAPIKey(c *gin.Context, authService *services.AuthService, rawKey string) {
+	apiKey, user, err := authService.AuthenticateAPIKey(rawKey)
+	if err != nil {
+		code := "INVALID_API_KEY"
+		switch err.Error() {
+		case "API key expired":
+			code = "API_KEY_EXPIRED"
+		case "account is disabled":
+			code = "ACCOUNT_DISABLED"
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+			"code":  code,
+		})
+		c.Abort()
+		return
+	}
+
+	resource, access := apiKeyScopeForRoute(c)
+	// A leaked key must not be able to mint more keys, end sessions, change
+	// two-factor settings or delete the account
+	if resource == "auth" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "API keys cannot be used for account endpoints",
+			"code":  "API_KEY_NOT_ALLOWED",
+		})
+		c.Abort()
+		return
+	}
+	if !services.APIKeyAllows(apiKey.Scopes, resource, access) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "API key scope does not cover this endpoint",
+			"code":  "INSUFFICIENT_SCOPE",
+			"scope": resource + ":" + access,
+		})
+		c.Abort()
+		return
+	}
+
+	// Same context keys as the JWT path
+	c.Set("userID", user.ID)
+	c.Set("email", user.Email)
+	c.Set("name", user.Name)
+	c.Set("subscriptionPlan", user.SubscriptionPlan)
+	c.Set("apiKeyID", apiKey.ID)
+
+	c.Next()
+}
+
+// apiKeyScopeForRoute maps a route to the resource and access level an API
+// key needs, e.g. GET /api/projects/:id requires projects:read. WebSocket
+// generation counts as AI access.
+func apiKeyScopeForRoute(c *gin.Context) (string, string) {
+	access := "write"
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		access = "read"
+	}
+
+	path := c.FullPath()
+	if path == "/ws" {
+		return "ai", access
+	}
+
+	resource, _, _ := strings.Cut(strings.TrimPrefix(path, "/api/"), "/")
+	return resource, access
+}
+
 // Optional auth middleware for public endpoints that may have auth
 func OptionalAuth(authService *services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -76,7 +179,7 @@ func OptionalAuth(authService *services.AuthService) gin.HandlerFunc {
 			parts := strings.Split(authHeader, " ")
 			if len(parts) == 2 && parts[0] == "Bearer" {
 				token := parts[1]
-				if claims, err := authService.ValidateToken(token); err == nil {
+				if claims, err := authService.ValidateToken(token); err == nil && !authService.IsTokenRevoked(claims) {
 					c.Set("userID", claims.UserID)
 					c.Set("email", claims.Email)
 					c.Set("name", claims.Name)
@@ -107,6 +210,26 @@ func UsageLimit(authService *services.AuthService) gin.HandlerFunc {
 			plan = subscriptionPlan.(string)
 		}
 
+		if authService.RequiresEmailVerification() {
+			verified, err := authService.IsEmailVerified(userID.(uuid.UUID))
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to check email verification",
+					"code":  "USAGE_CHECK_ERROR",
+				})
+				c.Abort()
+				return
+			}
+			if !verified {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error": "Please verify your email address to use AI features",
+					"code":  "EMAIL_NOT_VERIFIED",
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		allowed, usageInfo, err := authService.CheckUsageLimit(userID.(uuid.UUID), plan)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -134,6 +257,173 @@ func UsageLimit(authService *services.AuthService) gin.HandlerFunc {
 	}
 }
 
+// Export format gate middleware based on subscription plan
+func ExportFormatGate(format string, authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plan := "free"
+		if subscriptionPlan, exists := c.Get("subscriptionPlan"); exists {
+			if p, ok := subscriptionPlan.(string); ok && p != "" {
+				plan = p
+			}
+		}
+
+		if !authService.IsExportFormatAllowed(plan, format) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":       "Export format not available on your plan",
+				"code":        "FORMAT_NOT_ALLOWED_ON_PLAN",
+				"format":      format,
+				"plan":        plan,
+				"upgrade_url": "/billing/upgrade",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminAuth restricts routes to administrators. The access token must carry
+// the admin role, and the account must still be an active admin so demoted
+// users lose access before their token expires.
+func AdminAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userID")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+				"code":  "AUTH_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		if c.GetString("role") != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Administrator access required",
+				"code":  "ADMIN_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		user, err := authService.GetUserByID(userID.(uuid.UUID))
+		if err != nil || !user.IsAdmin || !user.IsActive {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Administrator access required",
+				"code":  "ADMIN_REQUIRED",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Maximum webhook body size accepted for signature verification
+const maxWebhookBodySize = 1 << 20
+
+// Stripe-style signatures whose timestamp is further than this from now are
+// rejected, so captured requests can't be replayed later
+const webhookTimestampTolerance = 5 * time.Minute
+
+// VerifyHMACSignature rejects requests whose body does not match the
+// HMAC-SHA256 signature in headerName. Plain hex, "sha256=<hex>" and Stripe's
+// "t=<timestamp>,v1=<hex>" header formats are accepted; the latter only within
+// webhookTimestampTolerance of its timestamp. The body is restored so handlers
+// can read it again.
+func VerifyHMACSignature(headerName, secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signature := c.GetHeader(headerName)
+		if signature == "" || secret == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Missing request signature",
+				"code":  "MISSING_SIGNATURE",
+			})
+			c.Abort()
+			return
+		}
+
+		var buf bytes.Buffer
+		body, err := io.ReadAll(io.TeeReader(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize), &buf))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Failed to read request body",
+				"code":  "INVALID_BODY",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(&buf)
+
+		if !validSignature(signature, body, secret, time.Now()) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid request signature",
+				"code":  "INVALID_SIGNATURE",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func validSignature(header string, body []byte, secret string, now time.Time) bool {
+	// Stripe signs "<timestamp>.<body>" and may send several v1 signatures
+	if strings.Contains(header, "v1=") {
+		var timestamp string
+		var candidates []string
+		for _, part := range strings.Split(header, ",") {
+			key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+			if !found {
+				continue
+			}
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				candidates = append(candidates, value)
+			}
+		}
+
+		signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		if age := now.Sub(time.Unix(signedAt, 0)); age > webhookTimestampTolerance || age < -webhookTimestampTolerance {
+			return false
+		}
+
+		expected := computeHMAC(append([]byte(timestamp+"."), body...), secret)
+		for _, candidate := range candidates {
+			if signaturesEqual(candidate, expected) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return signaturesEqual(strings.TrimPrefix(header, "sha256="), computeHMAC(body, secret))
+}
+
+func computeHMAC(payload []byte, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// signaturesEqual compares a hex-encoded signature against the expected MAC
+// in constant time.
+func signaturesEqual(signature string, expected []byte) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(decoded, expected)
+}
+
 // Security middleware
 func Security() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -146,47 +436,273 @@ func Security() gin.HandlerFunc {
 	}
 }
 
-// Logger middleware
+// Longest client-supplied X-Request-ID that is accepted
+const maxRequestIDLength = 128
+
+// RequestID tags each request with an ID, reusing a well-formed X-Request-ID
+// header from the client. The ID is stored under "requestID", added to the
+// request context for logging and outgoing calls, and echoed in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Set("requestID", requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header("X-Request-ID", requestID)
+		c.Next()
+	}
+}
+
+// validRequestID limits client IDs to a safe length and character set so
+// they can't inject anything into logs or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// Logger middleware. Each line ends with the request ID set by RequestID.
 func Logger(logger *logger.Logger) gin.HandlerFunc {
-	return gin.LoggerWithWriter(logger)
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Output: logger,
+		Formatter: func(params gin.LogFormatterParams) string {
+			requestID, _ := params.Keys["requestID"].(string)
+			return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | requestID=%s\n%s",
+				params.TimeStamp.Format("2006/01/02 - 15:04:05"),
+				params.StatusCode,
+				params.Latency,
+				params.ClientIP,
+				params.Method,
+				params.Path,
+				requestID,
+				params.ErrorMessage,
+			)
+		},
+	})
+}
+
+// Metrics middleware records request counts, latency and in-flight requests.
+// Requests that match no route share the "unmatched" path label.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		c.Next()
+
+		path := c.FullPath()
+		if path == "" {
+			path = "unmatched"
+		}
+		metrics.HTTPRequestsTotal.WithLabelValues(c.Request.Method, path, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, path).Observe(time.Since(start).Seconds())
+	}
+}
+
+// MetricsAuth requires "Authorization: Bearer <token>" when token is set
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid metrics token",
+				"code":  "INVALID_METRICS_TOKEN",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Window length for each rate limiter, keyed by Redis key prefix
+var rateLimitWindows = map[string]time.Duration{
+	"global":  15 * time.Minute,
+	"auth":    15 * time.Minute,
+	"project": time.Minute,
+	"ai":      time.Minute,
+	"guest":   time.Hour,
+	"export":  time.Minute,
+	"bulk":    time.Minute,
+}
+
+// Limiters reported by the status endpoint
+var statusRateLimitPrefixes = []string{"ai", "project", "export", "bulk", "global", "auth"}
+
+type RateLimitStatus struct {
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// GetStatus reports the current usage of each user-facing rate limiter under
+// the given plan without consuming a request. The global and auth limiters run
+// before authentication and are keyed by client IP. Results are cached for 5
+// seconds.
+func (rl *RateLimiter) GetStatus(userID uuid.UUID, plan, clientIP string) (map[string]RateLimitStatus, error) {
+	now := time.Now()
+	statuses := make(map[string]RateLimitStatus, len(statusRateLimitPrefixes))
+
+	if rl.redisClient == nil {
+		for _, prefix := range statusRateLimitPrefixes {
+			limit := rl.configStore.RateLimit(plan, prefix)
+			statuses[prefix] = RateLimitStatus{
+				Limit:     limit,
+				Remaining: limit,
+				ResetsAt:  now.Add(rateLimitWindows[prefix]),
+			}
+		}
+		return statuses, nil
+	}
+
+	cacheKey := "rate_limit_status:" + userID.String() + ":" + plan
+	if err := rl.redisClient.Get(cacheKey, &statuses); err == nil {
+		return statuses, nil
+	}
+
+	for _, prefix := range statusRateLimitPrefixes {
+		key := prefix + ":user:" + userID.String()
+		if prefix == "global" || prefix == "auth" {
+			key = prefix + ":ip:" + clientIP
+		}
+
+		limit := rl.configStore.RateLimit(plan, prefix)
+		status := RateLimitStatus{
+			Limit:    limit,
+			ResetsAt: now.Add(rateLimitWindows[prefix]),
+		}
+
+		if rl.configStore.RateLimitStrategy() == config.RateLimitLeakyBucket && limit > 0 {
+			rate := float64(limit) / rateLimitWindows[prefix].Seconds()
+			used, err := rl.redisClient.LeakyBucketLevel(key+":leaky", rate)
+			if err != nil {
+				return nil, err
+			}
+			status.Used = used
+			status.ResetsAt = now.Add(time.Duration(float64(used) / rate * float64(time.Second)))
+		} else if used, err := rl.redisClient.Client.Get(rl.redisClient.Ctx, key).Int64(); err != nil && err != goredis.Nil {
+			return nil, err
+		} else if err == nil {
+			status.Used = used
+			if ttl, err := rl.redisClient.Client.TTL(rl.redisClient.Ctx, key).Result(); err == nil && ttl > 0 {
+				status.ResetsAt = now.Add(ttl)
+			}
+		}
+
+		status.Remaining = limit - status.Used
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+		statuses[prefix] = status
+	}
+
+	rl.redisClient.Set(cacheKey, statuses, 5*time.Second)
+
+	return statuses, nil
 }
 
 // Rate limiting methods
 func (rl *RateLimiter) GlobalLimit() gin.HandlerFunc {
-	return rl.createRateLimit("global", 100, 15*time.Minute, "Too many requests")
+	return rl.createRateLimit("global", rateLimitWindows["global"], rl.planLimit("global"), "Too many requests")
 }
 
 func (rl *RateLimiter) AuthLimit() gin.HandlerFunc {
-	return rl.createRateLimit("auth", 5, 15*time.Minute, "Too many authentication attempts")
+	return rl.createRateLimit("auth", rateLimitWindows["auth"], rl.planLimit("auth"), "Too many authentication attempts")
 }
 
 func (rl *RateLimiter) ProjectLimit() gin.HandlerFunc {
-	return rl.createRateLimit("project", 30, time.Minute, "Too many project requests")
+	return rl.createRateLimit("project", rateLimitWindows["project"], rl.planLimit("project"), "Too many project requests")
 }
 
 func (rl *RateLimiter) AILimit() gin.HandlerFunc {
-	return rl.createRateLimit("ai", 10, time.Minute, "AI generation rate limit exceeded")
+	return rl.createRateLimit("ai", rateLimitWindows["ai"], rl.planLimit("ai"), "AI generation rate limit exceeded")
+}
+
+func (rl *RateLimiter) GuestLimit() gin.HandlerFunc {
+	return rl.createRateLimit("guest", rateLimitWindows["guest"], rl.planLimit("guest"), "Guest generation limit exceeded")
 }
 
 func (rl *RateLimiter) ExportLimit() gin.HandlerFunc {
-	return rl.createRateLimit("export", 10, time.Minute, "Export rate limit exceeded")
+	return rl.createRateLimit("export", rateLimitWindows["export"], rl.planLimit("export"), "Export rate limit exceeded")
+}
+
+func (rl *RateLimiter) BulkLimit() gin.HandlerFunc {
+	return rl.createRateLimit("bulk", rateLimitWindows["bulk"], rl.planLimit("bulk"), "Bulk operation rate limit exceeded")
 }
 
-func (rl *RateLimiter) createRateLimit(prefix string, limit int64, window time.Duration, message string) gin.HandlerFunc {
+// planLimit resolves a limiter's limit for a subscription plan from the config
+// store on every request, so reloaded limits apply immediately.
+func (rl *RateLimiter) planLimit(prefix string) func(plan string) int64 {
+	return func(plan string) int64 {
+		return rl.configStore.RateLimit(plan, prefix)
+	}
+}
+
+// checkLimit counts a request against key with the configured strategy. The
+// leaky bucket drains limit requests per window and lets at most limit
+// through at once; its reset time is when the bucket will be empty.
+func (rl *RateLimiter) checkLimit(c *gin.Context, key string, limit int64, window time.Duration) (bool, int64, time.Time, error) {
+	client := rl.redisClient.WithContext(c.Request.Context())
+	if rl.configStore.RateLimitStrategy() != config.RateLimitLeakyBucket {
+		return client.CheckRateLimit(key, limit, window)
+	}
+	if limit <= 0 {
+		return false, 0, time.Now().Add(window), nil
+	}
+
+	rate := float64(limit) / window.Seconds()
+	allowed, remaining, err := client.CheckLeakyBucket(key+":leaky", rate, limit)
+	if err != nil {
+		return true, 0, time.Time{}, err
+	}
+
+	drainTime := time.Duration(float64(limit-remaining) / rate * float64(time.Second))
+	return allowed, remaining, time.Now().Add(drainTime), nil
+}
+
+// createRateLimit builds a limiter whose limit depends on the caller's
+// subscription plan. Unauthenticated requests get the free tier.
+func (rl *RateLimiter) createRateLimit(prefix string, window time.Duration, limitFor func(plan string) int64, message string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if rl.redisClient == nil {
 			c.Next()
 			return
 		}
 
+		plan := "free"
+		if p := c.GetString("subscriptionPlan"); p != "" {
+			plan = p
+		}
+		limit := limitFor(plan)
+
+		// API key requests are throttled per key rather than per user
 		var key string
-		if userID, exists := c.Get("userID"); exists {
+		if apiKeyID, exists := c.Get("apiKeyID"); exists {
+			key = prefix + ":apikey:" + apiKeyID.(uuid.UUID).String()
+		} else if userID, exists := c.Get("userID"); exists {
 			key = prefix + ":user:" + userID.(uuid.UUID).String()
 		} else {
 			key = prefix + ":ip:" + c.ClientIP()
 		}
 
-		allowed, remaining, resetTime, err := rl.redisClient.CheckRateLimit(key, limit, window)
+		allowed, remaining, resetTime, err := rl.checkLimit(c, key, limit, window)
 		if err != nil {
 			// Continue on Redis error
 			c.Next()
@@ -0,0 +1,134 @@
+// internal/middleware/middleware_test.go
+package middleware
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testWebhookSecret = "whsec_test"
+
+func sign(payload, secret string) string {
+	return hex.EncodeToString(computeHMAC([]byte(payload), secret))
+}
+
+func TestSignaturesEqual(t *testing.T) {
+	expected := computeHMAC([]byte("payload"), testWebhookSecret)
+	valid := hex.EncodeToString(expected)
+
+	flipFirst := []byte(valid)
+	flipFirst[0] ^= 1
+	flipLast := []byte(valid)
+	flipLast[len(flipLast)-1] ^= 1
+
+	tests := []struct {
+		name      string
+		signature string
+		want      bool
+	}{
+		{"matching", valid, true},
+		{"upper case hex", strings.ToUpper(valid), true},
+		{"first byte differs", string(flipFirst), false},
+		{"last byte differs", string(flipLast), false},
+		{"truncated", valid[:len(valid)-2], false},
+		{"extended", valid + "00", false},
+		{"not hex", "zz" + valid[2:], false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signaturesEqual(tt.signature, expected); got != tt.want {
+				t.Errorf("signaturesEqual(%q) = %v, want %v", tt.signature, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+
+	stripeHeader := func(at time.Time, signature string) string {
+		return fmt.Sprintf("t=%d,v1=%s", at.Unix(), signature)
+	}
+	stripeSignature := func(at time.Time) string {
+		return sign(fmt.Sprintf("%d.%s", at.Unix(), body), testWebhookSecret)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"plain hex", sign(string(body), testWebhookSecret), true},
+		{"sha256 prefix", "sha256=" + sign(string(body), testWebhookSecret), true},
+		{"wrong secret", sign(string(body), "other"), false},
+		{"stripe", stripeHeader(now, stripeSignature(now)), true},
+		{"stripe with rotated secret", stripeHeader(now, sign("x", "old")) + ",v1=" + stripeSignature(now), true},
+		{"stripe within tolerance", stripeHeader(now.Add(-4*time.Minute), stripeSignature(now.Add(-4*time.Minute))), true},
+		{"stripe too old", stripeHeader(now.Add(-6*time.Minute), stripeSignature(now.Add(-6*time.Minute))), false},
+		{"stripe from the future", stripeHeader(now.Add(6*time.Minute), stripeSignature(now.Add(6*time.Minute))), false},
+		{"stripe timestamp not signed", stripeHeader(now, sign(string(body), testWebhookSecret)), false},
+		{"stripe without timestamp", "v1=" + stripeSignature(now), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validSignature(tt.header, body, testWebhookSecret, now); got != tt.want {
+				t.Errorf("validSignature(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyHMACSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := `{"event":"project.updated"}`
+
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{"valid", sign(body, testWebhookSecret), http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"invalid", sign(body, "other"), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			router := gin.New()
+			router.POST("/webhook", VerifyHMACSignature("X-Webhook-Signature", testWebhookSecret), func(c *gin.Context) {
+				data, _ := io.ReadAll(c.Request.Body)
+				received = string(data)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+			if tt.signature != "" {
+				req.Header.Set("X-Webhook-Signature", tt.signature)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			// The handler must still see the verified body
+			if tt.wantStatus == http.StatusOK && received != body {
+				t.Errorf("handler read body %q, want %q", received, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// internal/middleware/project_views.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/services"
+)
+
+// CountProjectView increments the view count of the project in the :id route
+// parameter after every successful response. It must run before
+// CacheResponse so responses served from the cache are counted as well.
+func CountProjectView(projectService *services.ProjectService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() != http.StatusOK {
+			return
+		}
+
+		projectID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return
+		}
+		projectService.IncrementViewCount(projectID)
+	}
+}
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,15 +17,29 @@ type User struct {
 	Name             *string        `json:"name"`
 	AvatarURL        *string        `json:"avatar_url"`
 	SubscriptionPlan string         `json:"subscription_plan" gorm:"default:'free'"`
+	PendingPlan      *string        `json:"pending_plan"`
+	PlanDowngradeAt  *time.Time     `json:"plan_downgrade_at"`
+	DowngradeWarned  bool           `json:"-" gorm:"default:false"`
 	APIUsageCount    int            `json:"api_usage_count" gorm:"default:0"`
 	APIUsageLimit    int            `json:"api_usage_limit" gorm:"default:100"`
 	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	IsGuest          bool           `json:"is_guest" gorm:"default:false"`
+	IsAdmin          bool           `json:"-" gorm:"default:false"`
+	PasswordSet      bool           `json:"-" gorm:"default:true"` // false for accounts created through OAuth
 	EmailVerified    bool           `json:"email_verified" gorm:"default:false"`
+	Timezone         string         `json:"timezone" gorm:"default:'UTC'"`
+	SAMLNameID       *string        `json:"-" gorm:"column:saml_name_id;uniqueIndex"`
 	LastLoginAt      *time.Time     `json:"last_login_at"`
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// End of the current paid billing period, kept up to date by billing events
+	SubscriptionRenewalAt *time.Time `json:"subscription_renewal_at"`
+
+	// Whether notifications are also sent by email
+	NotificationEmailEnabled bool `json:"notification_email_enabled" gorm:"default:false"`
+
 	// Relationships
 	Projects      []Project      `json:"projects,omitempty" gorm:"foreignKey:UserID"`
 	Conversations []Conversation `json:"conversations,omitempty" gorm:"foreignKey:UserID"`
@@ -37,23 +52,89 @@ type Project struct {
 	Name         string         `json:"name" gorm:"not null"`
 	Description  *string        `json:"description"`
 	HTMLCode     *string        `json:"html_code"`
+	HTMLCodeKey  *string        `json:"-"` // object key when HTML is kept in external storage
 	CSSCode      *string        `json:"css_code"`
 	JSCode       *string        `json:"js_code"`
 	PreviewURL   *string        `json:"preview_url"`
-	ThumbnailURL *string        `json:"thumbnail_url"`
+	ThumbnailURL *string        `json:"thumbnail_url" gorm:"type:text"`
 	Status       string         `json:"status" gorm:"default:'draft'"` // draft, published, archived
 	Tags         pq.StringArray `json:"tags" gorm:"type:text[]"`
 	IsPublic     bool           `json:"is_public" gorm:"default:false"`
+	IsFeatured   bool           `json:"is_featured" gorm:"default:false"` // curated for the discover gallery
 	ViewCount    int            `json:"view_count" gorm:"default:0"`
 	LikeCount    int            `json:"like_count" gorm:"default:0"`
+	ForkCount    int            `json:"fork_count" gorm:"default:0"`
+	ForkedFromID *uuid.UUID     `json:"forked_from_id" gorm:"type:uuid"`
+	CollectionID *uuid.UUID     `json:"collection_id" gorm:"type:uuid"`
+	SortOrder    int            `json:"sort_order" gorm:"default:0"` // position within the collection
 	PublishedAt  *time.Time     `json:"published_at"`
+	SearchVector *string        `json:"-" gorm:"type:tsvector;->:false;<-:false"` // maintained by the search index worker
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// Hash of the HTML the current thumbnail was rendered from
+	ThumbnailCodeHash    *string    `json:"-"`
+	ThumbnailRefreshedAt *time.Time `json:"-"`
+
 	// Relationships
-	User          User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Conversations []Conversation `json:"conversations,omitempty" gorm:"foreignKey:ProjectID"`
+	User          User               `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Conversations []Conversation     `json:"conversations,omitempty" gorm:"foreignKey:ProjectID"`
+	Collection    *ProjectCollection `json:"-" gorm:"foreignKey:CollectionID;constraint:OnDelete:SET NULL"`
+}
+
+// ProjectCollection is a user's folder of projects. Deleting it leaves the
+// projects in place, outside any collection.
+type ProjectCollection struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	Name         string    `json:"name" gorm:"not null"`
+	Description  *string   `json:"description"`
+	Color        string    `json:"color" gorm:"default:'#6366f1'"` // hex
+	Icon         *string   `json:"icon"`
+	SortOrder    int       `json:"sort_order" gorm:"default:0"`
+	ProjectCount int64     `json:"project_count" gorm:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// ProjectVersion is a snapshot of a project's code after each change. AI
+// generations link the conversation turn that produced the code.
+type ProjectVersion struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID      uuid.UUID  `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_versions_project_version"`
+	VersionNumber  int        `json:"version_number" gorm:"not null;uniqueIndex:idx_project_versions_project_version"`
+	ConversationID *uuid.UUID `json:"conversation_id" gorm:"type:uuid"`
+	HTMLCode       *string    `json:"html_code"`
+	CSSCode        *string    `json:"css_code"`
+	JSCode         *string    `json:"js_code"`
+	SizeBytes      int        `json:"size_bytes" gorm:"default:0"` // of the HTML, CSS and JS together
+	ChangedBy      uuid.UUID  `json:"changed_by" gorm:"type:uuid;not null"`
+	ChangeSource   string     `json:"change_source" gorm:"not null"` // manual_edit, ai_generation, import, restore
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Relationships
+	Project      Project       `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	Conversation *Conversation `json:"-" gorm:"foreignKey:ConversationID;constraint:OnDelete:SET NULL"`
+}
+
+// ProjectCollaborator grants another user access to a project. Access starts
+// once the invitation is accepted.
+type ProjectCollaborator struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID  uuid.UUID  `json:"project_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_collaborators_project_user"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_project_collaborators_project_user"`
+	Role       string     `json:"role" gorm:"not null"` // viewer, editor
+	InvitedBy  uuid.UUID  `json:"invited_by" gorm:"type:uuid;not null"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Relationships
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	User    User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
 type Conversation struct {
@@ -66,15 +147,32 @@ type Conversation struct {
 	TokensUsed         int       `json:"tokens_used" gorm:"default:0"`
 	ResponseTimeMS     *int      `json:"response_time_ms"`
 	ModelUsed          *string   `json:"model_used"`
-	MessageType        string    `json:"message_type" gorm:"default:'generation'"` // generation, refinement, question
+	MessageType        string    `json:"message_type" gorm:"default:'generation'"` // generation, refinement, question, imported
 	SatisfactionRating *int      `json:"satisfaction_rating"`                      // 1-5 rating
+	FromCache          bool      `json:"from_cache" gorm:"default:false"`
 	CreatedAt          time.Time `json:"created_at"`
 
+	// Name of the system prompt variant used for a generation, when the
+	// default prompt was not
+	PromptVariant *string `json:"prompt_variant" gorm:"size:100"`
+
+	// Branched turns point at the turn they follow. BranchPoint is the number
+	// of turns kept from the original chain; the main line leaves both unset.
+	ParentConversationID *uuid.UUID `json:"parent_conversation_id" gorm:"type:uuid"`
+	BranchPoint          *int       `json:"branch_point"`
+
 	// Relationships
 	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
 	User    User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// ConversationNode is a conversation turn with the turns that follow it, in
+// creation order
+type ConversationNode struct {
+	Conversation
+	Children []*ConversationNode `json:"children"`
+}
+
 type Template struct {
 	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name        string         `json:"name" gorm:"not null"`
@@ -87,16 +185,46 @@ type Template struct {
 	Tags        pq.StringArray `json:"tags" gorm:"type:text[]"`
 	UsageCount  int            `json:"usage_count" gorm:"default:0"`
 	Rating      float32        `json:"rating" gorm:"default:0.00"`
+	ReviewCount int            `json:"review_count" gorm:"default:0"`
 	IsPremium   bool           `json:"is_premium" gorm:"default:false"`
 	CreatedBy   *uuid.UUID     `json:"created_by" gorm:"type:uuid"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// User submissions start as drafts and are listed publicly once an admin
+	// approves them. Templates predating submissions are curated, hence the
+	// approved default.
+	PublishStatus   string     `json:"publish_status" gorm:"default:'approved'"` // draft, pending_review, approved
+	SourceProjectID *uuid.UUID `json:"source_project_id" gorm:"type:uuid"`
+
+	// Variables of the category's generation prompt
+	RequiredVars []string `json:"required_vars" gorm:"-"`
+	OptionalVars []string `json:"optional_vars" gorm:"-"`
+
+	// Number of reviews per star rating, keyed "1" to "5"
+	RatingDistribution map[string]int64 `json:"rating_distribution,omitempty" gorm:"-"`
+
 	// Relationships
 	Creator *User `json:"creator,omitempty" gorm:"foreignKey:CreatedBy"`
 }
 
+// TemplateReview is a user's rating of a template. Each user reviews a
+// template at most once; Template.Rating and ReviewCount are recalculated
+// from the reviews whenever one is written.
+type TemplateReview struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TemplateID uuid.UUID `json:"template_id" gorm:"type:uuid;not null;uniqueIndex:idx_template_reviews_template_user"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_template_reviews_template_user"`
+	Rating     int       `json:"rating" gorm:"not null"` // 1-5
+	Comment    *string   `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	Template Template `json:"-" gorm:"foreignKey:TemplateID"`
+	User     User     `json:"-" gorm:"foreignKey:UserID"`
+}
+
 type UserSession struct {
 	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
@@ -110,6 +238,41 @@ type UserSession struct {
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// PromptTemplate is a reusable generation prompt. Variables lists the
+// {{variable}} placeholders found in TemplateText.
+type PromptTemplate struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	Name         string         `json:"name" gorm:"not null"`
+	TemplateText string         `json:"template_text" gorm:"type:text;not null"`
+	Variables    pq.StringArray `json:"variables" gorm:"type:text[]"`
+	IsPublic     bool           `json:"is_public" gorm:"default:false"`
+	UsageCount   int            `json:"usage_count" gorm:"default:0"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// PromptVariant is an alternative system prompt for website generation, A/B
+// tested by users' satisfaction ratings. Each generation picks one of the
+// active variants at random in proportion to Weight, or the default prompt
+// when none is active.
+type PromptVariant struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name     string    `json:"name" gorm:"size:100;not null"`
+	Prompt   string    `json:"prompt" gorm:"type:text;not null"`
+	Weight   int       `json:"weight" gorm:"default:0"` // 0-100
+	IsActive bool      `json:"is_active" gorm:"default:false"`
+	// Share of rated generations rated 4 or 5, and the number rated, as of
+	// the last analysis
+	WinRate     float64   `json:"win_rate" gorm:"default:0"`
+	SampleCount int       `json:"sample_count" gorm:"default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 type APIUsage struct {
 	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
@@ -125,6 +288,331 @@ type APIUsage struct {
 	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// SAMLConfig holds identity provider settings for an organisation that signs
+// in with SAML SSO. Users are matched by email domain.
+type SAMLConfig struct {
+	ID               uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationName string         `json:"organization_name" gorm:"not null"`
+	Domain           string         `json:"domain" gorm:"uniqueIndex;not null"`
+	EntityID         string         `json:"entity_id" gorm:"not null"`
+	MetadataURL      string         `json:"metadata_url" gorm:"not null"`
+	DefaultPlan      string         `json:"default_plan" gorm:"default:'free'"`
+	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (SAMLConfig) TableName() string {
+	return "saml_config"
+}
+
+type AuditLog struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	Action    string    `json:"action" gorm:"not null"` // login, logout, password_change, profile_update
+	IPAddress *string   `json:"ip_address"`
+	UserAgent *string   `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// AdminAuditLog records an action an administrator took. It has no foreign
+// keys so the trail outlives deleted admins and targets.
+type AdminAuditLog struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	AdminUserID uuid.UUID       `json:"admin_user_id" gorm:"type:uuid;not null"`
+	Action      string          `json:"action" gorm:"not null"` // user_disabled, user_enabled, plan_changed, user_deleted, account_unlocked, moderation_mode_changed, project_featured
+	TargetID    *uuid.UUID      `json:"target_id" gorm:"type:uuid"`
+	Details     json.RawMessage `json:"details" gorm:"type:jsonb"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Notification is a message shown in a user's in-app notification list
+type Notification struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID       `json:"user_id" gorm:"type:uuid;not null"`
+	Type      string          `json:"type" gorm:"not null"` // generation_complete, export_ready, usage_warning, collaborator_invite, template_approved, system
+	Title     string          `json:"title" gorm:"not null"`
+	Body      string          `json:"body"`
+	IsRead    bool            `json:"is_read" gorm:"default:false"`
+	Data      json.RawMessage `json:"data" gorm:"type:jsonb"`
+	CreatedAt time.Time       `json:"created_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+type ProjectView struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"type:uuid;not null"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Relationships
+	Project Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
+type BatchImportJob struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	Status         string         `json:"status" gorm:"default:'running'"` // running, completed, failed, timed_out
+	TotalCount     int            `json:"total_count" gorm:"default:0"`
+	CompletedCount int            `json:"completed_count" gorm:"default:0"`
+	FailedCount    int            `json:"failed_count" gorm:"default:0"`
+	Errors         pq.StringArray `json:"errors" gorm:"type:text[]"`
+	CompletedAt    *time.Time     `json:"completed_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+type VerificationToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"` // hex SHA-256 of the emailed token
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// OAuthProvider links a user to an external identity provider account
+type OAuthProvider struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_oauth_providers_user_provider"`
+	Provider       string     `json:"provider" gorm:"not null;uniqueIndex:idx_oauth_providers_user_provider;uniqueIndex:idx_oauth_providers_provider_user_id"`
+	ProviderUserID string     `json:"-" gorm:"not null;uniqueIndex:idx_oauth_providers_provider_user_id"`
+	AccessToken    string     `json:"-"`
+	RefreshToken   string     `json:"-"`
+	ExpiresAt      *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// UserAPIKey authenticates server-side scripts through the X-API-Key header.
+// Only the SHA-256 hash of the key is stored.
+type UserAPIKey struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	KeyHash    string         `json:"key_hash" gorm:"uniqueIndex;not null"`
+	Label      string         `json:"label" gorm:"not null"`
+	Scopes     pq.StringArray `json:"scopes" gorm:"type:text[]"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// UserMFA holds a user's TOTP enrollment. The secret is AES-GCM encrypted and
+// backup codes are bcrypt hashes; the record is pending until EnabledAt is set.
+type UserMFA struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Secret       string         `json:"-" gorm:"not null"`
+	BackupCodes  pq.StringArray `json:"-" gorm:"type:text[]"`
+	LastUsedStep int64          `json:"-" gorm:"default:0"` // last accepted TOTP time step, to block code reuse
+	EnabledAt    *time.Time     `json:"enabled_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// LoginAttempt records every password login, successful or not, for lockout
+type LoginAttempt struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email     string    `json:"email" gorm:"not null"`
+	IPAddress string    `json:"ip_address"`
+	Success   bool      `json:"success" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportRecord is a user's completed export. ProjectID is nil for batch
+// exports, which record the number of projects instead.
+type ExportRecord struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID        uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	ProjectID     *uuid.UUID `json:"project_id" gorm:"type:uuid"`
+	Format        string     `json:"format" gorm:"not null"` // html, zip, batch
+	FileName      string     `json:"file_name"`
+	FileSize      int64      `json:"file_size"`
+	MinifyUsed    bool       `json:"minify_used"`
+	IncludeAssets bool       `json:"include_assets"`
+	ProjectCount  int        `json:"project_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ProjectActivity is one entry in a project's activity feed. UserID is the
+// user who performed the action.
+type ProjectActivity struct {
+	ID           uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID    uuid.UUID       `json:"project_id" gorm:"type:uuid;not null"`
+	UserID       uuid.UUID       `json:"user_id" gorm:"type:uuid;not null"`
+	ActivityType string          `json:"activity_type" gorm:"not null"` // created, renamed, code_updated, published, unpublished, duplicated, forked, collaborator_added, exported, ai_generated, deleted
+	Source       *string         `json:"source,omitempty"`              // how a created project was made: created, import_url, import_zip
+	Metadata     json.RawMessage `json:"metadata" gorm:"type:jsonb"`
+	CreatedAt    time.Time       `json:"created_at"`
+
+	// Relationships
+	Project Project `json:"-" gorm:"foreignKey:ProjectID"`
+	User    User    `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// UserPreference is one frontend setting of a user, such as the theme or the
+// editor font size
+type UserPreference struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primary_key"`
+	Key       string    `json:"key" gorm:"primary_key"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex;not null"` // hex SHA-256 of the emailed token
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+type ReplayJob struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	SourceProjectID uuid.UUID  `json:"source_project_id" gorm:"type:uuid;not null"`
+	ProjectID       uuid.UUID  `json:"project_id" gorm:"type:uuid;not null"`
+	Status          string     `json:"status" gorm:"default:'running'"` // running, completed, failed
+	TotalSteps      int        `json:"total_steps" gorm:"default:0"`
+	CompletedSteps  int        `json:"completed_steps" gorm:"default:0"`
+	Error           *string    `json:"error"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// AIJob is a website generation queued for the async worker pool. Input holds
+// the GenerateRequest and Result the GenerationResult once completed.
+type AIJob struct {
+	ID           uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID       `json:"user_id" gorm:"type:uuid;not null"`
+	ProjectID    uuid.UUID       `json:"project_id" gorm:"type:uuid;not null"`
+	Status       string          `json:"status" gorm:"default:'queued'"` // queued, processing, completed, failed
+	Input        json.RawMessage `json:"input" gorm:"type:jsonb;not null"`
+	Result       json.RawMessage `json:"result,omitempty" gorm:"type:jsonb"`
+	ErrorMessage *string         `json:"error_message"`
+	StartedAt    *time.Time      `json:"started_at"`
+	CompletedAt  *time.Time      `json:"completed_at"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// Job is a unit of background work run by the worker pool. Failed jobs are
+// retried after RunAfter until MaxAttempts is reached, then marked dead.
+type Job struct {
+	ID          uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Type        string          `json:"type" gorm:"not null"`
+	Payload     json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status      string          `json:"status" gorm:"not null;default:'queued'"` // queued, running, completed, dead
+	Attempts    int             `json:"attempts" gorm:"default:0"`
+	MaxAttempts int             `json:"max_attempts" gorm:"default:5"`
+	RunAfter    time.Time       `json:"run_after" gorm:"not null"`
+	LastError   *string         `json:"last_error"`
+	StartedAt   *time.Time      `json:"started_at"`
+	CompletedAt *time.Time      `json:"completed_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// AsyncJob is background work whose output the user downloads once it
+// completes, such as a data export of a large account
+type AsyncJob struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null"`
+	Type        string     `json:"type" gorm:"not null"`            // data_export
+	Status      string     `json:"status" gorm:"default:'running'"` // running, completed, failed
+	FileName    string     `json:"file_name"`
+	Result      []byte     `json:"-" gorm:"type:bytea"`
+	Error       *string    `json:"error"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// Webhook is a user's endpoint that receives a signed POST for each
+// subscribed event
+type Webhook struct {
+	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	URL            string         `json:"url" gorm:"not null"`
+	Secret         string         `json:"-" gorm:"not null"`
+	Events         pq.StringArray `json:"events" gorm:"type:text[]"` // generation.completed, project.published, export.completed
+	IsActive       bool           `json:"is_active" gorm:"default:true"`
+	LastDeliveryAt *time.Time     `json:"last_delivery_at"`
+	FailureCount   int            `json:"failure_count" gorm:"default:0"` // consecutive failed deliveries
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WebhookID  uuid.UUID       `json:"webhook_id" gorm:"type:uuid;not null"`
+	EventID    uuid.UUID       `json:"event_id" gorm:"type:uuid;not null"`
+	Event      string          `json:"event" gorm:"not null"`
+	Payload    json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	Attempt    int             `json:"attempt" gorm:"not null"`
+	StatusCode *int            `json:"status_code"`
+	Error      *string         `json:"error"`
+	Success    bool            `json:"success" gorm:"default:false"`
+	DurationMS int             `json:"duration_ms"`
+	CreatedAt  time.Time       `json:"created_at"`
+
+	// Relationships
+	Webhook Webhook `json:"-" gorm:"foreignKey:WebhookID"`
+}
+
+type ModerationEvent struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
+	PromptHash string    `json:"prompt_hash" gorm:"not null"`
+	Reason     string    `json:"reason" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relationships
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
 // DTOs for API requests/responses
 type RegisterRequest struct {
 	Email           string `json:"email" binding:"required,email"`
@@ -138,27 +626,205 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type ClaimGuestRequest struct {
+	GuestToken string `json:"guest_token" binding:"required"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token              string `json:"token" binding:"required"`
+	NewPassword        string `json:"newPassword" binding:"required,min=8,max=128"`
+	ConfirmNewPassword string `json:"confirmNewPassword" binding:"required"`
+}
+
 type ChangePasswordRequest struct {
 	CurrentPassword    string `json:"currentPassword" binding:"required"`
 	NewPassword        string `json:"newPassword" binding:"required,min=8,max=128"`
 	ConfirmNewPassword string `json:"confirmNewPassword" binding:"required"`
 }
 
+type CreateAPIKeyRequest struct {
+	Label         string   `json:"label" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=365"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+type UnlockAccountRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type DeleteAccountRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Confirmation string `json:"confirmation" binding:"required"`
+}
+
 type UpdateProfileRequest struct {
-	Name      *string `json:"name" binding:"omitempty,max=255"`
-	AvatarURL *string `json:"avatarUrl" binding:"omitempty,max=500"`
+	Name                     *string `json:"name" binding:"omitempty,max=255"`
+	AvatarURL                *string `json:"avatarUrl" binding:"omitempty,max=500"`
+	Timezone                 *string `json:"timezone" binding:"omitempty,max=64"`
+	NotificationEmailEnabled *bool   `json:"notificationEmailEnabled"`
 }
 
 type CreateProjectRequest struct {
 	Name        string   `json:"name" binding:"required,min=1,max=255"`
 	Description string   `json:"description" binding:"max=1000"`
+	HTMLCode    *string  `json:"html_code" binding:"omitempty,max=1000000"`
 	Tags        []string `json:"tags" binding:"max=10"`
 }
 
+type ImportURLRequest struct {
+	URL  string `json:"url" binding:"required,url,max=2048"`
+	Name string `json:"name" binding:"omitempty,max=255"` // page title or host when empty
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1"`
+	Secret string   `json:"secret" binding:"omitempty,min=16"` // generated when empty
+}
+
+type UpdateWebhookRequest struct {
+	URL      *string  `json:"url" binding:"omitempty,url"`
+	Events   []string `json:"events" binding:"omitempty,min=1"`
+	IsActive *bool    `json:"is_active"`
+}
+
+// RetryWebhookDeadLettersRequest limits a dead-letter retry to one webhook
+type RetryWebhookDeadLettersRequest struct {
+	WebhookID *uuid.UUID `json:"webhook_id"`
+}
+
+// WebhookStats summarizes outbound webhooks for admins. The 24-hour figures
+// cover the day before the request.
+type WebhookStats struct {
+	TotalWebhooks          int64   `json:"total_webhooks"`
+	Active                 int64   `json:"active"`
+	Inactive               int64   `json:"inactive"`
+	PendingDelivery        int64   `json:"pending_delivery"`  // events queued or being dispatched
+	DeadLetterCount        int64   `json:"dead_letter_count"` // events that failed every retry
+	TotalDeliveriesLast24h int64   `json:"total_deliveries_last_24h"`
+	SuccessRatePct         float64 `json:"success_rate_pct"`
+}
+
+type CreatePromptTemplateRequest struct {
+	Name         string `json:"name" binding:"required,min=1,max=255"`
+	TemplateText string `json:"template_text" binding:"required,min=1,max=5000"`
+	IsPublic     bool   `json:"is_public"`
+}
+
+type UpdatePromptTemplateRequest struct {
+	Name         *string `json:"name" binding:"omitempty,min=1,max=255"`
+	TemplateText *string `json:"template_text" binding:"omitempty,min=1,max=5000"`
+	IsPublic     *bool   `json:"is_public"`
+}
+
+type RenderPromptTemplateRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// BulkProjectsRequest names up to 50 of the caller's projects; projects of
+// other users are skipped
+type BulkProjectsRequest struct {
+	ProjectIDs []uuid.UUID `json:"projectIds" binding:"required,min=1,max=50,unique"`
+}
+
+type BulkStatusRequest struct {
+	ProjectIDs []uuid.UUID `json:"projectIds" binding:"required,min=1,max=50,unique"`
+	Status     string      `json:"status" binding:"required,oneof=draft published archived"`
+}
+
+type CreateCollectionRequest struct {
+	Name        string  `json:"name" binding:"required,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	Color       string  `json:"color" binding:"omitempty,hexcolor"`
+	Icon        *string `json:"icon" binding:"omitempty,max=50"`
+	SortOrder   int     `json:"sort_order"`
+}
+
+type UpdateCollectionRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	Color       *string `json:"color" binding:"omitempty,hexcolor"`
+	Icon        *string `json:"icon" binding:"omitempty,max=50"`
+	SortOrder   *int    `json:"sort_order"`
+}
+
+// CollectionProjectsRequest lists projects to add to a collection, or all of
+// its projects in their new order when reordering
+type CollectionProjectsRequest struct {
+	ProjectIDs []uuid.UUID `json:"project_ids" binding:"required,min=1,max=500"`
+}
+
+type PromptTemplatesResponse struct {
+	Templates  []PromptTemplate    `json:"templates"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+type CreatePromptVariantRequest struct {
+	Name     string `json:"name" binding:"required,min=1,max=100"`
+	Prompt   string `json:"prompt" binding:"required,min=1,max=20000"`
+	Weight   int    `json:"weight" binding:"min=0,max=100"`
+	IsActive bool   `json:"is_active"`
+}
+
+type UpdatePromptVariantRequest struct {
+	Name     *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Prompt   *string `json:"prompt" binding:"omitempty,min=1,max=20000"`
+	Weight   *int    `json:"weight" binding:"omitempty,min=0,max=100"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// PromptVariantStats summarizes the satisfaction ratings of one variant's
+// generations. Ratings counts them by rating, "1" to "5".
+type PromptVariantStats struct {
+	Name       string           `json:"name"`
+	Samples    int              `json:"samples"`
+	MeanRating float64          `json:"mean_rating"`
+	WinRate    float64          `json:"win_rate"`
+	Ratings    map[string]int64 `json:"ratings"`
+}
+
+// PromptVariantAnalysis compares the rating distributions of the variants
+// with a chi-squared test of independence. Significant is set when the
+// p-value is below 0.05.
+type PromptVariantAnalysis struct {
+	Variants         []PromptVariantStats `json:"variants"`
+	ChiSquared       float64              `json:"chi_squared"`
+	DegreesOfFreedom int                  `json:"degrees_of_freedom"`
+	PValue           float64              `json:"p_value"`
+	Significant      bool                 `json:"significant"`
+	AnalysedAt       time.Time            `json:"analysed_at"`
+}
+
+type InviteCollaboratorRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=viewer editor"`
+}
+
 type UpdateProjectRequest struct {
 	Name        *string  `json:"name" binding:"omitempty,min=1,max=255"`
 	Description *string  `json:"description" binding:"omitempty,max=1000"`
@@ -168,12 +834,54 @@ type UpdateProjectRequest struct {
 	Status      *string  `json:"status" binding:"omitempty,oneof=draft published archived"`
 	Tags        []string `json:"tags" binding:"max=10"`
 	IsPublic    *bool    `json:"is_public"`
+
+	// Recorded on the version snapshot when code changes; defaults to manual_edit
+	ChangeSource string `json:"-"`
+	// The conversation turn an AI generation's code came from
+	ConversationID *uuid.UUID `json:"-"`
 }
 
 type GenerateRequest struct {
 	ProjectID           uuid.UUID           `json:"projectId" binding:"required"`
 	Message             string              `json:"message" binding:"required,min=1,max=5000"`
 	ConversationHistory []ConversationEntry `json:"conversationHistory" binding:"max=50"`
+	// Rendered with TemplateVariables and prepended to Message when set
+	PromptTemplateID  *uuid.UUID        `json:"promptTemplateId"`
+	TemplateVariables map[string]string `json:"templateVariables"`
+}
+
+// GenerateStreamQuery carries the Generate fields as query parameters for the
+// SSE endpoint. ConversationHistory is a JSON-encoded []ConversationEntry.
+type GenerateStreamQuery struct {
+	ProjectID           uuid.UUID `form:"projectId" binding:"required"`
+	Message             string    `form:"message" binding:"required,min=1,max=5000"`
+	ConversationHistory string    `form:"conversationHistory"`
+}
+
+type BranchConversationRequest struct {
+	FromIndex  *int   `json:"fromIndex" binding:"required,min=0"`
+	NewMessage string `json:"newMessage" binding:"required,min=1,max=5000"`
+}
+
+type RateConversationRequest struct {
+	Rating int `json:"rating" binding:"required,min=1,max=5"`
+}
+
+type ReplayConversationsRequest struct {
+	UpToConversationID *uuid.UUID `json:"up_to_conversation_id"`
+}
+
+type ModerationModeRequest struct {
+	Mode string `json:"mode" binding:"required,oneof=blocklist ai"`
+}
+
+type ChangePlanRequest struct {
+	Plan string `json:"plan" binding:"required,oneof=free pro premium"`
+}
+
+type AnonymousGenerateRequest struct {
+	Message     string `json:"message" binding:"required,min=1,max=5000"`
+	ProjectName string `json:"projectName" binding:"max=255"`
 }
 
 type ConversationEntry struct {
@@ -191,6 +899,54 @@ type TemplateRequest struct {
 	Category    string  `json:"category" binding:"required,oneof=portfolio landing blog ecommerce restaurant business personal dashboard documentation"`
 	Style       *string `json:"style" binding:"omitempty,oneof=modern minimalist creative corporate playful"`
 	ColorScheme *string `json:"colorScheme" binding:"omitempty,oneof=blue green purple red orange dark light"`
+	// Values interpolated into the template prompt, e.g. {"company_name": "Acme Corp"}
+	TemplateVariables map[string]string `json:"template_variables" binding:"omitempty,max=20,dive,max=200"`
+}
+
+// CreateTemplateRequest submits a template built from the current HTML of one
+// of the user's projects
+type CreateTemplateRequest struct {
+	ProjectID       uuid.UUID `json:"project_id" binding:"required"`
+	Name            string    `json:"name" binding:"required,min=1,max=255"`
+	Description     *string   `json:"description" binding:"omitempty,max=1000"`
+	Category        string    `json:"category" binding:"required,oneof=portfolio landing blog ecommerce restaurant business personal dashboard documentation"`
+	Tags            []string  `json:"tags" binding:"omitempty,max=10,dive,min=1,max=50"`
+	SubmitForReview bool      `json:"submit_for_review"`
+}
+
+type UpdateTemplateRequest struct {
+	ProjectID       *uuid.UUID `json:"project_id"` // re-copies the project's current HTML
+	Name            *string    `json:"name" binding:"omitempty,min=1,max=255"`
+	Description     *string    `json:"description" binding:"omitempty,max=1000"`
+	Category        *string    `json:"category" binding:"omitempty,oneof=portfolio landing blog ecommerce restaurant business personal dashboard documentation"`
+	Tags            []string   `json:"tags" binding:"omitempty,max=10,dive,min=1,max=50"`
+	SubmitForReview bool       `json:"submit_for_review"`
+}
+
+type CreateTemplateReviewRequest struct {
+	Rating  int     `json:"rating" binding:"required,min=1,max=5"`
+	Comment *string `json:"comment" binding:"omitempty,max=500"`
+}
+
+type TemplateReviewsResponse struct {
+	Reviews    []TemplateReview    `json:"reviews"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+type TemplatesResponse struct {
+	Templates  []Template          `json:"templates"`
+	Categories []string            `json:"categories"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+type RecolorRequest struct {
+	ProjectID  uuid.UUID      `json:"projectId" binding:"required"`
+	NewPalette []ColorMapping `json:"new_palette" binding:"required,min=1,max=20,dive"`
+}
+
+type ColorMapping struct {
+	From string `json:"from" binding:"required,hexcolor"`
+	To   string `json:"to" binding:"required,hexcolor"`
 }
 
 type BatchExportRequest struct {
@@ -206,19 +962,51 @@ type AuthResponse struct {
 	AccessToken  string    `json:"accessToken,omitempty"`
 	RefreshToken string    `json:"refreshToken,omitempty"`
 	ExpiresIn    string    `json:"expiresIn,omitempty"`
+	MFARequired  bool      `json:"mfa_required,omitempty"`
+	MFAToken     string    `json:"mfa_token,omitempty"` // exchanged at /auth/mfa/verify
+}
+
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"` // PNG data URL
 }
 
 type UserInfo struct {
-	ID               uuid.UUID    `json:"id"`
-	Email            string       `json:"email"`
-	Name             *string      `json:"name"`
-	AvatarURL        *string      `json:"avatarUrl"`
-	SubscriptionPlan string       `json:"subscriptionPlan"`
-	EmailVerified    bool         `json:"emailVerified"`
-	ProjectCount     int64        `json:"projectCount"`
-	APIUsageInfo     APIUsageInfo `json:"APIUsageInfo"`
-	CreatedAt        time.Time    `json:"createdAt"`
-	LastLoginAt      *time.Time   `json:"lastLoginAt"`
+	ID                       uuid.UUID    `json:"id"`
+	Email                    string       `json:"email"`
+	Name                     *string      `json:"name"`
+	AvatarURL                *string      `json:"avatarUrl"`
+	SubscriptionPlan         string       `json:"subscriptionPlan"`
+	EmailVerified            bool         `json:"emailVerified"`
+	Timezone                 string       `json:"timezone,omitempty"`
+	NotificationEmailEnabled *bool        `json:"notificationEmailEnabled,omitempty"`
+	ProjectCount             int64        `json:"projectCount"`
+	APIUsageInfo             APIUsageInfo `json:"APIUsageInfo"`
+	CreatedAt                time.Time    `json:"createdAt"`
+	LastLoginAt              *time.Time   `json:"lastLoginAt"`
+}
+
+// CreateWebhookResponse is the only response that includes the signing secret
+type CreateWebhookResponse struct {
+	Secret  string   `json:"secret"`
+	Webhook *Webhook `json:"webhook"`
+}
+
+// SessionInfo is a signed-in device as listed to its user. ID is the start of
+// the session's token ID.
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	LoginTime time.Time `json:"login_time"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"`
+}
+
+type CreateAPIKeyResponse struct {
+	Key    string      `json:"key"` // shown only once
+	APIKey *UserAPIKey `json:"api_key"`
 }
 
 type APIUsageInfo struct {
@@ -241,6 +1029,9 @@ type GenerationResult struct {
 	TokensUsed             int       `json:"tokensUsed"`
 	ResponseTime           int       `json:"responseTime"`
 	FromCache              bool      `json:"fromCache"`
+	ContextUtilization     float64   `json:"contextUtilization"`
+	Warnings               []string  `json:"warnings,omitempty"`
+	Partial                bool      `json:"partial,omitempty"` // HTML recovered from a generation cut off by a timeout
 	GeneratedAt            time.Time `json:"generatedAt"`
 }
 
@@ -249,12 +1040,15 @@ type ProjectBasicInfo struct {
 	Name string    `json:"name"`
 }
 
+// PaginationResponse describes either an offset page or, when NextCursor
+// pagination is used, a keyset page without page numbers or totals.
 type PaginationResponse struct {
-	CurrentPage int   `json:"currentPage"`
-	TotalPages  int   `json:"totalPages"`
-	TotalCount  int64 `json:"totalCount"`
-	HasNextPage bool  `json:"hasNextPage"`
-	HasPrevPage bool  `json:"hasPrevPage"`
+	CurrentPage int    `json:"currentPage,omitempty"`
+	TotalPages  *int   `json:"totalPages,omitempty"`
+	TotalCount  *int64 `json:"totalCount,omitempty"`
+	HasNextPage bool   `json:"hasNextPage"`
+	HasPrevPage bool   `json:"hasPrevPage"`
+	NextCursor  string `json:"next_cursor,omitempty"`
 }
 
 type ProjectsResponse struct {
@@ -263,15 +1057,384 @@ type ProjectsResponse struct {
 }
 
 type ProjectInfo struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Description  *string    `json:"description"`
+	Status       string     `json:"status"`
+	Tags         []string   `json:"tags"`
+	IsPublic     bool       `json:"is_public"`
+	ViewCount    int        `json:"view_count"`
+	LikeCount    int        `json:"like_count"`
+	ForkCount    int        `json:"fork_count"`
+	ForkedFromID *uuid.UUID `json:"forked_from_id"`
+	HasCode      bool       `json:"has_code"`
+	ThumbnailURL string     `json:"thumbnail_url"` // gradient placeholder until a thumbnail is rendered
+	// Only set on the caller's own projects
+	CollectionID   *uuid.UUID `json:"collection_id"`
+	CollectionName *string    `json:"collection_name"`
+	Role           string     `json:"role,omitempty"` // owner, editor or viewer in project listings
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// DiscoverProject is a public project as listed in the discover gallery. It
+// carries the creator's profile but no code.
+type DiscoverProject struct {
+	ID               uuid.UUID      `json:"id"`
+	Name             string         `json:"name"`
+	Description      *string        `json:"description"`
+	Tags             pq.StringArray `json:"tags"`
+	ThumbnailURL     *string        `json:"thumbnail_url"`
+	ViewCount        int            `json:"view_count"`
+	LikeCount        int            `json:"like_count"`
+	ForkCount        int            `json:"fork_count"`
+	IsFeatured       bool           `json:"is_featured"`
+	CreatorID        uuid.UUID      `json:"creator_id"`
+	CreatorName      *string        `json:"creator_name"`
+	CreatorAvatarURL *string        `json:"creator_avatar_url"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+type DiscoverResponse struct {
+	Projects   []DiscoverProject   `json:"projects"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+type SetFeaturedRequest struct {
+	Featured *bool `json:"featured" binding:"required"`
+}
+
+// ProjectSearchResult is a full-text search hit. Headlines wrap matched terms
+// in <b> tags; HTMLHeadline is a short excerpt of the page text.
+type ProjectSearchResult struct {
+	ID                  uuid.UUID `json:"id"`
+	Name                string    `json:"name"`
+	Description         *string   `json:"description"`
+	Status              string    `json:"status"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	Rank                float64   `json:"rank"`
+	NameHeadline        string    `json:"name_headline"`
+	DescriptionHeadline string    `json:"description_headline"`
+	HTMLHeadline        string    `json:"html_headline"`
+}
+
+// ConversationSearchResult is a conversation turn matching a search, without
+// its generated code
+type ConversationSearchResult struct {
 	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description"`
-	Status      string    `json:"status"`
-	Tags        []string  `json:"tags"`
-	IsPublic    bool      `json:"is_public"`
-	ViewCount   int       `json:"view_count"`
-	LikeCount   int       `json:"like_count"`
-	HasCode     bool      `json:"has_code"`
+	ProjectID   uuid.UUID `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	UserMessage string    `json:"user_message"`
+	AIResponse  string    `json:"ai_response"`
+	MessageType string    `json:"message_type"`
+	ModelUsed   *string   `json:"model_used"`
+	TokensUsed  int       `json:"tokens_used"`
+	Rank        float64   `json:"rank"`
 	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ConversationSearchResponse struct {
+	Conversations []ConversationSearchResult `json:"conversations"`
+	Pagination    *PaginationResponse        `json:"pagination"`
+}
+
+// ConversationExportEntry is one conversation turn in a conversation export.
+// Exports use camelCase keys for consumption outside the app.
+type ConversationExportEntry struct {
+	UserMessage        string    `json:"userMessage"`
+	AIResponse         string    `json:"aiResponse"`
+	GeneratedCode      *string   `json:"generatedCode"`
+	TokensUsed         int       `json:"tokensUsed"`
+	ModelUsed          *string   `json:"modelUsed"`
+	MessageType        string    `json:"messageType"`
+	SatisfactionRating *int      `json:"satisfactionRating"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+type ProjectConversationExport struct {
+	ProjectID     uuid.UUID                 `json:"projectId"`
+	ProjectName   string                    `json:"projectName"`
+	Conversations []ConversationExportEntry `json:"conversations"`
+}
+
+// ConversationExport is a download of conversation history. When the full
+// history would be too large, the first conversations are exported with
+// Truncated set; the rest can be fetched by passing Offset + Count as the
+// next offset.
+type ConversationExport struct {
+	ExportedAt time.Time                   `json:"exportedAt"`
+	TotalCount int64                       `json:"totalCount"`
+	Offset     int                         `json:"offset"`
+	Count      int                         `json:"count"`
+	Truncated  bool                        `json:"truncated"`
+	Projects   []ProjectConversationExport `json:"projects"`
+}
+
+type CollaboratorInfo struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Email      string     `json:"email"`
+	Name       *string    `json:"name"`
+	Role       string     `json:"role"`
+	InvitedBy  uuid.UUID  `json:"invited_by"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ProjectVersionInfo struct {
+	ID             uuid.UUID  `json:"id"`
+	VersionNumber  int        `json:"version_number"`
+	ConversationID *uuid.UUID `json:"conversation_id"`
+	SizeBytes      int        `json:"size_bytes"`
+	ChangedBy      uuid.UUID  `json:"changed_by"`
+	ChangeSource   string     `json:"change_source"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ProjectVersionsResponse struct {
+	Versions   []ProjectVersionInfo `json:"versions"`
+	Pagination *PaginationResponse  `json:"pagination"`
+}
+
+type NotificationListResponse struct {
+	Notifications []Notification      `json:"notifications"`
+	UnreadCount   int64               `json:"unread_count"`
+	Pagination    *PaginationResponse `json:"pagination"`
+}
+
+type ExportHistoryResponse struct {
+	Exports    []ExportRecord      `json:"exports"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+// AdminUserInfo is a user as listed to administrators
+type AdminUserInfo struct {
+	ID               uuid.UUID  `json:"id"`
+	Email            string     `json:"email"`
+	Name             *string    `json:"name"`
+	SubscriptionPlan string     `json:"subscription_plan"`
+	IsActive         bool       `json:"is_active"`
+	IsAdmin          bool       `json:"is_admin"`
+	IsGuest          bool       `json:"is_guest"`
+	EmailVerified    bool       `json:"email_verified"`
+	APIUsageCount    int        `json:"api_usage_count"`
+	APIUsageLimit    int        `json:"api_usage_limit"`
+	ProjectCount     int64      `json:"project_count"`
+	LastLoginAt      *time.Time `json:"last_login_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type AdminUsersResponse struct {
+	Users      []AdminUserInfo     `json:"users"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+// AdminUserDetail is everything administrators see about one user
+type AdminUserDetail struct {
+	AdminUserInfo
+	AvatarURL         *string       `json:"avatar_url"`
+	Timezone          string        `json:"timezone"`
+	PendingPlan       *string       `json:"pending_plan"`
+	PlanDowngradeAt   *time.Time    `json:"plan_downgrade_at"`
+	MFAEnabled        bool          `json:"mfa_enabled"`
+	OAuthProviders    []string      `json:"oauth_providers"`
+	ConversationCount int64         `json:"conversation_count"`
+	TokensUsed        int64         `json:"tokens_used"`
+	Projects          []ProjectInfo `json:"projects"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// AdminStats summarizes the whole system. The 24-hour figures cover the day
+// before the request.
+type AdminStats struct {
+	TotalUsers         int64   `json:"total_users"`
+	ActiveUsers        int64   `json:"active_users"`
+	TotalProjects      int64   `json:"total_projects"`
+	TotalConversations int64   `json:"total_conversations"`
+	AICallsLast24h     int64   `json:"ai_calls_last_24h"`
+	RequestsLast24h    int64   `json:"requests_last_24h"`
+	ErrorsLast24h      int64   `json:"errors_last_24h"`
+	ErrorRate          float64 `json:"error_rate"`
+}
+
+type JobsResponse struct {
+	Jobs       []Job               `json:"jobs"`
+	Counts     map[string]int64    `json:"counts"` // jobs per status
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+type ActivityHeatmapResponse struct {
+	Matrix      [7][24]int `json:"matrix"`
+	MaxValue    int        `json:"max_value"`
+	TotalEvents int        `json:"total_events"`
+}
+
+type UserAnalytics struct {
+	PeriodDays               int               `json:"period_days"`
+	TotalProjects            int64             `json:"total_projects"`
+	ProjectsByStatus         map[string]int64  `json:"projects_by_status"`
+	TotalViewsAcrossProjects int64             `json:"total_views_across_projects"`
+	TotalLikes               int64             `json:"total_likes"`
+	MostViewedProject        *AnalyticsProject `json:"most_viewed_project"`
+	MostGeneratedProject     *AnalyticsProject `json:"most_generated_project"`
+	TotalTokensUsed          int64             `json:"total_tokens_used"`
+	TotalAISpendUSD          float64           `json:"total_ai_spend_usd"`
+	AvgSatisfactionScore     *float64          `json:"avg_satisfaction_score"`
+	TimeSeries               []DailyViewCount  `json:"time_series"`
+}
+
+type AnalyticsProject struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int64     `json:"count"`
+}
+
+type DailyViewCount struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
+}
+
+// ProjectStats aggregates a project's usage. ChartData holds the daily views
+// of the last 30 days.
+type ProjectStats struct {
+	ProjectID              uuid.UUID        `json:"project_id"`
+	Views                  ProjectViewStats `json:"views"`
+	ExportsByFormat        map[string]int64 `json:"exports_by_format"`
+	ConversationsByType    map[string]int64 `json:"conversations_by_type"`
+	AvgTokensPerGeneration *float64         `json:"avg_tokens_per_generation"`
+	AvgSatisfactionRating  *float64         `json:"avg_satisfaction_rating"`
+	CodeSizeBytes          int64            `json:"code_size_bytes"`
+	VersionCount           int64            `json:"version_count"`
+	ChartData              []DailyViewCount `json:"chart_data"`
+	GeneratedAt            time.Time        `json:"generated_at"`
+}
+
+type ProjectViewStats struct {
+	Total      int64 `json:"total"`
+	Last7Days  int64 `json:"last_7_days"`
+	Last30Days int64 `json:"last_30_days"`
+}
+
+// DashboardSummary is the overview shown on a user's dashboard. Sections that
+// could not be loaded are left empty and named in Errors.
+type DashboardSummary struct {
+	ProjectsByStatus      map[string]int64       `json:"projects_by_status"`
+	TotalProjects         int64                  `json:"total_projects"`
+	GenerationsThisMonth  int64                  `json:"generations_this_month"`
+	TokensThisMonth       int64                  `json:"tokens_this_month"`
+	TokensAllTime         int64                  `json:"tokens_all_time"`
+	Usage                 *APIUsageInfo          `json:"usage"`
+	UsagePercent          float64                `json:"usage_percent"`
+	RecentProjects        []DashboardProject     `json:"recent_projects"`
+	WeeklyGenerations     []DailyGenerationCount `json:"weekly_generations"`
+	SubscriptionPlan      string                 `json:"subscription_plan"`
+	SubscriptionRenewalAt *time.Time             `json:"subscription_renewal_at"`
+	Partial               bool                   `json:"partial"`
+	Errors                []string               `json:"errors,omitempty"`
+	GeneratedAt           time.Time              `json:"generated_at"`
+}
+
+type DashboardProject struct {
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	Status            string    `json:"status"`
+	ThumbnailURL      *string   `json:"thumbnail_url"`
+	ViewCount         int64     `json:"view_count"`
+	LikeCount         int64     `json:"like_count"`
+	ConversationCount int64     `json:"conversation_count"`
+	VersionCount      int64     `json:"version_count"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type DailyGenerationCount struct {
+	Date        string `json:"date"`
+	Generations int64  `json:"generations"`
+}
+
+type ProjectComparison struct {
+	Projects   []ProjectInfo     `json:"projects"`
+	Comparison ComparisonMetrics `json:"comparison"`
+}
+
+// ComparisonMetrics reports the second project's values minus the first's
+type ComparisonMetrics struct {
+	WordCountDiff           int     `json:"word_count_diff"`
+	ImageCountDiff          int     `json:"image_count_diff"`
+	LinkCountDiff           int     `json:"link_count_diff"`
+	ColorPaletteOverlapPct  float64 `json:"color_palette_overlap_pct"`
+	SEOScoreDiff            int     `json:"seo_score_diff"`
+	AccessibilityIssuesDiff int     `json:"accessibility_issues_diff"`
+}
+
+// ActivityEvent is a project activity as shown in a feed, with the actor's
+// profile
+type ActivityEvent struct {
+	ID             uuid.UUID       `json:"id"`
+	ProjectID      uuid.UUID       `json:"project_id"`
+	ProjectName    string          `json:"project_name"`
+	ActivityType   string          `json:"activity_type"`
+	ActorID        uuid.UUID       `json:"actor_id"`
+	ActorName      *string         `json:"actor_name"`
+	ActorAvatarURL *string         `json:"actor_avatar_url"`
+	Source         *string         `json:"source,omitempty"`
+	Metadata       json.RawMessage `json:"metadata"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type ActivityFeedResponse struct {
+	Events     []ActivityEvent     `json:"events"`
+	Pagination *PaginationResponse `json:"pagination"`
+}
+
+type ConversationAnalytics struct {
+	PeriodDays           int                    `json:"period_days"`
+	TotalConversations   int64                  `json:"total_conversations"`
+	AvgTokensPerTurn     float64                `json:"avg_tokens_per_turn"`
+	AvgResponseTimeMS    float64                `json:"avg_response_time_ms"`
+	CacheHitRate         float64                `json:"cache_hit_rate"`
+	TokenEfficiency      float64                `json:"token_efficiency"` // generated HTML characters per token
+	MostExpensiveProject *ProjectTokenUsage     `json:"most_expensive_project"`
+	FastestAvgResponse   *ProjectResponseTime   `json:"fastest_avg_response"`
+	ByMessageType        []MessageTypeAnalytics `json:"by_message_type"`
+}
+
+// ModelStats summarizes satisfaction ratings of AI responses
+type ModelStats struct {
+	TotalConversations   int64             `json:"total_conversations"`
+	RatedConversations   int64             `json:"rated_conversations"`
+	UnratedConversations int64             `json:"unrated_conversations"`
+	AverageRating        *float64          `json:"average_rating"` // nil until something is rated
+	ByModel              []RatingBreakdown `json:"by_model"`
+	ByMessageType        []RatingBreakdown `json:"by_message_type"`
+}
+
+// RatingBreakdown holds the rating stats of one model or message type
+type RatingBreakdown struct {
+	Key               string   `json:"key"`
+	Conversations     int64    `json:"conversations"`
+	Rated             int64    `json:"rated"`
+	AverageRating     *float64 `json:"average_rating"`
+	TotalTokens       int64    `json:"total_tokens"`
+	AvgResponseTimeMS float64  `json:"avg_response_time_ms"`
+}
+
+type ProjectTokenUsage struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	TotalTokens int64     `json:"total_tokens"`
+}
+
+type ProjectResponseTime struct {
+	ID                uuid.UUID `json:"id"`
+	Name              string    `json:"name"`
+	AvgResponseTimeMS float64   `json:"avg_response_time_ms"`
+}
+
+type MessageTypeAnalytics struct {
+	MessageType       string  `json:"message_type"`
+	Conversations     int64   `json:"conversations"`
+	TotalTokens       int64   `json:"total_tokens"`
+	AvgTokensPerTurn  float64 `json:"avg_tokens_per_turn"`
+	AvgResponseTimeMS float64 `json:"avg_response_time_ms"`
+	CacheHitRate      float64 `json:"cache_hit_rate"`
 }
@@ -0,0 +1,76 @@
+// internal/redis/pubsub.go
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPubSub publishes JSON messages to channels and delivers those published
+// by any server instance to subscribers
+type RedisPubSub struct {
+	client *Client
+}
+
+func NewRedisPubSub(client *Client) *RedisPubSub {
+	return &RedisPubSub{client: client}
+}
+
+func (p *RedisPubSub) Publish(channel string, msg interface{}) error {
+	if p.client == nil || p.client.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return p.client.Client.Publish(p.client.Ctx, channel, data).Err()
+}
+
+// Subscribe calls handler with the payload of every message published to
+// channel, which may be a glob pattern such as "ws:user:*". Messages are
+// handled one at a time in the order received. go-redis reconnects and
+// resubscribes on its own if the connection drops; messages published in the
+// meantime are lost. The returned cancel function ends the subscription.
+func (p *RedisPubSub) Subscribe(channel string, handler func(msg []byte)) (func(), error) {
+	if p.client == nil || p.client.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var sub *redis.PubSub
+	if strings.ContainsAny(channel, "*?[") {
+		sub = p.client.Client.PSubscribe(ctx, channel)
+	} else {
+		sub = p.client.Client.Subscribe(ctx, channel)
+	}
+
+	// Wait for the confirmation so messages published after Subscribe
+	// returns are not missed
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		cancel()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+
+	return func() {
+		cancel()
+		sub.Close()
+		<-done
+	}, nil
+}
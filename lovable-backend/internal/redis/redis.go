@@ -4,13 +4,22 @@ package redis
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
 
 	"lovable-backend/internal/config"
+	"lovable-backend/internal/metrics"
+	"lovable-backend/internal/telemetry"
 )
 
 type Client struct {
@@ -28,6 +37,8 @@ func Connect(cfg config.RedisConfig) *Client {
 		PoolSize:     10,
 	})
 
+	rdb.AddHook(instrumentationHook{})
+
 	// Test connection
 	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
@@ -35,12 +46,27 @@ func Connect(cfg config.RedisConfig) *Client {
 		return nil
 	}
 
+	// Rate limit checks call the script by hash
+	if err := leakyBucketScript.Load(ctx, rdb).Err(); err != nil {
+		fmt.Printf("Failed to load leaky bucket script: %v\n", err)
+	}
+
 	return &Client{
 		Client: rdb,
 		Ctx:    ctx, // Use uppercase Ctx
 	}
 }
 
+// WithContext returns a client whose commands carry ctx's trace, so they show
+// up as spans of the caller. Cancellation of ctx is ignored to keep the
+// existing fire-and-forget semantics of writes.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		Client: c.Client,
+		Ctx:    context.WithoutCancel(ctx),
+	}
+}
+
 func (c *Client) Set(key string, value interface{}, ttl time.Duration) error {
 	if c.Client == nil {
 		return fmt.Errorf("redis client not available")
@@ -54,6 +80,21 @@ func (c *Client) Set(key string, value interface{}, ttl time.Duration) error {
 	return c.Client.Set(c.Ctx, key, data, ttl).Err()
 }
 
+// SetNX stores value as Set does, unless key already exists. It reports
+// whether the value was stored.
+func (c *Client) SetNX(key string, value interface{}, ttl time.Duration) (bool, error) {
+	if c.Client == nil {
+		return false, fmt.Errorf("redis client not available")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	return c.Client.SetNX(c.Ctx, key, data, ttl).Result()
+}
+
 func (c *Client) Get(key string, dest interface{}) error {
 	if c.Client == nil {
 		return fmt.Errorf("redis client not available")
@@ -108,6 +149,307 @@ func (c *Client) SetTTL(key string, ttl time.Duration) error {
 	return c.Client.Expire(c.Ctx, key, ttl).Err()
 }
 
+// HSet stores value as JSON in one field of a hash
+func (c *Client) HSet(key, field string, value interface{}) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.Client.HSet(c.Ctx, key, field, data).Err()
+}
+
+func (c *Client) HGetAll(key string) (map[string]string, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.HGetAll(c.Ctx, key).Result()
+}
+
+func (c *Client) HDel(key string, fields ...string) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.HDel(c.Ctx, key, fields...).Err()
+}
+
+func (c *Client) ZAdd(key string, score float64, member string) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.ZAdd(c.Ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+func (c *Client) ZScore(key, member string) (float64, error) {
+	if c.Client == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.ZScore(c.Ctx, key, member).Result()
+}
+
+func (c *Client) ZCard(key string) (int64, error) {
+	if c.Client == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.ZCard(c.Ctx, key).Result()
+}
+
+// ZRemRangeByScore removes sorted set members with scores between min and max
+func (c *Client) ZRemRangeByScore(key, min, max string) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.ZRemRangeByScore(c.Ctx, key, min, max).Err()
+}
+
+func (c *Client) RPush(key string, values ...interface{}) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.RPush(c.Ctx, key, values...).Err()
+}
+
+// LDrain atomically returns all elements of a list and deletes it
+func (c *Client) LDrain(key string) ([]string, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	var items *redis.StringSliceCmd
+	_, err := c.Client.TxPipelined(c.Ctx, func(pipe redis.Pipeliner) error {
+		items = pipe.LRange(c.Ctx, key, 0, -1)
+		pipe.Del(c.Ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items.Val(), nil
+}
+
+// LRange returns the elements of a list from start to stop, inclusive
+func (c *Client) LRange(key string, start, stop int64) ([]string, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.LRange(c.Ctx, key, start, stop).Result()
+}
+
+// LRem removes up to count occurrences of value from a list and returns how
+// many were removed
+func (c *Client) LRem(key string, count int64, value interface{}) (int64, error) {
+	if c.Client == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.LRem(c.Ctx, key, count, value).Result()
+}
+
+func (c *Client) LLen(key string) (int64, error) {
+	if c.Client == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.LLen(c.Ctx, key).Result()
+}
+
+func (c *Client) SAdd(key string, members ...interface{}) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.SAdd(c.Ctx, key, members...).Err()
+}
+
+// SPopAll atomically returns all members of a set and deletes it
+func (c *Client) SPopAll(key string) ([]string, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	var members *redis.StringSliceCmd
+	_, err := c.Client.TxPipelined(c.Ctx, func(pipe redis.Pipeliner) error {
+		members = pipe.SMembers(c.Ctx, key)
+		pipe.Del(c.Ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return members.Val(), nil
+}
+
+func (c *Client) XAdd(stream string, values map[string]interface{}) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.XAdd(c.Ctx, &redis.XAddArgs{Stream: stream, Values: values}).Err()
+}
+
+// XLen returns the number of entries in a stream, 0 if it doesn't exist
+func (c *Client) XLen(stream string) (int64, error) {
+	if c.Client == nil {
+		return 0, fmt.Errorf("redis client not available")
+	}
+
+	return c.Client.XLen(c.Ctx, stream).Result()
+}
+
+// XGroupCreate creates a consumer group on the stream, creating the stream if
+// needed. An already existing group is not an error.
+func (c *Client) XGroupCreate(stream, group string) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	err := c.Client.XGroupCreateMkStream(c.Ctx, stream, group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// XReadGroup reads up to count new entries for the consumer, blocking for at
+// most block. It returns no entries and no error when the block times out.
+func (c *Client) XReadGroup(ctx context.Context, stream, group, consumer string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	streams, err := c.Client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []redis.XMessage
+	for _, s := range streams {
+		messages = append(messages, s.Messages...)
+	}
+	return messages, nil
+}
+
+// XAutoClaim takes over up to count entries that have been pending in the
+// group for at least minIdle, such as entries whose processing failed or
+// whose consumer died, and returns them for the consumer to process again
+func (c *Client) XAutoClaim(ctx context.Context, stream, group, consumer string, minIdle time.Duration, count int64) ([]redis.XMessage, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	messages, _, err := c.Client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return messages, err
+}
+
+// XDeliveryCounts returns how many times each of the given pending entries has
+// been delivered to the group's consumers. Entries no longer pending are left
+// out.
+func (c *Client) XDeliveryCounts(ctx context.Context, stream, group string, ids ...string) (map[string]int64, error) {
+	if c.Client == nil {
+		return nil, fmt.Errorf("redis client not available")
+	}
+
+	counts := make(map[string]int64, len(ids))
+	for _, id := range ids {
+		pending, err := c.Client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  group,
+			Start:  id,
+			End:    id,
+			Count:  1,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range pending {
+			counts[entry.ID] = entry.RetryCount
+		}
+	}
+	return counts, nil
+}
+
+// XAckDel acknowledges processed entries and removes them from the stream
+func (c *Client) XAckDel(stream, group string, ids ...string) error {
+	if c.Client == nil {
+		return fmt.Errorf("redis client not available")
+	}
+
+	if err := c.Client.XAck(c.Ctx, stream, group, ids...).Err(); err != nil {
+		return err
+	}
+	return c.Client.XDel(c.Ctx, stream, ids...).Err()
+}
+
+// Deletes a lock only while it still holds the caller's token, so a lock that
+// expired and was taken by someone else is left alone
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lock takes key with SET NX PX for at most ttl. The returned unlock function
+// releases it if it is still held by this caller. Without Redis, or when
+// Redis fails, the lock is reported as acquired so callers keep working.
+func (c *Client) Lock(key string, ttl time.Duration) (func(), bool, error) {
+	unlock := func() {}
+	if c == nil || c.Client == nil {
+		return unlock, true, nil
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return unlock, true, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	acquired, err := c.Client.SetNX(c.Ctx, key, token, ttl).Result()
+	if err != nil {
+		return unlock, true, err
+	}
+	if !acquired {
+		return unlock, false, nil
+	}
+
+	return func() {
+		unlockScript.Run(c.Ctx, c.Client, []string{key}, token)
+	}, true, nil
+}
+
 func (c *Client) CheckRateLimit(key string, limit int64, window time.Duration) (bool, int64, time.Time, error) {
 	if c.Client == nil {
 		return true, 0, time.Time{}, nil // Allow if Redis unavailable
@@ -128,6 +470,132 @@ func (c *Client) CheckRateLimit(key string, limit int64, window time.Duration) (
 	return count <= limit, limit - count, resetTime, nil
 }
 
+// Meters a leaky bucket stored as a hash of its fill level ("tokens") and the
+// time it was last updated. The level drains at ARGV[1] requests per second;
+// a request is admitted if it fits under the burst ceiling ARGV[2]. ARGV[3] is
+// the current time in milliseconds. Returns {allowed, remaining}.
+var leakyBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last_call_time")
+local level = tonumber(state[1]) or 0
+local last = tonumber(state[2]) or now
+
+level = math.max(0, level - math.max(0, now - last) / 1000 * rate)
+
+local allowed = 0
+if level + 1 <= burst then
+	level = level + 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(level), "last_call_time", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(level / rate * 1000) + 1000)
+
+return {allowed, math.floor(burst - level)}
+`)
+
+// CheckLeakyBucket admits a request to the bucket at key, which drains at
+// rate requests per second and holds at most burst. Unlike CheckRateLimit it
+// smooths traffic instead of allowing a full window's worth at once.
+func (c *Client) CheckLeakyBucket(key string, rate float64, burst int64) (bool, int64, error) {
+	if c.Client == nil {
+		return true, 0, nil // Allow if Redis unavailable
+	}
+
+	args := []interface{}{rate, burst, time.Now().UnixMilli()}
+	result, err := leakyBucketScript.EvalSha(c.Ctx, c.Client, []string{key}, args...).Int64Slice()
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		// Redis restarted or its script cache was flushed
+		if err := leakyBucketScript.Load(c.Ctx, c.Client).Err(); err != nil {
+			return true, 0, err
+		}
+		result, err = leakyBucketScript.EvalSha(c.Ctx, c.Client, []string{key}, args...).Int64Slice()
+	}
+	if err != nil {
+		return true, 0, err
+	}
+
+	return result[0] == 1, result[1], nil
+}
+
+// LeakyBucketLevel returns how full the bucket at key is now, without
+// admitting a request
+func (c *Client) LeakyBucketLevel(key string, rate float64) (int64, error) {
+	if c.Client == nil {
+		return 0, nil
+	}
+
+	state, err := c.Client.HMGet(c.Ctx, key, "tokens", "last_call_time").Result()
+	if err != nil {
+		return 0, err
+	}
+
+	level, _ := strconv.ParseFloat(fmt.Sprint(state[0]), 64)
+	last, err := strconv.ParseInt(fmt.Sprint(state[1]), 10, 64)
+	if err != nil {
+		return 0, nil
+	}
+
+	level -= float64(time.Now().UnixMilli()-last) / 1000 * rate
+	if level < 0 {
+		return 0, nil
+	}
+	return int64(math.Ceil(level)), nil
+}
+
+// instrumentationHook times every command and traces it as a span, including
+// commands issued directly on the underlying client
+type instrumentationHook struct{}
+
+func (instrumentationHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (instrumentationHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := startSpan(ctx, "redis."+cmd.Name())
+		defer span.End()
+
+		start := time.Now()
+		err := next(ctx, cmd)
+		metrics.RedisOperationDuration.WithLabelValues(cmd.Name()).Observe(time.Since(start).Seconds())
+		if err != nil && err != redis.Nil {
+			telemetry.RecordError(span, err)
+		}
+		return err
+	}
+}
+
+func (instrumentationHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := startSpan(ctx, "redis.pipeline")
+		defer span.End()
+
+		start := time.Now()
+		err := next(ctx, cmds)
+		metrics.RedisOperationDuration.WithLabelValues("pipeline").Observe(time.Since(start).Seconds())
+		if err != nil && err != redis.Nil {
+			telemetry.RecordError(span, err)
+		}
+		return err
+	}
+}
+
+// startSpan only traces commands issued within an existing trace. Commands on
+// the client's background context would otherwise each start a trace of their
+// own.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return telemetry.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+}
+
 func (c *Client) Close() error {
 	if c.Client != nil {
 		return c.Client.Close()
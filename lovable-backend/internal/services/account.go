@@ -0,0 +1,281 @@
+// internal/services/account.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/storage"
+	"lovable-backend/pkg/logger"
+)
+
+// Accounts with at least this many projects are exported in the background
+const dataExportSyncLimit = 100
+
+// Typed back by the user to confirm account deletion
+const deleteAccountConfirmation = "DELETE MY ACCOUNT"
+
+// AccountService exports and deletes everything stored about a user
+type AccountService struct {
+	db            *gorm.DB // primary
+	dbRouter      *database.DBRouter
+	storage       storage.StorageBackend
+	authService   *AuthService
+	notifications *NotificationService
+	logger        *logger.Logger
+}
+
+func NewAccountService(dbRouter *database.DBRouter, storageBackend storage.StorageBackend, authService *AuthService, notifications *NotificationService, logger *logger.Logger) *AccountService {
+	return &AccountService{
+		db:            dbRouter.Writer(),
+		dbRouter:      dbRouter,
+		storage:       storageBackend,
+		authService:   authService,
+		notifications: notifications,
+		logger:        logger,
+	}
+}
+
+// ExportUserData builds a ZIP of the user's data. Small accounts get the
+// archive back directly; larger ones get a job that builds it in the
+// background, and the returned data is nil.
+func (s *AccountService) ExportUserData(ctx context.Context, userID uuid.UUID) ([]byte, string, *models.AsyncJob, error) {
+	var projectCount int64
+	if err := s.dbRouter.Reader().Model(&models.Project{}).Where("user_id = ?", userID).Count(&projectCount).Error; err != nil {
+		return nil, "", nil, err
+	}
+
+	if projectCount < dataExportSyncLimit {
+		data, filename, err := s.buildDataExport(userID)
+		return data, filename, nil, err
+	}
+
+	// Only the latest export is kept
+	s.db.Where("user_id = ? AND type = ?", userID, "data_export").Delete(&models.AsyncJob{})
+
+	job := models.AsyncJob{
+		UserID: userID,
+		Type:   "data_export",
+		Status: "running",
+	}
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, "", nil, err
+	}
+
+	go s.runDataExport(job.ID, userID)
+
+	return nil, "", &job, nil
+}
+
+// GetDataExportJob returns one of the user's export jobs, including the
+// archive once it has completed
+func (s *AccountService) GetDataExportJob(ctx context.Context, userID, jobID uuid.UUID) (*models.AsyncJob, error) {
+	var job models.AsyncJob
+	if err := s.db.Where("id = ? AND user_id = ? AND type = ?", jobID, userID, "data_export").First(&job).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *AccountService) runDataExport(jobID, userID uuid.UUID) {
+	updates := map[string]interface{}{"completed_at": time.Now()}
+
+	data, filename, err := s.buildDataExport(userID)
+	if err != nil {
+		s.logger.Error("Data export failed", "userId", userID, "jobId", jobID, "error", err)
+		updates["status"] = "failed"
+		updates["error"] = err.Error()
+	} else {
+		updates["status"] = "completed"
+		updates["file_name"] = filename
+		updates["result"] = data
+	}
+
+	if err := s.db.Model(&models.AsyncJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		s.logger.Error("Failed to store data export", "userId", userID, "jobId", jobID, "error", err)
+		return
+	}
+	if updates["status"] != "completed" {
+		return
+	}
+
+	if err := s.notifications.Send(userID, NotificationExportReady,
+		"Your data export is ready",
+		"The export of your account data has finished and can now be downloaded.",
+		map[string]any{"job_id": jobID}); err != nil {
+		s.logger.Error("Failed to send export notification", "userId", userID, "jobId", jobID, "error", err)
+	}
+}
+
+// buildDataExport writes the user's account, projects with their full code,
+// conversations, sessions and API usage to one JSON file each
+func (s *AccountService) buildDataExport(userID uuid.UUID) ([]byte, string, error) {
+	db := s.dbRouter.Reader()
+
+	var user models.User
+	if err := db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, "", err
+	}
+
+	var projects []models.Project
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&projects).Error; err != nil {
+		return nil, "", err
+	}
+	for i := range projects {
+		if err := storage.LoadProjectHTML(s.storage, &projects[i]); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var conversations []models.Conversation
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&conversations).Error; err != nil {
+		return nil, "", err
+	}
+
+	var sessions []models.UserSession
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error; err != nil {
+		return nil, "", err
+	}
+
+	var usage []models.APIUsage
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&usage).Error; err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"user.json", user},
+		{"projects.json", projects},
+		{"conversations.json", conversations},
+		{"sessions.json", sessions},
+		{"api_usage.json", usage},
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		if err := addZIPFile(writer, file.name, string(content), now); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), fmt.Sprintf("account-data-%d.zip", now.Unix()), nil
+}
+
+// DeleteAccount permanently deletes the user and everything they own once the
+// password and confirmation phrase check out.
+func (s *AccountService) DeleteAccount(ctx context.Context, userID uuid.UUID, req *models.DeleteAccountRequest) error {
+	if req.Confirmation != deleteAccountConfirmation {
+		return errors.New("confirmation phrase does not match")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return errors.New("password is incorrect")
+	}
+
+	return s.PurgeUser(ctx, userID)
+}
+
+// PurgeUser hard-deletes the user, their projects and every row referencing
+// either, then removes stored project HTML. Tokens are revoked first so no
+// request can act on the account while it is being removed.
+func (s *AccountService) PurgeUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.authService.RevokeUserTokens(userID); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+	s.authService.DeleteSession(userID)
+
+	var htmlKeys []string
+	s.db.Unscoped().Model(&models.Project{}).Where("user_id = ? AND html_code_key IS NOT NULL", userID).Pluck("html_code_key", &htmlKeys)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		owned := tx.Unscoped().Model(&models.Project{}).Select("id").Where("user_id = ?", userID)
+
+		steps := []struct {
+			model interface{}
+			query string
+			args  []interface{}
+		}{
+			{&models.Conversation{}, "user_id = ? OR project_id IN (?)", []interface{}{userID, owned}},
+			{&models.APIUsage{}, "user_id = ?", []interface{}{userID}},
+			{&models.UserSession{}, "user_id = ?", []interface{}{userID}},
+
+			// Rows that reference the user's projects
+			{&models.ProjectVersion{}, "project_id IN (?)", []interface{}{owned}},
+			{&models.ProjectCollaborator{}, "user_id = ? OR project_id IN (?)", []interface{}{userID, owned}},
+			{&models.ProjectView{}, "project_id IN (?)", []interface{}{owned}},
+			{&models.ProjectActivity{}, "user_id = ? OR project_id IN (?)", []interface{}{userID, owned}},
+			{&models.AIJob{}, "user_id = ? OR project_id IN (?)", []interface{}{userID, owned}},
+			{&models.ReplayJob{}, "user_id = ? OR project_id IN (?) OR source_project_id IN (?)", []interface{}{userID, owned, owned}},
+
+			{&models.Project{}, "user_id = ?", []interface{}{userID}},
+
+			// Rows that reference the user
+			{&models.WebhookDelivery{}, "webhook_id IN (SELECT id FROM webhooks WHERE user_id = ?)", []interface{}{userID}},
+			{&models.Webhook{}, "user_id = ?", []interface{}{userID}},
+			{&models.AuditLog{}, "user_id = ?", []interface{}{userID}},
+			{&models.BatchImportJob{}, "user_id = ?", []interface{}{userID}},
+			{&models.AsyncJob{}, "user_id = ?", []interface{}{userID}},
+			{&models.ModerationEvent{}, "user_id = ?", []interface{}{userID}},
+			{&models.VerificationToken{}, "user_id = ?", []interface{}{userID}},
+			{&models.PasswordResetToken{}, "user_id = ?", []interface{}{userID}},
+			{&models.OAuthProvider{}, "user_id = ?", []interface{}{userID}},
+			{&models.UserAPIKey{}, "user_id = ?", []interface{}{userID}},
+			{&models.UserMFA{}, "user_id = ?", []interface{}{userID}},
+			{&models.UserPreference{}, "user_id = ?", []interface{}{userID}},
+			{&models.PromptTemplate{}, "user_id = ?", []interface{}{userID}},
+			{&models.ExportRecord{}, "user_id = ?", []interface{}{userID}},
+		}
+
+		for _, step := range steps {
+			if err := tx.Unscoped().Where(step.query, step.args...).Delete(step.model).Error; err != nil {
+				return err
+			}
+		}
+
+		// Shared templates outlive their author
+		if err := tx.Unscoped().Model(&models.Template{}).Where("created_by = ?", userID).Update("created_by", nil).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&models.User{}, "id = ?", userID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range htmlKeys {
+		if err := s.storage.Delete(key); err != nil {
+			s.logger.Warn("Failed to delete project HTML of deleted account", "key", key, "error", err)
+		}
+	}
+
+	return nil
+}
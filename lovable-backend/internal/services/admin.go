@@ -0,0 +1,313 @@
+// internal/services/admin.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+// AdminService lets administrators inspect and manage user accounts. Every
+// change is recorded in the admin audit log.
+type AdminService struct {
+	db             *gorm.DB // primary
+	dbRouter       *database.DBRouter
+	authService    *AuthService
+	accountService *AccountService
+	auditService   *AuditService
+	logger         *logger.Logger
+}
+
+func NewAdminService(dbRouter *database.DBRouter, authService *AuthService, accountService *AccountService, auditService *AuditService, logger *logger.Logger) *AdminService {
+	return &AdminService{
+		db:             dbRouter.Writer(),
+		dbRouter:       dbRouter,
+		authService:    authService,
+		accountService: accountService,
+		auditService:   auditService,
+		logger:         logger,
+	}
+}
+
+const adminUserColumns = `u.id, u.email, u.name, u.subscription_plan, u.is_active, u.is_admin, u.is_guest,
+	u.email_verified, u.api_usage_count, u.api_usage_limit, u.last_login_at, u.created_at,
+	(SELECT COUNT(*) FROM projects p WHERE p.user_id = u.id AND p.deleted_at IS NULL) AS project_count`
+
+// ListUsers returns a page of users, newest first, optionally limited to
+// those whose email or name contains the search term
+func (s *AdminService) ListUsers(ctx context.Context, search string, page, limit int) (*models.AdminUsersResponse, error) {
+	db := s.dbRouter.Reader().Table("users AS u").Where("u.deleted_at IS NULL")
+	if search != "" {
+		pattern := "%" + search + "%"
+		db = db.Where("u.email ILIKE ? OR u.name ILIKE ?", pattern, pattern)
+	}
+
+	var totalCount int64
+	if err := db.Session(&gorm.Session{}).Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	users := []models.AdminUserInfo{}
+	if err := db.Session(&gorm.Session{}).
+		Select(adminUserColumns).
+		Order("u.created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&users).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	return &models.AdminUsersResponse{
+		Users: users,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: page < totalPages,
+			HasPrevPage: page > 1,
+		},
+	}, nil
+}
+
+// GetUser returns a user's account, sign-in methods, usage and projects
+func (s *AdminService) GetUser(ctx context.Context, userID uuid.UUID) (*models.AdminUserDetail, error) {
+	reader := s.dbRouter.Reader()
+
+	var user models.User
+	if err := reader.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	detail := models.AdminUserDetail{
+		AdminUserInfo: models.AdminUserInfo{
+			ID:               user.ID,
+			Email:            user.Email,
+			Name:             user.Name,
+			SubscriptionPlan: user.SubscriptionPlan,
+			IsActive:         user.IsActive,
+			IsAdmin:          user.IsAdmin,
+			IsGuest:          user.IsGuest,
+			EmailVerified:    user.EmailVerified,
+			APIUsageCount:    user.APIUsageCount,
+			APIUsageLimit:    user.APIUsageLimit,
+			LastLoginAt:      user.LastLoginAt,
+			CreatedAt:        user.CreatedAt,
+		},
+		AvatarURL:       user.AvatarURL,
+		Timezone:        user.Timezone,
+		PendingPlan:     user.PendingPlan,
+		PlanDowngradeAt: user.PlanDowngradeAt,
+		OAuthProviders:  []string{},
+		UpdatedAt:       user.UpdatedAt,
+	}
+
+	var projects []models.Project
+	if err := reader.Where("user_id = ?", userID).Order("created_at DESC").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	detail.ProjectCount = int64(len(projects))
+	detail.Projects = make([]models.ProjectInfo, len(projects))
+	for i := range projects {
+		detail.Projects[i] = toProjectInfo(&projects[i])
+	}
+
+	var usage struct {
+		Conversations int64
+		Tokens        int64
+	}
+	if err := reader.Model(&models.Conversation{}).
+		Select("COUNT(*) AS conversations, COALESCE(SUM(tokens_used), 0) AS tokens").
+		Where("user_id = ?", userID).
+		Scan(&usage).Error; err != nil {
+		return nil, err
+	}
+	detail.ConversationCount = usage.Conversations
+	detail.TokensUsed = usage.Tokens
+
+	var mfaCount int64
+	reader.Model(&models.UserMFA{}).Where("user_id = ? AND enabled_at IS NOT NULL", userID).Count(&mfaCount)
+	detail.MFAEnabled = mfaCount > 0
+
+	reader.Model(&models.OAuthProvider{}).Where("user_id = ?", userID).Order("provider").Pluck("provider", &detail.OAuthProviders)
+
+	return &detail, nil
+}
+
+// SetUserActive disables or re-enables an account. Disabling signs the user
+// out everywhere.
+func (s *AdminService) SetUserActive(ctx context.Context, adminID, userID uuid.UUID, active bool) error {
+	if adminID == userID {
+		return errors.New("cannot modify your own account")
+	}
+
+	result := s.db.Model(&models.User{}).Where("id = ?", userID).Update("is_active", active)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	action := "user_enabled"
+	if !active {
+		action = "user_disabled"
+		if err := s.authService.RevokeUserTokens(userID); err != nil {
+			s.logger.Error("Failed to revoke tokens of disabled user", "userId", userID, "error", err)
+		}
+		s.authService.DeleteSession(userID)
+	}
+
+	s.recordAction(adminID, action, userID, nil)
+
+	return nil
+}
+
+// ChangePlan switches the user to another plan immediately, cancelling any
+// scheduled downgrade
+func (s *AdminService) ChangePlan(ctx context.Context, adminID, userID uuid.UUID, plan string) error {
+	if _, ok := projectLimits[plan]; !ok {
+		return errors.New("invalid plan")
+	}
+
+	var user models.User
+	if err := s.db.Select("id", "subscription_plan").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"subscription_plan": plan,
+		"pending_plan":      nil,
+		"plan_downgrade_at": nil,
+		"downgrade_warned":  false,
+	}).Error; err != nil {
+		return err
+	}
+
+	s.recordAction(adminID, "plan_changed", userID, map[string]interface{}{
+		"old_plan": user.SubscriptionPlan,
+		"new_plan": plan,
+	})
+
+	return nil
+}
+
+// DeleteUser permanently removes the user and everything they own
+func (s *AdminService) DeleteUser(ctx context.Context, adminID, userID uuid.UUID) error {
+	if adminID == userID {
+		return errors.New("cannot modify your own account")
+	}
+
+	var user models.User
+	if err := s.db.Unscoped().Select("id", "email").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	if err := s.accountService.PurgeUser(ctx, userID); err != nil {
+		return err
+	}
+
+	s.recordAction(adminID, "user_deleted", userID, map[string]interface{}{
+		"email": user.Email,
+	})
+
+	return nil
+}
+
+// GetStats returns system-wide totals and the last day's AI calls and API
+// error rate
+func (s *AdminService) GetStats(ctx context.Context) (*models.AdminStats, error) {
+	reader := s.dbRouter.Reader()
+	since := time.Now().Add(-24 * time.Hour)
+
+	var stats models.AdminStats
+	if err := reader.Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL) AS total_users,
+			(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL AND is_active = true) AS active_users,
+			(SELECT COUNT(*) FROM projects WHERE deleted_at IS NULL) AS total_projects,
+			(SELECT COUNT(*) FROM conversations) AS total_conversations,
+			(SELECT COUNT(*) FROM conversations WHERE created_at > @since) AS ai_calls_last24h,
+			(SELECT COUNT(*) FROM api_usage WHERE created_at > @since) AS requests_last24h,
+			(SELECT COUNT(*) FROM api_usage WHERE created_at > @since AND status_code >= 500) AS errors_last24h`,
+		map[string]interface{}{"since": since},
+	).Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	if stats.RequestsLast24h > 0 {
+		stats.ErrorRate = float64(stats.ErrorsLast24h) / float64(stats.RequestsLast24h)
+	}
+
+	return &stats, nil
+}
+
+// ListJobs returns a page of background jobs, newest first, optionally
+// limited to one status, with the number of jobs in each status
+func (s *AdminService) ListJobs(ctx context.Context, status string, page, limit int) (*models.JobsResponse, error) {
+	reader := s.dbRouter.Reader()
+
+	var statusCounts []struct {
+		Status string
+		Count  int64
+	}
+	if err := reader.Model(&models.Job{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&statusCounts).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(statusCounts))
+	for _, sc := range statusCounts {
+		counts[sc.Status] = sc.Count
+	}
+
+	db := reader.Model(&models.Job{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var totalCount int64
+	if err := db.Session(&gorm.Session{}).Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	jobs := []models.Job{}
+	if err := db.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	return &models.JobsResponse{
+		Jobs:   jobs,
+		Counts: counts,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: page < totalPages,
+			HasPrevPage: page > 1,
+		},
+	}, nil
+}
+
+func (s *AdminService) recordAction(adminID uuid.UUID, action string, targetID uuid.UUID, details map[string]interface{}) {
+	if err := s.auditService.RecordAdminAction(adminID, action, &targetID, details); err != nil {
+		s.logger.Error("Failed to record admin action", "adminId", adminID, "action", action, "targetId", targetID, "error", err)
+	}
+}
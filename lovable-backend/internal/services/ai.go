@@ -2,54 +2,62 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"lovable-backend/internal/config"
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/metrics"
 	"lovable-backend/internal/models"
 	"lovable-backend/internal/redis"
+	"lovable-backend/internal/services/aiprovider"
+	"lovable-backend/internal/telemetry"
 	"lovable-backend/pkg/logger"
 )
 
 type AIService struct {
-	config      config.AIConfig
-	redisClient *redis.Client
-	httpClient  *http.Client
-	logger      *logger.Logger
+	configStore    *config.ConfigStore
+	redisClient    *redis.Client
+	dbRouter       *database.DBRouter
+	fallbackChain  []fallbackStep
+	contentFilters []ContentFilter
+	claudeBreaker  *CircuitBreaker
+	logger         *logger.Logger
 }
 
-type ClaudeRequest struct {
-	Model     string    `json:"model"`
-	MaxTokens int       `json:"max_tokens"`
-	Messages  []Message `json:"messages"`
+// fallbackStep is a configured model of the fallback chain and the provider
+// that serves it
+type fallbackStep struct {
+	provider aiprovider.Provider
+	model    config.FallbackModel
 }
 
-type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
+// ModelUsed of generations answered with the static template
+const fallbackTemplateModel = "fallback-template"
 
-type ClaudeResponse struct {
-	Content []ContentBlock `json:"content"`
-	Usage   Usage          `json:"usage"`
-}
+// Claude circuit breaker: consecutive failures that open it, how long it
+// stays open, and half-open successes that close it again
+const (
+	claudeBreakerMaxFailures      = 5
+	claudeBreakerTimeout          = 60 * time.Second
+	claudeBreakerSuccessThreshold = 2
+)
 
-type ContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
-}
+type Message = aiprovider.Message
 
-type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
-}
+type ContentBlock = aiprovider.ContentBlock
 
 type GenerationResult struct {
 	ConversationalResponse string `json:"conversational_response"`
@@ -57,37 +65,172 @@ type GenerationResult struct {
 	TokensUsed             int    `json:"tokens_used"`
 	ResponseTime           int64  `json:"response_time"`
 	FromCache              bool   `json:"from_cache"`
+	// Model that produced the result, after any fallbacks
+	ModelUsed string `json:"model_used"`
+	// System prompt variant used, empty for the default prompt
+	PromptVariant string `json:"prompt_variant,omitempty"`
+	// Estimated share of the context window taken by the request, 0-1
+	ContextUtilization float64 `json:"context_utilization"`
+	// Structural and accessibility issues found in the generated HTML
+	Warnings []string `json:"warnings,omitempty"`
+	// Set when the HTML was recovered from a response cut off by a timeout
+	Partial bool `json:"partial,omitempty"`
 }
 
 type TemplateCategory struct {
-	Name        string
-	Description string
-	Prompt      string
+	Name         string
+	Description  string
+	Prompt       string
+	RequiredVars []string
+	OptionalVars []string
 }
 
-func NewAIService(config config.AIConfig, redisClient *redis.Client) *AIService {
+// Prompt fragments appended for each supplied template variable; the
+// {{key}} placeholder is replaced with the user's value
+var templateVariablePrompts = map[string]string{
+	"name":            " for {{name}}",
+	"profession":      ", who works as a {{profession}}",
+	"company_name":    " for a company called {{company_name}}",
+	"product_name":    " for a product called {{product_name}}",
+	"blog_name":       " for a blog called {{blog_name}}",
+	"store_name":      " for a store called {{store_name}}",
+	"restaurant_name": " for a restaurant called {{restaurant_name}}",
+	"app_name":        " for an application called {{app_name}}",
+	"project_name":    " for a project called {{project_name}}",
+	"industry":        " in the {{industry}} industry",
+	"cuisine":         " serving {{cuisine}} cuisine",
+	"product_type":    " selling {{product_type}}",
+	"topic":           " about {{topic}}",
+	"author_name":     " written by {{author_name}}",
+	"tagline":         ", featuring the tagline \"{{tagline}}\"",
+	"address":         ", located at {{address}}",
+	"phone":           ", with the phone number {{phone}}",
+	"email":           ", with the contact email {{email}}",
+	"primary_color":   ", using {{primary_color}} as the primary color",
+}
+
+func NewAIService(configStore *config.ConfigStore, redisClient *redis.Client, dbRouter *database.DBRouter) *AIService {
+	log := logger.New("development") // TODO: Get from config
+
+	// Timeouts are applied per request so reloaded values take effect
+	httpClient := &http.Client{}
+	aiConfig := configStore.AI()
+
+	// Only providers with an API key are set up. Claude calls go through a
+	// circuit breaker so an outage falls back without waiting out timeouts.
+	claudeBreaker := NewCircuitBreaker("claude", claudeBreakerMaxFailures, claudeBreakerSuccessThreshold, claudeBreakerTimeout, log)
+	available := make(map[string]aiprovider.Provider)
+	if aiConfig.ClaudeAPIKey != "" {
+		available["claude"] = &breakerProvider{
+			provider: aiprovider.NewClaudeProvider(configStore, httpClient, log),
+			breaker:  claudeBreaker,
+		}
+	}
+	if aiConfig.OpenAIAPIKey != "" {
+		available["openai"] = aiprovider.NewOpenAIProvider(configStore, httpClient, log)
+	}
+	if aiConfig.GeminiAPIKey != "" {
+		available["gemini"] = aiprovider.NewGeminiProvider(configStore, httpClient, log)
+	}
+
+	var fallbackChain []fallbackStep
+	for _, model := range aiConfig.FallbackChain {
+		provider, ok := available[model.Provider]
+		if !ok {
+			switch model.Provider {
+			case "claude", "openai", "gemini":
+				log.Info("Skipping AI provider without API key in fallback chain", "provider", model.Provider)
+			default:
+				log.Warn("Unknown AI provider in fallback chain", "provider", model.Provider)
+			}
+			continue
+		}
+		fallbackChain = append(fallbackChain, fallbackStep{provider: provider, model: model})
+	}
+
+	var contentFilters []ContentFilter
+	if path := configStore.AI().ContentFilterRules; path != "" {
+		if filter, err := NewRegexContentFilter(path); err != nil {
+			log.Warn("Failed to load content filter rules", "path", path, "error", err)
+		} else {
+			contentFilters = append(contentFilters, filter)
+		}
+	}
+	if configStore.AI().OpenAIAPIKey != "" {
+		contentFilters = append(contentFilters, NewOpenAIModerationFilter(configStore, httpClient))
+	}
+
 	return &AIService{
-		config:      config,
-		redisClient: redisClient,
-		httpClient: &http.Client{
-			Timeout: time.Duration(config.Timeout) * time.Second,
-		},
-		logger: logger.New("development"), // TODO: Get from config
+		configStore:    configStore,
+		redisClient:    redisClient,
+		dbRouter:       dbRouter,
+		fallbackChain:  fallbackChain,
+		contentFilters: contentFilters,
+		claudeBreaker:  claudeBreaker,
+		logger:         log,
+	}
+}
+
+// ClaudeCircuitBreaker returns the breaker guarding Claude API calls
+func (s *AIService) ClaudeCircuitBreaker() *CircuitBreaker {
+	return s.claudeBreaker
+}
+
+// PrimaryModel returns the first model of the fallback chain, which serves
+// generations while it is available. It reports false when no provider has
+// an API key, in which case generations use the static template.
+func (s *AIService) PrimaryModel() (string, bool) {
+	if len(s.fallbackChain) == 0 {
+		return fallbackTemplateModel, false
+	}
+
+	step := s.fallbackChain[0]
+	if step.model.Model != "" {
+		return step.model.Model, true
 	}
+
+	aiConfig := s.configStore.AI()
+	switch step.model.Provider {
+	case "openai":
+		return aiConfig.OpenAIModel, true
+	case "gemini":
+		return aiConfig.GeminiModel, true
+	default:
+		return aiConfig.Model, true
+	}
+}
+
+// LockProjectGeneration takes the lock that allows one generation per project
+// at a time, held for at most the configured AI timeout
+func (s *AIService) LockProjectGeneration(projectID uuid.UUID) (func(), bool, error) {
+	ttl := time.Duration(s.configStore.AI().Timeout) * time.Second
+	return s.redisClient.Lock("generation_lock:"+projectID.String(), ttl)
 }
 
-func (s *AIService) GenerateWebsite(userPrompt string, conversationHistory []models.ConversationEntry, progressCallback func(int)) (*GenerationResult, error) {
+func (s *AIService) GenerateWebsite(ctx context.Context, userPrompt string, conversationHistory []models.ConversationEntry, progressCallback func(int)) (*GenerationResult, error) {
 	startTime := time.Now()
 
+	if err := s.filterPrompt(userPrompt); err != nil {
+		return nil, err
+	}
+
 	// Check cache first
-	if cached, err := s.getCachedGeneration(userPrompt, conversationHistory); err == nil && cached != nil {
+	if cached, err := s.getCachedGeneration(ctx, userPrompt, conversationHistory); err == nil && cached != nil {
 		s.logger.Info("Using cached generation")
+		metrics.AIGenerationsTotal.WithLabelValues(s.configStore.AI().Model, metrics.ResultCache).Inc()
+		// Entries cached before the model was recorded fall back to the
+		// configured one
+		if cached.ModelUsed == "" {
+			cached.ModelUsed = s.configStore.AI().Model
+		}
 		return &GenerationResult{
 			ConversationalResponse: cached.ConversationalResponse,
 			HTMLCode:               cached.HTMLCode,
 			TokensUsed:             cached.TokensUsed,
 			ResponseTime:           time.Since(startTime).Milliseconds(),
 			FromCache:              true,
+			ModelUsed:              cached.ModelUsed,
+			PromptVariant:          cached.PromptVariant,
 		}, nil
 	}
 
@@ -96,22 +239,34 @@ func (s *AIService) GenerateWebsite(userPrompt string, conversationHistory []mod
 	}
 
 	// Build messages for Claude API
-	messages := s.buildConversationMessages(userPrompt, conversationHistory)
+	variant, systemPrompt := s.selectPromptVariant(ctx)
+	messages := s.buildConversationMessages(userPrompt, systemPrompt, conversationHistory)
+	maxContextTokens := s.configStore.AI().MaxContextTokens
+	messages = s.FitConversationHistory(messages, maxContextTokens)
 
 	if progressCallback != nil {
 		progressCallback(30)
 	}
 
-	// Call Claude API
-	response, err := s.callClaudeAPI(messages)
+	// Call the fallback chain
+	response, err := s.callProvider(ctx, messages)
 	if err != nil {
-		// Try fallback generation
-		if strings.Contains(err.Error(), "rate limit") || strings.Contains(err.Error(), "quota") {
+		// Every model failed: answer with the static template unless the
+		// caller gave up or the request itself was rejected
+		if ctx.Err() == nil && (aiprovider.IsRetryable(err) || errors.Is(err, ErrCircuitOpen) || strings.Contains(err.Error(), "quota")) {
+			metrics.AIGenerationsTotal.WithLabelValues(s.configStore.AI().Model, metrics.ResultFallback).Inc()
 			return s.generateFallbackWebsite(userPrompt), nil
 		}
+		metrics.AIGenerationsTotal.WithLabelValues(s.configStore.AI().Model, metrics.ResultError).Inc()
 		return nil, fmt.Errorf("AI generation failed: %w", err)
 	}
 
+	model := response.Model
+	if model == "" {
+		model = s.configStore.AI().Model
+	}
+	metrics.AIGenerationsTotal.WithLabelValues(model, metrics.ResultSuccess).Inc()
+
 	if progressCallback != nil {
 		progressCallback(70)
 	}
@@ -119,13 +274,18 @@ func (s *AIService) GenerateWebsite(userPrompt string, conversationHistory []mod
 	// Parse response
 	result := s.parseGenerationResponse(response)
 	result.ResponseTime = time.Since(startTime).Milliseconds()
+	result.ContextUtilization = contextUtilization(messages, maxContextTokens)
+	result.ModelUsed = model
+	result.PromptVariant = variant
 
 	if progressCallback != nil {
 		progressCallback(90)
 	}
 
-	// Cache the result
-	s.cacheGeneration(userPrompt, result, conversationHistory)
+	// Cache the result, unless it was cut off and a retry may complete it
+	if !result.Partial {
+		s.cacheGeneration(ctx, userPrompt, result, conversationHistory)
+	}
 
 	if progressCallback != nil {
 		progressCallback(100)
@@ -134,9 +294,13 @@ func (s *AIService) GenerateWebsite(userPrompt string, conversationHistory []mod
 	return result, nil
 }
 
-func (s *AIService) RefineWebsite(currentCode, refinementRequest string) (*GenerationResult, error) {
+func (s *AIService) RefineWebsite(ctx context.Context, currentCode, refinementRequest string) (*GenerationResult, error) {
 	startTime := time.Now()
 
+	if err := s.filterPrompt(refinementRequest); err != nil {
+		return nil, err
+	}
+
 	prompt := fmt.Sprintf(`I have this existing website code:
 
 %s
@@ -153,7 +317,7 @@ Provide the complete updated HTML code with your improvements.`, currentCode, re
 	}
 
 	// Call Claude API
-	response, err := s.callClaudeAPI(messages)
+	response, err := s.callProvider(ctx, messages)
 	if err != nil {
 		return nil, fmt.Errorf("AI refinement failed: %w", err)
 	}
@@ -161,11 +325,20 @@ Provide the complete updated HTML code with your improvements.`, currentCode, re
 	// Parse response
 	result := s.parseGenerationResponse(response)
 	result.ResponseTime = time.Since(startTime).Milliseconds()
-
+	result.ContextUtilization = contextUtilization(messages, s.configStore.AI().MaxContextTokens)
 	return result, nil
 }
 
-func (s *AIService) GenerateFromTemplate(category, style, colorScheme string) (*GenerationResult, error) {
+func (s *AIService) GenerateFromTemplate(ctx context.Context, category, style, colorScheme string, vars map[string]string) (*GenerationResult, error) {
+	prompt := s.BuildTemplatePrompt(category, style, colorScheme, vars)
+
+	return s.GenerateWebsite(ctx, prompt, []models.ConversationEntry{}, nil)
+}
+
+// BuildTemplatePrompt builds the generation prompt for a template category,
+// interpolating any supplied template variables. Variables should be checked
+// with ValidateTemplateVariables first; unknown keys are ignored.
+func (s *AIService) BuildTemplatePrompt(category, style, colorScheme string, vars map[string]string) string {
 	templates := s.getTemplatePrompts()
 	template, exists := templates[category]
 	if !exists {
@@ -173,6 +346,20 @@ func (s *AIService) GenerateFromTemplate(category, style, colorScheme string) (*
 	}
 
 	prompt := template.Prompt
+
+	var replacements []string
+	for _, key := range append(append([]string{}, template.RequiredVars...), template.OptionalVars...) {
+		value := strings.TrimSpace(vars[key])
+		if value == "" {
+			continue
+		}
+		prompt += templateVariablePrompts[key]
+		replacements = append(replacements, "{{"+key+"}}", value)
+	}
+	if len(replacements) > 0 {
+		prompt = strings.NewReplacer(replacements...).Replace(prompt)
+	}
+
 	if style != "" {
 		prompt += fmt.Sprintf(" with a %s design style", style)
 	}
@@ -180,10 +367,240 @@ func (s *AIService) GenerateFromTemplate(category, style, colorScheme string) (*
 		prompt += fmt.Sprintf(" using a %s color scheme", colorScheme)
 	}
 
-	return s.GenerateWebsite(prompt, []models.ConversationEntry{}, nil)
+	return prompt
+}
+
+// TemplateVariables returns the required and optional variable keys accepted
+// by a template category.
+func (s *AIService) TemplateVariables(category string) ([]string, []string) {
+	template, exists := s.getTemplatePrompts()[category]
+	if !exists {
+		return []string{}, []string{}
+	}
+
+	return template.RequiredVars, template.OptionalVars
+}
+
+// ValidateTemplateVariables checks variable keys against those allowed for the
+// category. Required variables only apply once any variable is supplied, so
+// requests without variables keep using the generic prompt.
+func (s *AIService) ValidateTemplateVariables(category string, vars map[string]string) error {
+	if len(vars) == 0 {
+		return nil
+	}
+
+	required, optional := s.TemplateVariables(category)
+
+	allowed := make(map[string]bool, len(required)+len(optional))
+	for _, key := range append(append([]string{}, required...), optional...) {
+		allowed[key] = true
+	}
+
+	for key := range vars {
+		if !allowed[key] {
+			return fmt.Errorf("unknown template variable: %s", key)
+		}
+	}
+
+	for _, key := range required {
+		if strings.TrimSpace(vars[key]) == "" {
+			return fmt.Errorf("missing required template variable: %s", key)
+		}
+	}
+
+	return nil
+}
+
+// RecolorWebsite applies a color mapping to a website. When the page defines
+// CSS custom properties in a :root rule, only that rule is regenerated by
+// Claude and spliced back in; otherwise the colors are substituted directly.
+func (s *AIService) RecolorWebsite(ctx context.Context, currentCode string, palette []models.ColorMapping) (*GenerationResult, error) {
+	startTime := time.Now()
+
+	// Without theme variables the colors are swapped directly, without the model
+	section := cssRuleRegex(":root").FindString(currentCode)
+	if section == "" {
+		return &GenerationResult{
+			ConversationalResponse: fmt.Sprintf("I've updated %d colors across your website.", len(palette)),
+			HTMLCode:               recolorCSS(currentCode, palette),
+			ResponseTime:           time.Since(startTime).Milliseconds(),
+		}, nil
+	}
+
+	var changes strings.Builder
+	for _, mapping := range palette {
+		fmt.Fprintf(&changes, "- %s -> %s\n", mapping.From, mapping.To)
+	}
+
+	messages := []Message{
+		{
+			Role: "user",
+			Content: fmt.Sprintf(`Here is the CSS custom properties section of a website:
+
+%s
+
+Update it to apply these color changes, adjusting related shades so the theme stays consistent:
+%s
+Keep every custom property name unchanged. Respond with only the updated CSS rule wrapped in <css_section> tags.`, section, changes.String()),
+		},
+	}
+
+	response, err := s.callProviderWithMaxTokens(ctx, messages, 1000)
+	if err != nil {
+		return nil, fmt.Errorf("AI recolor failed: %w", err)
+	}
+
+	if len(response.Content) == 0 {
+		return nil, fmt.Errorf("AI recolor failed: empty response")
+	}
+
+	matches := regexp.MustCompile(`<css_section>([\s\S]*?)</css_section>`).FindStringSubmatch(response.Content[0].Text)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("AI recolor failed: no CSS section in response")
+	}
+
+	htmlCode, err := s.UpdateSection(currentCode, ":root", strings.TrimSpace(matches[1]))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerationResult{
+		ConversationalResponse: fmt.Sprintf("I've updated your theme's color variables with %d new colors.", len(palette)),
+		HTMLCode:               htmlCode,
+		TokensUsed:             response.Usage.InputTokens + response.Usage.OutputTokens,
+		ResponseTime:           time.Since(startTime).Milliseconds(),
+		ModelUsed:              response.Model,
+	}, nil
+}
+
+// UpdateSection replaces the first CSS rule for selector in the given HTML
+// with a new rule.
+func (s *AIService) UpdateSection(html, selector, newSection string) (string, error) {
+	ruleRegex := cssRuleRegex(selector)
+	if !ruleRegex.MatchString(newSection) {
+		return "", fmt.Errorf("updated section is not a valid %s rule", selector)
+	}
+
+	location := ruleRegex.FindStringIndex(html)
+	if location == nil {
+		return "", fmt.Errorf("section %s not found", selector)
+	}
+
+	return html[:location[0]] + newSection + html[location[1]:], nil
+}
+
+func cssRuleRegex(selector string) *regexp.Regexp {
+	return regexp.MustCompile(regexp.QuoteMeta(selector) + `\s*\{[^{}]*\}`)
+}
+
+// SummarizeProject generates a short, one-sentence description of a website
+// from its HTML. Results are cached by a hash of the leading markup.
+func (s *AIService) SummarizeProject(ctx context.Context, html string) (string, error) {
+	if strings.TrimSpace(html) == "" {
+		return "", fmt.Errorf("no HTML to summarize")
+	}
+
+	prefix := html
+	if len(prefix) > 500 {
+		prefix = prefix[:500]
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(prefix)))
+	cacheKey := fmt.Sprintf("summary:%s", hash[:16])
+
+	if s.redisClient != nil {
+		var cached string
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil && cached != "" {
+			return cached, nil
+		}
+	}
+
+	// Only the beginning of the document is needed to identify the site
+	content := html
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+
+	messages := []Message{
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("In one sentence, describe what kind of website this is and its main purpose. Be concise.\n\n%s", content),
+		},
+	}
+
+	response, err := s.callProviderWithMaxTokens(ctx, messages, 100)
+	if err != nil {
+		return "", fmt.Errorf("AI summarization failed: %w", err)
+	}
+
+	if len(response.Content) == 0 {
+		return "", fmt.Errorf("empty summary response")
+	}
+
+	summary := truncateDescription(strings.TrimSpace(response.Content[0].Text), 160)
+	if summary == "" {
+		return "", fmt.Errorf("empty summary response")
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, summary, 24*time.Hour)
+	}
+
+	return summary, nil
+}
+
+func truncateDescription(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:maxLength-3])) + "..."
+}
+
+// ClassifyPrompt asks Claude whether a generation prompt violates the content
+// policy. The reason is empty when the prompt is not flagged.
+func (s *AIService) ClassifyPrompt(ctx context.Context, prompt string) (bool, string, error) {
+	messages := []Message{
+		{
+			Role: "user",
+			Content: fmt.Sprintf(`You are a content moderator for a website builder. Decide whether the following request asks for a website that is illegal, hateful, sexually explicit, promotes violence, or is intended for phishing or fraud.
+
+Respond with JSON only, in this format: {"flagged": true|false, "reason": "short reason if flagged"}
+
+Request:
+%s`, prompt),
+		},
+	}
+
+	response, err := s.callProviderWithMaxTokens(ctx, messages, 100)
+	if err != nil {
+		return false, "", fmt.Errorf("AI moderation failed: %w", err)
+	}
+
+	if len(response.Content) == 0 {
+		return false, "", fmt.Errorf("empty moderation response")
+	}
+
+	text := strings.TrimSpace(response.Content[0].Text)
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+
+	var verdict struct {
+		Flagged bool   `json:"flagged"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(text), &verdict); err != nil {
+		return false, "", fmt.Errorf("invalid moderation response: %w", err)
+	}
+
+	if !verdict.Flagged {
+		return false, "", nil
+	}
+
+	return true, strings.TrimSpace(verdict.Reason), nil
 }
 
-func (s *AIService) buildConversationMessages(userPrompt string, conversationHistory []models.ConversationEntry) []Message {
+func (s *AIService) buildConversationMessages(userPrompt, systemPrompt string, conversationHistory []models.ConversationEntry) []Message {
 	messages := []Message{}
 
 	// Add conversation history (last 10 messages to stay within context)
@@ -206,65 +623,141 @@ func (s *AIService) buildConversationMessages(userPrompt string, conversationHis
 
 %s
 
-Please provide both a conversational response AND complete HTML code as specified in your system instructions.`, s.getSystemPrompt(), userPrompt),
+Please provide both a conversational response AND complete HTML code as specified in your system instructions.`, systemPrompt, userPrompt),
 	})
 
 	return messages
 }
 
-func (s *AIService) callClaudeAPI(messages []Message) (*ClaudeResponse, error) {
-	if s.config.ClaudeAPIKey == "" {
-		return nil, fmt.Errorf("Claude API key not configured")
+// FitConversationHistory drops the oldest messages until the estimated input
+// fits in 80% of maxContextTokens, leaving the rest for the response. The last
+// message (the current prompt) is always kept.
+func (s *AIService) FitConversationHistory(messages []Message, maxContextTokens int) []Message {
+	if maxContextTokens <= 0 || len(messages) == 0 {
+		return messages
 	}
 
-	request := ClaudeRequest{
-		Model:     s.config.Model,
-		MaxTokens: s.config.MaxTokens,
-		Messages:  messages,
+	budget := int(float64(maxContextTokens) * 0.8)
+	total := 0
+	for _, message := range messages {
+		total += estimateTokens(message.Content)
 	}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	dropped := 0
+	for total > budget && dropped < len(messages)-1 {
+		total -= estimateTokens(messages[dropped].Content)
+		dropped++
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Claude expects the conversation to open with a user turn
+	for dropped < len(messages)-1 && messages[dropped].Role != "user" {
+		total -= estimateTokens(messages[dropped].Content)
+		dropped++
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", s.config.ClaudeAPIKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	if dropped == 0 {
+		return messages
+	}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("API request failed: %w", err)
+	s.logger.Warn("Trimmed conversation history to fit context window",
+		"dropped_messages_count", dropped,
+		"estimated_input_tokens", total,
+		"max_context_tokens", maxContextTokens,
+	)
+
+	return messages[dropped:]
+}
+
+// estimateTokens approximates token count at roughly 4 characters per token
+func estimateTokens(content string) int {
+	return len(content) / 4
+}
+
+func contextUtilization(messages []Message, maxContextTokens int) float64 {
+	if maxContextTokens <= 0 {
+		return 0
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("rate limit exceeded")
-	} else if resp.StatusCode == 401 {
-		return nil, fmt.Errorf("invalid API key")
-	} else if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
+	total := 0
+	for _, message := range messages {
+		total += estimateTokens(message.Content)
 	}
 
-	var response ClaudeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return roundTo(float64(total)/float64(maxContextTokens), 4)
+}
+
+func (s *AIService) callProvider(ctx context.Context, messages []Message) (*aiprovider.ProviderResponse, error) {
+	return s.callProviderWithMaxTokens(ctx, messages, s.configStore.AI().MaxTokens)
+}
+
+// callProviderWithMaxTokens tries each model of the fallback chain in order,
+// moving on when one is rate limited, times out or fails with a server error.
+// Each model's own token budget caps maxTokens.
+func (s *AIService) callProviderWithMaxTokens(ctx context.Context, messages []Message, maxTokens int) (*aiprovider.ProviderResponse, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "AIService.callProvider", trace.WithAttributes(
+		attribute.Int("ai.messages", len(messages)),
+		attribute.Int("ai.max_tokens", maxTokens),
+	))
+	defer span.End()
+
+	var lastErr error
+	for level, step := range s.fallbackChain {
+		opts := aiprovider.Options{
+			MaxTokens: maxTokens,
+			Model:     step.model.Model,
+			Timeout:   time.Duration(step.model.Timeout) * time.Second,
+		}
+		if step.model.MaxTokens > 0 && step.model.MaxTokens < maxTokens {
+			opts.MaxTokens = step.model.MaxTokens
+		}
+
+		response, err := step.provider.Generate(ctx, messages, opts)
+		if err == nil {
+			metrics.AIProviderCallsTotal.WithLabelValues(step.model.Provider, metrics.ResultSuccess).Inc()
+			if level > 0 {
+				s.logger.InfoContext(ctx, "AI fallback model used", "level", level, "provider", response.Provider, "model", response.Model)
+			}
+			span.SetAttributes(
+				attribute.String("ai.provider", response.Provider),
+				attribute.String("ai.model", response.Model),
+				attribute.Int("ai.fallback_level", level),
+				attribute.Int("ai.input_tokens", response.Usage.InputTokens),
+				attribute.Int("ai.output_tokens", response.Usage.OutputTokens),
+			)
+			return response, nil
+		}
+		if errors.Is(err, aiprovider.ErrNotConfigured) {
+			continue
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			lastErr = err
+			s.logger.WarnContext(ctx, "AI model skipped, circuit breaker open", "level", level, "provider", step.model.Provider)
+			continue
+		}
+		metrics.AIProviderCallsTotal.WithLabelValues(step.model.Provider, metrics.ResultError).Inc()
+
+		lastErr = err
+		if !aiprovider.IsRetryable(err) || ctx.Err() != nil {
+			telemetry.RecordError(span, err)
+			return nil, err
+		}
+		s.logger.WarnContext(ctx, "AI model unavailable, trying next", "level", level, "provider", step.model.Provider, "error", err)
 	}
 
-	return &response, nil
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no AI provider configured")
+	}
+	telemetry.RecordError(span, lastErr)
+	return nil, lastErr
 }
 
-func (s *AIService) parseGenerationResponse(response *ClaudeResponse) *GenerationResult {
+func (s *AIService) parseGenerationResponse(response *aiprovider.ProviderResponse) *GenerationResult {
 	if len(response.Content) == 0 {
 		return &GenerationResult{
 			ConversationalResponse: "I've created your website! Check out the preview to see how it looks.",
 			HTMLCode:               s.generateFallbackHTML("My Website"),
 			TokensUsed:             response.Usage.InputTokens + response.Usage.OutputTokens,
+			ModelUsed:              response.Model,
 		}
 	}
 
@@ -293,29 +786,25 @@ func (s *AIService) parseGenerationResponse(response *ClaudeResponse) *Generatio
 	}
 
 	// Validate HTML structure
-	if !s.validateHTML(htmlCode) {
-		s.logger.Warn("Generated HTML may have structural issues")
+	warnings, err := validateHTMLStructure(htmlCode)
+	if err != nil {
+		s.logger.Warn("Generated HTML could not be parsed", "error", err)
+		warnings = []ValidationWarning{{Code: "MALFORMED_HTML", Message: err.Error()}}
+	} else if len(warnings) > 0 {
+		s.logger.Warn("Generated HTML has structural issues", "warnings", len(warnings))
 	}
 
 	return &GenerationResult{
 		ConversationalResponse: conversationalResponse,
 		HTMLCode:               htmlCode,
 		TokensUsed:             response.Usage.InputTokens + response.Usage.OutputTokens,
+		Warnings:               warningMessages(warnings),
+		ModelUsed:              response.Model,
+		Partial:                response.Partial,
 	}
 }
 
-func (s *AIService) validateHTML(html string) bool {
-	hasDoctype := strings.Contains(html, "<!DOCTYPE html>")
-	hasHTMLTag := strings.Contains(html, "<html") && strings.Contains(html, "</html>")
-	hasHeadTag := strings.Contains(html, "<head>") && strings.Contains(html, "</head>")
-	hasBodyTag := strings.Contains(html, "<body>") && strings.Contains(html, "</body>")
-	hasTitle := strings.Contains(html, "<title>") && strings.Contains(html, "</title>")
-	hasViewport := strings.Contains(html, "viewport")
-
-	return hasDoctype && hasHTMLTag && hasHeadTag && hasBodyTag && hasTitle && hasViewport
-}
-
-func (s *AIService) getCachedGeneration(prompt string, conversationHistory []models.ConversationEntry) (*GenerationResult, error) {
+func (s *AIService) getCachedGeneration(ctx context.Context, prompt string, conversationHistory []models.ConversationEntry) (*GenerationResult, error) {
 	if s.redisClient == nil {
 		return nil, fmt.Errorf("redis not available")
 	}
@@ -328,14 +817,14 @@ func (s *AIService) getCachedGeneration(prompt string, conversationHistory []mod
 	cacheKey := fmt.Sprintf("generation:%s", hash[:16])
 
 	var cached GenerationResult
-	if err := s.redisClient.Get(cacheKey, &cached); err != nil {
+	if err := s.redisClient.WithContext(ctx).Get(cacheKey, &cached); err != nil {
 		return nil, err
 	}
 
 	return &cached, nil
 }
 
-func (s *AIService) cacheGeneration(prompt string, result *GenerationResult, conversationHistory []models.ConversationEntry) {
+func (s *AIService) cacheGeneration(ctx context.Context, prompt string, result *GenerationResult, conversationHistory []models.ConversationEntry) {
 	if s.redisClient == nil {
 		return
 	}
@@ -345,7 +834,7 @@ func (s *AIService) cacheGeneration(prompt string, result *GenerationResult, con
 	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(hashInput)))
 
 	cacheKey := fmt.Sprintf("generation:%s", hash[:16])
-	s.redisClient.Set(cacheKey, result, time.Hour) // Cache for 1 hour
+	s.redisClient.WithContext(ctx).Set(cacheKey, result, time.Hour) // Cache for 1 hour
 }
 
 func (s *AIService) generateFallbackWebsite(userPrompt string) *GenerationResult {
@@ -360,6 +849,7 @@ func (s *AIService) generateFallbackWebsite(userPrompt string) *GenerationResult
 		TokensUsed:             0,
 		ResponseTime:           100,
 		FromCache:              false,
+		ModelUsed:              fallbackTemplateModel,
 	}
 }
 
@@ -496,6 +986,38 @@ func (s *AIService) extractTitleFromPrompt(prompt string) string {
 	return ""
 }
 
+// selectPromptVariant picks one of the active prompt variants at random in
+// proportion to their weights. It returns an empty name and the default
+// prompt when no variant is active or they can't be loaded.
+func (s *AIService) selectPromptVariant(ctx context.Context) (string, string) {
+	var variants []models.PromptVariant
+	if err := s.dbRouter.Reader().WithContext(ctx).
+		Select("name", "prompt", "weight").
+		Where("is_active = ? AND weight > 0", true).
+		Order("name").
+		Find(&variants).Error; err != nil {
+		s.logger.WarnContext(ctx, "Failed to load prompt variants, using default prompt", "error", err)
+		return "", s.getSystemPrompt()
+	}
+
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	if total == 0 {
+		return "", s.getSystemPrompt()
+	}
+
+	pick := rand.IntN(total)
+	for _, variant := range variants {
+		if pick < variant.Weight {
+			return variant.Name, variant.Prompt
+		}
+		pick -= variant.Weight
+	}
+	return "", s.getSystemPrompt()
+}
+
 func (s *AIService) getSystemPrompt() string {
 	return `You are an expert web developer and designer specializing in creating beautiful, modern, responsive websites. Your job is to generate complete, functional HTML documents based on user requests.
 
@@ -554,49 +1076,67 @@ Remember: The HTML must be complete and self-contained. No external dependencies
 func (s *AIService) getTemplatePrompts() map[string]TemplateCategory {
 	return map[string]TemplateCategory{
 		"portfolio": {
-			Name:        "Portfolio",
-			Description: "A modern portfolio website for professionals",
-			Prompt:      "Create a modern portfolio website for a professional",
+			Name:         "Portfolio",
+			Description:  "A modern portfolio website for professionals",
+			Prompt:       "Create a modern portfolio website for a professional",
+			RequiredVars: []string{"name", "profession"},
+			OptionalVars: []string{"tagline", "primary_color", "email"},
 		},
 		"landing": {
-			Name:        "Landing Page",
-			Description: "A high-converting landing page for SaaS products",
-			Prompt:      "Create a compelling landing page for a SaaS product",
+			Name:         "Landing Page",
+			Description:  "A high-converting landing page for SaaS products",
+			Prompt:       "Create a compelling landing page for a SaaS product",
+			RequiredVars: []string{"product_name"},
+			OptionalVars: []string{"company_name", "tagline", "primary_color"},
 		},
 		"blog": {
-			Name:        "Blog",
-			Description: "A beautiful blog homepage with article previews",
-			Prompt:      "Create a beautiful blog homepage with article previews",
+			Name:         "Blog",
+			Description:  "A beautiful blog homepage with article previews",
+			Prompt:       "Create a beautiful blog homepage with article previews",
+			RequiredVars: []string{"blog_name"},
+			OptionalVars: []string{"author_name", "topic", "primary_color"},
 		},
 		"ecommerce": {
-			Name:        "E-commerce",
-			Description: "An e-commerce product showcase page",
-			Prompt:      "Create an e-commerce product showcase page",
+			Name:         "E-commerce",
+			Description:  "An e-commerce product showcase page",
+			Prompt:       "Create an e-commerce product showcase page",
+			RequiredVars: []string{"store_name"},
+			OptionalVars: []string{"product_type", "tagline", "primary_color"},
 		},
 		"restaurant": {
-			Name:        "Restaurant",
-			Description: "A restaurant website with menu and contact info",
-			Prompt:      "Create a restaurant website with menu and contact info",
+			Name:         "Restaurant",
+			Description:  "A restaurant website with menu and contact info",
+			Prompt:       "Create a restaurant website with menu and contact info",
+			RequiredVars: []string{"restaurant_name"},
+			OptionalVars: []string{"cuisine", "address", "phone"},
 		},
 		"business": {
-			Name:        "Business",
-			Description: "A professional business website",
-			Prompt:      "Create a professional business website",
+			Name:         "Business",
+			Description:  "A professional business website",
+			Prompt:       "Create a professional business website",
+			RequiredVars: []string{"company_name"},
+			OptionalVars: []string{"industry", "tagline", "primary_color", "phone", "email"},
 		},
 		"personal": {
-			Name:        "Personal",
-			Description: "A personal website homepage",
-			Prompt:      "Create a personal website homepage",
+			Name:         "Personal",
+			Description:  "A personal website homepage",
+			Prompt:       "Create a personal website homepage",
+			RequiredVars: []string{"name"},
+			OptionalVars: []string{"profession", "tagline", "primary_color"},
 		},
 		"dashboard": {
-			Name:        "Dashboard",
-			Description: "A web application dashboard interface",
-			Prompt:      "Create a web application dashboard interface",
+			Name:         "Dashboard",
+			Description:  "A web application dashboard interface",
+			Prompt:       "Create a web application dashboard interface",
+			RequiredVars: []string{"app_name"},
+			OptionalVars: []string{"company_name", "primary_color"},
 		},
 		"documentation": {
-			Name:        "Documentation",
-			Description: "A documentation website homepage",
-			Prompt:      "Create a documentation website homepage",
+			Name:         "Documentation",
+			Description:  "A documentation website homepage",
+			Prompt:       "Create a documentation website homepage",
+			RequiredVars: []string{"project_name"},
+			OptionalVars: []string{"tagline", "primary_color"},
 		},
 	}
 }
@@ -0,0 +1,364 @@
+// internal/services/ai_jobs.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/ws"
+	"lovable-backend/pkg/logger"
+)
+
+// Statuses of async AI generation jobs
+const (
+	AIJobQueued     = "queued"
+	AIJobProcessing = "processing"
+	AIJobCompleted  = "completed"
+	AIJobFailed     = "failed"
+)
+
+const (
+	// Job records are deleted, and no longer served, after this long
+	aiJobRetention = 24 * time.Hour
+
+	// How long an idle worker waits before checking the queue again
+	aiJobPollInterval = 2 * time.Second
+
+	// Deadline for one queued generation; async jobs may run longer than
+	// the synchronous endpoint allows
+	aiJobTimeout = 3 * time.Minute
+
+	// Jobs still processing after this long lost their worker and are requeued
+	aiJobStaleAfter = 2 * aiJobTimeout
+)
+
+// AIJobQueue stores generation requests in the ai_jobs table and runs them on
+// a pool of workers, which claim jobs with SELECT ... FOR UPDATE SKIP LOCKED
+// so any number of instances can share the queue.
+type AIJobQueue struct {
+	db             *gorm.DB
+	aiService      *AIService
+	projectService *ProjectService
+	authService    *AuthService
+	wsHub          *ws.WSHub
+	notifications  *NotificationService
+	poolSize       int
+	logger         *logger.Logger
+}
+
+func NewAIJobQueue(db *gorm.DB, aiService *AIService, projectService *ProjectService, authService *AuthService, wsHub *ws.WSHub, notifications *NotificationService, poolSize int, logger *logger.Logger) *AIJobQueue {
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+
+	return &AIJobQueue{
+		db:             db,
+		aiService:      aiService,
+		projectService: projectService,
+		authService:    authService,
+		wsHub:          wsHub,
+		notifications:  notifications,
+		poolSize:       poolSize,
+		logger:         logger,
+	}
+}
+
+// Enqueue stores a generation request for the worker pool after checking that
+// the user may edit the project
+func (q *AIJobQueue) Enqueue(ctx context.Context, userID uuid.UUID, req *models.GenerateRequest) (*models.AIJob, error) {
+	if _, err := q.projectService.findAccessibleProject(q.db.Select("id"), userID, req.ProjectID, ProjectRoleEditor); err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.AIJob{
+		UserID:    userID,
+		ProjectID: req.ProjectID,
+		Status:    AIJobQueued,
+		Input:     input,
+	}
+
+	if err := q.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (q *AIJobQueue) GetJob(ctx context.Context, userID, jobID uuid.UUID) (*models.AIJob, error) {
+	var job models.AIJob
+	if err := q.db.Where("id = ? AND user_id = ? AND created_at > ?", jobID, userID, time.Now().Add(-aiJobRetention)).
+		First(&job).Error; err != nil {
+		return nil, errors.New("job not found")
+	}
+
+	return &job, nil
+}
+
+// StartWorkers runs the worker pool and the expiry sweep until the context is
+// cancelled, then waits for the workers to stop. Jobs interrupted by the
+// shutdown are put back in the queue.
+func (q *AIJobQueue) StartWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.poolSize; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.runWorker(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		q.sweep()
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep deletes expired jobs and requeues jobs whose worker died
+func (q *AIJobQueue) sweep() {
+	if err := q.db.Where("created_at < ?", time.Now().Add(-aiJobRetention)).Delete(&models.AIJob{}).Error; err != nil {
+		q.logger.Error("Failed to delete expired AI jobs", "error", err)
+	}
+
+	result := q.db.Model(&models.AIJob{}).
+		Where("status = ? AND started_at < ?", AIJobProcessing, time.Now().Add(-aiJobStaleAfter)).
+		Updates(map[string]interface{}{"status": AIJobQueued, "started_at": nil})
+	if result.Error != nil {
+		q.logger.Error("Failed to requeue stale AI jobs", "error", result.Error)
+	} else if result.RowsAffected > 0 {
+		q.logger.Warn("Requeued stale AI jobs", "count", result.RowsAffected)
+	}
+}
+
+func (q *AIJobQueue) runWorker(ctx context.Context) {
+	for {
+		job, err := q.claimJob()
+		if err != nil {
+			q.logger.Error("Failed to claim AI job", "error", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(aiJobPollInterval):
+			}
+			continue
+		}
+
+		q.process(ctx, job)
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// claimJob marks the oldest queued job as processing and returns it, or nil
+// when the queue is empty
+func (q *AIJobQueue) claimJob() (*models.AIJob, error) {
+	var job models.AIJob
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", AIJobQueued).
+			Order("created_at").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = AIJobProcessing
+		job.StartedAt = &now
+		return tx.Model(&job).Updates(map[string]interface{}{"status": AIJobProcessing, "started_at": now}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (q *AIJobQueue) process(ctx context.Context, job *models.AIJob) {
+	var input models.GenerateRequest
+	if err := json.Unmarshal(job.Input, &input); err != nil {
+		q.fail(job, err)
+		return
+	}
+
+	// Wait for a generation already running on the project, from the API or
+	// another worker, instead of racing it on the project's code
+	unlock, acquired, err := q.aiService.LockProjectGeneration(job.ProjectID)
+	if err != nil {
+		q.logger.Warn("Generation lock unavailable, continuing without it", "jobId", job.ID, "projectId", job.ProjectID, "error", err)
+	}
+	if !acquired {
+		q.requeue(job)
+		select {
+		case <-ctx.Done():
+		case <-time.After(aiJobPollInterval):
+		}
+		return
+	}
+	defer unlock()
+
+	q.notify(job.UserID, map[string]interface{}{
+		"type":      "job_processing",
+		"jobId":     job.ID,
+		"projectId": job.ProjectID,
+	})
+
+	genCtx, cancel := context.WithTimeout(ctx, aiJobTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+
+	result, err := q.aiService.GenerateWebsite(genCtx, input.Message, input.ConversationHistory, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: leave the job for the next worker
+			q.requeue(job)
+			return
+		}
+		q.fail(job, err)
+		return
+	}
+
+	responseTime := time.Since(startTime).Milliseconds()
+
+	conversation, err := q.projectService.SaveConversation(
+		ctx, job.ProjectID, job.UserID, input.Message,
+		result.ConversationalResponse, result.HTMLCode,
+		result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, "generation", result.FromCache,
+	)
+	if err != nil {
+		q.fail(job, err)
+		return
+	}
+
+	if result.HTMLCode != "" {
+		updateReq := &models.UpdateProjectRequest{
+			HTMLCode:       &result.HTMLCode,
+			ChangeSource:   ChangeSourceAIGeneration,
+			ConversationID: &conversation.ID,
+		}
+		if _, err := q.projectService.UpdateProject(ctx, job.UserID, job.ProjectID, updateReq); err != nil {
+			q.fail(job, fmt.Errorf("failed to save generated code: %w", err))
+			return
+		}
+	}
+
+	if err := q.authService.IncrementUsage(job.UserID); err != nil {
+		q.fail(job, fmt.Errorf("failed to record usage: %w", err))
+		return
+	}
+
+	generation := models.GenerationResult{
+		ConversationID:         conversation.ID,
+		ConversationalResponse: result.ConversationalResponse,
+		HTMLCode:               result.HTMLCode,
+		TokensUsed:             result.TokensUsed,
+		ResponseTime:           int(responseTime),
+		FromCache:              result.FromCache,
+		ContextUtilization:     result.ContextUtilization,
+		Warnings:               result.Warnings,
+		Partial:                result.Partial,
+		GeneratedAt:            conversation.CreatedAt,
+	}
+
+	data, err := json.Marshal(generation)
+	if err != nil {
+		q.fail(job, err)
+		return
+	}
+
+	if err := q.db.Model(job).Updates(map[string]interface{}{
+		"status":       AIJobCompleted,
+		"result":       data,
+		"completed_at": time.Now(),
+	}).Error; err != nil {
+		q.logger.Error("Failed to store AI job result", "jobId", job.ID, "error", err)
+		return
+	}
+
+	q.notify(job.UserID, map[string]interface{}{
+		"type":      "job_completed",
+		"jobId":     job.ID,
+		"projectId": job.ProjectID,
+		"result":    generation,
+	})
+
+	projectName := "your project"
+	var project models.Project
+	if err := q.db.Select("name").First(&project, "id = ?", job.ProjectID).Error; err == nil {
+		projectName = fmt.Sprintf("\"%s\"", project.Name)
+	}
+	if err := q.notifications.Send(job.UserID, NotificationGenerationComplete,
+		"Your website is ready",
+		fmt.Sprintf("The generation you queued for %s has finished.", projectName),
+		map[string]any{
+			"job_id":          job.ID,
+			"project_id":      job.ProjectID,
+			"conversation_id": conversation.ID,
+		}); err != nil {
+		q.logger.Error("Failed to send generation notification", "jobId", job.ID, "error", err)
+	}
+}
+
+// requeue hands a claimed job back to the queue for the next worker
+func (q *AIJobQueue) requeue(job *models.AIJob) {
+	if err := q.db.Model(job).Updates(map[string]interface{}{"status": AIJobQueued, "started_at": nil}).Error; err != nil {
+		q.logger.Error("Failed to requeue AI job", "jobId", job.ID, "error", err)
+	}
+}
+
+func (q *AIJobQueue) fail(job *models.AIJob, jobErr error) {
+	q.logger.Error("AI job failed", "jobId", job.ID, "projectId", job.ProjectID, "error", jobErr)
+
+	message := jobErr.Error()
+	if err := q.db.Model(job).Updates(map[string]interface{}{
+		"status":        AIJobFailed,
+		"error_message": message,
+		"completed_at":  time.Now(),
+	}).Error; err != nil {
+		q.logger.Error("Failed to mark AI job as failed", "jobId", job.ID, "error", err)
+	}
+
+	q.notify(job.UserID, map[string]interface{}{
+		"type":      "job_failed",
+		"jobId":     job.ID,
+		"projectId": job.ProjectID,
+		"error":     message,
+	})
+}
+
+// notify pushes a job event to the user's open WebSocket connections, if any
+func (q *AIJobQueue) notify(userID uuid.UUID, msg interface{}) {
+	if q.wsHub != nil {
+		q.wsHub.Broadcast(userID, msg)
+	}
+}
@@ -0,0 +1,105 @@
+// internal/services/ai_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/services/aiprovider"
+	"lovable-backend/pkg/logger"
+)
+
+// redirectTransport sends every request to the test server instead of the
+// provider's real API
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGenerationCanceledWithContext(t *testing.T) {
+	started := make(chan struct{})
+	aborted := make(chan time.Time, 1)
+
+	// A provider that never answers, so only cancellation can end the call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server only notices the client going away once the body is read
+		io.Copy(io.Discard, r.Body)
+		close(started)
+		select {
+		case <-r.Context().Done():
+			aborted <- time.Now()
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	target, _ := url.Parse(server.URL)
+	store := config.NewConfigStore(&config.Config{AI: config.AIConfig{
+		ClaudeAPIKey:     "test-key",
+		Model:            "claude-test",
+		MaxTokens:        1000,
+		MaxContextTokens: 100000,
+		Timeout:          45,
+	}})
+	log := logger.New("test")
+	s := &AIService{
+		configStore: store,
+		fallbackChain: []fallbackStep{{
+			provider: aiprovider.NewClaudeProvider(store, &http.Client{Transport: redirectTransport{target}}, log),
+			model:    config.FallbackModel{Provider: "claude", Model: "claude-test"},
+		}},
+		logger: log,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.RefineWebsite(ctx, "<html><body>Hello</body></html>", "Make it blue")
+		done <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("provider was never called")
+	}
+
+	canceledAt := time.Now()
+	cancel()
+
+	select {
+	case err := <-done:
+		if elapsed := time.Since(canceledAt); elapsed > 100*time.Millisecond {
+			t.Errorf("generation returned %v after cancellation, want within 100ms", elapsed)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("generation did not return after cancellation")
+	}
+
+	select {
+	case at := <-aborted:
+		if elapsed := at.Sub(canceledAt); elapsed > 100*time.Millisecond {
+			t.Errorf("provider request aborted %v after cancellation, want within 100ms", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("provider request was not aborted")
+	}
+}
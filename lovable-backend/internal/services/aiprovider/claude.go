@@ -0,0 +1,93 @@
+// internal/services/aiprovider/claude.go
+package aiprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/pkg/logger"
+)
+
+type ClaudeProvider struct {
+	configStore *config.ConfigStore
+	httpClient  *http.Client
+	logger      *logger.Logger
+}
+
+type claudeRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []Message `json:"messages"`
+}
+
+func NewClaudeProvider(configStore *config.ConfigStore, httpClient *http.Client, logger *logger.Logger) *ClaudeProvider {
+	return &ClaudeProvider{
+		configStore: configStore,
+		httpClient:  httpClient,
+		logger:      logger,
+	}
+}
+
+func (p *ClaudeProvider) Generate(ctx context.Context, messages []Message, opts Options) (*ProviderResponse, error) {
+	aiConfig := p.configStore.AI()
+	if aiConfig.ClaudeAPIKey == "" {
+		return nil, ErrNotConfigured
+	}
+	model, timeout := opts.resolve(aiConfig.Model, aiConfig.Timeout)
+
+	jsonData, err := json.Marshal(claudeRequest{
+		Model:     model,
+		MaxTokens: opts.MaxTokens,
+		Messages:  messages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", aiConfig.ClaudeAPIKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	if requestID := logger.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Provider: "claude", StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body := newPartialReader(resp.Body)
+	response := ProviderResponse{Provider: "claude", Model: model}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		// Claude writes the text block before usage, so most of the HTML is
+		// often already here when the deadline hits
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			if text, ok := recoverPartialText(body.Bytes(), `"text":"`, p.logger); ok {
+				response.Content = []ContentBlock{{Type: "text", Text: text}}
+				response.Partial = true
+				return &response, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,121 @@
+// internal/services/aiprovider/gemini.go
+package aiprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/pkg/logger"
+)
+
+type GeminiProvider struct {
+	configStore *config.ConfigStore
+	httpClient  *http.Client
+	logger      *logger.Logger
+}
+
+type geminiContent struct {
+	Role  string       `json:"role"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiRequest struct {
+	Contents         []geminiContent `json:"contents"`
+	GenerationConfig struct {
+		MaxOutputTokens int `json:"maxOutputTokens"`
+	} `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func NewGeminiProvider(configStore *config.ConfigStore, httpClient *http.Client, logger *logger.Logger) *GeminiProvider {
+	return &GeminiProvider{
+		configStore: configStore,
+		httpClient:  httpClient,
+		logger:      logger,
+	}
+}
+
+func (p *GeminiProvider) Generate(ctx context.Context, messages []Message, opts Options) (*ProviderResponse, error) {
+	aiConfig := p.configStore.AI()
+	if aiConfig.GeminiAPIKey == "" {
+		return nil, ErrNotConfigured
+	}
+	model, timeout := opts.resolve(aiConfig.GeminiModel, aiConfig.Timeout)
+
+	// Gemini calls the assistant role "model"
+	var request geminiRequest
+	for _, message := range messages {
+		role := message.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		request.Contents = append(request.Contents, geminiContent{Role: role, Parts: []geminiPart{{Text: message.Content}}})
+	}
+	request.GenerationConfig.MaxOutputTokens = opts.MaxTokens
+
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	endpoint := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent", url.PathEscape(model))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", aiConfig.GeminiAPIKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Provider: "gemini", StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var generated geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&generated); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := ProviderResponse{Provider: "gemini", Model: model}
+	if len(generated.Candidates) > 0 {
+		var text strings.Builder
+		for _, part := range generated.Candidates[0].Content.Parts {
+			text.WriteString(part.Text)
+		}
+		response.Content = []ContentBlock{{Type: "text", Text: text.String()}}
+	}
+	response.Usage = Usage{
+		InputTokens:  generated.UsageMetadata.PromptTokenCount,
+		OutputTokens: generated.UsageMetadata.CandidatesTokenCount,
+	}
+
+	return &response, nil
+}
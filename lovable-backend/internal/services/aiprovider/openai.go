@@ -0,0 +1,107 @@
+// internal/services/aiprovider/openai.go
+package aiprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/pkg/logger"
+)
+
+type OpenAIProvider struct {
+	configStore *config.ConfigStore
+	httpClient  *http.Client
+	logger      *logger.Logger
+}
+
+type openAIRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	Messages  []Message `json:"messages"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func NewOpenAIProvider(configStore *config.ConfigStore, httpClient *http.Client, logger *logger.Logger) *OpenAIProvider {
+	return &OpenAIProvider{
+		configStore: configStore,
+		httpClient:  httpClient,
+		logger:      logger,
+	}
+}
+
+func (p *OpenAIProvider) Generate(ctx context.Context, messages []Message, opts Options) (*ProviderResponse, error) {
+	aiConfig := p.configStore.AI()
+	if aiConfig.OpenAIAPIKey == "" {
+		return nil, ErrNotConfigured
+	}
+	model, timeout := opts.resolve(aiConfig.OpenAIModel, aiConfig.Timeout)
+
+	jsonData, err := json.Marshal(openAIRequest{
+		Model:     model,
+		MaxTokens: opts.MaxTokens,
+		Messages:  messages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+aiConfig.OpenAIAPIKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Provider: "openai", StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	response := ProviderResponse{Provider: "openai", Model: model}
+
+	body := newPartialReader(resp.Body)
+	var completion openAIResponse
+	if err := json.NewDecoder(body).Decode(&completion); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			if text, ok := recoverPartialText(body.Bytes(), `"content":"`, p.logger); ok {
+				response.Content = []ContentBlock{{Type: "text", Text: text}}
+				response.Partial = true
+				return &response, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for _, choice := range completion.Choices {
+		response.Content = append(response.Content, ContentBlock{Type: "text", Text: choice.Message.Content})
+	}
+	response.Usage = Usage{
+		InputTokens:  completion.Usage.PromptTokens,
+		OutputTokens: completion.Usage.CompletionTokens,
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,130 @@
+// internal/services/aiprovider/partial.go
+package aiprovider
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"lovable-backend/pkg/logger"
+)
+
+// partialReader keeps a copy of everything read from the response body so
+// the bytes received before a timeout can still be inspected.
+type partialReader struct {
+	reader io.Reader
+	buf    bytes.Buffer
+}
+
+func newPartialReader(reader io.Reader) *partialReader {
+	return &partialReader{reader: reader}
+}
+
+func (r *partialReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.buf.Write(p[:n])
+	return n, err
+}
+
+func (r *partialReader) Bytes() []byte {
+	return r.buf.Bytes()
+}
+
+// recoverPartialText extracts the model text from a truncated JSON body, where
+// marker introduces the text field. It only succeeds when the text received so
+// far contains generated website code; an unterminated code block is closed so
+// it can be parsed as usual.
+func recoverPartialText(body []byte, marker string, logger *logger.Logger) (string, bool) {
+	start := bytes.Index(body, []byte(marker))
+	if start == -1 {
+		return "", false
+	}
+
+	text := decodePartialJSONString(body[start+len(marker):])
+	codeStart := strings.Index(text, "<website_code>")
+	if codeStart == -1 {
+		return "", false
+	}
+	if !strings.Contains(text[codeStart:], "</website_code>") {
+		text += "\n</website_code>"
+	}
+
+	partialHTML := text[codeStart+len("<website_code>"):]
+	partialHTML = partialHTML[:strings.Index(partialHTML, "</website_code>")]
+	logger.Warn("partial_recovery", "partial_html_length", len(strings.TrimSpace(partialHTML)))
+
+	return text, true
+}
+
+// decodePartialJSONString decodes the body of a JSON string literal up to its
+// closing quote or, when the input was cut off, up to the last complete
+// character.
+func decodePartialJSONString(data []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c == '"' {
+			break
+		}
+		if c != '\\' {
+			out.WriteByte(c)
+			continue
+		}
+
+		if i+1 >= len(data) {
+			break
+		}
+		i++
+		switch data[i] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case 'u':
+			r, consumed, ok := decodeJSONUnicodeEscape(data[i+1:])
+			if !ok {
+				return out.String()
+			}
+			out.WriteRune(r)
+			i += consumed
+		default:
+			out.WriteByte(data[i])
+		}
+	}
+
+	return out.String()
+}
+
+// decodeJSONUnicodeEscape decodes the hex digits following `\u`, including a
+// trailing low surrogate when present.
+func decodeJSONUnicodeEscape(data []byte) (rune, int, bool) {
+	if len(data) < 4 {
+		return 0, 0, false
+	}
+	code, err := strconv.ParseUint(string(data[:4]), 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	r := rune(code)
+	if !utf16.IsSurrogate(r) {
+		return r, 4, true
+	}
+	if len(data) < 10 || data[4] != '\\' || data[5] != 'u' {
+		return 0, 0, false
+	}
+	low, err := strconv.ParseUint(string(data[6:10]), 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return utf16.DecodeRune(r, rune(low)), 10, true
+}
@@ -0,0 +1,97 @@
+// internal/services/aiprovider/provider.go
+package aiprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrNotConfigured is returned by providers whose API key is not set so the
+// caller can move on to the next one.
+var ErrNotConfigured = errors.New("provider not configured")
+
+// Provider sends a conversation to a hosted model and returns its reply.
+type Provider interface {
+	Generate(ctx context.Context, messages []Message, opts Options) (*ProviderResponse, error)
+}
+
+// Options set the token limit of one call and may override the provider's
+// configured model and timeout
+type Options struct {
+	MaxTokens int
+	Model     string        // configured model when empty
+	Timeout   time.Duration // configured AI timeout when zero
+}
+
+// resolve returns the model and timeout to use, falling back to the
+// configured ones
+func (o Options) resolve(model string, timeoutSeconds int) (string, time.Duration) {
+	if o.Model != "" {
+		model = o.Model
+	}
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if o.Timeout > 0 {
+		timeout = o.Timeout
+	}
+	return model, timeout
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// ProviderResponse is a provider-neutral model reply, shaped after Claude's
+// messages API.
+type ProviderResponse struct {
+	Provider string         `json:"provider"`
+	Model    string         `json:"model"`
+	Content  []ContentBlock `json:"content"`
+	Usage    Usage          `json:"usage"`
+	// Set when the call timed out and only the text received so far was
+	// recovered
+	Partial bool `json:"partial"`
+}
+
+// StatusError reports a non-200 response from a provider API
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	switch e.StatusCode {
+	case 429:
+		return "rate limit exceeded"
+	case 401:
+		return "invalid API key"
+	default:
+		return fmt.Sprintf("API error: %s", e.Status)
+	}
+}
+
+// IsRetryable reports whether another provider should be tried after err:
+// rate limits, server errors and timeouts
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == 429 || statusErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
@@ -0,0 +1,183 @@
+// internal/services/api_keys.go
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+)
+
+// Prefix on raw API keys so they are recognisable in scripts and secret scanners
+const apiKeyPrefix = "lov_"
+
+// Maximum number of active API keys per user
+const maxAPIKeysPerUser = 20
+
+// last_used_at is only rewritten when older than this, to avoid a write per request
+const apiKeyLastUsedResolution = time.Minute
+
+// Resources an API key can be scoped to. A scope is either "*", a resource
+// name, or "<resource>:read" / "<resource>:write".
+var apiKeyResources = []string{"projects", "ai", "export", "analytics"}
+
+// HashAPIKey returns the hex SHA-256 digest stored for a raw API key
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAllows reports whether scopes grant the given access ("read" or
+// "write") to a resource. "*" covers only the resources in apiKeyResources,
+// never account, billing or admin endpoints.
+func APIKeyAllows(scopes []string, resource, access string) bool {
+	scopable := false
+	for _, r := range apiKeyResources {
+		if r == resource {
+			scopable = true
+			break
+		}
+	}
+	if !scopable {
+		return false
+	}
+
+	for _, scope := range scopes {
+		if scope == "*" || scope == resource || scope == resource+":"+access {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AuthService) CreateAPIKey(userID uuid.UUID, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error) {
+	scopes, err := normalizeAPIKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.UserAPIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, errors.New("API key limit reached")
+	}
+
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	rawKey := apiKeyPrefix + hex.EncodeToString(randomBytes)
+
+	apiKey := models.UserAPIKey{
+		UserID:  userID,
+		KeyHash: HashAPIKey(rawKey),
+		Label:   strings.TrimSpace(req.Label),
+		Scopes:  scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+
+	return &models.CreateAPIKeyResponse{
+		Key:    rawKey,
+		APIKey: &apiKey,
+	}, nil
+}
+
+func (s *AuthService) ListAPIKeys(userID uuid.UUID) ([]models.UserAPIKey, error) {
+	var keys []models.UserAPIKey
+	if err := s.dbRouter.Reader().Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (s *AuthService) RevokeAPIKey(userID, keyID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.UserAPIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("API key not found")
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey resolves a raw API key to its record and owner. Lookups
+// go to the primary so revoked keys stop working immediately.
+func (s *AuthService) AuthenticateAPIKey(rawKey string) (*models.UserAPIKey, *models.User, error) {
+	var apiKey models.UserAPIKey
+	if err := s.db.Where("key_hash = ?", HashAPIKey(rawKey)).First(&apiKey).Error; err != nil {
+		return nil, nil, errors.New("invalid API key")
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return nil, nil, errors.New("API key expired")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", apiKey.UserID).Error; err != nil {
+		return nil, nil, errors.New("invalid API key")
+	}
+	if !user.IsActive {
+		return nil, nil, errors.New("account is disabled")
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedResolution {
+		s.db.Model(&apiKey).Update("last_used_at", now)
+	}
+
+	return &apiKey, &user, nil
+}
+
+func normalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !validAPIKeyScope(scope) {
+			return nil, fmt.Errorf("invalid scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+
+	return normalized, nil
+}
+
+func validAPIKeyScope(scope string) bool {
+	if scope == "*" {
+		return true
+	}
+
+	resource, access, hasAccess := strings.Cut(scope, ":")
+	if hasAccess && access != "read" && access != "write" {
+		return false
+	}
+	for _, r := range apiKeyResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
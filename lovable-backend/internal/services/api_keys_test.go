@@ -0,0 +1,34 @@
+// internal/services/api_keys_test.go
+package services
+
+import "testing"
+
+func TestAPIKeyAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		resource string
+		access   string
+		want     bool
+	}{
+		{"wildcard covers projects", []string{"*"}, "projects", "write", true},
+		{"wildcard covers ai", []string{"*"}, "ai", "read", true},
+		{"wildcard excludes auth", []string{"*"}, "auth", "write", false},
+		{"wildcard excludes admin", []string{"*"}, "admin", "read", false},
+		{"wildcard excludes webhooks", []string{"*"}, "webhooks", "write", false},
+		{"resource scope", []string{"export"}, "export", "write", true},
+		{"read scope allows read", []string{"projects:read"}, "projects", "read", true},
+		{"read scope denies write", []string{"projects:read"}, "projects", "write", false},
+		{"other resource", []string{"ai"}, "projects", "read", false},
+		{"auth scope is never honoured", []string{"auth"}, "auth", "read", false},
+		{"no scopes", nil, "projects", "read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := APIKeyAllows(tt.scopes, tt.resource, tt.access); got != tt.want {
+				t.Errorf("APIKeyAllows(%v, %q, %q) = %v, want %v", tt.scopes, tt.resource, tt.access, got, tt.want)
+			}
+		})
+	}
+}
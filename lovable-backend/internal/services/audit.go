@@ -0,0 +1,161 @@
+// internal/services/audit.go
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// Longest date range one audit log export may cover
+const maxAuditExportRange = 90 * 24 * time.Hour
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{
+		db: db,
+	}
+}
+
+// Record stores an audit entry. Failures are returned but callers generally
+// should not fail the audited action because of them.
+func (s *AuditService) Record(userID uuid.UUID, action, ipAddress, userAgent string) error {
+	entry := models.AuditLog{
+		UserID:    userID,
+		Action:    action,
+		IPAddress: &ipAddress,
+		UserAgent: &userAgent,
+	}
+
+	return s.db.Create(&entry).Error
+}
+
+// RecordAdminAction stores an entry in the admin audit log. Like Record, a
+// failure should not fail the action itself.
+func (s *AuditService) RecordAdminAction(adminID uuid.UUID, action string, targetID *uuid.UUID, details map[string]interface{}) error {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	entry := models.AdminAuditLog{
+		AdminUserID: adminID,
+		Action:      action,
+		TargetID:    targetID,
+		Details:     data,
+	}
+
+	return s.db.Create(&entry).Error
+}
+
+// ExportLogs serialises audit entries created in [from, to) as gzip-compressed
+// CSV or NDJSON. A nil userID exports entries for all users. The range may
+// span at most maxAuditExportRange, and entries are streamed from the
+// database rather than loaded all at once.
+func (s *AuditService) ExportLogs(userID *uuid.UUID, from, to time.Time, format string) ([]byte, string, error) {
+	if format != "csv" && format != "json" {
+		return nil, "", errors.New("unsupported export format")
+	}
+
+	if !to.After(from) {
+		return nil, "", errors.New("invalid date range")
+	}
+	if to.Sub(from) > maxAuditExportRange {
+		return nil, "", errors.New("date range exceeds 90 days")
+	}
+
+	query := s.db.Model(&models.AuditLog{}).Where("created_at >= ? AND created_at < ?", from, to)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	rows, err := query.Order("created_at ASC").Rows()
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+
+	var extension string
+	switch format {
+	case "csv":
+		extension = "csv"
+		writer := csv.NewWriter(gz)
+		writer.Write([]string{"id", "user_id", "action", "ip_address", "user_agent", "created_at"})
+		for rows.Next() {
+			var entry models.AuditLog
+			if err := s.db.ScanRows(rows, &entry); err != nil {
+				return nil, "", err
+			}
+			writer.Write([]string{
+				entry.ID.String(),
+				entry.UserID.String(),
+				csvSafe(entry.Action),
+				csvSafe(stringValue(entry.IPAddress)),
+				csvSafe(stringValue(entry.UserAgent)),
+				entry.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	case "json":
+		// Newline-delimited JSON, one entry per line
+		extension = "ndjson"
+		encoder := json.NewEncoder(gz)
+		for rows.Next() {
+			var entry models.AuditLog
+			if err := s.db.ScanRows(rows, &entry); err != nil {
+				return nil, "", err
+			}
+			if err := encoder.Encode(entry); err != nil {
+				return nil, "", fmt.Errorf("failed to write JSON: %w", err)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to compress export: %w", err)
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.%s.gz", from.Format("2006-01-02"), extension)
+	return buf.Bytes(), filename, nil
+}
+
+// csvSafe prefixes values that spreadsheets would evaluate as a formula with a
+// quote, so a crafted user agent can't run as one when the export is opened
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,29 @@
+// internal/services/audit_test.go
+package services
+
+import "testing"
+
+func TestCSVSafe(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"login", "login"},
+		{"", ""},
+		{"203.0.113.7", "203.0.113.7"},
+		{"Mozilla/5.0 (X11; Linux x86_64)", "Mozilla/5.0 (X11; Linux x86_64)"},
+		{"=HYPERLINK(\"http://evil.example\")", "'=HYPERLINK(\"http://evil.example\")"},
+		{"+1+cmd|' /C calc'!A0", "'+1+cmd|' /C calc'!A0"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1", "'\t=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := csvSafe(tt.value); got != tt.want {
+				t.Errorf("csvSafe(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
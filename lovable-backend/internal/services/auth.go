@@ -2,8 +2,13 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,14 +17,22 @@ import (
 	"gorm.io/gorm"
 
 	"lovable-backend/internal/config"
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/metrics"
 	"lovable-backend/internal/models"
 	"lovable-backend/internal/redis"
+	"lovable-backend/pkg/logger"
 )
 
 type AuthService struct {
-	db          *gorm.DB
-	redisClient *redis.Client
-	jwtConfig   config.JWTConfig
+	db                 *gorm.DB // primary
+	dbRouter           *database.DBRouter
+	redisClient        *redis.Client
+	jwtConfig          config.JWTConfig
+	subscriptionConfig config.SubscriptionConfig
+	securityConfig     config.SecurityConfig
+	emailService       EmailSender
+	logger             *logger.Logger
 }
 
 type JWTClaims struct {
@@ -27,24 +40,37 @@ type JWTClaims struct {
 	Email            string    `json:"email"`
 	Name             *string   `json:"name"`
 	SubscriptionPlan string    `json:"subscription_plan"`
-	Type             string    `json:"type"` // "access" or "refresh"
+	Type             string    `json:"type"`           // "access", "refresh", "guest" or "mfa"
+	Role             string    `json:"role,omitempty"` // "admin" on administrators' access tokens
 	jwt.RegisteredClaims
 }
 
+// SessionData describes one signed-in device, stored under its access
+// token's jti in the user's sessions hash
 type SessionData struct {
 	UserID    uuid.UUID `json:"user_id"`
+	TokenID   string    `json:"token_id"`
 	Email     string    `json:"email"`
 	Name      *string   `json:"name"`
 	LoginTime time.Time `json:"login_time"`
+	ExpiresAt time.Time `json:"expires_at"`
 	IPAddress string    `json:"ip_address"`
 	UserAgent string    `json:"user_agent"`
 }
 
-func NewAuthService(db *gorm.DB, redisClient *redis.Client, jwtConfig config.JWTConfig) *AuthService {
+// How long a login IP address is remembered before it counts as a new device again
+const knownIPRetention = 30 * 24 * time.Hour
+
+func NewAuthService(dbRouter *database.DBRouter, redisClient *redis.Client, jwtConfig config.JWTConfig, subscriptionConfig config.SubscriptionConfig, securityConfig config.SecurityConfig, emailService EmailSender, logger *logger.Logger) *AuthService {
 	return &AuthService{
-		db:          db,
-		redisClient: redisClient,
-		jwtConfig:   jwtConfig,
+		db:                 dbRouter.Writer(),
+		dbRouter:           dbRouter,
+		redisClient:        redisClient,
+		jwtConfig:          jwtConfig,
+		subscriptionConfig: subscriptionConfig,
+		securityConfig:     securityConfig,
+		emailService:       emailService,
+		logger:             logger,
 	}
 }
 
@@ -61,7 +87,7 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -77,15 +103,15 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(&user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	// A failed email shouldn't fail signup; the user can request another one
+	if err := s.SendVerificationEmail(&user); err != nil {
+		s.logger.Error("Failed to send verification email", "userId", user.ID, "error", err)
 	}
 
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	// Generate tokens
+	accessToken, refreshToken, err := s.generateTokenPair(&user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
 	}
 
 	return &models.AuthResponse{
@@ -105,9 +131,14 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthRespons
 	}, nil
 }
 
-func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
+func (s *AuthService) Login(req *models.LoginRequest, ipAddress, userAgent string) (*models.AuthResponse, error) {
+	if err := s.checkLockout(req.Email); err != nil {
+		return nil, err
+	}
+
 	var user models.User
 	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		s.recordLoginAttempt(req.Email, ipAddress, false)
 		return nil, errors.New("invalid email or password")
 	}
 
@@ -118,23 +149,38 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, err
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		s.recordLoginAttempt(req.Email, ipAddress, false)
 		return nil, errors.New("invalid email or password")
 	}
 
+	s.recordLoginAttempt(req.Email, ipAddress, true)
+
+	// Upgrade hashes created with a lower cost while the plaintext is at hand
+	if cost, err := bcrypt.Cost([]byte(user.PasswordHash)); err == nil && cost < s.securityConfig.BCryptCost {
+		go s.rehashPassword(user.ID, user.PasswordHash, req.Password)
+	}
+
+	// Accounts with two-factor authentication finish logging in at /auth/mfa/verify
+	if s.HasMFAEnabled(user.ID) {
+		return s.mfaChallenge(user.ID)
+	}
+
+	return s.completeLogin(&user, ipAddress, userAgent)
+}
+
+// completeLogin records the login and issues the access/refresh token pair
+func (s *AuthService) completeLogin(user *models.User, ipAddress, userAgent string) (*models.AuthResponse, error) {
 	// Update last login
 	now := time.Now()
 	user.LastLoginAt = &now
-	s.db.Model(&user).Update("last_login_at", now)
+	s.db.Model(user).Update("last_login_at", now)
 
-	// Generate tokens
-	accessToken, err := s.generateAccessToken(&user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
-	}
+	s.checkNewDeviceLogin(user, ipAddress, userAgent, now)
 
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	// Generate tokens
+	accessToken, refreshToken, err := s.generateTokenPair(user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		return nil, err
 	}
 
 	// Get project count
@@ -166,12 +212,56 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, err
 	}, nil
 }
 
+// checkNewDeviceLogin records the login IP and alerts the user when it has not
+// been seen in the last 30 days. It never blocks the login.
+func (s *AuthService) checkNewDeviceLogin(user *models.User, ipAddress, userAgent string, now time.Time) {
+	if s.redisClient == nil || ipAddress == "" {
+		return
+	}
+
+	key := fmt.Sprintf("user_ips:%s", user.ID)
+
+	// Forget addresses that have not been used within the retention window
+	cutoff := now.Add(-knownIPRetention).Unix()
+	s.redisClient.ZRemRangeByScore(key, "-inf", fmt.Sprintf("(%d", cutoff))
+
+	_, err := s.redisClient.ZScore(key, ipAddress)
+	seen := err == nil
+
+	// The first recorded login has nothing to compare against
+	known, _ := s.redisClient.ZCard(key)
+	isNewDevice := !seen && known > 0
+
+	s.redisClient.ZAdd(key, float64(now.Unix()), ipAddress)
+	s.redisClient.SetTTL(key, knownIPRetention)
+
+	if !isNewDevice {
+		return
+	}
+
+	metrics.SuspiciousLoginsTotal.Inc()
+	s.logger.LogSecurityEvent("new_device_login", user.ID.String(), ipAddress, map[string]any{
+		"userAgent": userAgent,
+	})
+
+	// No IP geolocation is available, so the location is reported as unknown
+	if err := s.emailService.SendNewLoginAlert(user.Email, ipAddress, userAgent, "Unknown"); err != nil {
+		s.logger.Error("Failed to send new login alert", "userID", user.ID, "error", err)
+	}
+}
+
 func (s *AuthService) RefreshToken(req *models.RefreshTokenRequest) (*models.AuthResponse, error) {
 	claims, err := s.validateRefreshToken(req.RefreshToken)
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
+	// Refresh tokens share their session's jti, so they die with a revoked
+	// session, a logout or a revocation of all the user's tokens
+	if s.IsTokenRevoked(claims) {
+		return nil, errors.New("refresh token has been revoked")
+	}
+
 	var user models.User
 	if err := s.db.First(&user, "id = ?", claims.UserID).Error; err != nil {
 		return nil, errors.New("user not found")
@@ -182,15 +272,16 @@ func (s *AuthService) RefreshToken(req *models.RefreshTokenRequest) (*models.Aut
 	}
 
 	// Generate new tokens
-	accessToken, err := s.generateAccessToken(&user)
+	accessToken, newRefreshToken, err := s.generateTokenPair(&user)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+		return nil, err
 	}
 
-	newRefreshToken, err := s.generateRefreshToken(user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	// The new pair replaces the old session, whose tokens can't be used again
+	if err := s.RevokeSessionToken(claims.ID); err != nil {
+		return nil, err
 	}
+	s.RemoveSession(user.ID, claims.ID)
 
 	return &models.AuthResponse{
 		Message:      "Token refreshed successfully",
@@ -200,6 +291,127 @@ func (s *AuthService) RefreshToken(req *models.RefreshTokenRequest) (*models.Aut
 	}, nil
 }
 
+// CreateGuestUser creates a temporary account for anonymous generation and
+// returns it with a guest token that can later be used to claim its projects.
+func (s *AuthService) CreateGuestUser() (*models.User, string, error) {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, "", fmt.Errorf("failed to generate guest credentials: %w", err)
+	}
+
+	// Guests never log in with a password, so store an unusable hash
+	hashedPassword, err := bcrypt.GenerateFromPassword(randomBytes[:16], bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := models.User{
+		Email:        fmt.Sprintf("guest-%s@guest.local", hex.EncodeToString(randomBytes[16:])),
+		PasswordHash: string(hashedPassword),
+		IsGuest:      true,
+	}
+
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create guest user: %w", err)
+	}
+
+	guestToken, err := s.generateGuestToken(user.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate guest token: %w", err)
+	}
+
+	return &user, guestToken, nil
+}
+
+// ClaimGuest moves every project and conversation owned by the guest account
+// behind guestToken to the given user, then removes the guest account.
+func (s *AuthService) ClaimGuest(userID uuid.UUID, guestToken string) (int64, error) {
+	claims, err := s.validateGuestToken(guestToken)
+	if err != nil {
+		return 0, errors.New("invalid guest token")
+	}
+
+	if claims.UserID == userID {
+		return 0, errors.New("invalid guest token")
+	}
+
+	var claimed int64
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var guest models.User
+		if err := tx.First(&guest, "id = ? AND is_guest = ?", claims.UserID, true).Error; err != nil {
+			return errors.New("guest account not found")
+		}
+
+		result := tx.Model(&models.Project{}).Where("user_id = ?", guest.ID).Update("user_id", userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected
+
+		if err := tx.Model(&models.Conversation{}).Where("user_id = ?", guest.ID).Update("user_id", userID).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&guest).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return claimed, nil
+}
+
+// PurgeGuestAccounts permanently deletes guest accounts, and any projects they
+// still own, created before the given time.
+func (s *AuthService) PurgeGuestAccounts(before time.Time) (int64, error) {
+	var guestIDs []uuid.UUID
+	if err := s.db.Unscoped().Model(&models.User{}).Where("is_guest = ? AND created_at < ?", true, before).
+		Pluck("id", &guestIDs).Error; err != nil {
+		return 0, err
+	}
+
+	if len(guestIDs) == 0 {
+		return 0, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id IN ?", guestIDs).Delete(&models.Conversation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id IN ?", guestIDs).Delete(&models.Project{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", guestIDs).Delete(&models.User{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(guestIDs)), nil
+}
+
+// StartGuestPurgeWorker removes expired guest accounts once a day until the
+// context is cancelled.
+func (s *AuthService) StartGuestPurgeWorker(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		// Guest tokens expire after 24 hours, after which projects can no longer be claimed
+		if purged, err := s.PurgeGuestAccounts(time.Now().Add(-24 * time.Hour)); err != nil {
+			log.Error("Guest purge failed", "error", err)
+		} else if purged > 0 {
+			log.Info("Purged guest accounts", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.jwtConfig.Secret), nil
@@ -209,7 +421,8 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	// MFA tokens only prove the password step and can't be used as access tokens
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.Type != "mfa" {
 		return claims, nil
 	}
 
@@ -218,7 +431,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 
 func (s *AuthService) GetUserByID(userID uuid.UUID) (*models.User, error) {
 	var user models.User
-	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+	if err := s.dbRouter.Reader().First(&user, "id = ?", userID).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -237,6 +450,15 @@ func (s *AuthService) UpdateProfile(userID uuid.UUID, req *models.UpdateProfileR
 	if req.AvatarURL != nil {
 		updates["avatar_url"] = *req.AvatarURL
 	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			return nil, errors.New("invalid timezone")
+		}
+		updates["timezone"] = *req.Timezone
+	}
+	if req.NotificationEmailEnabled != nil {
+		updates["notification_email_enabled"] = *req.NotificationEmailEnabled
+	}
 
 	if len(updates) > 0 {
 		if err := s.db.Model(&user).Updates(updates).Error; err != nil {
@@ -263,13 +485,54 @@ func (s *AuthService) ChangePassword(userID uuid.UUID, req *models.ChangePasswor
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.hashPassword(req.NewPassword)
 	if err != nil {
 		return fmt.Errorf("failed to hash new password: %w", err)
 	}
 
 	// Update password
-	return s.db.Model(&user).Update("password_hash", string(hashedPassword)).Error
+	if err := s.db.Model(&user).Update("password_hash", string(hashedPassword)).Error; err != nil {
+		return err
+	}
+
+	// Tokens issued before the change stop working, so a session opened with
+	// the old password can't outlive it
+	if err := s.RevokeUserTokens(userID); err != nil {
+		s.logger.Error("Failed to revoke tokens after password change", "userId", userID, "error", err)
+	}
+	if err := s.DeleteSession(userID); err != nil {
+		s.logger.Error("Failed to clear sessions after password change", "userId", userID, "error", err)
+	}
+
+	return nil
+}
+
+// hashPassword hashes a user's password with the configured bcrypt cost
+func (s *AuthService) hashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), s.securityConfig.BCryptCost)
+}
+
+// rehashPassword replaces oldHash with a hash at the configured cost, unless
+// the password was changed in the meantime
+func (s *AuthService) rehashPassword(userID uuid.UUID, oldHash, password string) {
+	hashedPassword, err := s.hashPassword(password)
+	if err != nil {
+		s.logger.Error("Failed to rehash password", "userId", userID, "error", err)
+		return
+	}
+
+	result := s.db.Model(&models.User{}).
+		Where("id = ? AND password_hash = ?", userID, oldHash).
+		Update("password_hash", string(hashedPassword))
+	if result.Error != nil {
+		s.logger.Error("Failed to store rehashed password", "userId", userID, "error", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	s.logger.Info("Password rehashed with updated cost", "userId", userID, "cost", s.securityConfig.BCryptCost)
 }
 
 func (s *AuthService) CheckUsageLimit(userID uuid.UUID, subscriptionPlan string) (bool, *models.APIUsageInfo, error) {
@@ -279,6 +542,14 @@ func (s *AuthService) CheckUsageLimit(userID uuid.UUID, subscriptionPlan string)
 		"premium": 500,
 	}
 
+	// Prefer the stored plan so scheduled downgrades apply only once their
+	// grace period has passed, regardless of the plan embedded in the token
+	var user models.User
+	if err := s.db.Select("subscription_plan", "pending_plan", "plan_downgrade_at").
+		First(&user, "id = ?", userID).Error; err == nil {
+		subscriptionPlan = effectivePlan(&user, time.Now())
+	}
+
 	dailyLimit := limits[subscriptionPlan]
 	if dailyLimit == 0 {
 		dailyLimit = limits["free"]
@@ -307,6 +578,30 @@ func (s *AuthService) CheckUsageLimit(userID uuid.UUID, subscriptionPlan string)
 	return dailyUsage < int64(dailyLimit), usageInfo, nil
 }
 
+func (s *AuthService) IsExportFormatAllowed(subscriptionPlan, format string) bool {
+	allowedFormats, exists := s.subscriptionConfig.ExportLimits.AllowedFormats[subscriptionPlan]
+	if !exists {
+		allowedFormats = s.subscriptionConfig.ExportLimits.AllowedFormats["free"]
+	}
+
+	for _, allowed := range allowedFormats {
+		if allowed == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// effectivePlan returns the plan a user is entitled to at the given time,
+// taking any pending downgrade into account.
+func effectivePlan(user *models.User, now time.Time) string {
+	if user.PendingPlan != nil && user.PlanDowngradeAt != nil && !now.Before(*user.PlanDowngradeAt) {
+		return *user.PendingPlan
+	}
+	return user.SubscriptionPlan
+}
+
 func (s *AuthService) IncrementUsage(userID uuid.UUID) error {
 	// Increment in database
 	if err := s.db.Model(&models.User{}).Where("id = ?", userID).
@@ -328,25 +623,294 @@ func (s *AuthService) IncrementUsage(userID uuid.UUID) error {
 	return nil
 }
 
-func (s *AuthService) SetSession(userID uuid.UUID, sessionData *SessionData) error {
+// Number of jti characters shown to users to identify a session
+const sessionIDLength = 8
+
+func sessionsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("sessions:%s", userID.String())
+}
+
+// SetSession records the session of a newly issued access token. The user,
+// token ID and expiry are taken from the token itself.
+func (s *AuthService) SetSession(accessToken string, sessionData *SessionData) error {
 	if s.redisClient == nil {
 		return nil
 	}
 
-	sessionKey := fmt.Sprintf("session:%s", userID.String())
-	return s.redisClient.Set(sessionKey, sessionData, 24*time.Hour)
+	claims, err := s.ValidateToken(accessToken)
+	if err != nil {
+		return err
+	}
+
+	sessionData.UserID = claims.UserID
+	sessionData.TokenID = claims.ID
+	if claims.ExpiresAt != nil {
+		sessionData.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	key := sessionsKey(claims.UserID)
+	if err := s.redisClient.HSet(key, claims.ID, sessionData); err != nil {
+		return err
+	}
+	return s.redisClient.SetTTL(key, s.sessionTTL())
 }
 
+// TouchSession keeps the user's sessions hash alive while it is in use
+func (s *AuthService) TouchSession(userID uuid.UUID) {
+	if s.redisClient == nil {
+		return
+	}
+
+	s.redisClient.SetTTL(sessionsKey(userID), s.sessionTTL())
+}
+
+func (s *AuthService) sessionTTL() time.Duration {
+	return time.Duration(s.jwtConfig.ExpirationHours) * time.Hour
+}
+
+// ListSessions returns the user's sessions whose tokens are still valid,
+// newest first. Expired and revoked entries are dropped from the hash.
+func (s *AuthService) ListSessions(userID uuid.UUID, currentTokenID string) ([]models.SessionInfo, error) {
+	sessions, err := s.activeSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]models.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, models.SessionInfo{
+			ID:        maskTokenID(session.TokenID),
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			LoginTime: session.LoginTime,
+			ExpiresAt: session.ExpiresAt,
+			Current:   session.TokenID == currentTokenID,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LoginTime.After(infos[j].LoginTime)
+	})
+
+	return infos, nil
+}
+
+// RevokeSession blacklists the token of the session whose masked ID is
+// sessionID and forgets the session
+func (s *AuthService) RevokeSession(userID uuid.UUID, sessionID string) error {
+	sessions, err := s.activeSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	var match *SessionData
+	for i := range sessions {
+		if maskTokenID(sessions[i].TokenID) != sessionID {
+			continue
+		}
+		if match != nil {
+			return errors.New("session ID is ambiguous")
+		}
+		match = &sessions[i]
+	}
+	if match == nil {
+		return errors.New("session not found")
+	}
+
+	return s.revokeSessions(userID, []SessionData{*match})
+}
+
+// RevokeOtherSessions signs the user out of every session except the one
+// using currentTokenID, returning how many were revoked
+func (s *AuthService) RevokeOtherSessions(userID uuid.UUID, currentTokenID string) (int, error) {
+	sessions, err := s.activeSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	others := make([]SessionData, 0, len(sessions))
+	for _, session := range sessions {
+		if session.TokenID != currentTokenID {
+			others = append(others, session)
+		}
+	}
+
+	if err := s.revokeSessions(userID, others); err != nil {
+		return 0, err
+	}
+	return len(others), nil
+}
+
+// RemoveSession forgets one session, as on logout
+func (s *AuthService) RemoveSession(userID uuid.UUID, tokenID string) error {
+	if s.redisClient == nil || tokenID == "" {
+		return nil
+	}
+
+	return s.redisClient.HDel(sessionsKey(userID), tokenID)
+}
+
+// DeleteSession forgets all of the user's sessions
 func (s *AuthService) DeleteSession(userID uuid.UUID) error {
 	if s.redisClient == nil {
 		return nil
 	}
 
-	sessionKey := fmt.Sprintf("session:%s", userID.String())
-	return s.redisClient.Del(sessionKey)
+	return s.redisClient.Del(sessionsKey(userID))
+}
+
+func (s *AuthService) activeSessions(userID uuid.UUID) ([]SessionData, error) {
+	if s.redisClient == nil {
+		return nil, errors.New("session tracking is unavailable")
+	}
+
+	key := sessionsKey(userID)
+	fields, err := s.redisClient.HGetAll(key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := make([]SessionData, 0, len(fields))
+	var stale []string
+	for tokenID, value := range fields {
+		var session SessionData
+		if err := json.Unmarshal([]byte(value), &session); err != nil || now.After(session.ExpiresAt) || s.IsTokenBlacklisted(tokenID) {
+			stale = append(stale, tokenID)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(stale) > 0 {
+		s.redisClient.HDel(key, stale...)
+	}
+
+	return sessions, nil
+}
+
+func (s *AuthService) revokeSessions(userID uuid.UUID, sessions []SessionData) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	tokenIDs := make([]string, len(sessions))
+	for i, session := range sessions {
+		if err := s.RevokeSessionToken(session.TokenID); err != nil {
+			return err
+		}
+		tokenIDs[i] = session.TokenID
+	}
+
+	return s.redisClient.HDel(sessionsKey(userID), tokenIDs...)
+}
+
+func maskTokenID(tokenID string) string {
+	if len(tokenID) <= sessionIDLength {
+		return tokenID
+	}
+	return tokenID[:sessionIDLength]
+}
+
+// BlacklistToken revokes an access token by its jti until it would have
+// expired anyway.
+func (s *AuthService) BlacklistToken(tokenID string, expiry time.Duration) error {
+	if tokenID == "" || expiry <= 0 {
+		return nil
+	}
+	if s.redisClient == nil {
+		s.logger.Warn("Redis unavailable, token not blacklisted", "tokenId", tokenID)
+		return nil
+	}
+
+	return s.redisClient.Set("blacklist:"+tokenID, true, expiry)
+}
+
+// RevokeSessionToken blacklists a session's jti, which its access and refresh
+// tokens share, until the refresh token would have expired
+func (s *AuthService) RevokeSessionToken(tokenID string) error {
+	return s.BlacklistToken(tokenID, s.refreshTokenTTL())
+}
+
+// IsTokenBlacklisted reports whether a token was revoked. Without Redis the
+// blacklist can't be consulted, so tokens are accepted until they expire
+// rather than failing every authenticated request.
+func (s *AuthService) IsTokenBlacklisted(tokenID string) bool {
+	if tokenID == "" {
+		return false
+	}
+	if s.redisClient == nil || s.redisClient.Client == nil {
+		s.logger.Warn("Redis unavailable, skipping token blacklist check")
+		return false
+	}
+
+	count, err := s.redisClient.Client.Exists(s.redisClient.Ctx, "blacklist:"+tokenID).Result()
+	if err != nil {
+		s.logger.Warn("Token blacklist check failed, allowing request", "error", err)
+		return false
+	}
+
+	return count > 0
+}
+
+// RevokeUserTokens revokes every access and refresh token issued to the user
+// so far, for as long as the newest of them could still be valid
+func (s *AuthService) RevokeUserTokens(userID uuid.UUID) error {
+	if s.redisClient == nil {
+		s.logger.Warn("Redis unavailable, user tokens not revoked", "userId", userID)
+		return nil
+	}
+
+	ttl := s.refreshTokenTTL()
+	if accessTTL := time.Duration(s.jwtConfig.ExpirationHours) * time.Hour; accessTTL > ttl {
+		ttl = accessTTL
+	}
+
+	return s.redisClient.Set("revoked:user:"+userID.String(), time.Now().Unix(), ttl)
+}
+
+func (s *AuthService) refreshTokenTTL() time.Duration {
+	return time.Duration(s.jwtConfig.RefreshExpirationDays) * 24 * time.Hour
+}
+
+// IsTokenRevoked reports whether a token was blacklisted on its own or issued
+// before all of its user's tokens were revoked
+func (s *AuthService) IsTokenRevoked(claims *JWTClaims) bool {
+	if s.IsTokenBlacklisted(claims.ID) {
+		return true
+	}
+	if s.redisClient == nil || s.redisClient.Client == nil || claims.IssuedAt == nil {
+		return false
+	}
+
+	var revokedAt int64
+	if err := s.redisClient.Get("revoked:user:"+claims.UserID.String(), &revokedAt); err != nil {
+		return false
+	}
+
+	return claims.IssuedAt.Unix() <= revokedAt
 }
 
-func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
+// generateTokenPair issues an access and a refresh token for a new session.
+// Both carry the session's ID as their jti, so revoking the session revokes
+// both.
+func (s *AuthService) generateTokenPair(user *models.User) (string, string, error) {
+	sessionID := uuid.New().String()
+
+	accessToken, err := s.generateAccessToken(user, sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	refreshToken, err := s.generateRefreshToken(user.ID, sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+func (s *AuthService) generateAccessToken(user *models.User, tokenID string) (string, error) {
 	claims := JWTClaims{
 		UserID:           user.ID,
 		Email:            user.Email,
@@ -358,22 +922,27 @@ func (s *AuthService) generateAccessToken(user *models.User) (string, error) {
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "lovable-backend",
 			Subject:   user.ID.String(),
+			ID:        tokenID, // jti, used for blacklisting
 		},
 	}
+	if user.IsAdmin {
+		claims.Role = "admin"
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtConfig.Secret))
 }
 
-func (s *AuthService) generateRefreshToken(userID uuid.UUID) (string, error) {
+func (s *AuthService) generateRefreshToken(userID uuid.UUID, tokenID string) (string, error) {
 	claims := JWTClaims{
 		UserID: userID,
 		Type:   "refresh",
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.jwtConfig.RefreshExpirationDays) * 24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.refreshTokenTTL())),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "lovable-backend",
 			Subject:   userID.String(),
+			ID:        tokenID, // the session's jti, shared with its access token
 		},
 	}
 
@@ -386,6 +955,38 @@ func (s *AuthService) generateRefreshToken(userID uuid.UUID) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
+func (s *AuthService) generateGuestToken(userID uuid.UUID) (string, error) {
+	claims := JWTClaims{
+		UserID: userID,
+		Type:   "guest",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "lovable-backend",
+			Subject:   userID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.jwtConfig.GuestSecret))
+}
+
+func (s *AuthService) validateGuestToken(tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtConfig.GuestSecret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.Type == "guest" {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid guest token")
+}
+
 func (s *AuthService) validateRefreshToken(tokenString string) (*JWTClaims, error) {
 	secret := s.jwtConfig.RefreshSecret
 	if secret == "" {
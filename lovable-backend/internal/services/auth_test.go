@@ -0,0 +1,33 @@
+// internal/services/auth_test.go
+package services
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"lovable-backend/internal/config"
+)
+
+func TestHashPasswordUsesConfiguredCost(t *testing.T) {
+	for _, cost := range []int{config.MinBCryptCost, 11, 12} {
+		s := &AuthService{securityConfig: config.SecurityConfig{BCryptCost: cost}}
+
+		hash, err := s.hashPassword("correct horse battery staple")
+		if err != nil {
+			t.Fatalf("cost %d: hashPassword failed: %v", cost, err)
+		}
+
+		got, err := bcrypt.Cost(hash)
+		if err != nil {
+			t.Fatalf("cost %d: bcrypt.Cost failed: %v", cost, err)
+		}
+		if got != cost {
+			t.Errorf("hash cost = %d, want the configured %d", got, cost)
+		}
+
+		if err := bcrypt.CompareHashAndPassword(hash, []byte("correct horse battery staple")); err != nil {
+			t.Errorf("cost %d: hash does not match the password: %v", cost, err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// internal/services/batch_import.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+// Maximum accepted size for batch import archives
+const maxBatchImportSize = 100 << 20
+
+// Maximum size of a single file read from a batch import archive
+const maxBatchImportFileSize = 10 << 20
+
+// Maximum total size of the files read from a batch import archive once
+// decompressed, so a small ZIP bomb can't exhaust memory
+const maxBatchImportUncompressedSize = 200 << 20
+
+// Batch imports still running after this long are marked as timed out
+const batchImportTimeout = 5 * time.Minute
+
+type batchImportFolder struct {
+	name   string
+	html   string
+	css    *string
+	js     *string
+	readme string
+}
+
+// BatchImport restores projects from a ZIP produced by BatchExport. The archive
+// is validated up front and processed in the background; progress is tracked
+// on the returned job.
+func (s *ProjectService) BatchImport(ctx context.Context, userID uuid.UUID, zipData []byte) (*models.BatchImportJob, error) {
+	if len(zipData) > maxBatchImportSize {
+		return nil, errors.New("import file exceeds 100 MB limit")
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, errors.New("import file is not a valid ZIP archive")
+	}
+
+	folders, err := readBatchImportFolders(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(folders) == 0 {
+		return nil, errors.New("no projects found in import file")
+	}
+
+	job := models.BatchImportJob{
+		UserID:     userID,
+		Status:     "running",
+		TotalCount: len(folders),
+		Errors:     []string{},
+	}
+
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	go s.runBatchImport(context.WithoutCancel(ctx), job.ID, userID, folders)
+
+	return &job, nil
+}
+
+// failInterruptedBatchImports marks imports that have been running for longer
+// than any import can as failed. Imports run in the server process, so one
+// still running after a restart will never finish.
+func (s *ProjectService) failInterruptedBatchImports() (int64, error) {
+	result := s.db.Model(&models.BatchImportJob{}).
+		Where("status = ? AND created_at < ?", "running", time.Now().Add(-batchImportTimeout-time.Minute)).
+		Updates(map[string]interface{}{
+			"status":       "failed",
+			"errors":       gorm.Expr("array_append(errors, ?)", "import interrupted by a server restart"),
+			"completed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// StartBatchImportSweepWorker fails imports interrupted by a restart, once at
+// startup and then periodically for imports another instance was running
+func (s *ProjectService) StartBatchImportSweepWorker(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(batchImportTimeout)
+	defer ticker.Stop()
+
+	for {
+		if failed, err := s.failInterruptedBatchImports(); err != nil {
+			log.Error("Failed to fail interrupted batch imports", "error", err)
+		} else if failed > 0 {
+			log.Warn("Failed interrupted batch imports", "count", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ProjectService) GetBatchImportJob(ctx context.Context, userID, jobID uuid.UUID) (*models.BatchImportJob, error) {
+	var job models.BatchImportJob
+	if err := s.db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (s *ProjectService) runBatchImport(ctx context.Context, jobID, userID uuid.UUID, folders []batchImportFolder) {
+	ctx, cancel := context.WithTimeout(ctx, batchImportTimeout)
+	defer cancel()
+
+	completed, failed := 0, 0
+	importErrors := []string{}
+
+	for _, folder := range folders {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := s.importBatchFolder(userID, folder); err != nil {
+			failed++
+			importErrors = append(importErrors, fmt.Sprintf("%s: %v", folder.name, err))
+		} else {
+			completed++
+		}
+
+		s.db.Model(&models.BatchImportJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+			"completed_count": completed,
+			"failed_count":    failed,
+			"errors":          pq.StringArray(importErrors),
+		})
+	}
+
+	status := "completed"
+	if ctx.Err() != nil && completed+failed < len(folders) {
+		status = "timed_out"
+		importErrors = append(importErrors, fmt.Sprintf("import timed out after %s", batchImportTimeout))
+	} else if completed == 0 {
+		status = "failed"
+	}
+
+	now := time.Now()
+	s.db.Model(&models.BatchImportJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"status":       status,
+		"errors":       pq.StringArray(importErrors),
+		"completed_at": now,
+	})
+}
+
+func (s *ProjectService) importBatchFolder(userID uuid.UUID, folder batchImportFolder) error {
+	if err := s.checkProjectLimit(userID); err != nil {
+		return err
+	}
+
+	name, description := parseBatchReadme(folder.readme)
+	if name == "" {
+		name = folder.name
+	}
+
+	html := folder.html
+	project := models.Project{
+		UserID:      userID,
+		Name:        name,
+		Description: description,
+		HTMLCode:    &html,
+		CSSCode:     folder.css,
+		JSCode:      folder.js,
+		Tags:        []string{"imported"},
+	}
+
+	if err := s.insertProject(s.db, &project); err != nil {
+		return err
+	}
+
+	enqueueSearchIndex(s.db, s.redisClient, s.storage, project.ID)
+	return nil
+}
+
+// readBatchImportFolders groups archive entries by top level folder. Folders
+// without an index.html are skipped.
+func readBatchImportFolders(reader *zip.Reader) ([]batchImportFolder, error) {
+	byName := make(map[string]*batchImportFolder)
+	budget := int64(maxBatchImportUncompressedSize)
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		dir, base := path.Split(path.Clean(file.Name))
+		dir = strings.Trim(dir, "/")
+		if dir == "" || strings.Contains(dir, "/") {
+			continue
+		}
+
+		folder, exists := byName[dir]
+		if !exists {
+			folder = &batchImportFolder{name: dir}
+			byName[dir] = folder
+		}
+
+		switch base {
+		case "index.html", "README.md", "styles.css", "script.js":
+		default:
+			continue
+		}
+
+		if file.UncompressedSize64 > maxBatchImportFileSize {
+			return nil, fmt.Errorf("invalid archive entry %s: file too large", file.Name)
+		}
+		// The header's size can't be trusted, so the budget bounds what is read
+		content, err := readZIPFile(file, &budget)
+		if err != nil {
+			if err.Error() == "archive too large" {
+				return nil, errors.New("import file exceeds 200 MB uncompressed")
+			}
+			return nil, fmt.Errorf("invalid archive entry %s: %w", file.Name, err)
+		}
+		if len(content) > maxBatchImportFileSize {
+			return nil, fmt.Errorf("invalid archive entry %s: file too large", file.Name)
+		}
+
+		switch base {
+		case "index.html":
+			folder.html = content
+		case "README.md":
+			folder.readme = content
+		case "styles.css":
+			folder.css = &content
+		case "script.js":
+			folder.js = &content
+		}
+	}
+
+	var folders []batchImportFolder
+	for _, folder := range byName {
+		if strings.TrimSpace(folder.html) != "" {
+			folders = append(folders, *folder)
+		}
+	}
+
+	// Keep the export order, which prefixes folders with their position
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].name < folders[j].name
+	})
+
+	return folders, nil
+}
+
+// parseBatchReadme extracts the project name and description from a README
+// written by generateReadme: a "# Name" heading followed by the description.
+func parseBatchReadme(readme string) (string, *string) {
+	var name string
+	var descriptionLines []string
+
+	for _, line := range strings.Split(readme, "\n") {
+		line = strings.TrimSpace(line)
+
+		if name == "" {
+			if strings.HasPrefix(line, "# ") {
+				name = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "## ") {
+			break
+		}
+		if line != "" {
+			descriptionLines = append(descriptionLines, line)
+		}
+	}
+
+	if len(name) > 255 {
+		name = name[:255]
+	}
+
+	description := strings.Join(descriptionLines, " ")
+	if description == "" || description == "AI-generated website" {
+		return name, nil
+	}
+
+	return name, &description
+}
@@ -0,0 +1,86 @@
+// internal/services/batch_import_test.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadBatchImportFoldersLimitsUncompressedSize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a 200 MB archive")
+	}
+
+	// Each file is within the per-file limit, but together they decompress
+	// past the archive's budget while compressing to well under 1 MB
+	page := strings.Repeat(" ", maxBatchImportFileSize)
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for i := 0; i <= maxBatchImportUncompressedSize/maxBatchImportFileSize; i++ {
+		file, err := writer.Create(fmt.Sprintf("%03d-project/index.html", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(page)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readBatchImportFolders(reader)
+	if err == nil || err.Error() != "import file exceeds 200 MB uncompressed" {
+		t.Errorf("error = %v, want the uncompressed size limit", err)
+	}
+}
+
+func TestReadBatchImportFolders(t *testing.T) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	files := map[string]string{
+		"01-landing/index.html":  "<html>Landing</html>",
+		"01-landing/README.md":   "# Landing\n\nA landing page",
+		"01-landing/styles.css":  "body {}",
+		"02-empty/README.md":     "# Empty",
+		"03-blog/index.html":     "<html>Blog</html>",
+		"nested/deep/index.html": "<html>Ignored</html>",
+	}
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folders, err := readBatchImportFolders(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(folders) != 2 || folders[0].name != "01-landing" || folders[1].name != "03-blog" {
+		t.Fatalf("folders = %v, want 01-landing and 03-blog", folders)
+	}
+	if folders[0].css == nil || *folders[0].css != "body {}" {
+		t.Errorf("styles.css was not read")
+	}
+	if folders[0].readme != files["01-landing/README.md"] {
+		t.Errorf("README.md = %q", folders[0].readme)
+	}
+}
@@ -0,0 +1,209 @@
+// internal/services/billing.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+type BillingService struct {
+	db           *gorm.DB
+	emailService *EmailService
+	config       config.SubscriptionConfig
+	logger       *logger.Logger
+}
+
+type BillingEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID               string            `json:"id"`
+			Customer         string            `json:"customer"`
+			CurrentPeriodEnd int64             `json:"current_period_end"` // unix seconds
+			Metadata         map[string]string `json:"metadata"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+func NewBillingService(db *gorm.DB, emailService *EmailService, config config.SubscriptionConfig, logger *logger.Logger) *BillingService {
+	return &BillingService{
+		db:           db,
+		emailService: emailService,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+func (s *BillingService) HandleWebhook(payload []byte) error {
+	var event BillingEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return errors.New("invalid webhook payload")
+	}
+
+	switch event.Type {
+	case "customer.subscription.created", "customer.subscription.updated":
+		userID, err := uuid.Parse(event.Data.Object.Metadata["user_id"])
+		if err != nil {
+			return errors.New("subscription event missing user_id metadata")
+		}
+		if event.Data.Object.CurrentPeriodEnd == 0 {
+			return nil
+		}
+		return s.db.Model(&models.User{}).Where("id = ?", userID).
+			Update("subscription_renewal_at", time.Unix(event.Data.Object.CurrentPeriodEnd, 0)).Error
+	case "customer.subscription.deleted":
+		userID, err := uuid.Parse(event.Data.Object.Metadata["user_id"])
+		if err != nil {
+			return errors.New("subscription event missing user_id metadata")
+		}
+		if err := s.db.Model(&models.User{}).Where("id = ?", userID).
+			Update("subscription_renewal_at", nil).Error; err != nil {
+			return err
+		}
+		return s.ScheduleDowngrade(userID, "free")
+	default:
+		// Unhandled event types are acknowledged so the provider stops retrying
+		s.logger.Debug("Ignoring billing event", "type", event.Type, "id", event.ID)
+		return nil
+	}
+}
+
+// ScheduleDowngrade records a pending plan change that takes effect after the
+// configured grace period. The user keeps their current plan until then.
+func (s *BillingService) ScheduleDowngrade(userID uuid.UUID, plan string) error {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	if user.SubscriptionPlan == plan {
+		return nil
+	}
+
+	downgradeAt := time.Now().AddDate(0, 0, s.config.DowngradeGraceDays)
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"pending_plan":      plan,
+		"plan_downgrade_at": downgradeAt,
+		"downgrade_warned":  false,
+	}).Error; err != nil {
+		return err
+	}
+
+	s.sendDowngradeWarning(&user, plan, downgradeAt)
+	return nil
+}
+
+// ProcessPendingDowngrades sends final reminders for downgrades due within a
+// day and applies downgrades whose grace period has passed.
+func (s *BillingService) ProcessPendingDowngrades(now time.Time) error {
+	var reminders []models.User
+	if err := s.db.Where("pending_plan IS NOT NULL AND downgrade_warned = ? AND plan_downgrade_at > ? AND plan_downgrade_at <= ?",
+		false, now, now.Add(24*time.Hour)).Find(&reminders).Error; err != nil {
+		return err
+	}
+
+	for _, user := range reminders {
+		s.sendDowngradeWarning(&user, *user.PendingPlan, *user.PlanDowngradeAt)
+		s.db.Model(&user).Update("downgrade_warned", true)
+	}
+
+	var due []models.User
+	if err := s.db.Where("pending_plan IS NOT NULL AND plan_downgrade_at <= ?", now).Find(&due).Error; err != nil {
+		return err
+	}
+
+	for _, user := range due {
+		if err := s.applyDowngrade(&user); err != nil {
+			s.logger.Error("Failed to apply plan downgrade", "userID", user.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// StartDowngradeWorker checks for pending downgrades once a day until the
+// context is cancelled.
+func (s *BillingService) StartDowngradeWorker(ctx context.Context) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := s.ProcessPendingDowngrades(time.Now()); err != nil {
+			s.logger.Error("Downgrade worker run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyDowngrade switches the user to their pending plan and archives the
+// oldest projects that exceed the new plan's project limit. Nothing is deleted.
+func (s *BillingService) applyDowngrade(user *models.User) error {
+	plan := *user.PendingPlan
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"subscription_plan": plan,
+			"pending_plan":      nil,
+			"plan_downgrade_at": nil,
+			"downgrade_warned":  false,
+		}).Error; err != nil {
+			return err
+		}
+
+		limit := projectLimits[plan]
+		if limit == 0 {
+			limit = projectLimits["free"]
+		}
+
+		var activeCount int64
+		if err := tx.Model(&models.Project{}).Where("user_id = ? AND status <> ?", user.ID, "archived").
+			Count(&activeCount).Error; err != nil {
+			return err
+		}
+
+		if activeCount <= limit {
+			return nil
+		}
+
+		var excessIDs []uuid.UUID
+		if err := tx.Model(&models.Project{}).Where("user_id = ? AND status <> ?", user.ID, "archived").
+			Order("created_at ASC").Limit(int(activeCount-limit)).Pluck("id", &excessIDs).Error; err != nil {
+			return err
+		}
+
+		s.logger.Info("Archiving projects over plan limit", "userID", user.ID, "plan", plan, "count", len(excessIDs))
+
+		return tx.Model(&models.Project{}).Where("id IN ?", excessIDs).Update("status", "archived").Error
+	})
+}
+
+func (s *BillingService) sendDowngradeWarning(user *models.User, plan string, downgradeAt time.Time) {
+	subject := fmt.Sprintf("Your plan will change to %s on %s", plan, downgradeAt.Format("January 2, 2006"))
+	body := fmt.Sprintf(`Hi,
+
+Your subscription has ended. Your account will move from the %s plan to the %s plan on %s.
+
+Until then you keep full access to your current plan. Projects above the %s plan limit will be archived, not deleted, and can be restored if you resubscribe.
+
+AI Website Builder`, user.SubscriptionPlan, plan, downgradeAt.Format(time.RFC1123), plan)
+
+	if err := s.emailService.Send(user.Email, subject, body); err != nil {
+		s.logger.Error("Failed to send downgrade warning", "userID", user.ID, "error", err)
+	}
+}
@@ -0,0 +1,165 @@
+// internal/services/circuit_breaker.go
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"lovable-backend/internal/services/aiprovider"
+	"lovable-backend/pkg/logger"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit
+// breaker is open, so the next model of the fallback chain is tried at once
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitBreakerStatus is a snapshot of a breaker's state
+type CircuitBreakerStatus struct {
+	Name            string    `json:"name"`
+	State           string    `json:"state"`
+	Failures        int       `json:"failures"`
+	LastStateChange time.Time `json:"last_state_change"`
+}
+
+// CircuitBreaker stops calls to a failing dependency. After maxFailures
+// consecutive failures it opens and rejects calls for timeout, then lets
+// calls through half-open: successThreshold successes close it again, and
+// any failure reopens it.
+type CircuitBreaker struct {
+	name             string
+	maxFailures      int
+	successThreshold int
+	timeout          time.Duration
+	logger           *logger.Logger
+
+	mu              sync.Mutex
+	state           string
+	failures        int
+	successes       int
+	lastStateChange time.Time
+}
+
+func NewCircuitBreaker(name string, maxFailures, successThreshold int, timeout time.Duration, logger *logger.Logger) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:             name,
+		maxFailures:      maxFailures,
+		successThreshold: successThreshold,
+		timeout:          timeout,
+		logger:           logger,
+		state:            CircuitClosed,
+		lastStateChange:  time.Now(),
+	}
+}
+
+// Allow reports whether a call may be made now, moving an open breaker to
+// half-open once its timeout has passed
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if time.Since(b.lastStateChange) < b.timeout {
+			return false
+		}
+		b.setState(CircuitHalfOpen)
+	}
+	return true
+}
+
+// Record updates the breaker with the outcome of a call
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.maxFailures {
+			b.setState(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if !success {
+			b.failures++
+			b.setState(CircuitOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.successThreshold {
+			b.setState(CircuitClosed)
+		}
+	}
+}
+
+// Reset closes the breaker and clears its failure count
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.setState(CircuitClosed)
+}
+
+func (b *CircuitBreaker) Status() CircuitBreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return CircuitBreakerStatus{
+		Name:            b.name,
+		State:           b.state,
+		Failures:        b.failures,
+		LastStateChange: b.lastStateChange,
+	}
+}
+
+// setState must be called with mu held
+func (b *CircuitBreaker) setState(state string) {
+	if b.state != state {
+		b.logger.Warn("Circuit breaker state changed", "breaker", b.name, "from", b.state, "to", state, "failures", b.failures)
+	}
+
+	b.state = state
+	b.successes = 0
+	if state == CircuitClosed {
+		b.failures = 0
+	}
+	b.lastStateChange = time.Now()
+}
+
+// breakerProvider guards a provider with a circuit breaker
+type breakerProvider struct {
+	provider aiprovider.Provider
+	breaker  *CircuitBreaker
+}
+
+func (p *breakerProvider) Generate(ctx context.Context, messages []Message, opts aiprovider.Options) (*aiprovider.ProviderResponse, error) {
+	if !p.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	response, err := p.provider.Generate(ctx, messages, opts)
+	switch {
+	case err == nil:
+		p.breaker.Record(true)
+	case errors.Is(err, aiprovider.ErrNotConfigured) || ctx.Err() != nil:
+		// Neither an unset API key nor a caller that gave up says anything
+		// about the provider's health
+	default:
+		var statusErr *aiprovider.StatusError
+		if !errors.As(err, &statusErr) || aiprovider.IsRetryable(err) {
+			p.breaker.Record(false)
+		}
+	}
+	return response, err
+}
@@ -0,0 +1,225 @@
+// internal/services/collaboration.go
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/worker"
+	"lovable-backend/pkg/logger"
+)
+
+// Project roles. Owners are the project's UserID; the others are collaborators.
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleEditor = "editor"
+	ProjectRoleViewer = "viewer"
+)
+
+type CollaborationService struct {
+	db           *gorm.DB
+	emailService *EmailService
+	logger       *logger.Logger
+}
+
+func NewCollaborationService(db *gorm.DB, emailService *EmailService, logger *logger.Logger) *CollaborationService {
+	return &CollaborationService{
+		db:           db,
+		emailService: emailService,
+		logger:       logger,
+	}
+}
+
+// InviteCollaborator adds the user with the given email to an owned project
+// and emails them an invitation.
+func (s *CollaborationService) InviteCollaborator(ownerID, projectID uuid.UUID, req *models.InviteCollaboratorRequest) (*models.ProjectCollaborator, error) {
+	var project models.Project
+	if err := s.db.Where("id = ? AND user_id = ?", projectID, ownerID).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	var invitee models.User
+	if err := s.db.Where("email = ? AND is_guest = ?", req.Email, false).First(&invitee).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if invitee.ID == ownerID {
+		return nil, errors.New("cannot invite the project owner")
+	}
+
+	var existing int64
+	s.db.Model(&models.ProjectCollaborator{}).Where("project_id = ? AND user_id = ?", projectID, invitee.ID).Count(&existing)
+	if existing > 0 {
+		return nil, errors.New("user is already a collaborator")
+	}
+
+	collaborator := models.ProjectCollaborator{
+		ProjectID: projectID,
+		UserID:    invitee.ID,
+		Role:      req.Role,
+		InvitedBy: ownerID,
+	}
+	if err := s.db.Create(&collaborator).Error; err != nil {
+		return nil, err
+	}
+	recordProjectActivity(s.db, projectID, ownerID, ActivityCollaboratorAdded, map[string]interface{}{
+		"collaborator_id": invitee.ID,
+		"email":           invitee.Email,
+		"role":            req.Role,
+	})
+
+	var owner models.User
+	inviter := "A collaborator"
+	if err := s.db.Select("email", "name").First(&owner, "id = ?", ownerID).Error; err == nil {
+		inviter = owner.Email
+		if owner.Name != nil && *owner.Name != "" {
+			inviter = *owner.Name
+		}
+	}
+
+	// The invitation stays valid even if the email doesn't go out. It is sent
+	// by the job workers, or directly when it can't be queued.
+	email := s.emailService.CollaborationInviteEmail(invitee.Email, inviter, project.Name, project.ID.String(), req.Role)
+	if _, err := worker.Enqueue(s.db, JobTypeEmail, email); err != nil {
+		s.logger.Error("Failed to queue collaboration invite", "projectId", projectID, "userId", invitee.ID, "error", err)
+		if err := s.emailService.Send(email.To, email.Subject, email.Body); err != nil {
+			s.logger.Error("Failed to send collaboration invite", "projectId", projectID, "userId", invitee.ID, "error", err)
+		}
+	}
+
+	return &collaborator, nil
+}
+
+func (s *CollaborationService) AcceptInvitation(userID, projectID uuid.UUID) error {
+	result := s.db.Model(&models.ProjectCollaborator{}).
+		Where("project_id = ? AND user_id = ? AND accepted_at IS NULL", projectID, userID).
+		Update("accepted_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invitation not found")
+	}
+
+	return nil
+}
+
+// ListCollaborators returns a project's collaborators, including pending
+// invitations. The owner and accepted collaborators may list them.
+func (s *CollaborationService) ListCollaborators(userID, projectID uuid.UUID) ([]models.CollaboratorInfo, error) {
+	var project models.Project
+	if err := s.db.Select("id").Where(accessibleProjectCondition, projectID, userID, userID, []string{ProjectRoleEditor, ProjectRoleViewer}).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	var collaborators []models.CollaboratorInfo
+	err := s.db.Table("project_collaborators").
+		Select("project_collaborators.user_id, users.email, users.name, project_collaborators.role, project_collaborators.invited_by, project_collaborators.accepted_at, project_collaborators.created_at").
+		Joins("JOIN users ON users.id = project_collaborators.user_id").
+		Where("project_collaborators.project_id = ?", projectID).
+		Order("project_collaborators.created_at ASC").
+		Scan(&collaborators).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return collaborators, nil
+}
+
+// RemoveCollaborator revokes a collaborator's access. Owners can remove
+// anyone; collaborators can only remove themselves.
+func (s *CollaborationService) RemoveCollaborator(requesterID, projectID, collaboratorID uuid.UUID) error {
+	if requesterID != collaboratorID {
+		var project models.Project
+		if err := s.db.Select("id").Where("id = ? AND user_id = ?", projectID, requesterID).First(&project).Error; err != nil {
+			return err
+		}
+	}
+
+	result := s.db.Where("project_id = ? AND user_id = ?", projectID, collaboratorID).Delete(&models.ProjectCollaborator{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("collaborator not found")
+	}
+
+	return nil
+}
+
+// Matches a project id the user owns or collaborates on with one of the roles.
+// Arguments: project ID, user ID, user ID, roles.
+const accessibleProjectCondition = "id = ? AND (user_id = ? OR id IN (SELECT project_id FROM project_collaborators WHERE user_id = ? AND accepted_at IS NOT NULL AND role IN ?))"
+
+// findAccessibleProject loads a project the user owns or collaborates on with
+// one of the given roles.
+func (s *ProjectService) findAccessibleProject(db *gorm.DB, userID, projectID uuid.UUID, roles ...string) (*models.Project, error) {
+	var project models.Project
+	if err := db.Where(accessibleProjectCondition, projectID, userID, userID, roles).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+// toProjectInfos converts projects for a listing, tagging each with the
+// caller's role.
+func (s *ProjectService) toProjectInfos(userID uuid.UUID, projects []models.Project) []models.ProjectInfo {
+	var sharedIDs []uuid.UUID
+	for _, p := range projects {
+		if p.UserID != userID {
+			sharedIDs = append(sharedIDs, p.ID)
+		}
+	}
+
+	roles := make(map[uuid.UUID]string, len(sharedIDs))
+	if len(sharedIDs) > 0 {
+		var collaborators []models.ProjectCollaborator
+		s.dbRouter.Reader().Select("project_id", "role").
+			Where("user_id = ? AND project_id IN ?", userID, sharedIDs).
+			Find(&collaborators)
+		for _, collaborator := range collaborators {
+			roles[collaborator.ProjectID] = collaborator.Role
+		}
+	}
+
+	// Collections are private to the owner, so only their own projects show one
+	var collectionIDs []uuid.UUID
+	for _, p := range projects {
+		if p.UserID == userID && p.CollectionID != nil {
+			collectionIDs = append(collectionIDs, *p.CollectionID)
+		}
+	}
+
+	collectionNames := make(map[uuid.UUID]string, len(collectionIDs))
+	if len(collectionIDs) > 0 {
+		var collections []models.ProjectCollection
+		s.dbRouter.Reader().Select("id", "name").
+			Where("user_id = ? AND id IN ?", userID, collectionIDs).
+			Find(&collections)
+		for _, collection := range collections {
+			collectionNames[collection.ID] = collection.Name
+		}
+	}
+
+	projectInfos := make([]models.ProjectInfo, len(projects))
+	for i, p := range projects {
+		projectInfos[i] = toProjectInfo(&p)
+		projectInfos[i].Role = ProjectRoleOwner
+		if p.UserID != userID {
+			projectInfos[i].Role = roles[p.ID]
+			continue
+		}
+		if p.CollectionID != nil {
+			if name, ok := collectionNames[*p.CollectionID]; ok {
+				projectInfos[i].CollectionID = p.CollectionID
+				projectInfos[i].CollectionName = &name
+			}
+		}
+	}
+
+	return projectInfos
+}
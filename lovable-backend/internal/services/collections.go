@@ -0,0 +1,271 @@
+// internal/services/collections.go
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+)
+
+// CollectionService manages the folders users group their projects into.
+// Membership changes use UpdateColumns so filing a project doesn't count as
+// editing it.
+type CollectionService struct {
+	db          *gorm.DB // primary
+	dbRouter    *database.DBRouter
+	redisClient *redis.Client
+}
+
+func NewCollectionService(dbRouter *database.DBRouter, redisClient *redis.Client) *CollectionService {
+	return &CollectionService{
+		db:          dbRouter.Writer(),
+		dbRouter:    dbRouter,
+		redisClient: redisClient,
+	}
+}
+
+func (s *CollectionService) CreateCollection(ctx context.Context, userID uuid.UUID, req *models.CreateCollectionRequest) (*models.ProjectCollection, error) {
+	collection := models.ProjectCollection{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Color:       req.Color,
+		Icon:        req.Icon,
+		SortOrder:   req.SortOrder,
+	}
+
+	if err := s.db.Create(&collection).Error; err != nil {
+		return nil, err
+	}
+
+	return &collection, nil
+}
+
+// ListCollections returns the user's collections in display order, each with
+// the number of projects filed in it
+func (s *CollectionService) ListCollections(ctx context.Context, userID uuid.UUID) ([]models.ProjectCollection, error) {
+	collections := []models.ProjectCollection{}
+	if err := s.dbRouter.Reader().Where("user_id = ?", userID).
+		Order("sort_order, name").
+		Find(&collections).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		CollectionID uuid.UUID
+		Count        int64
+	}
+	if err := s.dbRouter.Reader().Model(&models.Project{}).
+		Select("collection_id, COUNT(*) AS count").
+		Where("user_id = ? AND collection_id IS NOT NULL", userID).
+		Group("collection_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	projectCounts := make(map[uuid.UUID]int64, len(counts))
+	for _, count := range counts {
+		projectCounts[count.CollectionID] = count.Count
+	}
+	for i := range collections {
+		collections[i].ProjectCount = projectCounts[collections[i].ID]
+	}
+
+	return collections, nil
+}
+
+func (s *CollectionService) UpdateCollection(ctx context.Context, userID, collectionID uuid.UUID, req *models.UpdateCollectionRequest) (*models.ProjectCollection, error) {
+	var collection models.ProjectCollection
+	if err := s.db.Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+		return nil, errors.New("collection not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Color != nil {
+		updates["color"] = *req.Color
+	}
+	if req.Icon != nil {
+		updates["icon"] = *req.Icon
+	}
+	if req.SortOrder != nil {
+		updates["sort_order"] = *req.SortOrder
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&collection).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Reload collection
+	if err := s.db.First(&collection, "id = ?", collectionID).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.Project{}).Where("collection_id = ?", collectionID).Count(&collection.ProjectCount).Error; err != nil {
+		return nil, err
+	}
+
+	return &collection, nil
+}
+
+// DeleteCollection removes the collection and moves its projects out of it;
+// the projects themselves are kept
+func (s *CollectionService) DeleteCollection(ctx context.Context, userID, collectionID uuid.UUID) error {
+	var projectIDs []uuid.UUID
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", collectionID, userID).Delete(&models.ProjectCollection{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("collection not found")
+		}
+
+		if err := tx.Model(&models.Project{}).Where("collection_id = ?", collectionID).Pluck("id", &projectIDs).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Project{}).Where("collection_id = ?", collectionID).
+			UpdateColumns(map[string]interface{}{"collection_id": nil, "sort_order": 0}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateProjects(userID, projectIDs)
+	return nil
+}
+
+// AssignProjects files the given projects at the end of the collection, in the
+// order listed, moving them out of any collection they were in. Every project
+// must belong to the user or nothing is changed.
+func (s *CollectionService) AssignProjects(ctx context.Context, userID, collectionID uuid.UUID, projectIDs []uuid.UUID) error {
+	projectIDs = distinctIDs(projectIDs)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var collection models.ProjectCollection
+		if err := tx.Select("id").Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+			return errors.New("collection not found")
+		}
+
+		var lastPosition int
+		if err := tx.Model(&models.Project{}).
+			Select("COALESCE(MAX(sort_order), -1)").
+			Where("collection_id = ?", collectionID).
+			Scan(&lastPosition).Error; err != nil {
+			return err
+		}
+
+		for i, projectID := range projectIDs {
+			result := tx.Model(&models.Project{}).Where("id = ? AND user_id = ?", projectID, userID).
+				UpdateColumns(map[string]interface{}{
+					"collection_id": collectionID,
+					"sort_order":    lastPosition + 1 + i,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("project not found")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateProjects(userID, projectIDs)
+	return nil
+}
+
+// ReorderProjects puts the collection's projects in the given order. Projects
+// left out of the list keep their relative order after the listed ones.
+func (s *CollectionService) ReorderProjects(ctx context.Context, userID, collectionID uuid.UUID, projectIDs []uuid.UUID) error {
+	projectIDs = distinctIDs(projectIDs)
+
+	var memberIDs []uuid.UUID
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var collection models.ProjectCollection
+		if err := tx.Select("id").Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+			return errors.New("collection not found")
+		}
+
+		if err := tx.Model(&models.Project{}).Where("collection_id = ?", collectionID).
+			Order("sort_order, created_at").
+			Pluck("id", &memberIDs).Error; err != nil {
+			return err
+		}
+
+		members := make(map[uuid.UUID]bool, len(memberIDs))
+		for _, id := range memberIDs {
+			members[id] = true
+		}
+
+		order := make([]uuid.UUID, 0, len(memberIDs))
+		listed := make(map[uuid.UUID]bool, len(projectIDs))
+		for _, id := range projectIDs {
+			if !members[id] {
+				return errors.New("project not in collection")
+			}
+			listed[id] = true
+			order = append(order, id)
+		}
+		for _, id := range memberIDs {
+			if !listed[id] {
+				order = append(order, id)
+			}
+		}
+
+		for i, id := range order {
+			if err := tx.Model(&models.Project{}).Where("id = ?", id).UpdateColumn("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateProjects(userID, memberIDs)
+	return nil
+}
+
+// invalidateProjects drops the owner's cached copies of projects whose
+// collection or position changed
+func (s *CollectionService) invalidateProjects(userID uuid.UUID, projectIDs []uuid.UUID) {
+	if s.redisClient == nil {
+		return
+	}
+
+	for _, projectID := range projectIDs {
+		s.redisClient.Del(ResponseCacheKey(ProjectCacheKey(userID, projectID)))
+	}
+}
+
+func distinctIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	distinct := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+	return distinct
+}
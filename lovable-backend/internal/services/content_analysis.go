@@ -0,0 +1,120 @@
+// internal/services/content_analysis.go
+package services
+
+import (
+	"regexp"
+	"strings"
+)
+
+type ContentAnalysis struct {
+	WordCount           int `json:"word_count"`
+	ImageCount          int `json:"image_count"`
+	LinkCount           int `json:"link_count"`
+	SEOScore            int `json:"seo_score"` // 0-100
+	AccessibilityIssues int `json:"accessibility_issues"`
+}
+
+var (
+	scriptOrStyleRegex  = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlTagRegex        = regexp.MustCompile(`(?s)<[^>]+>`)
+	imgTagRegex         = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	altAttrRegex        = regexp.MustCompile(`(?i)\balt\s*=\s*("[^"]*"|'[^']*')`)
+	anchorRegex         = regexp.MustCompile(`(?is)<a\b([^>]*)>(.*?)</a>`)
+	hrefAttrRegex       = regexp.MustCompile(`(?i)\bhref\s*=`)
+	ariaLabelRegex      = regexp.MustCompile(`(?i)\baria-label(ledby)?\s*=\s*("[^"]+"|'[^']+')`)
+	titleTagRegex       = regexp.MustCompile(`(?is)<title[^>]*>\s*\S.*?</title>`)
+	metaDescRegex       = regexp.MustCompile(`(?i)<meta\b[^>]*name\s*=\s*["']description["'][^>]*>`)
+	metaViewportRegex   = regexp.MustCompile(`(?i)<meta\b[^>]*name\s*=\s*["']viewport["'][^>]*>`)
+	h1TagRegex          = regexp.MustCompile(`(?i)<h1\b`)
+	htmlLangRegex       = regexp.MustCompile(`(?i)<html\b[^>]*\blang\s*=\s*["'][^"']+["']`)
+	buttonRegex         = regexp.MustCompile(`(?is)<button\b([^>]*)>(.*?)</button>`)
+	inputRegex          = regexp.MustCompile(`(?i)<input\b([^>]*)>`)
+	inputTypeRegex      = regexp.MustCompile(`(?i)\btype\s*=\s*["']?(hidden|submit|button|reset|image)\b`)
+	idAttrRegex         = regexp.MustCompile(`(?i)\bid\s*=\s*["']([^"']+)["']`)
+	labelForRegex       = regexp.MustCompile(`(?i)<label\b[^>]*\bfor\s*=\s*["']([^"']+)["']`)
+	htmlWhitespaceRegex = regexp.MustCompile(`&nbsp;|&#160;`)
+)
+
+// AnalyzeContent computes simple content, SEO and accessibility metrics for a
+// page using the same regex-based parsing as the palette extractor.
+func (s *ExportService) AnalyzeContent(html string) ContentAnalysis {
+	var analysis ContentAnalysis
+
+	text := scriptOrStyleRegex.ReplaceAllString(html, " ")
+	text = htmlTagRegex.ReplaceAllString(text, " ")
+	text = htmlWhitespaceRegex.ReplaceAllString(text, " ")
+	analysis.WordCount = len(strings.Fields(text))
+
+	images := imgTagRegex.FindAllString(html, -1)
+	analysis.ImageCount = len(images)
+
+	imagesMissingAlt := 0
+	for _, img := range images {
+		if !altAttrRegex.MatchString(img) {
+			imagesMissingAlt++
+		}
+	}
+
+	emptyLinks := 0
+	for _, match := range anchorRegex.FindAllStringSubmatch(html, -1) {
+		if hrefAttrRegex.MatchString(match[1]) {
+			analysis.LinkCount++
+		}
+		if strings.TrimSpace(htmlTagRegex.ReplaceAllString(match[2], "")) == "" && !ariaLabelRegex.MatchString(match[1]) {
+			emptyLinks++
+		}
+	}
+
+	emptyButtons := 0
+	for _, match := range buttonRegex.FindAllStringSubmatch(html, -1) {
+		if strings.TrimSpace(htmlTagRegex.ReplaceAllString(match[2], "")) == "" && !ariaLabelRegex.MatchString(match[1]) {
+			emptyButtons++
+		}
+	}
+
+	labelled := make(map[string]bool)
+	for _, match := range labelForRegex.FindAllStringSubmatch(html, -1) {
+		labelled[match[1]] = true
+	}
+	unlabelledInputs := 0
+	for _, match := range inputRegex.FindAllStringSubmatch(html, -1) {
+		attrs := match[1]
+		if inputTypeRegex.MatchString(attrs) || ariaLabelRegex.MatchString(attrs) {
+			continue
+		}
+		if id := idAttrRegex.FindStringSubmatch(attrs); id != nil && labelled[id[1]] {
+			continue
+		}
+		unlabelledInputs++
+	}
+
+	hasLang := htmlLangRegex.MatchString(html)
+	analysis.AccessibilityIssues = imagesMissingAlt + emptyLinks + emptyButtons + unlabelledInputs
+	if !hasLang {
+		analysis.AccessibilityIssues++
+	}
+
+	// SEO score from basic on-page signals
+	if titleTagRegex.MatchString(html) {
+		analysis.SEOScore += 20
+	}
+	if metaDescRegex.MatchString(html) {
+		analysis.SEOScore += 20
+	}
+	if h1Count := len(h1TagRegex.FindAllString(html, -1)); h1Count == 1 {
+		analysis.SEOScore += 20
+	} else if h1Count > 1 {
+		analysis.SEOScore += 10
+	}
+	if metaViewportRegex.MatchString(html) {
+		analysis.SEOScore += 15
+	}
+	if hasLang {
+		analysis.SEOScore += 10
+	}
+	if imagesMissingAlt == 0 {
+		analysis.SEOScore += 15
+	}
+
+	return analysis
+}
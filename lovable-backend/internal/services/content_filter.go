@@ -0,0 +1,188 @@
+// internal/services/content_filter.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"lovable-backend/internal/config"
+)
+
+// ErrContentFiltered is returned when a content filter rejects a prompt
+var ErrContentFiltered = errors.New("prompt rejected by content filter")
+
+// ContentFilter decides whether a prompt may be sent to the AI provider. The
+// reason names the rule or category that rejected it.
+type ContentFilter interface {
+	Check(prompt string) (allowed bool, reason string, err error)
+}
+
+type contentFilterRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// RegexContentFilter rejects prompts matching any pattern of a blocklist
+type RegexContentFilter struct {
+	rules []contentFilterRule
+}
+
+type contentFilterRules struct {
+	Rules []struct {
+		Name    string `yaml:"name"`
+		Pattern string `yaml:"pattern"`
+	} `yaml:"rules"`
+}
+
+// NewRegexContentFilter loads the blocklist from a YAML file of named
+// patterns
+func NewRegexContentFilter(path string) (*RegexContentFilter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file contentFilterRules
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid content filter rules: %w", err)
+	}
+
+	filter := &RegexContentFilter{}
+	for i, rule := range file.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for content filter rule %d: %w", i, err)
+		}
+
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule_%d", i)
+		}
+		filter.rules = append(filter.rules, contentFilterRule{name: name, pattern: pattern})
+	}
+
+	return filter, nil
+}
+
+func (f *RegexContentFilter) Check(prompt string) (bool, string, error) {
+	for _, rule := range f.rules {
+		if rule.pattern.MatchString(prompt) {
+			return false, rule.name, nil
+		}
+	}
+	return true, "", nil
+}
+
+// OpenAIModerationFilter rejects prompts that the OpenAI moderation API
+// flags. Prompts pass unchecked while no OpenAI API key is configured.
+type OpenAIModerationFilter struct {
+	configStore *config.ConfigStore
+	httpClient  *http.Client
+}
+
+func NewOpenAIModerationFilter(configStore *config.ConfigStore, httpClient *http.Client) *OpenAIModerationFilter {
+	return &OpenAIModerationFilter{
+		configStore: configStore,
+		httpClient:  httpClient,
+	}
+}
+
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+func (f *OpenAIModerationFilter) Check(prompt string) (bool, string, error) {
+	aiConfig := f.configStore.AI()
+	if aiConfig.OpenAIAPIKey == "" {
+		return true, "", nil
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"input": prompt})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(aiConfig.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/moderations", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to create moderation request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+aiConfig.OpenAIAPIKey)
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return false, "", fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("moderation API returned %s", resp.Status)
+	}
+
+	var moderation openAIModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&moderation); err != nil {
+		return false, "", fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+
+	for _, result := range moderation.Results {
+		if !result.Flagged {
+			continue
+		}
+
+		var categories []string
+		for category, flagged := range result.Categories {
+			if flagged {
+				categories = append(categories, category)
+			}
+		}
+		sort.Strings(categories)
+
+		if len(categories) == 0 {
+			return false, "flagged", nil
+		}
+		return false, strings.Join(categories, ","), nil
+	}
+
+	return true, "", nil
+}
+
+// filterPrompt runs the prompt through each content filter in turn. A filter
+// that fails is skipped so an unavailable moderation API doesn't block
+// generation.
+func (s *AIService) filterPrompt(prompt string) error {
+	for _, filter := range s.contentFilters {
+		allowed, reason, err := filter.Check(prompt)
+		if err != nil {
+			s.logger.Warn("Content filter failed, skipping it", "filter", fmt.Sprintf("%T", filter), "error", err)
+			continue
+		}
+
+		if !allowed {
+			s.logger.LogSecurityEvent("content_filtered", "", "", map[string]any{
+				"filter": fmt.Sprintf("%T", filter),
+				"reason": reason,
+			})
+			return fmt.Errorf("%w: %s", ErrContentFiltered, reason)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+// internal/services/conversation_analytics.go
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+)
+
+type ConversationAnalyticsService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+}
+
+func NewConversationAnalyticsService(db *gorm.DB, redisClient *redis.Client) *ConversationAnalyticsService {
+	return &ConversationAnalyticsService{
+		db:          db,
+		redisClient: redisClient,
+	}
+}
+
+// Compute aggregates token usage, latency and cache efficiency over a user's
+// conversations from the last `days` days.
+func (s *ConversationAnalyticsService) Compute(userID uuid.UUID, days int) (*models.ConversationAnalytics, error) {
+	cacheKey := fmt.Sprintf("conv_analytics:%s:%d", userID.String(), days)
+	if s.redisClient != nil {
+		var cached models.ConversationAnalytics
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	var row struct {
+		TotalConversations int64
+		TotalTokens        int64
+		TotalCodeChars     int64
+		CacheHits          int64
+		AvgResponseTime    *float64
+		ExpensiveID        *uuid.UUID
+		ExpensiveName      *string
+		ExpensiveTokens    *int64
+		FastestID          *uuid.UUID
+		FastestName        *string
+		FastestAvg         *float64
+		ByMessageType      string
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	if err := s.db.Raw(`
+		WITH period_conversations AS (
+			SELECT c.project_id, c.message_type, c.tokens_used, c.response_time_ms, c.from_cache,
+			       COALESCE(LENGTH(c.generated_code), 0) AS code_chars
+			FROM conversations c
+			WHERE c.user_id = @user_id AND c.created_at >= @since
+		),
+		by_type AS (
+			SELECT message_type,
+			       COUNT(*) AS conversations,
+			       COALESCE(SUM(tokens_used), 0) AS total_tokens,
+			       COALESCE(SUM(code_chars), 0) AS code_chars,
+			       COUNT(*) FILTER (WHERE from_cache) AS cache_hits,
+			       SUM(response_time_ms) AS response_time_sum,
+			       COUNT(response_time_ms) AS response_time_count,
+			       COALESCE(AVG(response_time_ms), 0)::float8 AS avg_response_time_ms
+			FROM period_conversations
+			GROUP BY message_type
+		),
+		by_project AS (
+			SELECT pc.project_id, p.name,
+			       COALESCE(SUM(pc.tokens_used), 0) AS total_tokens,
+			       AVG(pc.response_time_ms)::float8 AS avg_response_time_ms
+			FROM period_conversations pc
+			JOIN projects p ON p.id = pc.project_id AND p.deleted_at IS NULL
+			GROUP BY pc.project_id, p.name
+		),
+		most_expensive AS (
+			SELECT project_id, name, total_tokens
+			FROM by_project
+			ORDER BY total_tokens DESC
+			LIMIT 1
+		),
+		fastest AS (
+			SELECT project_id, name, avg_response_time_ms
+			FROM by_project
+			WHERE avg_response_time_ms IS NOT NULL
+			ORDER BY avg_response_time_ms ASC
+			LIMIT 1
+		)
+		SELECT COALESCE(SUM(bt.conversations), 0) AS total_conversations,
+		       COALESCE(SUM(bt.total_tokens), 0) AS total_tokens,
+		       COALESCE(SUM(bt.code_chars), 0) AS total_code_chars,
+		       COALESCE(SUM(bt.cache_hits), 0) AS cache_hits,
+		       (SUM(bt.response_time_sum)::float8 / NULLIF(SUM(bt.response_time_count), 0)) AS avg_response_time,
+		       me.project_id AS expensive_id, me.name AS expensive_name, me.total_tokens AS expensive_tokens,
+		       f.project_id AS fastest_id, f.name AS fastest_name, f.avg_response_time_ms AS fastest_avg,
+		       COALESCE(json_agg(json_build_object(
+		           'message_type', bt.message_type,
+		           'conversations', bt.conversations,
+		           'total_tokens', bt.total_tokens,
+		           'avg_tokens_per_turn', ROUND(bt.total_tokens::numeric / bt.conversations, 2),
+		           'avg_response_time_ms', ROUND(bt.avg_response_time_ms::numeric, 2),
+		           'cache_hit_rate', ROUND(bt.cache_hits::numeric / bt.conversations, 4)
+		       ) ORDER BY bt.conversations DESC) FILTER (WHERE bt.message_type IS NOT NULL), '[]'::json)::text AS by_message_type
+		FROM by_type bt
+		LEFT JOIN most_expensive me ON true
+		LEFT JOIN fastest f ON true
+		GROUP BY me.project_id, me.name, me.total_tokens, f.project_id, f.name, f.avg_response_time_ms`,
+		map[string]interface{}{"user_id": userID, "since": since},
+	).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	analytics := &models.ConversationAnalytics{
+		PeriodDays:         days,
+		TotalConversations: row.TotalConversations,
+		ByMessageType:      []models.MessageTypeAnalytics{},
+	}
+
+	if row.TotalConversations > 0 {
+		analytics.AvgTokensPerTurn = roundTo(float64(row.TotalTokens)/float64(row.TotalConversations), 2)
+		analytics.CacheHitRate = roundTo(float64(row.CacheHits)/float64(row.TotalConversations), 4)
+	}
+	if row.AvgResponseTime != nil {
+		analytics.AvgResponseTimeMS = roundTo(*row.AvgResponseTime, 2)
+	}
+	if row.TotalTokens > 0 {
+		analytics.TokenEfficiency = roundTo(float64(row.TotalCodeChars)/float64(row.TotalTokens), 2)
+	}
+
+	if row.ExpensiveID != nil {
+		analytics.MostExpensiveProject = &models.ProjectTokenUsage{ID: *row.ExpensiveID, Name: *row.ExpensiveName, TotalTokens: *row.ExpensiveTokens}
+	}
+	if row.FastestID != nil {
+		analytics.FastestAvgResponse = &models.ProjectResponseTime{ID: *row.FastestID, Name: *row.FastestName, AvgResponseTimeMS: roundTo(*row.FastestAvg, 2)}
+	}
+
+	if row.ByMessageType != "" {
+		if err := json.Unmarshal([]byte(row.ByMessageType), &analytics.ByMessageType); err != nil {
+			return nil, fmt.Errorf("failed to parse message type breakdown: %w", err)
+		}
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, analytics, 10*time.Minute) // Cache for 10 minutes
+	}
+
+	return analytics, nil
+}
+
+func roundTo(value float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(value*factor) / factor
+}
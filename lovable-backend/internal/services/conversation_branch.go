@@ -0,0 +1,187 @@
+// internal/services/conversation_branch.go
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+)
+
+// isMainLine reports whether a turn belongs to the project's original,
+// unbranched conversation
+func isMainLine(conversation *models.Conversation) bool {
+	return conversation.ParentConversationID == nil && conversation.BranchPoint == nil
+}
+
+// conversationChain returns the turns leading to and including targetID, in
+// order. conversations must be every turn of the project, oldest first.
+func conversationChain(conversations []models.Conversation, targetID uuid.UUID) ([]models.Conversation, error) {
+	byID := make(map[uuid.UUID]int, len(conversations))
+	for i := range conversations {
+		byID[conversations[i].ID] = i
+	}
+
+	index, ok := byID[targetID]
+	if !ok {
+		return nil, errors.New("conversation not found")
+	}
+
+	// Follow parent links back to the start of the branch
+	var reversed []models.Conversation
+	for {
+		current := conversations[index]
+		reversed = append(reversed, current)
+		if current.ParentConversationID == nil {
+			break
+		}
+		parentIndex, ok := byID[*current.ParentConversationID]
+		if !ok {
+			break
+		}
+		index = parentIndex
+	}
+
+	// Main-line turns are linked implicitly by creation order
+	var chain []models.Conversation
+	if first := reversed[len(reversed)-1]; isMainLine(&first) {
+		for _, conversation := range conversations[:index] {
+			if isMainLine(&conversation) {
+				chain = append(chain, conversation)
+			}
+		}
+	}
+
+	for i := len(reversed) - 1; i >= 0; i-- {
+		chain = append(chain, reversed[i])
+	}
+
+	return chain, nil
+}
+
+// BranchConversation starts a new branch after the first fromIndex turns of
+// the chain ending at conversationID and generates its first turn from
+// message. The original turns are kept; the project's code is replaced by the
+// branch's result.
+func (s *ProjectService) BranchConversation(ctx context.Context, userID, projectID, conversationID uuid.UUID, fromIndex int, message string) (*models.GenerationResult, error) {
+	if s.aiService == nil {
+		return nil, errors.New("AI service not available")
+	}
+
+	conversations, err := s.GetConversations(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := conversationChain(conversations, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromIndex > len(chain) {
+		return nil, errors.New("branch point out of range")
+	}
+
+	history := make([]models.ConversationEntry, 0, fromIndex*2)
+	for _, turn := range chain[:fromIndex] {
+		history = append(history,
+			models.ConversationEntry{Role: "user", Content: turn.UserMessage},
+			models.ConversationEntry{Role: "assistant", Content: turn.AIResponse},
+		)
+	}
+
+	startTime := time.Now()
+
+	result, err := s.aiService.GenerateWebsite(ctx, message, history, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	responseTime := int(time.Since(startTime).Milliseconds())
+	modelUsed := result.ModelUsed
+
+	conversation := models.Conversation{
+		ProjectID:      projectID,
+		UserID:         userID,
+		UserMessage:    message,
+		AIResponse:     result.ConversationalResponse,
+		GeneratedCode:  &result.HTMLCode,
+		TokensUsed:     result.TokensUsed,
+		ResponseTimeMS: &responseTime,
+		ModelUsed:      &modelUsed,
+		MessageType:    "generation",
+		FromCache:      result.FromCache,
+		BranchPoint:    &fromIndex,
+	}
+	if fromIndex > 0 {
+		conversation.ParentConversationID = &chain[fromIndex-1].ID
+	}
+	if result.PromptVariant != "" {
+		conversation.PromptVariant = &result.PromptVariant
+	}
+
+	if err := s.db.Create(&conversation).Error; err != nil {
+		return nil, err
+	}
+
+	if result.HTMLCode != "" {
+		s.UpdateProject(ctx, userID, projectID, &models.UpdateProjectRequest{HTMLCode: &result.HTMLCode, ChangeSource: ChangeSourceAIGeneration, ConversationID: &conversation.ID})
+	}
+
+	return &models.GenerationResult{
+		ConversationID:         conversation.ID,
+		ConversationalResponse: result.ConversationalResponse,
+		HTMLCode:               result.HTMLCode,
+		TokensUsed:             result.TokensUsed,
+		ResponseTime:           responseTime,
+		FromCache:              result.FromCache,
+		ContextUtilization:     result.ContextUtilization,
+		Warnings:               result.Warnings,
+		Partial:                result.Partial,
+		GeneratedAt:            conversation.CreatedAt,
+	}, nil
+}
+
+// GetConversationTree returns a project's conversations as a forest. The main
+// line forms one chain from its first turn; branches hang off the turn they
+// follow, or form their own root when they were started from the beginning.
+func (s *ProjectService) GetConversationTree(ctx context.Context, userID, projectID uuid.UUID) ([]*models.ConversationNode, error) {
+	conversations, err := s.GetConversations(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uuid.UUID]*models.ConversationNode, len(conversations))
+	for _, conversation := range conversations {
+		nodes[conversation.ID] = &models.ConversationNode{
+			Conversation: conversation,
+			Children:     []*models.ConversationNode{},
+		}
+	}
+
+	roots := []*models.ConversationNode{}
+	var lastMainLine *models.ConversationNode
+	for _, conversation := range conversations {
+		node := nodes[conversation.ID]
+
+		switch {
+		case isMainLine(&conversation):
+			if lastMainLine == nil {
+				roots = append(roots, node)
+			} else {
+				lastMainLine.Children = append(lastMainLine.Children, node)
+			}
+			lastMainLine = node
+		case conversation.ParentConversationID != nil && nodes[*conversation.ParentConversationID] != nil:
+			parent := nodes[*conversation.ParentConversationID]
+			parent.Children = append(parent.Children, node)
+		default:
+			roots = append(roots, node)
+		}
+	}
+
+	return roots, nil
+}
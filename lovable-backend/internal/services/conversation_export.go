@@ -0,0 +1,135 @@
+// internal/services/conversation_export.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// Largest serialized size of the conversations in one export
+const maxConversationExportSize = 50 << 20
+
+// ExportProjectConversations exports the conversation history of one of the
+// user's projects, oldest first, starting after offset conversations. It
+// also returns the download's file name.
+func (s *ProjectService) ExportProjectConversations(ctx context.Context, userID, projectID uuid.UUID, offset int) (*models.ConversationExport, string, error) {
+	reader := s.dbRouter.Reader()
+
+	var project models.Project
+	if err := reader.Select("id", "name").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return nil, "", errors.New("project not found")
+	}
+
+	export, err := s.exportConversations(reader.Where("c.project_id = ?", projectID), map[uuid.UUID]string{project.ID: project.Name}, offset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := strings.ReplaceAll(strings.ToLower(project.Name), " ", "-")
+	name = strings.ReplaceAll(name, `"`, "")
+	return export, fmt.Sprintf("%s-conversations.json", name), nil
+}
+
+// ExportAllConversations exports the conversation history of every project
+// the user owns, grouped by project, starting after offset conversations.
+// It also returns the download's file name.
+func (s *ProjectService) ExportAllConversations(ctx context.Context, userID uuid.UUID, offset int) (*models.ConversationExport, string, error) {
+	reader := s.dbRouter.Reader()
+
+	var projects []models.Project
+	if err := reader.Select("id", "name").Where("user_id = ?", userID).Find(&projects).Error; err != nil {
+		return nil, "", err
+	}
+
+	projectNames := make(map[uuid.UUID]string, len(projects))
+	for _, project := range projects {
+		projectNames[project.ID] = project.Name
+	}
+
+	export, err := s.exportConversations(reader.Where("p.user_id = ?", userID), projectNames, offset)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return export, fmt.Sprintf("conversations-%s.json", time.Now().Format("2006-01-02")), nil
+}
+
+// exportConversations reads the conversations in scope, ordered by project
+// and then time, until their serialized size would pass
+// maxConversationExportSize. Rows are streamed so a large history isn't
+// loaded at once.
+func (s *ProjectService) exportConversations(scope *gorm.DB, projectNames map[uuid.UUID]string, offset int) (*models.ConversationExport, error) {
+	db := scope.Table("conversations c").
+		Joins("JOIN projects p ON p.id = c.project_id AND p.deleted_at IS NULL")
+
+	export := &models.ConversationExport{
+		ExportedAt: time.Now(),
+		Offset:     offset,
+		Projects:   []models.ProjectConversationExport{},
+	}
+
+	if err := db.Session(&gorm.Session{}).Count(&export.TotalCount).Error; err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Session(&gorm.Session{}).
+		Select("c.*").
+		Order("p.created_at, c.project_id, c.created_at, c.id").
+		Offset(offset).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	size := 0
+	for rows.Next() {
+		var conversation models.Conversation
+		if err := s.dbRouter.Reader().ScanRows(rows, &conversation); err != nil {
+			return nil, err
+		}
+
+		entry := models.ConversationExportEntry{
+			UserMessage:        conversation.UserMessage,
+			AIResponse:         conversation.AIResponse,
+			GeneratedCode:      conversation.GeneratedCode,
+			TokensUsed:         conversation.TokensUsed,
+			ModelUsed:          conversation.ModelUsed,
+			MessageType:        conversation.MessageType,
+			SatisfactionRating: conversation.SatisfactionRating,
+			CreatedAt:          conversation.CreatedAt,
+		}
+
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		if size+len(encoded) > maxConversationExportSize {
+			export.Truncated = true
+			break
+		}
+		size += len(encoded)
+
+		last := len(export.Projects) - 1
+		if last < 0 || export.Projects[last].ProjectID != conversation.ProjectID {
+			export.Projects = append(export.Projects, models.ProjectConversationExport{
+				ProjectID:   conversation.ProjectID,
+				ProjectName: projectNames[conversation.ProjectID],
+			})
+			last++
+		}
+		export.Projects[last].Conversations = append(export.Projects[last].Conversations, entry)
+		export.Count++
+	}
+
+	return export, rows.Err()
+}
@@ -0,0 +1,78 @@
+// internal/services/conversation_search.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+)
+
+// Document searched by SearchConversations. It must match the expression of
+// idx_conversations_search for the index to be used.
+const conversationSearchDocument = "to_tsvector('english', c.user_message || ' ' || c.ai_response)"
+
+type ConversationSearchQuery struct {
+	Q           string
+	ProjectID   *uuid.UUID // limits the search to one project
+	MessageType string
+	Page        int
+	Limit       int
+}
+
+// SearchConversations ranks the conversation turns of projects the user owns
+// or collaborates on against a full-text query. Generated code is left out of
+// the results to keep them small.
+func (s *ProjectService) SearchConversations(ctx context.Context, userID uuid.UUID, query *ConversationSearchQuery) (*models.ConversationSearchResponse, error) {
+	reader := s.dbRouter.Reader()
+
+	if query.ProjectID != nil {
+		if _, err := s.findAccessibleProject(reader, userID, *query.ProjectID, ProjectRoleEditor, ProjectRoleViewer); err != nil {
+			return nil, errors.New("project not found")
+		}
+	}
+
+	db := reader.Table("conversations c").
+		Joins("JOIN projects p ON p.id = c.project_id AND p.deleted_at IS NULL").
+		Joins("CROSS JOIN plainto_tsquery('english', ?) query", query.Q).
+		Where("(p.user_id = ? OR p.id IN (SELECT project_id FROM project_collaborators WHERE user_id = ? AND accepted_at IS NOT NULL))", userID, userID).
+		Where(conversationSearchDocument + " @@ query")
+
+	if query.ProjectID != nil {
+		db = db.Where("c.project_id = ?", *query.ProjectID)
+	}
+	if query.MessageType != "" {
+		db = db.Where("c.message_type = ?", query.MessageType)
+	}
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	results := []models.ConversationSearchResult{}
+	if err := db.Select("c.id, c.project_id, p.name AS project_name, c.user_message, c.ai_response, c.message_type, c.model_used, c.tokens_used, c.created_at, " +
+		"ts_rank(" + conversationSearchDocument + ", query) AS rank").
+		Order("rank DESC, c.created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(query.Limit)))
+
+	return &models.ConversationSearchResponse{
+		Conversations: results,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: query.Page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: query.Page < totalPages,
+			HasPrevPage: query.Page > 1,
+		},
+	}, nil
+}
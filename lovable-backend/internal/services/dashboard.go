@@ -0,0 +1,168 @@
+// internal/services/dashboard.go
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// Number of recently updated projects shown on the dashboard
+const dashboardRecentProjects = 5
+
+// GetDashboardSummary gathers the overview for the user's dashboard. Months
+// and days are counted in the user's timezone. A section whose query fails is
+// left empty and named in the summary's errors rather than failing the whole
+// summary; only complete summaries are cached.
+func (s *AuthService) GetDashboardSummary(userID uuid.UUID) (*models.DashboardSummary, error) {
+	cacheKey := fmt.Sprintf("dashboard:%s", userID.String())
+	if s.redisClient != nil {
+		var cached models.DashboardSummary
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	reader := s.dbRouter.Reader()
+
+	var user models.User
+	if err := reader.Select("id", "subscription_plan", "pending_plan", "plan_downgrade_at", "timezone", "subscription_renewal_at").
+		First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil || user.Timezone == "" {
+		location = time.UTC
+	}
+
+	now := time.Now().In(location)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+
+	summary := &models.DashboardSummary{
+		ProjectsByStatus:      map[string]int64{},
+		RecentProjects:        []models.DashboardProject{},
+		WeeklyGenerations:     []models.DailyGenerationCount{},
+		SubscriptionPlan:      effectivePlan(&user, now),
+		SubscriptionRenewalAt: user.SubscriptionRenewalAt,
+		GeneratedAt:           now,
+	}
+
+	failed := func(section string, err error) {
+		s.logger.Warn("Dashboard section failed", "userID", userID, "section", section, "error", err)
+		summary.Errors = append(summary.Errors, section)
+	}
+
+	// Projects by status
+	var statusCounts []struct {
+		Status string
+		Count  int64
+	}
+	if err := reader.Model(&models.Project{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("status").
+		Scan(&statusCounts).Error; err != nil {
+		failed("projects", err)
+	} else {
+		for _, row := range statusCounts {
+			summary.ProjectsByStatus[row.Status] = row.Count
+			summary.TotalProjects += row.Count
+		}
+	}
+
+	// Generations and tokens. Token totals come from the conversations, which
+	// record every AI call of the user's projects.
+	var totals struct {
+		GenerationsThisMonth int64
+		TokensThisMonth      int64
+		TokensAllTime        int64
+	}
+	if err := reader.Raw(`
+		SELECT COUNT(*) FILTER (WHERE c.message_type = 'generation' AND c.created_at >= @month_start) AS generations_this_month,
+		       COALESCE(SUM(c.tokens_used) FILTER (WHERE c.created_at >= @month_start), 0) AS tokens_this_month,
+		       COALESCE(SUM(c.tokens_used), 0) AS tokens_all_time
+		FROM conversations c
+		WHERE c.user_id = @user_id`,
+		map[string]interface{}{
+			"user_id":     userID,
+			"month_start": monthStart,
+		},
+	).Scan(&totals).Error; err != nil {
+		failed("generations", err)
+	} else {
+		summary.GenerationsThisMonth = totals.GenerationsThisMonth
+		summary.TokensThisMonth = totals.TokensThisMonth
+		summary.TokensAllTime = totals.TokensAllTime
+	}
+
+	// Usage against the plan's daily limit
+	if _, usage, err := s.CheckUsageLimit(userID, summary.SubscriptionPlan); err != nil {
+		failed("usage", err)
+	} else {
+		summary.Usage = usage
+		if usage.Limit > 0 {
+			summary.UsagePercent = float64(usage.Used) / float64(usage.Limit) * 100
+		}
+	}
+
+	// Recently updated projects
+	if err := reader.Raw(`
+		SELECT p.id, p.name, p.status, p.thumbnail_url, p.view_count, p.like_count, p.updated_at,
+		       (SELECT COUNT(*) FROM conversations c WHERE c.project_id = p.id) AS conversation_count,
+		       (SELECT COUNT(*) FROM project_versions v WHERE v.project_id = p.id) AS version_count
+		FROM projects p
+		WHERE p.user_id = @user_id AND p.deleted_at IS NULL
+		ORDER BY p.updated_at DESC
+		LIMIT @limit`,
+		map[string]interface{}{
+			"user_id": userID,
+			"limit":   dashboardRecentProjects,
+		},
+	).Scan(&summary.RecentProjects).Error; err != nil {
+		summary.RecentProjects = []models.DashboardProject{}
+		failed("recent_projects", err)
+	}
+
+	// Daily generations of the last 7 days
+	var series string
+	if err := reader.Raw(`
+		SELECT COALESCE(json_agg(json_build_object('date', to_char(d.day, 'YYYY-MM-DD'), 'generations', COALESCE(g.generations, 0)) ORDER BY d.day), '[]'::json)::text
+		FROM generate_series(@since::date, @today::date, interval '1 day') AS d(day)
+		LEFT JOIN (
+			SELECT (created_at AT TIME ZONE @tz)::date AS day, COUNT(*) AS generations
+			FROM conversations
+			WHERE user_id = @user_id AND message_type = 'generation' AND created_at >= @since_at
+			GROUP BY 1
+		) g ON g.day = d.day`,
+		map[string]interface{}{
+			"user_id":  userID,
+			"tz":       location.String(),
+			"since":    today.AddDate(0, 0, -6).Format("2006-01-02"),
+			"today":    today.Format("2006-01-02"),
+			"since_at": today.AddDate(0, 0, -6),
+		},
+	).Scan(&series).Error; err != nil {
+		failed("weekly_generations", err)
+	} else if err := json.Unmarshal([]byte(series), &summary.WeeklyGenerations); err != nil {
+		failed("weekly_generations", err)
+	}
+
+	summary.Partial = len(summary.Errors) > 0
+
+	if s.redisClient != nil && !summary.Partial {
+		s.redisClient.Set(cacheKey, summary, 2*time.Minute) // Cache for 2 minutes
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,224 @@
+// internal/services/discover.go
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// Sort orders for the public gallery
+const (
+	DiscoverSortNewest     = "newest"
+	DiscoverSortMostViewed = "most_viewed"
+	DiscoverSortMostLiked  = "most_liked"
+	DiscoverSortTrending   = "trending"
+)
+
+// Column each gallery sort orders by, descending
+var discoverSortColumns = map[string]string{
+	DiscoverSortNewest:     "created_at",
+	DiscoverSortMostViewed: "view_count",
+	DiscoverSortMostLiked:  "like_count",
+	DiscoverSortTrending:   "trending_score",
+}
+
+// Trending ranks by views recorded in the window plus likes, with a like
+// worth several views. Likes carry no timestamp, so all of them count.
+const (
+	trendingWindow     = 7 * 24 * time.Hour
+	trendingLikeWeight = 5
+)
+
+// Number of projects returned by GetFeaturedProjects
+const featuredProjectsLimit = 12
+
+// How long a user's repeat views of a project are ignored
+const projectViewDedupWindow = time.Hour
+
+const discoverColumns = `p.id, p.name, p.description, p.tags, p.thumbnail_url, p.view_count, p.like_count, p.fork_count,
+	p.is_featured, p.user_id AS creator_id, u.name AS creator_name, u.avatar_url AS creator_avatar_url, p.created_at, p.updated_at`
+
+type discoverRow struct {
+	models.DiscoverProject
+	TrendingScore int64
+}
+
+func (s *ProjectService) publicProjects() *gorm.DB {
+	return s.dbRouter.Reader().Table("projects p").
+		Joins("JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL").
+		Where("p.is_public = ? AND p.deleted_at IS NULL", true)
+}
+
+// DiscoverProjects pages through public projects in the given sort order.
+// Listing never counts as a view.
+func (s *ProjectService) DiscoverProjects(ctx context.Context, sort, cursor string, limit int) (*models.DiscoverResponse, error) {
+	column, ok := discoverSortColumns[sort]
+	if !ok {
+		return nil, errors.New("invalid sort")
+	}
+
+	inner := s.publicProjects()
+	if sort == DiscoverSortTrending {
+		inner = inner.Select(discoverColumns+`,
+			(SELECT COUNT(*) FROM project_views v WHERE v.project_id = p.id AND v.created_at > ?) + ? * p.like_count AS trending_score`,
+			time.Now().Add(-trendingWindow), trendingLikeWeight)
+	} else {
+		inner = inner.Select(discoverColumns)
+	}
+
+	db := s.dbRouter.Reader().Table("(?) AS d", inner)
+	if cursor != "" {
+		value, id, err := decodeDiscoverCursor(sort, cursor)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) < (?, ?)", column), value, id)
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	var rows []discoverRow
+	if err := db.Order(fmt.Sprintf("%s DESC, id DESC", column)).Limit(limit + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hasNext := len(rows) > limit
+	if hasNext {
+		rows = rows[:limit]
+	}
+
+	projects := make([]models.DiscoverProject, len(rows))
+	for i, row := range rows {
+		projects[i] = row.DiscoverProject
+	}
+
+	pagination := &models.PaginationResponse{
+		HasNextPage: hasNext,
+		HasPrevPage: cursor != "",
+	}
+	if hasNext {
+		pagination.NextCursor = encodeDiscoverCursor(sort, rows[len(rows)-1])
+	}
+
+	return &models.DiscoverResponse{
+		Projects:   projects,
+		Pagination: pagination,
+	}, nil
+}
+
+// Gallery cursors are base64 of "<sort value>|<id>", where the sort value is
+// a timestamp for newest and an integer otherwise
+func encodeDiscoverCursor(sort string, row discoverRow) string {
+	var value string
+	switch sort {
+	case DiscoverSortNewest:
+		value = row.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case DiscoverSortMostViewed:
+		value = strconv.Itoa(row.ViewCount)
+	case DiscoverSortMostLiked:
+		value = strconv.Itoa(row.LikeCount)
+	default:
+		value = strconv.FormatInt(row.TrendingScore, 10)
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "|" + row.ID.String()))
+}
+
+func decodeDiscoverCursor(sort, cursor string) (interface{}, uuid.UUID, error) {
+	invalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, uuid.Nil, invalid
+	}
+
+	valuePart, idPart, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, uuid.Nil, invalid
+	}
+
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return nil, uuid.Nil, invalid
+	}
+
+	if sort == DiscoverSortNewest {
+		t, err := time.Parse(time.RFC3339Nano, valuePart)
+		if err != nil {
+			return nil, uuid.Nil, invalid
+		}
+		return t, id, nil
+	}
+
+	n, err := strconv.ParseInt(valuePart, 10, 64)
+	if err != nil {
+		return nil, uuid.Nil, invalid
+	}
+	return n, id, nil
+}
+
+// GetFeaturedProjects returns the curated public projects, most recently
+// updated first
+func (s *ProjectService) GetFeaturedProjects(ctx context.Context) ([]models.DiscoverProject, error) {
+	projects := []models.DiscoverProject{}
+	err := s.publicProjects().
+		Select(discoverColumns).
+		Where("p.is_featured = ?", true).
+		Order("p.updated_at DESC").
+		Limit(featuredProjectsLimit).
+		Scan(&projects).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// SetFeatured adds a public project to or removes it from the featured list
+func (s *ProjectService) SetFeatured(ctx context.Context, projectID uuid.UUID, featured bool) error {
+	var project models.Project
+	if err := s.db.Select("id", "is_public").First(&project, "id = ?", projectID).Error; err != nil {
+		return errors.New("project not found")
+	}
+
+	if featured && !project.IsPublic {
+		return errors.New("only public projects can be featured")
+	}
+
+	return s.db.Model(&project).UpdateColumn("is_featured", featured).Error
+}
+
+// RecordProjectView counts a view of a public or owned project. Repeat views
+// by the same user within projectViewDedupWindow are ignored so that
+// refreshing a page does not inflate the count.
+func (s *ProjectService) RecordProjectView(ctx context.Context, userID, projectID uuid.UUID) error {
+	var project models.Project
+	if err := s.dbRouter.Reader().Select("id").
+		Where("id = ? AND (is_public = ? OR user_id = ?)", projectID, true, userID).
+		First(&project).Error; err != nil {
+		return errors.New("project not found")
+	}
+
+	if s.redisClient != nil && s.redisClient.Client != nil {
+		key := fmt.Sprintf("project_view:%s:%s", projectID, userID)
+		first, err := s.redisClient.Client.SetNX(ctx, key, 1, projectViewDedupWindow).Result()
+		if err == nil && !first {
+			return nil
+		}
+	}
+
+	if err := s.db.Model(&project).UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error; err != nil {
+		return err
+	}
+
+	return s.db.Create(&models.ProjectView{ProjectID: project.ID}).Error
+}
@@ -0,0 +1,161 @@
+// internal/services/email.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/pkg/logger"
+)
+
+// EmailSender is the subset of EmailService used for account emails, so
+// callers can swap in a no-op implementation.
+type EmailSender interface {
+	SendNewLoginAlert(to, ip, userAgent, location string) error
+	SendVerification(email, token string) error
+	SendPasswordReset(email, token string) error
+}
+
+type EmailService struct {
+	config config.EmailConfig
+	logger *logger.Logger
+}
+
+func NewEmailService(config config.EmailConfig, logger *logger.Logger) *EmailService {
+	return &EmailService{
+		config: config,
+		logger: logger,
+	}
+}
+
+// Send delivers a plain text email. When no SMTP host is configured the
+// message is logged instead so development setups work without a mail server.
+func (s *EmailService) Send(to, subject, body string) error {
+	return s.send(to, subject, "text/plain", body)
+}
+
+// SendHTML delivers an HTML email
+func (s *EmailService) SendHTML(to, subject, body string) error {
+	return s.send(to, subject, "text/html", body)
+}
+
+// JobTypeEmail is the background job type for queued emails
+const JobTypeEmail = "email"
+
+// EmailJob is the payload of an email background job
+type EmailJob struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	HTML    bool   `json:"html,omitempty"`
+}
+
+// HandleEmailJob sends a queued email; it is registered with the worker pool
+// for JobTypeEmail
+func (s *EmailService) HandleEmailJob(ctx context.Context, payload json.RawMessage) error {
+	var job EmailJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+
+	if job.HTML {
+		return s.SendHTML(job.To, job.Subject, job.Body)
+	}
+	return s.Send(job.To, job.Subject, job.Body)
+}
+
+func (s *EmailService) send(to, subject, contentType, body string) error {
+	if s.config.SMTPHost == "" {
+		s.logger.Info("Email (SMTP not configured)", "to", to, "subject", subject)
+		return nil
+	}
+
+	message := strings.Join([]string{
+		fmt.Sprintf("From: %s", s.config.FromAddress),
+		fmt.Sprintf("To: %s", to),
+		fmt.Sprintf("Subject: %s", subject),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: %s; charset=\"utf-8\"", contentType),
+		"",
+		body,
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if s.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, s.config.SMTPHost)
+	}
+
+	addr := fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort)
+	if err := smtp.SendMail(addr, auth, s.config.FromAddress, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *EmailService) SendNewLoginAlert(to, ip, userAgent, location string) error {
+	subject := "New sign-in to your account"
+	body := fmt.Sprintf(`Hi,
+
+We noticed a sign-in to your account from a device we haven't seen recently.
+
+Time: %s
+IP address: %s
+Location: %s
+Device: %s
+
+If this was you, no action is needed. If not, change your password right away.
+
+AI Website Builder`, time.Now().UTC().Format(time.RFC1123), ip, location, userAgent)
+
+	return s.Send(to, subject, body)
+}
+
+func (s *EmailService) SendVerification(email, token string) error {
+	subject := "Verify your email address"
+	body := fmt.Sprintf(`Hi,
+
+Thanks for signing up! Confirm your email address by opening the link below:
+
+%s/verify-email?token=%s
+
+The link expires in 24 hours. If you didn't create an account, you can ignore this email.
+
+AI Website Builder`, s.config.AppURL, token)
+
+	return s.Send(email, subject, body)
+}
+
+func (s *EmailService) SendPasswordReset(email, token string) error {
+	subject := "Reset your password"
+	body := fmt.Sprintf(`Hi,
+
+We received a request to reset the password for your account. Choose a new password here:
+
+%s/reset-password?token=%s
+
+The link expires in 1 hour and can only be used once. If you didn't ask for a reset, you can ignore this email.
+
+AI Website Builder`, s.config.AppURL, token)
+
+	return s.Send(email, subject, body)
+}
+
+// CollaborationInviteEmail builds the invitation email without sending it
+func (s *EmailService) CollaborationInviteEmail(email, inviter, projectName, projectID, role string) EmailJob {
+	subject := fmt.Sprintf("%s shared \"%s\" with you", inviter, projectName)
+	body := fmt.Sprintf(`Hi,
+
+%s invited you to collaborate on "%s" as a %s. Open the project to accept the invitation:
+
+%s/projects/%s
+
+AI Website Builder`, inviter, projectName, role, s.config.AppURL, projectID)
+
+	return EmailJob{To: email, Subject: subject, Body: body}
+}
@@ -4,119 +4,254 @@ package services
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/metrics"
 	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+	"lovable-backend/internal/storage"
+	"lovable-backend/internal/telemetry"
 )
 
 type ExportService struct {
-	db *gorm.DB
+	dbRouter    *database.DBRouter
+	storage     storage.StorageBackend
+	redisClient *redis.Client
+	config      config.ExportConfig
+	pdfClient   *http.Client
 }
 
-func NewExportService(db *gorm.DB) *ExportService {
+func NewExportService(dbRouter *database.DBRouter, storageBackend storage.StorageBackend, redisClient *redis.Client, config config.ExportConfig) *ExportService {
 	return &ExportService{
-		db: db,
+		dbRouter:    dbRouter,
+		storage:     storageBackend,
+		redisClient: redisClient,
+		config:      config,
+		pdfClient:   &http.Client{Timeout: time.Duration(config.PDFServiceTimeout) * time.Second},
 	}
 }
 
-func (s *ExportService) ExportHTML(userID, projectID uuid.UUID, minify bool) ([]byte, string, error) {
+// findProject loads a project the user owns or collaborates on, so
+// collaborators can export whatever they can open
+func (s *ExportService) findProject(userID, projectID uuid.UUID) (*models.Project, error) {
 	var project models.Project
-	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+	if err := s.dbRouter.Reader().Where(accessibleProjectCondition, projectID, userID, userID, []string{ProjectRoleEditor, ProjectRoleViewer}).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (s *ExportService) ExportHTML(ctx context.Context, userID, projectID uuid.UUID, minify MinifyMode) ([]byte, string, error) {
+	project, err := s.findProject(userID, projectID)
+	if err != nil {
 		return nil, "", fmt.Errorf("project not found")
 	}
 
+	if err := storage.LoadProjectHTML(s.storage, project); err != nil {
+		return nil, "", err
+	}
+
 	if project.HTMLCode == nil || *project.HTMLCode == "" {
 		return nil, "", fmt.Errorf("no HTML code available for this project")
 	}
 
-	htmlContent := *project.HTMLCode
+	htmlContent := minifyHTML(*project.HTMLCode, minify)
 
-	// Minify HTML if requested
-	if minify {
-		htmlContent = strings.ReplaceAll(htmlContent, "\n", "")
-		htmlContent = strings.ReplaceAll(htmlContent, "\t", "")
-		htmlContent = strings.ReplaceAll(htmlContent, "  ", " ")
-	}
+	metrics.ExportsTotal.WithLabelValues("html").Inc()
 
 	filename := fmt.Sprintf("%s.html", strings.ReplaceAll(strings.ToLower(project.Name), " ", "-"))
+	s.recordExport(&models.ExportRecord{
+		UserID:       userID,
+		ProjectID:    &project.ID,
+		Format:       "html",
+		FileName:     filename,
+		FileSize:     int64(len(htmlContent)),
+		MinifyUsed:   minify != MinifyNone,
+		ProjectCount: 1,
+	})
+
 	return []byte(htmlContent), filename, nil
 }
 
-func (s *ExportService) ExportZIP(userID, projectID uuid.UUID, includeAssets bool) ([]byte, string, error) {
-	var project models.Project
-	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+// PrepareZIPExport loads a project for ZIP export. Lookup errors surface here,
+// before StreamZIP has started writing the response.
+func (s *ExportService) PrepareZIPExport(ctx context.Context, userID, projectID uuid.UUID) (*models.Project, string, error) {
+	project, err := s.findProject(userID, projectID)
+	if err != nil {
 		return nil, "", fmt.Errorf("project not found")
 	}
 
+	if err := storage.LoadProjectHTML(s.storage, project); err != nil {
+		return nil, "", err
+	}
+
 	if project.HTMLCode == nil || *project.HTMLCode == "" {
 		return nil, "", fmt.Errorf("no code available for this project")
 	}
 
-	// Create ZIP buffer
-	var buf bytes.Buffer
-	writer := zip.NewWriter(&buf)
+	filename := fmt.Sprintf("%s-website.zip", strings.ReplaceAll(strings.ToLower(project.Name), " ", "-"))
+	return project, filename, nil
+}
 
-	// Add main HTML file
-	htmlWriter, err := writer.Create("index.html")
+// ZIPExportOptions selects what a ZIP export contains
+type ZIPExportOptions struct {
+	IncludeAssets bool
+	Minify        MinifyMode
+	Preset        ExportPreset
+	Domain        string // custom domain for the GitHub Pages CNAME file
+}
+
+// StreamZIP writes the project's ZIP archive to w as it is built. The status
+// code has already been sent by then, so a failure part way through is
+// recorded in the archive comment before the archive is closed. Completed
+// exports are recorded in the owner's export history under filename.
+func (s *ExportService) StreamZIP(ctx context.Context, w io.Writer, project *models.Project, filename string, opts ZIPExportOptions) error {
+	_, span := telemetry.Tracer.Start(ctx, "ExportService.StreamZIP", trace.WithAttributes(
+		attribute.String("project.id", project.ID.String()),
+		attribute.Bool("export.include_assets", opts.IncludeAssets),
+		attribute.Int("export.minify_mode", int(opts.Minify)),
+		attribute.String("export.preset", opts.Preset.String()),
+	))
+	defer span.End()
+
+	counter := &countingWriter{w: w}
+	writer := zip.NewWriter(counter)
+
+	err := s.writeZIPEntries(writer, project, opts)
 	if err != nil {
-		return nil, "", err
+		writer.SetComment(zipExportErrorPrefix + err.Error())
+	}
+
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return err
+	}
+
+	metrics.ExportsTotal.WithLabelValues("zip").Inc()
+	s.recordExport(&models.ExportRecord{
+		UserID:        project.UserID,
+		ProjectID:     &project.ID,
+		Format:        "zip",
+		FileName:      filename,
+		FileSize:      counter.n,
+		MinifyUsed:    opts.Minify != MinifyNone,
+		IncludeAssets: opts.IncludeAssets,
+		ProjectCount:  1,
+	})
+
+	return nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Prefix of the archive comment written when a streamed export fails
+const zipExportErrorPrefix = "EXPORT INCOMPLETE: "
+
+func (s *ExportService) writeZIPEntries(writer *zip.Writer, project *models.Project, opts ZIPExportOptions) error {
+	modified := project.UpdatedAt
+
+	// Add main HTML file
+	if err := addZIPFile(writer, "index.html", minifyHTML(*project.HTMLCode, opts.Minify), modified); err != nil {
+		return err
 	}
-	htmlWriter.Write([]byte(*project.HTMLCode))
 
 	// Add separate CSS file if external
 	if project.CSSCode != nil && !strings.Contains(*project.HTMLCode, "<style>") {
-		cssWriter, err := writer.Create("styles.css")
-		if err != nil {
-			return nil, "", err
+		if err := addZIPFile(writer, "styles.css", *project.CSSCode, modified); err != nil {
+			return err
 		}
-		cssWriter.Write([]byte(*project.CSSCode))
 	}
 
 	// Add separate JS file if external
 	if project.JSCode != nil && !strings.Contains(*project.HTMLCode, "<script>") {
-		jsWriter, err := writer.Create("script.js")
-		if err != nil {
-			return nil, "", err
+		if err := addZIPFile(writer, "script.js", *project.JSCode, modified); err != nil {
+			return err
 		}
-		jsWriter.Write([]byte(*project.JSCode))
 	}
 
 	// Add README
-	readmeContent := s.generateReadme(&project)
-	readmeWriter, err := writer.Create("README.md")
-	if err != nil {
-		return nil, "", err
+	if err := addZIPFile(writer, "README.md", s.generateReadme(project, opts.Preset, opts.Domain), modified); err != nil {
+		return err
+	}
+
+	// Add the hosting provider's configuration
+	if err := writePresetFiles(writer, opts.Preset, opts.Domain, modified); err != nil {
+		return err
 	}
-	readmeWriter.Write([]byte(readmeContent))
 
 	// Add package.json
-	packageJSON := s.generatePackageJSON(&project)
-	packageWriter, err := writer.Create("package.json")
-	if err != nil {
-		return nil, "", err
+	if err := addZIPFile(writer, "package.json", s.generatePackageJSON(project), modified); err != nil {
+		return err
 	}
-	packageWriter.Write([]byte(packageJSON))
 
-	// Add basic assets if requested
-	if includeAssets {
-		s.addBasicAssets(writer)
+	// Add basic assets and a sitemap if requested
+	if opts.IncludeAssets {
+		baseURL := ""
+		if project.PreviewURL != nil {
+			baseURL = *project.PreviewURL
+		}
+		sitemap, err := s.GenerateSitemap(project, baseURL)
+		if err != nil {
+			return err
+		}
+		if err := addZIPFile(writer, "sitemap.xml", string(sitemap), modified); err != nil {
+			return err
+		}
+
+		return s.addBasicAssets(writer)
 	}
 
-	writer.Close()
+	return nil
+}
 
-	filename := fmt.Sprintf("%s-website.zip", strings.ReplaceAll(strings.ToLower(project.Name), " ", "-"))
-	return buf.Bytes(), filename, nil
+func addZIPFile(writer *zip.Writer, name, content string, modified time.Time) error {
+	fileWriter, err := writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+
+	if _, err := io.Copy(fileWriter, strings.NewReader(content)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
 }
 
-func (s *ExportService) BatchExport(userID uuid.UUID, projectIDs []uuid.UUID, includeAssets bool) ([]byte, string, error) {
+func (s *ExportService) BatchExport(ctx context.Context, userID uuid.UUID, projectIDs []uuid.UUID, includeAssets bool) ([]byte, string, error) {
 	// Get all projects
 	var projects []models.Project
-	if err := s.db.Where("user_id = ? AND id IN ?", userID, projectIDs).Find(&projects).Error; err != nil {
+	if err := s.dbRouter.Reader().Where("user_id = ? AND id IN ?", userID, projectIDs).Find(&projects).Error; err != nil {
 		return nil, "", err
 	}
 
@@ -124,6 +259,12 @@ func (s *ExportService) BatchExport(userID uuid.UUID, projectIDs []uuid.UUID, in
 		return nil, "", fmt.Errorf("no projects found")
 	}
 
+	for i := range projects {
+		if err := storage.LoadProjectHTML(s.storage, &projects[i]); err != nil {
+			return nil, "", err
+		}
+	}
+
 	// Create ZIP buffer
 	var buf bytes.Buffer
 	writer := zip.NewWriter(&buf)
@@ -148,7 +289,7 @@ func (s *ExportService) BatchExport(userID uuid.UUID, projectIDs []uuid.UUID, in
 		}
 
 		// Add project README
-		readmeContent := s.generateReadme(&project)
+		readmeContent := s.generateReadme(&project, ExportPresetGeneric, "")
 		readmeWriter, _ := writer.Create(fmt.Sprintf("%s/README.md", folderName))
 		readmeWriter.Write([]byte(readmeContent))
 	}
@@ -160,12 +301,97 @@ func (s *ExportService) BatchExport(userID uuid.UUID, projectIDs []uuid.UUID, in
 
 	writer.Close()
 
+	metrics.ExportsTotal.WithLabelValues("batch_zip").Inc()
+
 	filename := fmt.Sprintf("websites-batch-%d.zip", time.Now().Unix())
+	s.recordExport(&models.ExportRecord{
+		UserID:        userID,
+		Format:        "batch",
+		FileName:      filename,
+		FileSize:      int64(buf.Len()),
+		IncludeAssets: includeAssets,
+		ProjectCount:  len(projects),
+	})
+	for _, project := range projects {
+		recordProjectActivity(s.dbRouter.Writer(), project.ID, userID, ActivityExported, map[string]interface{}{
+			"format":    "batch",
+			"file_name": filename,
+		})
+	}
+
 	return buf.Bytes(), filename, nil
 }
 
-func (s *ExportService) GetProjectForPreview(projectID uuid.UUID, userID *uuid.UUID) (*models.Project, error) {
-	query := s.db.Where("id = ?", projectID)
+// recordExport adds a completed export to the user's history. The export has
+// already been produced, so a failed insert does not fail it.
+func (s *ExportService) recordExport(record *models.ExportRecord) {
+	s.dbRouter.Writer().Create(record)
+
+	if record.ProjectID != nil {
+		recordProjectActivity(s.dbRouter.Writer(), *record.ProjectID, record.UserID, ActivityExported, map[string]interface{}{
+			"format":    record.Format,
+			"file_name": record.FileName,
+		})
+	}
+
+	publishWebhookEvent(s.redisClient, record.UserID, WebhookEventExportCompleted, map[string]interface{}{
+		"project_id":    record.ProjectID,
+		"format":        record.Format,
+		"file_name":     record.FileName,
+		"file_size":     record.FileSize,
+		"project_count": record.ProjectCount,
+	})
+}
+
+// GetExportHistory lists the user's exports, newest first, optionally limited
+// to one format.
+func (s *ExportService) GetExportHistory(ctx context.Context, userID uuid.UUID, format string, page, limit int) (*models.ExportHistoryResponse, error) {
+	db := s.dbRouter.Reader().Model(&models.ExportRecord{}).Where("user_id = ?", userID)
+	if format != "" {
+		db = db.Where("format = ?", format)
+	}
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	exports := []models.ExportRecord{}
+	if err := db.Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&exports).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	return &models.ExportHistoryResponse{
+		Exports: exports,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: page < totalPages,
+			HasPrevPage: page > 1,
+		},
+	}, nil
+}
+
+func (s *ExportService) DeleteExportRecord(ctx context.Context, userID, recordID uuid.UUID) error {
+	result := s.dbRouter.Writer().Where("id = ? AND user_id = ?", recordID, userID).Delete(&models.ExportRecord{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("export record not found")
+	}
+
+	return nil
+}
+
+func (s *ExportService) GetProjectForPreview(ctx context.Context, projectID uuid.UUID, userID *uuid.UUID) (*models.Project, error) {
+	query := s.dbRouter.Reader().Where("id = ?", projectID)
 
 	if userID != nil {
 		// Authenticated user: check ownership OR public
@@ -180,13 +406,110 @@ func (s *ExportService) GetProjectForPreview(projectID uuid.UUID, userID *uuid.U
 		return nil, err
 	}
 
-	// Increment view count
-	s.db.Model(&project).Update("view_count", gorm.Expr("view_count + 1"))
+	if err := storage.LoadProjectHTML(s.storage, &project); err != nil {
+		return nil, err
+	}
+
+	// Increment view count and record the view for daily analytics
+	s.dbRouter.Writer().Model(&project).Update("view_count", gorm.Expr("view_count + 1"))
+	s.dbRouter.Writer().Create(&models.ProjectView{ProjectID: project.ID})
 
 	return &project, nil
 }
 
-func (s *ExportService) generateReadme(project *models.Project) string {
+func (s *ExportService) GetProjectPalette(ctx context.Context, userID, projectID uuid.UUID) ([]ColorSwatch, error) {
+	project, err := s.findProject(userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("project not found")
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, project); err != nil {
+		return nil, err
+	}
+
+	if project.HTMLCode == nil || *project.HTMLCode == "" {
+		return nil, fmt.Errorf("no HTML code available for this project")
+	}
+
+	return s.ExtractColorPalette(*project.HTMLCode), nil
+}
+
+// CompareProjects analyzes two projects the user owns or that are public and
+// reports how the second differs from the first.
+func (s *ExportService) CompareProjects(ctx context.Context, userID uuid.UUID, projectIDs [2]uuid.UUID) (*models.ProjectComparison, error) {
+	cacheKey := fmt.Sprintf("compare:%s:%s:%s", userID.String(), projectIDs[0].String(), projectIDs[1].String())
+	if s.redisClient != nil {
+		var cached models.ProjectComparison
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	var projects [2]models.Project
+	var analyses [2]ContentAnalysis
+	var palettes [2][]ColorSwatch
+	for i, projectID := range projectIDs {
+		if err := s.dbRouter.Reader().Where("id = ? AND (user_id = ? OR is_public = ?)", projectID, userID, true).
+			First(&projects[i]).Error; err != nil {
+			return nil, fmt.Errorf("project not found")
+		}
+
+		if err := storage.LoadProjectHTML(s.storage, &projects[i]); err != nil {
+			return nil, err
+		}
+
+		html := ""
+		if projects[i].HTMLCode != nil {
+			html = *projects[i].HTMLCode
+		}
+		analyses[i] = s.AnalyzeContent(html)
+		palettes[i] = s.ExtractColorPalette(html)
+	}
+
+	comparison := &models.ProjectComparison{
+		Projects: []models.ProjectInfo{toProjectInfo(&projects[0]), toProjectInfo(&projects[1])},
+		Comparison: models.ComparisonMetrics{
+			WordCountDiff:           analyses[1].WordCount - analyses[0].WordCount,
+			ImageCountDiff:          analyses[1].ImageCount - analyses[0].ImageCount,
+			LinkCountDiff:           analyses[1].LinkCount - analyses[0].LinkCount,
+			ColorPaletteOverlapPct:  paletteOverlap(palettes[0], palettes[1]),
+			SEOScoreDiff:            analyses[1].SEOScore - analyses[0].SEOScore,
+			AccessibilityIssuesDiff: analyses[1].AccessibilityIssues - analyses[0].AccessibilityIssues,
+		},
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, comparison, 5*time.Minute) // Cache for 5 minutes
+	}
+
+	return comparison, nil
+}
+
+// paletteOverlap returns the share of distinct swatch colors common to both
+// palettes, as a percentage of all colors used by either.
+func paletteOverlap(a, b []ColorSwatch) float64 {
+	colors := make(map[string]int)
+	for _, swatch := range a {
+		colors[swatch.Hex] |= 1
+	}
+	for _, swatch := range b {
+		colors[swatch.Hex] |= 2
+	}
+	if len(colors) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for _, sources := range colors {
+		if sources == 3 {
+			shared++
+		}
+	}
+
+	return math.Round(float64(shared)/float64(len(colors))*1000) / 10
+}
+
+func (s *ExportService) generateReadme(project *models.Project, preset ExportPreset, domain string) string {
 	description := "AI-generated website"
 	if project.Description != nil {
 		description = *project.Description
@@ -210,10 +533,7 @@ func (s *ExportService) generateReadme(project *models.Project) string {
 - `+"`index.html`"+` - Main HTML file
 %s
 
-## Setup
-
-1. Open `+"`index.html`"+` in your web browser
-2. Or serve the files using a web server for best results
+%s
 
 ## Generated By
 
@@ -223,6 +543,7 @@ Project ID: %s`,
 		project.Name,
 		description,
 		additionalFiles,
+		presetSetupInstructions(preset, domain),
 		project.CreatedAt.Format(time.RFC3339),
 		project.ID.String())
 }
@@ -281,22 +602,24 @@ Total Projects: %d
 `, len(projects), projectList, time.Now().Format(time.RFC3339), len(projects))
 }
 
-func (s *ExportService) addBasicAssets(writer *zip.Writer) {
+func (s *ExportService) addBasicAssets(writer *zip.Writer) error {
 	// Add favicon
 	faviconSVG := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 32 32">
   <rect width="32" height="32" fill="#667eea"/>
   <text x="16" y="20" font-family="Arial" font-size="18" fill="white" text-anchor="middle">W</text>
 </svg>`
-	faviconWriter, _ := writer.Create("favicon.svg")
-	faviconWriter.Write([]byte(faviconSVG))
+	if err := addZIPFile(writer, "favicon.svg", faviconSVG, time.Now()); err != nil {
+		return err
+	}
 
 	// Add robots.txt
 	robotsTxt := `User-agent: *
 Allow: /
 
 Sitemap: /sitemap.xml`
-	robotsWriter, _ := writer.Create("robots.txt")
-	robotsWriter.Write([]byte(robotsTxt))
+	if err := addZIPFile(writer, "robots.txt", robotsTxt, time.Now()); err != nil {
+		return err
+	}
 
 	// Add .gitignore
 	gitignore := `# Logs
@@ -318,6 +641,5 @@ node_modules/
 # OS
 .DS_Store
 Thumbs.db`
-	gitignoreWriter, _ := writer.Create(".gitignore")
-	gitignoreWriter.Write([]byte(gitignore))
+	return addZIPFile(writer, ".gitignore", gitignore, time.Now())
 }
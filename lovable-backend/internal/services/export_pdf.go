@@ -0,0 +1,108 @@
+// internal/services/export_pdf.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/metrics"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/storage"
+)
+
+var (
+	ErrPDFExportDisabled     = errors.New("pdf export is not enabled")
+	ErrPDFServiceUnavailable = errors.New("pdf service unavailable")
+)
+
+// PDFExportEnabled reports whether PDF export is switched on and has a
+// rendering service to use
+func (s *ExportService) PDFExportEnabled() bool {
+	return s.config.EnablePDFExport && s.config.PDFServiceURL != ""
+}
+
+// ExportPDF renders the project's page to PDF with the configured headless
+// browser service. The HTML is sent as the index.html file of a multipart
+// form, as Gotenberg's Chromium HTML route expects.
+func (s *ExportService) ExportPDF(ctx context.Context, userID, projectID uuid.UUID) ([]byte, string, error) {
+	if !s.PDFExportEnabled() {
+		return nil, "", ErrPDFExportDisabled
+	}
+
+	var project models.Project
+	if err := s.dbRouter.Reader().Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return nil, "", fmt.Errorf("project not found")
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, &project); err != nil {
+		return nil, "", err
+	}
+
+	if project.HTMLCode == nil || *project.HTMLCode == "" {
+		return nil, "", fmt.Errorf("no HTML code available for this project")
+	}
+
+	pdf, err := s.renderPDF(ctx, *project.HTMLCode)
+	if err != nil {
+		return nil, "", err
+	}
+
+	metrics.ExportsTotal.WithLabelValues("pdf").Inc()
+
+	filename := fmt.Sprintf("%s.pdf", strings.ReplaceAll(strings.ToLower(project.Name), " ", "-"))
+	s.recordExport(&models.ExportRecord{
+		UserID:       userID,
+		ProjectID:    &project.ID,
+		Format:       "pdf",
+		FileName:     filename,
+		FileSize:     int64(len(pdf)),
+		ProjectCount: 1,
+	})
+
+	return pdf, filename, nil
+}
+
+func (s *ExportService) renderPDF(ctx context.Context, htmlCode string) ([]byte, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("files", "index.html")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(file, htmlCode); err != nil {
+		return nil, err
+	}
+	if err := form.WriteField("printBackground", "true"); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.PDFServiceURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := s.pdfClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPDFServiceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrPDFServiceUnavailable, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
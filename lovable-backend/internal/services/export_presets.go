@@ -0,0 +1,154 @@
+// internal/services/export_presets.go
+package services
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportPreset selects the hosting provider a ZIP export is prepared for
+type ExportPreset int
+
+const (
+	ExportPresetGeneric ExportPreset = iota
+	// .nojekyll, plus a CNAME file when a custom domain is given
+	ExportPresetGitHubPages
+	// netlify.toml and a _redirects file routing unknown paths to index.html
+	ExportPresetNetlify
+	// vercel.json routing unknown paths to index.html
+	ExportPresetVercel
+)
+
+var exportPresetNames = map[ExportPreset]string{
+	ExportPresetGeneric:     "generic",
+	ExportPresetGitHubPages: "github-pages",
+	ExportPresetNetlify:     "netlify",
+	ExportPresetVercel:      "vercel",
+}
+
+func (p ExportPreset) String() string {
+	return exportPresetNames[p]
+}
+
+// ParseExportPreset maps the export ?preset= query value to a preset. An
+// empty value selects the generic export.
+func ParseExportPreset(value string) (ExportPreset, error) {
+	switch strings.ToLower(value) {
+	case "", "generic":
+		return ExportPresetGeneric, nil
+	case "github-pages", "github_pages", "githubpages":
+		return ExportPresetGitHubPages, nil
+	case "netlify":
+		return ExportPresetNetlify, nil
+	case "vercel":
+		return ExportPresetVercel, nil
+	default:
+		return ExportPresetGeneric, errors.New("unknown export preset")
+	}
+}
+
+// NormalizeExportDomain lower-cases a custom domain for a CNAME file and
+// checks that it is a plain host name such as "www.example.com"
+func NormalizeExportDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return "", nil
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(domain) > 253 || len(labels) < 2 {
+		return "", errors.New("invalid domain")
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", errors.New("invalid domain")
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return "", errors.New("invalid domain")
+			}
+		}
+	}
+
+	return domain, nil
+}
+
+// writePresetFiles adds the configuration files the preset's host reads
+func writePresetFiles(writer *zip.Writer, preset ExportPreset, domain string, modified time.Time) error {
+	switch preset {
+	case ExportPresetGitHubPages:
+		// Serve the files as they are instead of building them with Jekyll
+		if err := addZIPFile(writer, ".nojekyll", "", modified); err != nil {
+			return err
+		}
+		if domain != "" {
+			return addZIPFile(writer, "CNAME", domain+"\n", modified)
+		}
+	case ExportPresetNetlify:
+		netlifyTOML := `[build]
+  publish = "."
+  command = ""
+`
+		if err := addZIPFile(writer, "netlify.toml", netlifyTOML, modified); err != nil {
+			return err
+		}
+		// Existing files are served as they are; other paths get index.html
+		return addZIPFile(writer, "_redirects", "/*    /index.html    200\n", modified)
+	case ExportPresetVercel:
+		// The filesystem handler serves existing files before the catch-all
+		vercelJSON := `{
+  "routes": [
+    { "handle": "filesystem" },
+    { "src": "/(.*)", "dest": "/index.html" }
+  ]
+}
+`
+		return addZIPFile(writer, "vercel.json", vercelJSON, modified)
+	}
+
+	return nil
+}
+
+// presetSetupInstructions returns the README's setup section for the preset
+func presetSetupInstructions(preset ExportPreset, domain string) string {
+	switch preset {
+	case ExportPresetGitHubPages:
+		customDomain := "5. To use a custom domain, add a `CNAME` file containing it and point the domain's DNS at GitHub Pages"
+		if domain != "" {
+			customDomain = fmt.Sprintf("5. Point the DNS of `%s` at GitHub Pages; the included `CNAME` file sets it as the site's custom domain", domain)
+		}
+		return `## Deploy to GitHub Pages
+
+1. Create a GitHub repository and push the contents of this archive to it
+2. Open the repository's Settings > Pages
+3. Under Build and deployment, choose "Deploy from a branch" and select your branch and the ` + "`/ (root)`" + ` folder
+4. The site is published at ` + "`https://<user>.github.io/<repository>/`" + ` within a few minutes
+` + customDomain + `
+
+The ` + "`.nojekyll`" + ` file tells GitHub Pages to serve the files as they are.`
+	case ExportPresetNetlify:
+		return `## Deploy to Netlify
+
+1. Drag this folder onto https://app.netlify.com/drop
+2. Or push it to a Git repository and import it with "Add new site" > "Import an existing project"
+3. No build command is needed; ` + "`netlify.toml`" + ` publishes the folder as it is
+
+The ` + "`_redirects`" + ` file serves ` + "`index.html`" + ` for unknown paths, so client-side routes work on reload.`
+	case ExportPresetVercel:
+		return `## Deploy to Vercel
+
+1. Install the Vercel CLI with ` + "`npm i -g vercel`" + `
+2. Run ` + "`vercel`" + ` in this folder and follow the prompts, or push it to a Git repository and import it at https://vercel.com/new
+3. Choose "Other" as the framework preset; no build command is needed
+
+` + "`vercel.json`" + ` serves ` + "`index.html`" + ` for unknown paths, so client-side routes work on reload.`
+	default:
+		return `## Setup
+
+1. Open ` + "`index.html`" + ` in your web browser
+2. Or serve the files using a web server for best results`
+	}
+}
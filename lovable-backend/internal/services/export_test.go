@@ -0,0 +1,144 @@
+// internal/services/export_test.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+)
+
+func TestZIPExportPresets(t *testing.T) {
+	html := "<!DOCTYPE html><html><head><title>Acme</title></head><body><h1>Acme</h1></body></html>"
+	project := &models.Project{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Name:      "Acme Studio",
+		HTMLCode:  &html,
+		UpdatedAt: time.Now(),
+	}
+
+	presetFiles := []string{".nojekyll", "CNAME", "netlify.toml", "_redirects", "vercel.json"}
+
+	tests := []struct {
+		name   string
+		preset ExportPreset
+		domain string
+		// Files that must be in the archive, mapped to text they must contain
+		want map[string]string
+		// Deploy instructions expected in the README
+		readme string
+	}{
+		{
+			name:   "generic",
+			preset: ExportPresetGeneric,
+			want:   map[string]string{},
+			readme: "## Setup",
+		},
+		{
+			name:   "github pages",
+			preset: ExportPresetGitHubPages,
+			want:   map[string]string{".nojekyll": ""},
+			readme: "## Deploy to GitHub Pages",
+		},
+		{
+			name:   "github pages with domain",
+			preset: ExportPresetGitHubPages,
+			domain: "www.example.com",
+			want:   map[string]string{".nojekyll": "", "CNAME": "www.example.com\n"},
+			readme: "Point the DNS of `www.example.com` at GitHub Pages",
+		},
+		{
+			name:   "netlify",
+			preset: ExportPresetNetlify,
+			want:   map[string]string{"netlify.toml": `publish = "."`, "_redirects": "/*    /index.html    200"},
+			readme: "## Deploy to Netlify",
+		},
+		{
+			name:   "vercel",
+			preset: ExportPresetVercel,
+			want:   map[string]string{"vercel.json": `"dest": "/index.html"`},
+			readme: "## Deploy to Vercel",
+		},
+	}
+
+	s := &ExportService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive bytes.Buffer
+			writer := zip.NewWriter(&archive)
+			if err := s.writeZIPEntries(writer, project, ZIPExportOptions{Preset: tt.preset, Domain: tt.domain}); err != nil {
+				t.Fatalf("writeZIPEntries failed: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("failed to close archive: %v", err)
+			}
+
+			files := unzip(t, archive.Bytes())
+
+			for _, name := range []string{"index.html", "README.md", "package.json"} {
+				if _, ok := files[name]; !ok {
+					t.Errorf("archive is missing %s", name)
+				}
+			}
+			if files["index.html"] != html {
+				t.Errorf("index.html = %q, want the project's HTML", files["index.html"])
+			}
+			if !strings.Contains(files["README.md"], tt.readme) {
+				t.Errorf("README.md does not contain %q", tt.readme)
+			}
+
+			for name, content := range tt.want {
+				got, ok := files[name]
+				if !ok {
+					t.Errorf("archive is missing %s", name)
+					continue
+				}
+				if !strings.Contains(got, content) {
+					t.Errorf("%s = %q, want it to contain %q", name, got, content)
+				}
+			}
+
+			// Other presets' configuration must not leak into the archive
+			for _, name := range presetFiles {
+				if _, wanted := tt.want[name]; !wanted {
+					if _, ok := files[name]; ok {
+						t.Errorf("archive unexpectedly contains %s", name)
+					}
+				}
+			}
+		})
+	}
+}
+
+// unzip returns the contents of each file in the archive by name
+func unzip(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("invalid ZIP archive: %v", err)
+	}
+
+	files := make(map[string]string, len(reader.File))
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file.Name, err)
+		}
+		files[file.Name] = string(content)
+	}
+
+	return files
+}
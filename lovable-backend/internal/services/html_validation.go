@@ -0,0 +1,180 @@
+// internal/services/html_validation.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+
+	"lovable-backend/internal/storage"
+)
+
+// ValidationWarning describes a structural or accessibility problem in a page
+// that does not stop it from rendering
+type ValidationWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrMalformedHTML is returned for documents that cannot be validated at all
+var ErrMalformedHTML = errors.New("document is not valid HTML")
+
+// validateHTMLStructure tokenizes a generated page and reports missing or
+// duplicated document elements and common accessibility gaps as warnings.
+// Text inside <script>, <style> and comments is never mistaken for markup.
+// Only documents the tokenizer cannot read, or that contain no elements at
+// all, are rejected with an error.
+func validateHTMLStructure(document string) ([]ValidationWarning, error) {
+	tokenizer := html.NewTokenizer(strings.NewReader(document))
+
+	counts := map[string]int{}
+	var (
+		elements        int
+		inHead          bool
+		titlesInHead    int
+		hasViewport     bool
+		hasLang         bool
+		inlineNoType    int
+		imagesWithNoAlt int
+	)
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("%w: %v", ErrMalformedHTML, err)
+			}
+			break
+		}
+
+		if tokenType == html.EndTagToken {
+			if name, _ := tokenizer.TagName(); string(name) == "head" {
+				inHead = false
+			}
+			continue
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		elements++
+		token := tokenizer.Token()
+		counts[token.Data]++
+
+		switch token.Data {
+		case "html":
+			hasLang = hasLang || strings.TrimSpace(tokenAttr(token, "lang")) != ""
+		case "head":
+			inHead = tokenType == html.StartTagToken
+		case "body":
+			inHead = false
+		case "title":
+			if inHead {
+				titlesInHead++
+			}
+		case "meta":
+			if strings.EqualFold(tokenAttr(token, "name"), "viewport") {
+				hasViewport = true
+			}
+		case "script":
+			if !tokenHasAttr(token, "src") && !tokenHasAttr(token, "type") {
+				inlineNoType++
+			}
+		case "img":
+			if !tokenHasAttr(token, "alt") {
+				imagesWithNoAlt++
+			}
+		}
+	}
+
+	if elements == 0 {
+		return nil, fmt.Errorf("%w: no elements found", ErrMalformedHTML)
+	}
+
+	warnings := []ValidationWarning{}
+	for _, tag := range []string{"html", "head", "body"} {
+		switch {
+		case counts[tag] == 0:
+			warnings = append(warnings, ValidationWarning{Code: "MISSING_" + strings.ToUpper(tag), Message: fmt.Sprintf("Document has no <%s> element", tag)})
+		case counts[tag] > 1:
+			warnings = append(warnings, ValidationWarning{Code: "DUPLICATE_" + strings.ToUpper(tag), Message: fmt.Sprintf("Document has %d <%s> elements", counts[tag], tag)})
+		}
+	}
+
+	switch {
+	case titlesInHead == 0:
+		warnings = append(warnings, ValidationWarning{Code: "MISSING_TITLE", Message: "Document has no <title> inside <head>"})
+	case titlesInHead > 1:
+		warnings = append(warnings, ValidationWarning{Code: "DUPLICATE_TITLE", Message: fmt.Sprintf("Document has %d <title> elements inside <head>", titlesInHead)})
+	}
+
+	if !hasViewport {
+		warnings = append(warnings, ValidationWarning{Code: "MISSING_VIEWPORT", Message: "Document has no viewport meta tag"})
+	}
+	if counts["html"] > 0 && !hasLang {
+		warnings = append(warnings, ValidationWarning{Code: "MISSING_LANG", Message: "The <html> element has no lang attribute"})
+	}
+	if inlineNoType > 0 {
+		warnings = append(warnings, ValidationWarning{Code: "SCRIPT_WITHOUT_TYPE", Message: fmt.Sprintf("%d inline <script> element(s) have no type attribute", inlineNoType)})
+	}
+	if imagesWithNoAlt > 0 {
+		warnings = append(warnings, ValidationWarning{Code: "IMAGE_WITHOUT_ALT", Message: fmt.Sprintf("%d image(s) have no alt attribute", imagesWithNoAlt)})
+	}
+
+	return warnings, nil
+}
+
+func tokenAttr(token html.Token, key string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func tokenHasAttr(token html.Token, key string) bool {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// warningMessages flattens warnings for GenerationResult
+func warningMessages(warnings []ValidationWarning) []string {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(warnings))
+	for i, warning := range warnings {
+		messages[i] = warning.Message
+	}
+	return messages
+}
+
+// ValidateProject runs validateHTMLStructure against the project's saved code
+func (s *ProjectService) ValidateProject(ctx context.Context, userID, projectID uuid.UUID) ([]ValidationWarning, error) {
+	project, err := s.findAccessibleProject(s.dbRouter.Reader(), userID, projectID, ProjectRoleEditor, ProjectRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, project); err != nil {
+		return nil, err
+	}
+
+	if project.HTMLCode == nil || *project.HTMLCode == "" {
+		return nil, errors.New("project has no code")
+	}
+
+	return validateHTMLStructure(*project.HTMLCode)
+}
@@ -0,0 +1,113 @@
+// internal/services/lockout.go
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"lovable-backend/internal/models"
+)
+
+var ErrAccountLocked = errors.New("account temporarily locked due to too many failed login attempts")
+
+// AccountLockedError is returned by Login while an email address is locked
+// out. It matches ErrAccountLocked with errors.Is.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+func lockoutKey(email string) string {
+	return "lockout:" + strings.ToLower(email)
+}
+
+func (s *AuthService) lockoutWindow() time.Duration {
+	return time.Duration(s.securityConfig.LockoutWindowMinutes) * time.Minute
+}
+
+// checkLockout returns an AccountLockedError while the email is locked. With
+// Redis the lockout key is authoritative, so no query runs per login;
+// without it the attempts table is consulted directly.
+func (s *AuthService) checkLockout(email string) error {
+	if s.securityConfig.MaxLoginAttempts <= 0 {
+		return nil
+	}
+
+	if s.redisClient != nil && s.redisClient.Client != nil {
+		ttl, err := s.redisClient.Client.TTL(s.redisClient.Ctx, lockoutKey(email)).Result()
+		if err == nil {
+			if ttl > 0 {
+				return &AccountLockedError{RetryAfter: ttl}
+			}
+			return nil
+		}
+		s.logger.Warn("Lockout check failed, falling back to database", "error", err)
+	}
+
+	if retryAfter := s.lockoutRemaining(email); retryAfter > 0 {
+		return &AccountLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// lockoutRemaining reports how long the email stays locked. An email is locked
+// while the last MaxLoginAttempts failures since its latest successful login
+// all fall inside the window.
+func (s *AuthService) lockoutRemaining(email string) time.Duration {
+	window := s.lockoutWindow()
+
+	var failedAt []time.Time
+	s.db.Model(&models.LoginAttempt{}).
+		Where("email = ? AND success = ? AND created_at > ?", strings.ToLower(email), false, time.Now().Add(-window)).
+		Where("created_at > COALESCE((SELECT MAX(created_at) FROM login_attempts WHERE email = ? AND success), '-infinity')", strings.ToLower(email)).
+		Order("created_at DESC").
+		Limit(s.securityConfig.MaxLoginAttempts).
+		Pluck("created_at", &failedAt)
+
+	if len(failedAt) < s.securityConfig.MaxLoginAttempts {
+		return 0
+	}
+
+	// Unlocks once the oldest of those failures leaves the window
+	return time.Until(failedAt[len(failedAt)-1].Add(window))
+}
+
+// recordLoginAttempt stores the attempt and, after a failure, sets the Redis
+// lockout key if the email has now reached the threshold.
+func (s *AuthService) recordLoginAttempt(email, ipAddress string, success bool) {
+	attempt := models.LoginAttempt{
+		Email:     strings.ToLower(email),
+		IPAddress: ipAddress,
+		Success:   success,
+	}
+	if err := s.db.Create(&attempt).Error; err != nil {
+		s.logger.Error("Failed to record login attempt", "error", err)
+		return
+	}
+
+	if success || s.securityConfig.MaxLoginAttempts <= 0 || s.redisClient == nil {
+		return
+	}
+
+	if retryAfter := s.lockoutRemaining(email); retryAfter > 0 {
+		s.redisClient.Set(lockoutKey(email), true, retryAfter)
+		s.logger.LogSecurityEvent("account_locked", "", ipAddress, map[string]any{"email": attempt.Email, "retry_after": retryAfter.String()})
+	}
+}
+
+// UnlockAccount clears an email's lockout and the failed attempts behind it
+func (s *AuthService) UnlockAccount(email string) error {
+	if s.redisClient != nil {
+		s.redisClient.Del(lockoutKey(email))
+	}
+
+	return s.db.Where("email = ? AND success = ?", strings.ToLower(email), false).Delete(&models.LoginAttempt{}).Error
+}
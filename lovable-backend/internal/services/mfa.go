@@ -0,0 +1,387 @@
+// internal/services/mfa.go
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+var ErrMFANotConfigured = errors.New("two-factor authentication is not configured")
+
+// TOTP parameters (RFC 6238 defaults, which authenticator apps assume)
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// Accepted clock drift either side of the current step
+	totpSkewSteps = 1
+)
+
+// Issuer shown in authenticator apps
+const totpIssuer = "Lovable"
+
+// How long the intermediate token issued by Login stays valid
+const mfaTokenTTL = 5 * time.Minute
+
+const mfaBackupCodeCount = 10
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// SetupMFA generates a new TOTP secret for the user and stores it pending
+// confirmation. Any earlier unconfirmed setup is replaced.
+func (s *AuthService) SetupMFA(userID uuid.UUID) (*models.MFASetupResponse, error) {
+	if s.securityConfig.MFAEncryptionKey == "" {
+		return nil, ErrMFANotConfigured
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	var existing models.UserMFA
+	if err := s.db.Where("user_id = ?", userID).First(&existing).Error; err == nil && existing.EnabledAt != nil {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	rawSecret := make([]byte, 20)
+	if _, err := rand.Read(rawSecret); err != nil {
+		return nil, fmt.Errorf("failed to generate MFA secret: %w", err)
+	}
+	secret := base32NoPadding.EncodeToString(rawSecret)
+
+	encrypted, err := s.encryptMFASecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND enabled_at IS NULL", userID).Delete(&models.UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserMFA{UserID: userID, Secret: encrypted}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store MFA secret: %w", err)
+	}
+
+	uri := totpProvisioningURI(secret, user.Email)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QR code: %w", err)
+	}
+
+	return &models.MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// EnableMFA confirms a pending setup with a code from the authenticator app
+// and returns single-use backup codes, which are only shown this once.
+func (s *AuthService) EnableMFA(userID uuid.UUID, code string) ([]string, error) {
+	if s.securityConfig.MFAEncryptionKey == "" {
+		return nil, ErrMFANotConfigured
+	}
+
+	var mfa models.UserMFA
+	if err := s.db.Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		return nil, errors.New("two-factor authentication not set up")
+	}
+	if mfa.EnabledAt != nil {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	step, err := s.matchTOTP(&mfa, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+
+	backupCodes := make([]string, mfaBackupCodeCount)
+	hashedCodes := make(pq.StringArray, mfaBackupCodeCount)
+	for i := range backupCodes {
+		randomBytes := make([]byte, 5)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, fmt.Errorf("failed to generate backup codes: %w", err)
+		}
+		backupCodes[i] = strings.ToLower(base32NoPadding.EncodeToString(randomBytes))
+
+		// Backup codes are random, so the default cost is enough
+		hashed, err := bcrypt.GenerateFromPassword([]byte(backupCodes[i]), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash backup codes: %w", err)
+		}
+		hashedCodes[i] = string(hashed)
+	}
+
+	err = s.db.Model(&mfa).Updates(map[string]interface{}{
+		"enabled_at":     time.Now(),
+		"backup_codes":   hashedCodes,
+		"last_used_step": step,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return backupCodes, nil
+}
+
+// DisableMFA removes the user's enrollment after re-checking their password
+func (s *AuthService) DisableMFA(userID uuid.UUID, password string) error {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+
+	result := s.db.Where("user_id = ?", userID).Delete(&models.UserMFA{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("two-factor authentication not enabled")
+	}
+
+	return nil
+}
+
+// VerifyMFA exchanges the intermediate token from Login plus a TOTP or backup
+// code for the normal access/refresh token pair.
+func (s *AuthService) VerifyMFA(req *models.MFAVerifyRequest, ipAddress, userAgent string) (*models.AuthResponse, error) {
+	claims, err := s.validateMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", claims.UserID).Error; err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+	if !user.IsActive {
+		return nil, errors.New("account is disabled")
+	}
+
+	var mfa models.UserMFA
+	if err := s.db.Where("user_id = ? AND enabled_at IS NOT NULL", user.ID).First(&mfa).Error; err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	code := strings.ToLower(strings.TrimSpace(req.Code))
+	if step, err := s.matchTOTP(&mfa, code); err == nil {
+		// Conditional update so a code can't be replayed within its window
+		result := s.db.Model(&models.UserMFA{}).
+			Where("id = ? AND last_used_step < ?", mfa.ID, step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, errors.New("invalid verification code")
+		}
+	} else if !s.redeemBackupCode(&mfa, code) {
+		return nil, errors.New("invalid verification code")
+	}
+
+	return s.completeLogin(&user, ipAddress, userAgent)
+}
+
+// HasMFAEnabled reports whether login must be completed with a second factor
+func (s *AuthService) HasMFAEnabled(userID uuid.UUID) bool {
+	var count int64
+	s.db.Model(&models.UserMFA{}).Where("user_id = ? AND enabled_at IS NOT NULL", userID).Count(&count)
+	return count > 0
+}
+
+// matchTOTP returns the time step the code is valid for, allowing for clock
+// drift. Steps already used are rejected.
+func (s *AuthService) matchTOTP(mfa *models.UserMFA, code string) (int64, error) {
+	secret, err := s.decryptMFASecret(mfa.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		return 0, errors.New("invalid MFA secret")
+	}
+
+	current := time.Now().Unix() / totpPeriod
+	for offset := int64(-totpSkewSteps); offset <= totpSkewSteps; offset++ {
+		step := current + offset
+		if step <= mfa.LastUsedStep {
+			continue
+		}
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, nil
+		}
+	}
+
+	return 0, errors.New("invalid verification code")
+}
+
+// redeemBackupCode consumes a matching backup code so it can't be used again
+func (s *AuthService) redeemBackupCode(mfa *models.UserMFA, code string) bool {
+	for _, hashed := range mfa.BackupCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hashed), []byte(code)) != nil {
+			continue
+		}
+
+		// Only the request that actually removes the code may use it
+		result := s.db.Model(&models.UserMFA{}).
+			Where("id = ? AND ? = ANY(backup_codes)", mfa.ID, hashed).
+			Update("backup_codes", gorm.Expr("array_remove(backup_codes, ?)", hashed))
+		return result.Error == nil && result.RowsAffected == 1
+	}
+
+	return false
+}
+
+// totpCode computes the RFC 6238 code (HMAC-SHA1, dynamic truncation) for a
+// time step.
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%uint32(math.Pow10(totpDigits)))
+}
+
+func totpProvisioningURI(secret, email string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(totpIssuer + ":" + email)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+func (s *AuthService) mfaCipher() (cipher.AEAD, error) {
+	if s.securityConfig.MFAEncryptionKey == "" {
+		return nil, ErrMFANotConfigured
+	}
+
+	// Derive a fixed-size AES-256 key from the configured value
+	key := sha256.Sum256([]byte(s.securityConfig.MFAEncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (s *AuthService) encryptMFASecret(secret string) (string, error) {
+	gcm, err := s.mfaCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to encrypt MFA secret: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *AuthService) decryptMFASecret(encrypted string) (string, error) {
+	gcm, err := s.mfaCipher()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid MFA secret")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("invalid MFA secret")
+	}
+
+	return string(plain), nil
+}
+
+// mfaChallenge answers a login whose first factor succeeded with the
+// intermediate token to be exchanged at /auth/mfa/verify
+func (s *AuthService) mfaChallenge(userID uuid.UUID) (*models.AuthResponse, error) {
+	mfaToken, err := s.generateMFAToken(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MFA token: %w", err)
+	}
+
+	return &models.AuthResponse{
+		Message:     "Two-factor authentication required",
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   mfaTokenTTL.String(),
+	}, nil
+}
+
+func (s *AuthService) generateMFAToken(userID uuid.UUID) (string, error) {
+	claims := JWTClaims{
+		UserID: userID,
+		Type:   "mfa",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "lovable-backend",
+			Subject:   userID.String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.jwtConfig.Secret))
+}
+
+func (s *AuthService) validateMFAToken(tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtConfig.Secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.Type == "mfa" {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid MFA token")
+}
@@ -0,0 +1,237 @@
+// internal/services/minify.go
+package services
+
+import (
+	"html"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// MinifyMode selects how exported HTML is minified
+type MinifyMode int
+
+const (
+	MinifyNone MinifyMode = iota
+	// Drop comments and collapse insignificant whitespace
+	MinifyWhitespace
+	// Whitespace, plus unquoted attribute values and bare boolean attributes
+	MinifyAggressive
+)
+
+// ParseMinifyMode maps the export ?minify= query value to a mode: "true"
+// selects whitespace minification and "aggressive" the aggressive mode
+func ParseMinifyMode(value string) MinifyMode {
+	switch strings.ToLower(value) {
+	case "true", "whitespace":
+		return MinifyWhitespace
+	case "aggressive":
+		return MinifyAggressive
+	default:
+		return MinifyNone
+	}
+}
+
+// Elements whose content is kept byte for byte
+var preservedElements = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+}
+
+// Elements next to which whitespace never renders
+var blockElements = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true, "link": true, "base": true,
+	"script": true, "style": true, "noscript": true, "template": true,
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true, "dialog": true,
+	"div": true, "dl": true, "dd": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hgroup": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "summary": true, "ul": true, "option": true, "optgroup": true,
+	"table": true, "caption": true, "colgroup": true, "col": true, "thead": true, "tbody": true,
+	"tfoot": true, "tr": true, "td": true, "th": true,
+}
+
+// Attributes whose presence alone carries their meaning
+var booleanAttributes = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true, "checked": true,
+	"controls": true, "default": true, "defer": true, "disabled": true, "formnovalidate": true,
+	"hidden": true, "inert": true, "ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true, "playsinline": true,
+	"readonly": true, "required": true, "reversed": true, "selected": true,
+}
+
+// minifyHTML rewrites a document token by token. Comments other than IE
+// conditional comments are dropped, whitespace next to block-level elements
+// is removed and other whitespace runs collapse to one space. Content of
+// <pre>, <textarea>, <script> and <style> is copied verbatim, as are SVG and
+// MathML tags, whose attribute names are case-sensitive.
+func minifyHTML(document string, mode MinifyMode) string {
+	if mode == MinifyNone {
+		return document
+	}
+
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(document))
+
+	var out strings.Builder
+	out.Grow(len(document))
+
+	var (
+		preserveDepth int
+		foreignDepth  int
+		// The start of the document behaves like a block boundary
+		afterBlock   = true
+		pendingSpace bool
+	)
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == nethtml.ErrorToken {
+			// Reading from a string only fails at EOF
+			break
+		}
+
+		// Copy before TagName and Token lowercase the buffer in place
+		raw := string(tokenizer.Raw())
+
+		switch tokenType {
+		case nethtml.CommentToken:
+			if strings.HasPrefix(raw, "<!--[if") || strings.Contains(raw, "<![endif]") {
+				out.WriteString(raw)
+			}
+
+		case nethtml.TextToken:
+			if preserveDepth > 0 {
+				out.WriteString(raw)
+				afterBlock = false
+				continue
+			}
+
+			text := collapseWhitespace(raw)
+			if text == " " || text == "" {
+				pendingSpace = pendingSpace || !afterBlock
+				continue
+			}
+
+			if strings.HasPrefix(text, " ") {
+				pendingSpace = pendingSpace || !afterBlock
+				text = text[1:]
+			}
+			if pendingSpace {
+				out.WriteByte(' ')
+				pendingSpace = false
+			}
+
+			// A trailing space is only kept if the next tag is inline
+			if strings.HasSuffix(text, " ") {
+				text = text[:len(text)-1]
+				pendingSpace = true
+			}
+			out.WriteString(text)
+			afterBlock = false
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken, nethtml.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			block := blockElements[tag]
+
+			if pendingSpace && !block {
+				out.WriteByte(' ')
+			}
+			pendingSpace = false
+			afterBlock = block
+
+			if tokenType == nethtml.EndTagToken {
+				out.WriteString(raw)
+				if preservedElements[tag] && preserveDepth > 0 {
+					preserveDepth--
+				}
+				if (tag == "svg" || tag == "math") && foreignDepth > 0 {
+					foreignDepth--
+				}
+				continue
+			}
+
+			if mode == MinifyAggressive && foreignDepth == 0 && tag != "svg" && tag != "math" {
+				writeMinifiedTag(&out, tag, tokenizer)
+			} else {
+				out.WriteString(raw)
+			}
+
+			if tokenType == nethtml.StartTagToken {
+				if preservedElements[tag] {
+					preserveDepth++
+				}
+				if tag == "svg" || tag == "math" {
+					foreignDepth++
+				}
+			}
+
+		default:
+			// Doctype
+			out.WriteString(raw)
+			afterBlock = true
+		}
+	}
+
+	return out.String()
+}
+
+// collapseWhitespace replaces each run of HTML whitespace with one space
+func collapseWhitespace(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+
+	inSpace := false
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !inSpace {
+				b.WriteByte(' ')
+				inSpace = true
+			}
+		default:
+			b.WriteByte(text[i])
+			inSpace = false
+		}
+	}
+
+	return b.String()
+}
+
+// writeMinifiedTag writes the current start tag with boolean attributes
+// reduced to their name and values unquoted where HTML allows it
+func writeMinifiedTag(out *strings.Builder, tag string, tokenizer *nethtml.Tokenizer) {
+	out.WriteByte('<')
+	out.WriteString(tag)
+
+	for {
+		key, value, more := tokenizer.TagAttr()
+		if key == nil {
+			break
+		}
+
+		name := string(key)
+		val := string(value)
+
+		out.WriteByte(' ')
+		out.WriteString(name)
+
+		switch {
+		case booleanAttributes[name] && (val == "" || strings.EqualFold(val, name)):
+		case val != "" && !strings.ContainsAny(val, " \t\n\r\f\"'=<>`&"):
+			out.WriteByte('=')
+			out.WriteString(val)
+		default:
+			out.WriteString(`="`)
+			out.WriteString(html.EscapeString(val))
+			out.WriteByte('"')
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	// A trailing slash would be read as part of an unquoted value, and is
+	// meaningless on HTML elements anyway
+	out.WriteByte('>')
+}
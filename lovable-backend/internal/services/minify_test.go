@@ -0,0 +1,100 @@
+// internal/services/minify_test.go
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Size of the benchmark document, about that of a typical generated site
+const benchmarkHTMLSize = 50 << 10
+
+// benchmarkHTML builds an indented, commented landing page of about 50 KB
+// with the inline CSS, JavaScript, <pre> and <textarea> content generated
+// sites usually have.
+func benchmarkHTML() string {
+	var page strings.Builder
+	page.WriteString(`<!DOCTYPE html>
+<html lang="en">
+    <head>
+        <meta charset="UTF-8">
+        <meta name="viewport" content="width=device-width, initial-scale=1.0">
+        <title>Acme Studio</title>
+        <style>
+            :root { --primary: #6366f1; --text: #111827; }
+            body { font-family: "Inter", sans-serif; color: var(--text); }
+            .card > h2 { margin: 0 0 8px; }
+        </style>
+    </head>
+    <body>
+        <!-- Navigation -->
+        <nav class="navbar">
+            <ul>
+                <li><a href="#features">Features</a></li>
+                <li><a href="#pricing">Pricing</a></li>
+            </ul>
+        </nav>
+`)
+
+	for i := 0; page.Len() < benchmarkHTMLSize-1024; i++ {
+		fmt.Fprintf(&page, `
+        <!-- Section %d -->
+        <section id="section-%d" class="section">
+            <div class="card">
+                <h2>Feature   number %d</h2>
+                <p>
+                    Build   beautiful websites in minutes with <strong>AI assistance</strong>,
+                    then   export them   anywhere.
+                </p>
+                <pre><code>npm install acme
+acme deploy   --site %d</code></pre>
+                <form>
+                    <input type="checkbox" checked="checked" disabled="disabled" name="opt-in-%d">
+                    <textarea name="notes">  Keep
+    this   spacing  </textarea>
+                    <button type="submit" class="btn btn-primary">Subscribe</button>
+                </form>
+            </div>
+        </section>
+`, i, i, i, i, i)
+	}
+
+	page.WriteString(`
+        <script>
+            document.querySelectorAll(".btn").forEach(function (button) {
+                button.addEventListener("click", function () { console.log("clicked   " + button.textContent); });
+            });
+        </script>
+    </body>
+</html>
+`)
+
+	return page.String()
+}
+
+func BenchmarkMinifyHTML(b *testing.B) {
+	document := benchmarkHTML()
+
+	for _, mode := range []struct {
+		name string
+		mode MinifyMode
+	}{
+		{"whitespace", MinifyWhitespace},
+		{"aggressive", MinifyAggressive},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			b.SetBytes(int64(len(document)))
+			b.ReportAllocs()
+
+			var minified string
+			for i := 0; i < b.N; i++ {
+				minified = minifyHTML(document, mode.mode)
+			}
+
+			b.ReportMetric(float64(len(document)), "input-bytes")
+			b.ReportMetric(float64(len(minified)), "output-bytes")
+			b.ReportMetric(100*(1-float64(len(minified))/float64(len(document))), "%reduction")
+		})
+	}
+}
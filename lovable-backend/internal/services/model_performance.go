@@ -0,0 +1,122 @@
+// internal/services/model_performance.go
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+type ratingRow struct {
+	Key               string
+	Conversations     int64
+	Rated             int64
+	AverageRating     *float64
+	TotalTokens       int64
+	AvgResponseTimeMS float64
+}
+
+// GetModelPerformanceStats summarizes how the user rated AI responses,
+// overall and per model and message type
+func (s *AIService) GetModelPerformanceStats(userID uuid.UUID) (*models.ModelStats, error) {
+	return s.modelStats(s.dbRouter.Reader().Model(&models.Conversation{}).Where("user_id = ?", userID))
+}
+
+func (s *AIService) modelStats(scope *gorm.DB) (*models.ModelStats, error) {
+	byModel, err := ratingBreakdown(scope, "COALESCE(model_used, 'unknown')")
+	if err != nil {
+		return nil, err
+	}
+
+	byMessageType, err := ratingBreakdown(scope, "message_type")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.ModelStats{
+		ByModel:       byModel,
+		ByMessageType: byMessageType,
+	}
+
+	var ratingSum float64
+	for _, row := range byModel {
+		stats.TotalConversations += row.Conversations
+		stats.RatedConversations += row.Rated
+		if row.AverageRating != nil {
+			ratingSum += *row.AverageRating * float64(row.Rated)
+		}
+	}
+	stats.UnratedConversations = stats.TotalConversations - stats.RatedConversations
+	if stats.RatedConversations > 0 {
+		average := ratingSum / float64(stats.RatedConversations)
+		stats.AverageRating = &average
+	}
+
+	return stats, nil
+}
+
+func ratingBreakdown(scope *gorm.DB, keyExpr string) ([]models.RatingBreakdown, error) {
+	var rows []ratingRow
+	if err := scope.Session(&gorm.Session{}).
+		Select(keyExpr + ` AS key,
+			COUNT(*) AS conversations,
+			COUNT(satisfaction_rating) AS rated,
+			AVG(satisfaction_rating)::float8 AS average_rating,
+			COALESCE(SUM(tokens_used), 0) AS total_tokens,
+			COALESCE(AVG(response_time_ms), 0)::float8 AS avg_response_time_ms`).
+		Group("1").
+		Order("conversations DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	breakdown := make([]models.RatingBreakdown, len(rows))
+	for i, row := range rows {
+		breakdown[i] = models.RatingBreakdown(row)
+	}
+	return breakdown, nil
+}
+
+// StartPerformanceReportWorker logs a summary of the AI responses rated
+// across all users once per interval until the context is cancelled
+func (s *AIService) StartPerformanceReportWorker(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		since := time.Now().Add(-interval)
+		stats, err := s.modelStats(s.dbRouter.Reader().Model(&models.Conversation{}).Where("created_at > ?", since))
+		if err != nil {
+			s.logger.Error("Failed to build AI performance summary", "error", err)
+			continue
+		}
+
+		s.logger.Info("AI performance summary",
+			"since", since.Format(time.RFC3339),
+			"conversations", stats.TotalConversations,
+			"rated", stats.RatedConversations,
+			"averageRating", stats.AverageRating,
+		)
+
+		// One generation log entry per model with its totals; a model counts
+		// as successful while its average rating is at least 3
+		for _, model := range stats.ByModel {
+			success := model.AverageRating == nil || *model.AverageRating >= 3
+			s.logger.LogAIGeneration("", "", model.Key, int(model.TotalTokens), int(model.AvgResponseTimeMS), success)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// internal/services/moderation.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+const (
+	ModerationModeBlocklist = "blocklist"
+	ModerationModeAI        = "ai"
+)
+
+// Interval at which the blocklist is re-read even without file events
+const blocklistReloadInterval = 5 * time.Minute
+
+type ModerationService struct {
+	db        *gorm.DB
+	aiService *AIService
+	config    config.ModerationConfig
+	logger    *logger.Logger
+
+	mu        sync.RWMutex
+	mode      string
+	blocklist map[string]struct{}
+}
+
+type moderationBlocklist struct {
+	BlockedKeywords []string `yaml:"blocked_keywords"`
+}
+
+func NewModerationService(db *gorm.DB, aiService *AIService, config config.ModerationConfig, logger *logger.Logger) *ModerationService {
+	mode := config.Mode
+	if mode != ModerationModeAI {
+		mode = ModerationModeBlocklist
+	}
+
+	s := &ModerationService{
+		db:        db,
+		aiService: aiService,
+		config:    config,
+		logger:    logger,
+		mode:      mode,
+		blocklist: make(map[string]struct{}),
+	}
+
+	if err := s.loadBlocklist(); err != nil {
+		logger.Warn("Failed to load moderation blocklist", "path", config.BlocklistPath, "error", err)
+	}
+
+	return s
+}
+
+// CheckPrompt reports whether a prompt may be sent for generation. Blocked
+// prompts are recorded as moderation events.
+func (s *ModerationService) CheckPrompt(ctx context.Context, userID uuid.UUID, prompt string) (bool, string, error) {
+	var allowed bool
+	var reason string
+	var err error
+
+	switch s.Mode() {
+	case ModerationModeAI:
+		allowed, reason, err = s.checkWithAI(ctx, prompt)
+	default:
+		allowed, reason = s.checkBlocklist(prompt)
+	}
+
+	if err != nil {
+		return false, "", err
+	}
+
+	if !allowed {
+		event := models.ModerationEvent{
+			UserID:     userID,
+			PromptHash: fmt.Sprintf("%x", sha256.Sum256([]byte(prompt))),
+			Reason:     reason,
+		}
+		if err := s.db.WithContext(ctx).Create(&event).Error; err != nil {
+			s.logger.Error("Failed to record moderation event", "userID", userID, "error", err)
+		}
+	}
+
+	return allowed, reason, nil
+}
+
+func (s *ModerationService) Mode() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mode
+}
+
+func (s *ModerationService) SetMode(mode string) error {
+	if mode != ModerationModeBlocklist && mode != ModerationModeAI {
+		return errors.New("invalid moderation mode")
+	}
+
+	s.mu.Lock()
+	s.mode = mode
+	s.mu.Unlock()
+
+	s.logger.Info("Moderation mode changed", "mode", mode)
+	return nil
+}
+
+// WatchBlocklist reloads the blocklist whenever the file changes and at a
+// fixed interval, until the context is cancelled.
+func (s *ModerationService) WatchBlocklist(ctx context.Context) {
+	ticker := time.NewTicker(blocklistReloadInterval)
+	defer ticker.Stop()
+
+	var events chan fsnotify.Event
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.logger.Warn("Blocklist file watching unavailable", "error", err)
+	} else {
+		defer watcher.Close()
+
+		// Watch the directory so files replaced by editors or config mounts are picked up
+		if err := watcher.Add(filepath.Dir(s.config.BlocklistPath)); err != nil {
+			s.logger.Warn("Failed to watch blocklist directory", "path", s.config.BlocklistPath, "error", err)
+		} else {
+			events = watcher.Events
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if filepath.Clean(event.Name) != filepath.Clean(s.config.BlocklistPath) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := s.loadBlocklist(); err != nil {
+				s.logger.Warn("Failed to reload moderation blocklist", "error", err)
+			}
+		case <-ticker.C:
+			if err := s.loadBlocklist(); err != nil {
+				s.logger.Warn("Failed to reload moderation blocklist", "error", err)
+			}
+		}
+	}
+}
+
+func (s *ModerationService) loadBlocklist() error {
+	data, err := os.ReadFile(s.config.BlocklistPath)
+	if err != nil {
+		return err
+	}
+
+	var list moderationBlocklist
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("invalid blocklist file: %w", err)
+	}
+
+	blocklist := make(map[string]struct{}, len(list.BlockedKeywords))
+	for _, keyword := range list.BlockedKeywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" {
+			blocklist[keyword] = struct{}{}
+		}
+	}
+
+	s.mu.Lock()
+	s.blocklist = blocklist
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *ModerationService) checkBlocklist(prompt string) (bool, string) {
+	words := strings.FieldsFunc(strings.ToLower(prompt), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, word := range words {
+		if _, blocked := s.blocklist[word]; blocked {
+			return false, fmt.Sprintf("prompt contains blocked keyword %q", word)
+		}
+	}
+
+	return true, ""
+}
+
+func (s *ModerationService) checkWithAI(ctx context.Context, prompt string) (bool, string, error) {
+	flagged, reason, err := s.aiService.ClassifyPrompt(ctx, prompt)
+	if err != nil {
+		return false, "", err
+	}
+
+	if flagged {
+		if reason == "" {
+			reason = "prompt flagged by AI moderation"
+		}
+		return false, reason, nil
+	}
+
+	return true, "", nil
+}
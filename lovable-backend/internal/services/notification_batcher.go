@@ -0,0 +1,244 @@
+// internal/services/notification_batcher.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+	"lovable-backend/pkg/logger"
+)
+
+// How often queued events are summarized into a single email per user
+const notificationBatchInterval = 15 * time.Minute
+
+const notificationPendingUsersKey = "notif_batch:pending"
+
+type NotificationBatcher struct {
+	db           *gorm.DB
+	redisClient  *redis.Client
+	emailService *EmailService
+	logger       *logger.Logger
+}
+
+type notificationEvent struct {
+	EventType string    `json:"event_type"`
+	ProjectID string    `json:"project_id"`
+	QueuedAt  time.Time `json:"queued_at"`
+}
+
+type batchedProjectSummary struct {
+	ProjectName string
+	EventType   string
+	Count       int
+}
+
+type batchedEmailData struct {
+	Name      string
+	Headline  string
+	Summaries []batchedProjectSummary
+	Period    string
+}
+
+var BatchedEmailTemplate = template.Must(template.New("batched_email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif; color: #1f2937;">
+  <p>Hi {{.Name}},</p>
+  <p>{{.Headline}}</p>
+  <table cellpadding="8" cellspacing="0" style="border-collapse: collapse; border: 1px solid #e5e7eb;">
+    <thead>
+      <tr style="background: #f9fafb; text-align: left;">
+        <th>Project</th>
+        <th>Activity</th>
+        <th>Count</th>
+      </tr>
+    </thead>
+    <tbody>
+      {{range .Summaries}}
+      <tr style="border-top: 1px solid #e5e7eb;">
+        <td>{{.ProjectName}}</td>
+        <td>{{.EventType}}</td>
+        <td>{{.Count}}</td>
+      </tr>
+      {{end}}
+    </tbody>
+  </table>
+  <p style="color: #6b7280;">Activity from the last {{.Period}}.</p>
+  <p>AI Website Builder</p>
+</body>
+</html>`))
+
+func NewNotificationBatcher(db *gorm.DB, redisClient *redis.Client, emailService *EmailService, logger *logger.Logger) *NotificationBatcher {
+	return &NotificationBatcher{
+		db:           db,
+		redisClient:  redisClient,
+		emailService: emailService,
+		logger:       logger,
+	}
+}
+
+// Queue records an event to be included in the user's next summary email
+func (b *NotificationBatcher) Queue(userID uuid.UUID, eventType, projectID string) error {
+	if b.redisClient == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(notificationEvent{
+		EventType: eventType,
+		ProjectID: projectID,
+		QueuedAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := b.redisClient.RPush(fmt.Sprintf("notif_batch:%s", userID.String()), string(data)); err != nil {
+		return err
+	}
+
+	return b.redisClient.SAdd(notificationPendingUsersKey, userID.String())
+}
+
+func (b *NotificationBatcher) StartWorker(ctx context.Context) {
+	if b.redisClient == nil {
+		return
+	}
+
+	ticker := time.NewTicker(notificationBatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.flush()
+		}
+	}
+}
+
+func (b *NotificationBatcher) flush() {
+	userIDs, err := b.redisClient.SPopAll(notificationPendingUsersKey)
+	if err != nil {
+		b.logger.Error("Failed to read pending notification users", "error", err)
+		return
+	}
+
+	for _, userIDStr := range userIDs {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			continue
+		}
+
+		items, err := b.redisClient.LDrain(fmt.Sprintf("notif_batch:%s", userIDStr))
+		if err != nil {
+			b.logger.Error("Failed to drain notification batch", "userID", userID, "error", err)
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		if err := b.sendSummary(userID, items); err != nil {
+			b.logger.Error("Failed to send notification summary", "userID", userID, "error", err)
+		}
+	}
+}
+
+func (b *NotificationBatcher) sendSummary(userID uuid.UUID, items []string) error {
+	var user models.User
+	if err := b.db.Select("id", "email", "name", "is_guest").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	if user.IsGuest {
+		return nil
+	}
+
+	// Group events by project and type
+	type groupKey struct{ projectID, eventType string }
+	counts := make(map[groupKey]int)
+	seenProjects := make(map[string]bool)
+	var projectIDs []string
+	for _, item := range items {
+		var event notificationEvent
+		if err := json.Unmarshal([]byte(item), &event); err != nil {
+			continue
+		}
+		if !seenProjects[event.ProjectID] {
+			seenProjects[event.ProjectID] = true
+			projectIDs = append(projectIDs, event.ProjectID)
+		}
+		counts[groupKey{event.ProjectID, event.EventType}]++
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	var projects []models.Project
+	b.db.Select("id", "name").Where("id IN ?", projectIDs).Find(&projects)
+	names := make(map[string]string, len(projects))
+	for _, project := range projects {
+		names[project.ID.String()] = project.Name
+	}
+
+	summaries := make([]batchedProjectSummary, 0, len(counts))
+	for key, count := range counts {
+		name, ok := names[key.projectID]
+		if !ok {
+			name = "Untitled project"
+		}
+		summaries = append(summaries, batchedProjectSummary{
+			ProjectName: name,
+			EventType:   pluralizeEvent(key.eventType, count),
+			Count:       count,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].ProjectName < summaries[j].ProjectName
+	})
+
+	period := fmt.Sprintf("%d minutes", int(notificationBatchInterval.Minutes()))
+	headline := fmt.Sprintf("You completed %d %s on '%s' in the last %s.",
+		summaries[0].Count, summaries[0].EventType, summaries[0].ProjectName, period)
+	if len(projectIDs) > 1 {
+		headline = fmt.Sprintf("You were busy in the last %s. Here's a summary of your activity across %d projects.", period, len(projectIDs))
+	} else if len(summaries) > 1 {
+		headline = fmt.Sprintf("You were busy on '%s' in the last %s. Here's a summary of your activity.", summaries[0].ProjectName, period)
+	}
+
+	name := "there"
+	if user.Name != nil && *user.Name != "" {
+		name = *user.Name
+	}
+
+	var body bytes.Buffer
+	if err := BatchedEmailTemplate.Execute(&body, batchedEmailData{
+		Name:      name,
+		Headline:  headline,
+		Summaries: summaries,
+		Period:    period,
+	}); err != nil {
+		return err
+	}
+
+	return b.emailService.SendHTML(user.Email, "Your recent activity", body.String())
+}
+
+func pluralizeEvent(eventType string, count int) string {
+	if count == 1 {
+		return eventType
+	}
+	return eventType + "s"
+}
@@ -0,0 +1,205 @@
+// internal/services/notifications.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/worker"
+	"lovable-backend/internal/ws"
+	"lovable-backend/pkg/logger"
+)
+
+// Notification types
+const (
+	NotificationGenerationComplete = "generation_complete"
+	NotificationExportReady        = "export_ready"
+	NotificationUsageWarning       = "usage_warning"
+	NotificationCollaboratorInvite = "collaborator_invite"
+	NotificationTemplateApproved   = "template_approved"
+	NotificationSystem             = "system"
+)
+
+var notificationTypes = map[string]bool{
+	NotificationGenerationComplete: true,
+	NotificationExportReady:        true,
+	NotificationUsageWarning:       true,
+	NotificationCollaboratorInvite: true,
+	NotificationTemplateApproved:   true,
+	NotificationSystem:             true,
+}
+
+// NotificationService stores users' in-app notifications, pushes them to
+// open WebSocket connections and emails them to users who opted in
+type NotificationService struct {
+	db           *gorm.DB // primary
+	dbRouter     *database.DBRouter
+	emailService *EmailService
+	wsHub        *ws.WSHub
+	logger       *logger.Logger
+}
+
+func NewNotificationService(dbRouter *database.DBRouter, emailService *EmailService, wsHub *ws.WSHub, logger *logger.Logger) *NotificationService {
+	return &NotificationService{
+		db:           dbRouter.Writer(),
+		dbRouter:     dbRouter,
+		emailService: emailService,
+		wsHub:        wsHub,
+		logger:       logger,
+	}
+}
+
+// Send stores a notification for the user and pushes it as a "notification"
+// event to their open WebSocket connections. Users with notification emails
+// enabled are also sent it by email. Only storing it can fail; delivery
+// failures are logged.
+func (s *NotificationService) Send(userID uuid.UUID, notificationType, title, body string, data map[string]any) error {
+	if !notificationTypes[notificationType] {
+		return errors.New("invalid notification type")
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	notification := models.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+		Data:   payload,
+	}
+	if err := s.db.Create(&notification).Error; err != nil {
+		return err
+	}
+
+	if s.wsHub != nil {
+		if err := s.wsHub.Broadcast(userID, map[string]interface{}{
+			"type":         "notification",
+			"notification": notification,
+		}); err != nil {
+			s.logger.Warn("Failed to push notification", "userID", userID, "notificationId", notification.ID, "error", err)
+		}
+	}
+
+	var user models.User
+	if err := s.db.Select("email", "is_guest", "notification_email_enabled").First(&user, "id = ?", userID).Error; err != nil {
+		s.logger.Error("Failed to load notification recipient", "userID", userID, "error", err)
+		return nil
+	}
+	if !user.NotificationEmailEnabled || user.IsGuest {
+		return nil
+	}
+
+	// Sent by the job workers, or directly when it can't be queued
+	email := EmailJob{
+		To:      user.Email,
+		Subject: title,
+		Body:    fmt.Sprintf("Hi,\n\n%s\n\nAI Website Builder", body),
+	}
+	if _, err := worker.Enqueue(s.db, JobTypeEmail, email); err != nil {
+		s.logger.Error("Failed to queue notification email", "userID", userID, "notificationId", notification.ID, "error", err)
+		if err := s.emailService.Send(email.To, email.Subject, email.Body); err != nil {
+			s.logger.Error("Failed to send notification email", "userID", userID, "notificationId", notification.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// ListNotifications returns the user's notifications, newest first,
+// optionally only the read or unread ones
+func (s *NotificationService) ListNotifications(ctx context.Context, userID uuid.UUID, isRead *bool, page, limit int) (*models.NotificationListResponse, error) {
+	reader := s.dbRouter.Reader()
+
+	db := reader.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if isRead != nil {
+		db = db.Where("is_read = ?", *isRead)
+	}
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	notifications := []models.Notification{}
+	if err := db.Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	unreadCount, err := s.UnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	return &models.NotificationListResponse{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: page < totalPages,
+			HasPrevPage: page > 1,
+		},
+	}, nil
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	if err := s.dbRouter.Reader().Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	result := s.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("is_read", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("notification not found")
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and
+// returns how many there were
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Update("is_read", true)
+	return result.RowsAffected, result.Error
+}
+
+func (s *NotificationService) DeleteNotification(ctx context.Context, userID, notificationID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", notificationID, userID).Delete(&models.Notification{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("notification not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+// internal/services/oauth.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+)
+
+// How long the user has to finish the provider's consent screen
+const oauthStateTTL = 10 * time.Minute
+
+const googleUserInfoURL = "https://www.googleapis.com/oauth2/v3/userinfo"
+
+var ErrOAuthNotConfigured = errors.New("Google sign-in is not configured")
+
+type OAuthService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	authService *AuthService
+	google      *oauth2.Config
+}
+
+type googleProfile struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// NewOAuthService returns a service with Google sign-in disabled unless a
+// client ID, secret and redirect URL are configured.
+func NewOAuthService(cfg config.GoogleOAuthConfig, db *gorm.DB, redisClient *redis.Client, authService *AuthService) *OAuthService {
+	s := &OAuthService{
+		db:          db,
+		redisClient: redisClient,
+		authService: authService,
+	}
+
+	if cfg.ClientID != "" && cfg.ClientSecret != "" && cfg.RedirectURL != "" {
+		s.google = &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{"openid", "email", "profile"},
+			Endpoint:     google.Endpoint,
+		}
+	}
+
+	return s
+}
+
+// GoogleLoginURL returns Google's consent URL with a fresh state value that the
+// callback must present.
+func (s *OAuthService) GoogleLoginURL() (string, error) {
+	if s.google == nil {
+		return "", ErrOAuthNotConfigured
+	}
+
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	state := hex.EncodeToString(randomBytes)
+
+	if s.redisClient != nil {
+		s.redisClient.Set(oauthStateKey(state), true, oauthStateTTL)
+	}
+
+	return s.google.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+}
+
+// HandleGoogleCallback exchanges the authorization code, signs in the matching
+// user (creating the account on first login) and issues the standard token
+// pair, or an MFA challenge when the account has two-factor authentication.
+func (s *OAuthService) HandleGoogleCallback(ctx context.Context, state, code string) (*models.AuthResponse, error) {
+	if s.google == nil {
+		return nil, ErrOAuthNotConfigured
+	}
+
+	if s.redisClient != nil {
+		if state == "" || !s.redisClient.Exists(oauthStateKey(state)) {
+			return nil, errors.New("invalid OAuth state")
+		}
+		s.redisClient.Del(oauthStateKey(state))
+	}
+
+	token, err := s.google.Exchange(ctx, code)
+	if err != nil {
+		return nil, errors.New("failed to exchange authorization code")
+	}
+
+	profile, err := s.fetchGoogleProfile(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !profile.EmailVerified {
+		return nil, errors.New("Google account email is not verified")
+	}
+
+	user, err := s.findOrCreateGoogleUser(profile)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, errors.New("account is disabled")
+	}
+
+	s.saveProviderTokens(user.ID, "google", profile.Sub, token)
+
+	// Google only proves the first factor; accounts with two-factor
+	// authentication finish logging in at /auth/mfa/verify like password logins
+	if s.authService.HasMFAEnabled(user.ID) {
+		return s.authService.mfaChallenge(user.ID)
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	s.db.Model(user).Update("last_login_at", now)
+
+	accessToken, refreshToken, err := s.authService.generateTokenPair(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Message: "Login successful",
+		User: &models.UserInfo{
+			ID:               user.ID,
+			Email:            user.Email,
+			Name:             user.Name,
+			AvatarURL:        user.AvatarURL,
+			SubscriptionPlan: user.SubscriptionPlan,
+			EmailVerified:    user.EmailVerified,
+			CreatedAt:        user.CreatedAt,
+			LastLoginAt:      user.LastLoginAt,
+		},
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    "24h",
+	}, nil
+}
+
+func (s *OAuthService) fetchGoogleProfile(ctx context.Context, token *oauth2.Token) (*googleProfile, error) {
+	resp, err := s.google.Client(ctx, token).Get(googleUserInfoURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Google profile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch Google profile: %s", resp.Status)
+	}
+
+	var profile googleProfile
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return nil, fmt.Errorf("failed to decode Google profile: %w", err)
+	}
+	if profile.Sub == "" || profile.Email == "" {
+		return nil, errors.New("Google profile is missing an email address")
+	}
+
+	return &profile, nil
+}
+
+func (s *OAuthService) findOrCreateGoogleUser(profile *googleProfile) (*models.User, error) {
+	var user models.User
+
+	var link models.OAuthProvider
+	if err := s.db.Where("provider = ? AND provider_user_id = ?", "google", profile.Sub).First(&link).Error; err == nil {
+		if err := s.db.First(&user, "id = ?", link.UserID).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	email := strings.ToLower(profile.Email)
+	err := s.db.Where("email = ?", email).First(&user).Error
+	if err == nil {
+		// Google has confirmed the address, so the existing account is too
+		if !user.EmailVerified {
+			s.db.Model(&user).Update("email_verified", true)
+			user.EmailVerified = true
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// OAuth users never log in with a password, so store an unusable hash
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate credentials: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(randomBytes)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user = models.User{
+		Email:         email,
+		PasswordHash:  string(hashedPassword),
+		EmailVerified: true,
+		IsActive:      true,
+	}
+	if profile.Name != "" {
+		user.Name = &profile.Name
+	}
+	if profile.Picture != "" {
+		user.AvatarURL = &profile.Picture
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		// Zero values are skipped on create, so clear the column explicitly
+		return tx.Model(&user).Update("password_set", false).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *OAuthService) saveProviderTokens(userID uuid.UUID, provider, providerUserID string, token *oauth2.Token) {
+	link := models.OAuthProvider{
+		UserID:         userID,
+		Provider:       provider,
+		ProviderUserID: providerUserID,
+		AccessToken:    token.AccessToken,
+		RefreshToken:   token.RefreshToken,
+	}
+	if !token.Expiry.IsZero() {
+		link.ExpiresAt = &token.Expiry
+	}
+
+	var existing models.OAuthProvider
+	if err := s.db.Where("user_id = ? AND provider = ?", userID, provider).First(&existing).Error; err != nil {
+		s.db.Create(&link)
+		return
+	}
+
+	updates := map[string]interface{}{
+		"provider_user_id": providerUserID,
+		"access_token":     token.AccessToken,
+		"expires_at":       link.ExpiresAt,
+	}
+	// Google only returns a refresh token on the first consent
+	if token.RefreshToken != "" {
+		updates["refresh_token"] = token.RefreshToken
+	}
+	s.db.Model(&existing).Updates(updates)
+}
+
+func (s *OAuthService) ListProviders(userID uuid.UUID) ([]models.OAuthProvider, error) {
+	var providers []models.OAuthProvider
+	if err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&providers).Error; err != nil {
+		return nil, err
+	}
+
+	return providers, nil
+}
+
+// UnlinkProvider removes a provider link. Accounts without a password must
+// keep at least one way to sign in, so they can't unlink.
+func (s *OAuthService) UnlinkProvider(userID uuid.UUID, provider string) error {
+	var user models.User
+	if err := s.db.Select("id", "password_set").First(&user, "id = ?", userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	if !user.PasswordSet {
+		return errors.New("set a password before unlinking")
+	}
+
+	result := s.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.OAuthProvider{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("provider not linked")
+	}
+
+	return nil
+}
+
+func oauthStateKey(state string) string {
+	return fmt.Sprintf("oauth_state:%s", state)
+}
@@ -0,0 +1,390 @@
+// internal/services/palette.go
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lovable-backend/internal/models"
+)
+
+// Colors closer than this in L*a*b* space are treated as the same swatch
+const paletteSimilarityThreshold = 10.0
+
+const maxPaletteSize = 8
+
+type ColorSwatch struct {
+	Hex          string `json:"hex"`
+	Name         string `json:"name"`
+	UsageCount   int    `json:"usage_count"`
+	IsBackground bool   `json:"is_background"`
+	IsText       bool   `json:"is_text"`
+}
+
+type rgbColor struct {
+	R, G, B uint8
+}
+
+type labColor struct {
+	L, A, B float64
+}
+
+var (
+	styleBlockRegex     = regexp.MustCompile(`(?i)<style[^>]*>([\s\S]*?)</style>`)
+	inlineStyleRegex    = regexp.MustCompile(`(?i)style\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	cssDeclarationRegex = regexp.MustCompile(`([a-zA-Z-]+)\s*:\s*([^;{}]+)`)
+	cssColorRegex       = regexp.MustCompile(`(?i)#(?:[0-9a-f]{8}|[0-9a-f]{6}|[0-9a-f]{3,4})\b|rgba?\([^)]*\)|hsla?\([^)]*\)`)
+	// A declaration's value up to its terminator, so selectors like .red,
+	// a:hover or #fff are never taken for values
+	cssValueRegex = regexp.MustCompile(`:[^;{}]*[;}]`)
+	// Colors and identifiers in a value. Identifiers are matched with their
+	// hyphens so names like dark-red or --red-500 are never split.
+	cssColorTokenRegex = regexp.MustCompile(`(?i)#[0-9a-f]{3,8}\b|(?:rgba?|hsla?)\([^()]*\)|-*[a-z][\w-]*`)
+)
+
+var namedColors = map[string]rgbColor{
+	"black":    {0, 0, 0},
+	"white":    {255, 255, 255},
+	"charcoal": {51, 51, 51},
+	"gray":     {128, 128, 128},
+	"silver":   {192, 192, 192},
+	"red":      {231, 76, 60},
+	"maroon":   {128, 0, 0},
+	"orange":   {243, 156, 18},
+	"yellow":   {241, 196, 15},
+	"olive":    {128, 128, 0},
+	"green":    {46, 204, 113},
+	"teal":     {0, 128, 128},
+	"cyan":     {0, 188, 212},
+	"blue":     {52, 152, 219},
+	"navy":     {44, 62, 80},
+	"indigo":   {102, 126, 234},
+	"purple":   {118, 75, 162},
+	"pink":     {255, 107, 129},
+	"brown":    {139, 69, 19},
+	"beige":    {245, 245, 220},
+}
+
+// ExtractColorPalette collects the colors used in a page's <style> blocks and
+// inline style attributes, merges perceptually similar ones and returns the
+// most used swatches.
+func (s *ExportService) ExtractColorPalette(html string) []ColorSwatch {
+	var cssSources []string
+	for _, match := range styleBlockRegex.FindAllStringSubmatch(html, -1) {
+		cssSources = append(cssSources, match[1])
+	}
+	for _, match := range inlineStyleRegex.FindAllStringSubmatch(html, -1) {
+		cssSources = append(cssSources, match[1]+match[2])
+	}
+
+	// Count every distinct color along with how it is used
+	counts := make(map[rgbColor]*ColorSwatch)
+	var order []rgbColor
+	for _, css := range cssSources {
+		for _, declaration := range cssDeclarationRegex.FindAllStringSubmatch(css, -1) {
+			property := strings.ToLower(strings.TrimSpace(declaration[1]))
+
+			for _, raw := range cssColorRegex.FindAllString(declaration[2], -1) {
+				color, ok := parseCSSColor(raw)
+				if !ok {
+					continue
+				}
+
+				swatch, exists := counts[color]
+				if !exists {
+					swatch = &ColorSwatch{Hex: color.hex()}
+					counts[color] = swatch
+					order = append(order, color)
+				}
+
+				swatch.UsageCount++
+				if strings.Contains(property, "background") {
+					swatch.IsBackground = true
+				}
+				if property == "color" {
+					swatch.IsText = true
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]].UsageCount > counts[order[j]].UsageCount
+	})
+
+	// Merge similar colors into the most used representative
+	var representatives []rgbColor
+	var palette []ColorSwatch
+	for _, color := range order {
+		swatch := counts[color]
+		lab := color.lab()
+
+		merged := false
+		for i, representative := range representatives {
+			if lab.distance(representative.lab()) < paletteSimilarityThreshold {
+				palette[i].UsageCount += swatch.UsageCount
+				palette[i].IsBackground = palette[i].IsBackground || swatch.IsBackground
+				palette[i].IsText = palette[i].IsText || swatch.IsText
+				merged = true
+				break
+			}
+		}
+
+		if !merged {
+			representatives = append(representatives, color)
+			palette = append(palette, ColorSwatch{
+				Hex:          swatch.Hex,
+				Name:         nearestColorName(color),
+				UsageCount:   swatch.UsageCount,
+				IsBackground: swatch.IsBackground,
+				IsText:       swatch.IsText,
+			})
+		}
+	}
+
+	sort.SliceStable(palette, func(i, j int) bool {
+		return palette[i].UsageCount > palette[j].UsageCount
+	})
+
+	if len(palette) > maxPaletteSize {
+		palette = palette[:maxPaletteSize]
+	}
+
+	return palette
+}
+
+func parseCSSColor(raw string) (rgbColor, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+
+	if strings.HasPrefix(raw, "#") {
+		return parseHexColor(raw)
+	}
+
+	open := strings.Index(raw, "(")
+	if open < 0 || !strings.HasSuffix(raw, ")") {
+		return rgbColor{}, false
+	}
+
+	function := raw[:open]
+	args := strings.FieldsFunc(raw[open+1:len(raw)-1], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/'
+	})
+	if len(args) < 3 {
+		return rgbColor{}, false
+	}
+
+	// Skip fully transparent colors
+	if len(args) >= 4 {
+		if alpha, ok := parseCSSNumber(args[3], 1); ok && alpha == 0 {
+			return rgbColor{}, false
+		}
+	}
+
+	switch function {
+	case "rgb", "rgba":
+		var channels [3]uint8
+		for i := 0; i < 3; i++ {
+			value, ok := parseCSSNumber(args[i], 255)
+			if !ok {
+				return rgbColor{}, false
+			}
+			channels[i] = clampChannel(value)
+		}
+		return rgbColor{channels[0], channels[1], channels[2]}, true
+	case "hsl", "hsla":
+		hue, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+		if err != nil {
+			return rgbColor{}, false
+		}
+		saturation, ok := parseCSSNumber(args[1], 1)
+		if !ok {
+			return rgbColor{}, false
+		}
+		lightness, ok := parseCSSNumber(args[2], 1)
+		if !ok {
+			return rgbColor{}, false
+		}
+		return hslToRGB(hue, saturation, lightness), true
+	}
+
+	return rgbColor{}, false
+}
+
+func parseHexColor(raw string) (rgbColor, bool) {
+	hex := strings.TrimPrefix(raw, "#")
+
+	switch len(hex) {
+	case 3, 4:
+		if len(hex) == 4 && hex[3] == '0' {
+			return rgbColor{}, false
+		}
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	case 6:
+	case 8:
+		if hex[6:] == "00" {
+			return rgbColor{}, false
+		}
+		hex = hex[:6]
+	default:
+		return rgbColor{}, false
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgbColor{}, false
+	}
+
+	return rgbColor{uint8(value >> 16), uint8(value >> 8), uint8(value)}, true
+}
+
+// parseCSSNumber parses a plain or percentage value, scaling percentages to
+// the given maximum. Plain values are returned unchanged.
+func parseCSSNumber(value string, max float64) (float64, bool) {
+	if strings.HasSuffix(value, "%") {
+		number, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return 0, false
+		}
+		return number / 100 * max, true
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+func clampChannel(value float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(255, value))))
+}
+
+func hslToRGB(hue, saturation, lightness float64) rgbColor {
+	hue = math.Mod(math.Mod(hue, 360)+360, 360) / 360
+	saturation = math.Max(0, math.Min(1, saturation))
+	lightness = math.Max(0, math.Min(1, lightness))
+
+	if saturation == 0 {
+		v := clampChannel(lightness * 255)
+		return rgbColor{v, v, v}
+	}
+
+	var q float64
+	if lightness < 0.5 {
+		q = lightness * (1 + saturation)
+	} else {
+		q = lightness + saturation - lightness*saturation
+	}
+	p := 2*lightness - q
+
+	hueToChannel := func(t float64) float64 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 1.0/2:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+
+	return rgbColor{
+		clampChannel(hueToChannel(hue+1.0/3) * 255),
+		clampChannel(hueToChannel(hue) * 255),
+		clampChannel(hueToChannel(hue-1.0/3) * 255),
+	}
+}
+
+func (c rgbColor) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// lab converts an sRGB color to CIE L*a*b* using the D65 white point
+func (c rgbColor) lab() labColor {
+	linearize := func(channel uint8) float64 {
+		v := float64(channel) / 255
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+
+	x := (r*0.4124 + g*0.3576 + b*0.1805) / 0.95047
+	y := (r*0.2126 + g*0.7152 + b*0.0722) / 1.00000
+	z := (r*0.0193 + g*0.1192 + b*0.9505) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Cbrt(t)
+		}
+		return 7.787*t + 16.0/116
+	}
+
+	fx, fy, fz := f(x), f(y), f(z)
+	return labColor{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+func (c labColor) distance(other labColor) float64 {
+	return math.Sqrt(math.Pow(c.L-other.L, 2) + math.Pow(c.A-other.A, 2) + math.Pow(c.B-other.B, 2))
+}
+
+func nearestColorName(color rgbColor) string {
+	lab := color.lab()
+
+	bestName := ""
+	bestDistance := math.MaxFloat64
+	for name, named := range namedColors {
+		distance := lab.distance(named.lab())
+		if distance < bestDistance || (distance == bestDistance && name < bestName) {
+			bestName = name
+			bestDistance = distance
+		}
+	}
+
+	return bestName
+}
+
+// recolorCSS replaces colors in a page's <style> blocks and inline style
+// attributes. Each color token is looked up once, so only whole colors are
+// replaced and two colors can be swapped with each other.
+func recolorCSS(html string, palette []models.ColorMapping) string {
+	replacements := make(map[string]string, len(palette))
+	for _, mapping := range palette {
+		replacements[normalizeCSSColor(mapping.From)] = mapping.To
+	}
+
+	recolor := func(css string) string {
+		return cssColorTokenRegex.ReplaceAllStringFunc(css, func(token string) string {
+			if to, ok := replacements[normalizeCSSColor(token)]; ok {
+				return to
+			}
+			return token
+		})
+	}
+
+	html = styleBlockRegex.ReplaceAllStringFunc(html, func(block string) string {
+		return cssValueRegex.ReplaceAllStringFunc(block, recolor)
+	})
+	return inlineStyleRegex.ReplaceAllStringFunc(html, recolor)
+}
+
+func normalizeCSSColor(color string) string {
+	return strings.ToLower(strings.Join(strings.Fields(color), ""))
+}
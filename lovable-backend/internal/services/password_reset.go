@@ -0,0 +1,106 @@
+// internal/services/password_reset.go
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// How long a password reset link stays valid
+const passwordResetTokenTTL = time.Hour
+
+// RequestPasswordReset emails a reset link when the address belongs to an
+// account. Unknown addresses are ignored so the endpoint can't be used to
+// discover which emails are registered.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	var user models.User
+	if err := s.db.Where("email = ? AND is_guest = ?", email, false).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	tokenValue := hex.EncodeToString(randomBytes)
+
+	token := models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashEmailedToken(tokenValue),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+	}
+	if err := s.db.Create(&token).Error; err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	return s.emailService.SendPasswordReset(user.Email, tokenValue)
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out everywhere. It returns the ID of the user whose password changed.
+func (s *AuthService) ResetPassword(req *models.ResetPasswordRequest) (uuid.UUID, error) {
+	if req.NewPassword != req.ConfirmNewPassword {
+		return uuid.Nil, errors.New("new passwords do not match")
+	}
+
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	var token models.PasswordResetToken
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", hashEmailedToken(req.Token)).First(&token).Error; err != nil {
+			return errors.New("invalid reset token")
+		}
+		if token.UsedAt != nil {
+			return errors.New("reset token already used")
+		}
+		if time.Now().After(token.ExpiresAt) {
+			return errors.New("reset token expired")
+		}
+
+		// Spend every outstanding token for the user, not just this one
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("reset token already used")
+		}
+
+		// Resetting is also how OAuth-only accounts set their first password
+		return tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+			"password_hash": string(hashedPassword),
+			"password_set":  true,
+		}).Error
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Sign out every device, including one held by whoever knew the old
+	// password
+	if err := s.RevokeUserTokens(token.UserID); err != nil {
+		s.logger.Error("Failed to revoke tokens after password reset", "userId", token.UserID, "error", err)
+	}
+	if err := s.DeleteSession(token.UserID); err != nil {
+		s.logger.Error("Failed to clear sessions after password reset", "userId", token.UserID, "error", err)
+	}
+
+	return token.UserID, nil
+}
@@ -0,0 +1,161 @@
+// internal/services/preferences.go
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+)
+
+// Upper bound on the summed length of a user's preference values
+const preferencesSizeLimit = 64 * 1024
+
+const preferencesCacheTTL = 5 * time.Minute
+
+// PreferenceService stores the frontend settings of users as key-value pairs
+type PreferenceService struct {
+	db          *gorm.DB // primary
+	dbRouter    *database.DBRouter
+	redisClient *redis.Client
+	allowedKeys map[string]bool
+}
+
+func NewPreferenceService(dbRouter *database.DBRouter, redisClient *redis.Client, config config.PreferencesConfig) *PreferenceService {
+	allowedKeys := make(map[string]bool, len(config.AllowedKeys))
+	for _, key := range config.AllowedKeys {
+		allowedKeys[key] = true
+	}
+
+	return &PreferenceService{
+		db:          dbRouter.Writer(),
+		dbRouter:    dbRouter,
+		redisClient: redisClient,
+		allowedKeys: allowedKeys,
+	}
+}
+
+func preferencesCacheKey(userID uuid.UUID) string {
+	return "prefs:" + userID.String()
+}
+
+// GetPreferences returns all of the user's preferences keyed by name
+func (s *PreferenceService) GetPreferences(ctx context.Context, userID uuid.UUID) (map[string]string, error) {
+	cacheKey := preferencesCacheKey(userID)
+	if s.redisClient != nil {
+		var cached map[string]string
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	var rows []models.UserPreference
+	if err := s.dbRouter.Reader().Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]string, len(rows))
+	for _, row := range rows {
+		preferences[row.Key] = row.Value
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, preferences, preferencesCacheTTL)
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences merges the given values into the user's preferences,
+// leaving keys not mentioned untouched, and returns the result
+func (s *PreferenceService) UpdatePreferences(ctx context.Context, userID uuid.UUID, updates map[string]string) (map[string]string, error) {
+	for key := range updates {
+		if !s.allowedKeys[key] {
+			return nil, errors.New("unknown preference key")
+		}
+	}
+
+	preferences := make(map[string]string)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Serialize updates per user so concurrent writes can't exceed the size limit together
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		var rows []models.UserPreference
+		if err := tx.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			preferences[row.Key] = row.Value
+		}
+		for key, value := range updates {
+			preferences[key] = value
+		}
+
+		size := 0
+		for _, value := range preferences {
+			size += len(value)
+		}
+		if size > preferencesSizeLimit {
+			return errors.New("preferences too large")
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		upserts := make([]models.UserPreference, 0, len(updates))
+		for key, value := range updates {
+			upserts = append(upserts, models.UserPreference{
+				UserID:    userID,
+				Key:       key,
+				Value:     value,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&upserts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateCache(userID)
+
+	return preferences, nil
+}
+
+func (s *PreferenceService) DeletePreference(ctx context.Context, userID uuid.UUID, key string) error {
+	result := s.db.Where("user_id = ? AND key = ?", userID, key).Delete(&models.UserPreference{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("preference not found")
+	}
+
+	s.invalidateCache(userID)
+
+	return nil
+}
+
+func (s *PreferenceService) invalidateCache(userID uuid.UUID) {
+	if s.redisClient != nil {
+		s.redisClient.Del(preferencesCacheKey(userID))
+	}
+}
@@ -2,22 +2,67 @@
 package services
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/database"
 	"lovable-backend/internal/models"
 	"lovable-backend/internal/redis"
+	"lovable-backend/internal/storage"
+	"lovable-backend/internal/telemetry"
+	"lovable-backend/internal/worker"
+	"lovable-backend/pkg/logger"
 )
 
 type ProjectService struct {
-	db          *gorm.DB
+	db          *gorm.DB // primary, for writes and reads that must see them
+	dbRouter    *database.DBRouter
 	redisClient *redis.Client
+	aiService   *AIService
+	authService *AuthService
+	storage     storage.StorageBackend
+	storageMode string
+	urlImport   config.URLImportConfig
+	versions    config.VersionsConfig
+	thumbnails  *ThumbnailService
+	logger      *logger.Logger
 }
 
+// Maximum accepted size for conversation export uploads
+const maxConversationImportSize = 5 << 20
+
+// Blended Claude input/output price used to estimate AI spend from token counts
+const estimatedCostPerMillionTokens = 9.0
+
+// Maximum number of projects per subscription plan
+var projectLimits = map[string]int64{
+	"free":    5,
+	"pro":     50,
+	"premium": 500,
+}
+
+// Pagination modes for GetProjects
+const (
+	PaginationModeCursor = "cursor"
+	PaginationModeOffset = "offset"
+)
+
 type ProjectQuery struct {
+	Mode   string // cursor (default) or offset
+	Cursor string
 	Page   int
 	Limit  int
 	Status string
@@ -25,20 +70,32 @@ type ProjectQuery struct {
 	Tags   []string
 	Sort   string
 	Order  string
+
+	// Only the caller's own projects filed in this collection
+	CollectionID *uuid.UUID
 }
 
-func NewProjectService(db *gorm.DB, redisClient *redis.Client) *ProjectService {
+func NewProjectService(dbRouter *database.DBRouter, redisClient *redis.Client, aiService *AIService, authService *AuthService, storageBackend storage.StorageBackend, storageMode string, urlImport config.URLImportConfig, versions config.VersionsConfig, thumbnailService *ThumbnailService, logger *logger.Logger) *ProjectService {
 	return &ProjectService{
-		db:          db,
+		db:          dbRouter.Writer(),
+		dbRouter:    dbRouter,
 		redisClient: redisClient,
+		aiService:   aiService,
+		authService: authService,
+		storage:     storageBackend,
+		storageMode: storageMode,
+		urlImport:   urlImport,
+		versions:    versions,
+		thumbnails:  thumbnailService,
+		logger:      logger,
 	}
 }
 
-func (s *ProjectService) GetProjects(userID uuid.UUID, query *ProjectQuery) (*models.ProjectsResponse, error) {
-	offset := (query.Page - 1) * query.Limit
-
+func (s *ProjectService) GetProjects(ctx context.Context, userID uuid.UUID, query *ProjectQuery) (*models.ProjectsResponse, error) {
 	// Build base query
-	db := s.db.Model(&models.Project{}).Where("user_id = ?", userID)
+	// Owned projects plus those shared with the user
+	db := s.dbRouter.Reader().Model(&models.Project{}).
+		Where("user_id = ? OR id IN (SELECT project_id FROM project_collaborators WHERE user_id = ? AND accepted_at IS NOT NULL)", userID, userID)
 
 	// Apply filters
 	if query.Status != "" {
@@ -46,14 +103,24 @@ func (s *ProjectService) GetProjects(userID uuid.UUID, query *ProjectQuery) (*mo
 	}
 
 	if query.Search != "" {
-		search := "%" + query.Search + "%"
-		db = db.Where("name ILIKE ? OR description ILIKE ?", search, search)
+		db = db.Where("search_vector @@ plainto_tsquery('english', ?)", query.Search)
 	}
 
 	if len(query.Tags) > 0 {
 		db = db.Where("tags && ?", query.Tags)
 	}
 
+	if query.CollectionID != nil {
+		db = db.Where("user_id = ? AND collection_id = ?", userID, *query.CollectionID)
+	}
+
+	// Keyset pagination only works on the timestamp sorts; the rest page by offset
+	if query.Mode != PaginationModeOffset && (query.Sort == "updated_at" || query.Sort == "created_at") {
+		return s.getProjectsByCursor(userID, db, query)
+	}
+
+	offset := (query.Page - 1) * query.Limit
+
 	// Get total count
 	var totalCount int64
 	if err := db.Count(&totalCount).Error; err != nil {
@@ -71,22 +138,7 @@ func (s *ProjectService) GetProjects(userID uuid.UUID, query *ProjectQuery) (*mo
 	}
 
 	// Convert to response format
-	projectInfos := make([]models.ProjectInfo, len(projects))
-	for i, p := range projects {
-		projectInfos[i] = models.ProjectInfo{
-			ID:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Status:      p.Status,
-			Tags:        p.Tags,
-			IsPublic:    p.IsPublic,
-			ViewCount:   p.ViewCount,
-			LikeCount:   p.LikeCount,
-			HasCode:     p.HTMLCode != nil,
-			CreatedAt:   p.CreatedAt,
-			UpdatedAt:   p.UpdatedAt,
-		}
-	}
+	projectInfos := s.toProjectInfos(userID, projects)
 
 	// Calculate pagination
 	totalPages := int(math.Ceil(float64(totalCount) / float64(query.Limit)))
@@ -95,71 +147,386 @@ func (s *ProjectService) GetProjects(userID uuid.UUID, query *ProjectQuery) (*mo
 		Projects: projectInfos,
 		Pagination: &models.PaginationResponse{
 			CurrentPage: query.Page,
-			TotalPages:  totalPages,
-			TotalCount:  totalCount,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
 			HasNextPage: query.Page < totalPages,
 			HasPrevPage: query.Page > 1,
 		},
 	}, nil
 }
 
-func (s *ProjectService) GetProject(userID, projectID uuid.UUID) (*models.Project, error) {
+// getProjectsByCursor pages with WHERE (sort, id) past the cursor instead of
+// OFFSET, and skips the total count.
+func (s *ProjectService) getProjectsByCursor(userID uuid.UUID, db *gorm.DB, query *ProjectQuery) (*models.ProjectsResponse, error) {
+	comparison := "<"
+	if query.Order == "asc" {
+		comparison = ">"
+	}
+
+	if query.Cursor != "" {
+		cursorTime, cursorID, err := decodeProjectCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", query.Sort, comparison), cursorTime, cursorID)
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	orderClause := fmt.Sprintf("%s %s, id %s", query.Sort, query.Order, query.Order)
+	var projects []models.Project
+	if err := db.Order(orderClause).Limit(query.Limit + 1).Find(&projects).Error; err != nil {
+		return nil, err
+	}
+
+	hasNext := len(projects) > query.Limit
+	if hasNext {
+		projects = projects[:query.Limit]
+	}
+
+	projectInfos := s.toProjectInfos(userID, projects)
+
+	pagination := &models.PaginationResponse{
+		HasNextPage: hasNext,
+		HasPrevPage: query.Cursor != "",
+	}
+	if hasNext {
+		last := projects[len(projects)-1]
+		sortTime := last.UpdatedAt
+		if query.Sort == "created_at" {
+			sortTime = last.CreatedAt
+		}
+		pagination.NextCursor = encodeProjectCursor(sortTime, last.ID)
+	}
+
+	return &models.ProjectsResponse{
+		Projects:   projectInfos,
+		Pagination: pagination,
+	}, nil
+}
+
+// Cursors are opaque to clients: base64 of "<RFC3339Nano time>|<id>"
+func encodeProjectCursor(t time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeProjectCursor(cursor string) (time.Time, uuid.UUID, error) {
+	invalid := errors.New("invalid cursor")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, invalid
+	}
+
+	timePart, idPart, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, uuid.Nil, invalid
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, timePart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, invalid
+	}
+
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, invalid
+	}
+
+	return t, id, nil
+}
+
+func toProjectInfo(p *models.Project) models.ProjectInfo {
+	info := models.ProjectInfo{
+		ID:           p.ID,
+		Name:         p.Name,
+		Description:  p.Description,
+		Status:       p.Status,
+		Tags:         p.Tags,
+		IsPublic:     p.IsPublic,
+		ViewCount:    p.ViewCount,
+		LikeCount:    p.LikeCount,
+		ForkCount:    p.ForkCount,
+		ForkedFromID: p.ForkedFromID,
+		HasCode:      p.HTMLCode != nil || p.HTMLCodeKey != nil,
+		ThumbnailURL: thumbnailPlaceholderURL(p.ID),
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+	if p.ThumbnailURL != nil && *p.ThumbnailURL != "" {
+		info.ThumbnailURL = *p.ThumbnailURL
+	}
+
+	return info
+}
+
+// RegenerateThumbnail queues a fresh screenshot thumbnail for a project the
+// user owns, whether or not its HTML has changed
+func (s *ProjectService) RegenerateThumbnail(ctx context.Context, userID, projectID uuid.UUID) (*models.Job, error) {
+	if !s.thumbnails.Enabled() {
+		return nil, ErrThumbnailServiceDisabled
+	}
+
 	var project models.Project
-	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+	if err := s.dbRouter.Reader().Select("id", "html_code", "html_code_key").
+		Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return nil, errors.New("project not found")
+	}
+	if project.HTMLCode == nil && project.HTMLCodeKey == nil {
+		return nil, errors.New("no HTML code available for this project")
+	}
+
+	return worker.Enqueue(s.db, JobTypeThumbnail, ThumbnailJob{ProjectID: project.ID})
+}
+
+// Number of results returned by SearchProjects
+const projectSearchLimit = 20
+
+// Length of the generated HTML excerpt in search results
+const searchHTMLExcerptLength = 200
+
+// SearchProjects ranks the projects the user can access against a full-text
+// query and highlights the matches. Headlines are only computed for the top
+// results.
+func (s *ProjectService) SearchProjects(ctx context.Context, userID uuid.UUID, q string) ([]models.ProjectSearchResult, error) {
+	results := []models.ProjectSearchResult{}
+	err := s.dbRouter.Reader().Raw(`
+		SELECT p.id, p.name, p.description, p.status, p.updated_at, p.rank,
+			ts_headline('english', p.name, query) AS name_headline,
+			ts_headline('english', COALESCE(p.description, ''), query) AS description_headline,
+			ts_headline('english', regexp_replace(COALESCE(p.html_code, ''), '<[^>]*>', ' ', 'g'), query, 'MaxFragments=1, MaxWords=30, MinWords=10') AS html_headline
+		FROM (
+			SELECT id, name, description, status, html_code, updated_at, ts_rank(search_vector, query) AS rank
+			FROM projects, plainto_tsquery('english', ?) query
+			WHERE (user_id = ? OR id IN (SELECT project_id FROM project_collaborators WHERE user_id = ? AND accepted_at IS NOT NULL))
+				AND search_vector @@ query
+			ORDER BY rank DESC
+			LIMIT ?
+		) p, plainto_tsquery('english', ?) query
+		ORDER BY p.rank DESC`,
+		q, userID, userID, projectSearchLimit, q,
+	).Scan(&results).Error
+	if err != nil {
 		return nil, err
 	}
 
-	// Increment view count
-	s.db.Model(&project).Update("view_count", gorm.Expr("view_count + 1"))
+	for i := range results {
+		results[i].HTMLHeadline = truncateHeadline(strings.TrimSpace(results[i].HTMLHeadline), searchHTMLExcerptLength)
+	}
 
-	return &project, nil
+	return results, nil
 }
 
-func (s *ProjectService) CreateProject(userID uuid.UUID, req *models.CreateProjectRequest) (*models.Project, error) {
-	// Check project limit based on subscription
+// truncateHeadline shortens a ts_headline result to maxLength runes without
+// leaving a partial or unclosed <b> highlight tag.
+func truncateHeadline(headline string, maxLength int) string {
+	runes := []rune(headline)
+	if len(runes) <= maxLength {
+		return headline
+	}
+
+	truncated := string(runes[:maxLength])
+	if open := strings.LastIndex(truncated, "<"); open > strings.LastIndex(truncated, ">") {
+		truncated = truncated[:open]
+	}
+	if strings.Count(truncated, "<b>") > strings.Count(truncated, "</b>") {
+		truncated += "</b>"
+	}
+
+	return truncated + "..."
+}
+
+func (s *ProjectService) GetProject(ctx context.Context, userID, projectID uuid.UUID) (*models.Project, error) {
+	_, span := telemetry.Tracer.Start(ctx, "ProjectService.GetProject", trace.WithAttributes(
+		attribute.String("project.id", projectID.String()),
+	))
+	defer span.End()
+
+	project, err := s.findAccessibleProject(s.dbRouter.Reader(), userID, projectID, ProjectRoleEditor, ProjectRoleViewer)
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, project); err != nil {
+		telemetry.RecordError(span, err)
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// IncrementViewCount counts one more view of the project. It is called from
+// middleware.CountProjectView, outside the response cache, so cached reads
+// are counted too.
+func (s *ProjectService) IncrementViewCount(projectID uuid.UUID) error {
+	return s.db.Model(&models.Project{}).Where("id = ?", projectID).
+		Update("view_count", gorm.Expr("view_count + 1")).Error
+}
+
+// ResponseCacheKey is the Redis key of a response cached under key by the
+// response cache middleware
+func ResponseCacheKey(key string) string {
+	return "cache:" + key
+}
+
+// ProjectCacheKey identifies a user's cached GET /projects/:id response
+func ProjectCacheKey(userID, projectID uuid.UUID) string {
+	return fmt.Sprintf("project:%s:%s", userID, projectID)
+}
+
+// InvalidateProjectCache drops the user's cached copy of the project
+func (s *ProjectService) InvalidateProjectCache(userID, projectID uuid.UUID) {
+	if s.redisClient != nil {
+		s.redisClient.Del(ResponseCacheKey(ProjectCacheKey(userID, projectID)))
+	}
+}
+
+// checkProjectLimit returns an error when the user already owns the maximum
+// number of projects allowed by their subscription plan.
+func (s *ProjectService) checkProjectLimit(userID uuid.UUID) error {
 	var count int64
 	s.db.Model(&models.Project{}).Where("user_id = ?", userID).Count(&count)
 
 	// Get user's subscription plan
 	var user models.User
 	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
-		return nil, err
+		return err
 	}
 
-	limits := map[string]int64{
-		"free":    5,
-		"pro":     50,
-		"premium": 500,
-	}
-
-	limit := limits[user.SubscriptionPlan]
+	limit := projectLimits[user.SubscriptionPlan]
 	if limit == 0 {
-		limit = limits["free"]
+		limit = projectLimits["free"]
 	}
 
 	if count >= limit {
-		return nil, fmt.Errorf("project limit reached for %s plan (%d projects)", user.SubscriptionPlan, limit)
+		return fmt.Errorf("project limit reached for %s plan (%d projects)", user.SubscriptionPlan, limit)
 	}
 
-	project := models.Project{
+	return nil
+}
+
+func (s *ProjectService) CreateProject(ctx context.Context, userID uuid.UUID, req *models.CreateProjectRequest) (*models.Project, error) {
+	return s.createProject(ctx, userID, &models.Project{
 		UserID:      userID,
 		Name:        req.Name,
 		Description: &req.Description,
+		HTMLCode:    req.HTMLCode,
 		Tags:        req.Tags,
+	}, ActivitySourceCreated)
+}
+
+// createProject saves a new project of the user, recording in its activity
+// feed where it came from. Imported code is also kept as the first version.
+func (s *ProjectService) createProject(ctx context.Context, userID uuid.UUID, project *models.Project, source string) (*models.Project, error) {
+	// Check project limit based on subscription
+	if err := s.checkProjectLimit(userID); err != nil {
+		return nil, err
 	}
 
-	if err := s.db.Create(&project).Error; err != nil {
+	// Describe the site automatically when it was created with code but no description
+	if (project.Description == nil || *project.Description == "") && project.HTMLCode != nil && *project.HTMLCode != "" {
+		if description := s.generateDescription(*project.HTMLCode); description != nil {
+			project.Description = description
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.insertProject(tx, project); err != nil {
+			return err
+		}
+
+		if source == ActivitySourceCreated || project.HTMLCode == nil || *project.HTMLCode == "" {
+			return nil
+		}
+		return createProjectVersion(tx, &models.ProjectVersion{
+			ProjectID:    project.ID,
+			HTMLCode:     project.HTMLCode,
+			CSSCode:      project.CSSCode,
+			JSCode:       project.JSCode,
+			ChangedBy:    userID,
+			ChangeSource: ChangeSourceImport,
+		}, s.versions.MaxPerProject)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &project, nil
+	enqueueSearchIndex(s.db, s.redisClient, s.storage, project.ID)
+	recordProjectActivityFrom(s.db, project.ID, userID, ActivityCreated, &source, map[string]interface{}{
+		"name": project.Name,
+	})
+
+	return project, nil
 }
 
-func (s *ProjectService) UpdateProject(userID, projectID uuid.UUID, req *models.UpdateProjectRequest) (*models.Project, error) {
-	var project models.Project
-	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+// insertProject creates the project's row. In S3 mode its HTML is put in
+// external storage first and only the object key is written to Postgres; the
+// struct keeps the HTML for the caller.
+func (s *ProjectService) insertProject(tx *gorm.DB, project *models.Project) error {
+	if s.storageMode != storage.ModeS3 || project.HTMLCode == nil {
+		return tx.Create(project).Error
+	}
+
+	// The key is derived from the ID, so it is assigned before the insert
+	if project.ID == uuid.Nil {
+		project.ID = uuid.New()
+	}
+
+	key := storage.ProjectHTMLKey(project.ID)
+	if err := s.storage.Put(key, *project.HTMLCode); err != nil {
+		return err
+	}
+	project.HTMLCodeKey = &key
+
+	return tx.Omit("html_code").Create(project).Error
+}
+
+// generateDescription summarizes the given HTML via the AI service, returning
+// nil when no summary could be produced.
+func (s *ProjectService) generateDescription(html string) *string {
+	if s.aiService == nil {
+		return nil
+	}
+
+	description, err := s.aiService.SummarizeProject(context.Background(), html)
+	if err != nil || description == "" {
+		return nil
+	}
+
+	return &description
+}
+
+func (s *ProjectService) UpdateProject(ctx context.Context, userID, projectID uuid.UUID, req *models.UpdateProjectRequest) (*models.Project, error) {
+	found, err := s.findAccessibleProject(s.db, userID, projectID, ProjectRoleEditor)
+	if err != nil {
 		return nil, err
 	}
+	project := *found
+
+	// Code changes are snapshotted with the full code as it stands after the update
+	var version *models.ProjectVersion
+	if req.HTMLCode != nil || req.CSSCode != nil || req.JSCode != nil {
+		if req.HTMLCode == nil {
+			if err := storage.LoadProjectHTML(s.storage, &project); err != nil {
+				return nil, err
+			}
+		}
+
+		source := req.ChangeSource
+		if source == "" {
+			source = ChangeSourceManualEdit
+		}
+
+		version = &models.ProjectVersion{
+			ProjectID:      project.ID,
+			ConversationID: req.ConversationID,
+			HTMLCode:       coalesceCode(req.HTMLCode, project.HTMLCode),
+			CSSCode:        coalesceCode(req.CSSCode, project.CSSCode),
+			JSCode:         coalesceCode(req.JSCode, project.JSCode),
+			ChangedBy:      userID,
+			ChangeSource:   source,
+		}
+	}
 
 	// Build updates map
 	updates := make(map[string]interface{})
@@ -170,7 +537,17 @@ func (s *ProjectService) UpdateProject(userID, projectID uuid.UUID, req *models.
 		updates["description"] = *req.Description
 	}
 	if req.HTMLCode != nil {
-		updates["html_code"] = *req.HTMLCode
+		if s.storageMode == storage.ModeS3 {
+			// Keep large HTML out of Postgres and store only the object key
+			key := storage.ProjectHTMLKey(project.ID)
+			if err := s.storage.Put(key, *req.HTMLCode); err != nil {
+				return nil, err
+			}
+			updates["html_code"] = nil
+			updates["html_code_key"] = key
+		} else {
+			updates["html_code"] = *req.HTMLCode
+		}
 	}
 	if req.CSSCode != nil {
 		updates["css_code"] = *req.CSSCode
@@ -189,24 +566,102 @@ func (s *ProjectService) UpdateProject(userID, projectID uuid.UUID, req *models.
 	}
 
 	if len(updates) > 0 {
-		if err := s.db.Model(&project).Updates(updates).Error; err != nil {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&project).Updates(updates).Error; err != nil {
+				return err
+			}
+			if version != nil {
+				return createProjectVersion(tx, version, s.versions.MaxPerProject)
+			}
+			return nil
+		})
+		if err != nil {
 			return nil, err
 		}
 	}
 
+	if req.Name != nil || req.Description != nil || req.HTMLCode != nil {
+		enqueueSearchIndex(s.db, s.redisClient, s.storage, project.ID)
+	}
+
+	if req.HTMLCode != nil {
+		if project.ThumbnailCodeHash == nil || *project.ThumbnailCodeHash != thumbnailCodeHash(*req.HTMLCode) {
+			s.thumbnails.QueueRefresh(project.ID)
+		}
+	}
+
+	if req.Name != nil && *req.Name != project.Name {
+		recordProjectActivity(s.db, project.ID, userID, ActivityRenamed, map[string]interface{}{
+			"old_name": project.Name,
+			"new_name": *req.Name,
+		})
+	}
+
+	if version != nil {
+		activityType := ActivityCodeUpdated
+		if version.ChangeSource == ChangeSourceAIGeneration {
+			activityType = ActivityAIGenerated
+		}
+		recordProjectActivity(s.db, project.ID, userID, activityType, map[string]interface{}{
+			"version_number": version.VersionNumber,
+			"change_source":  version.ChangeSource,
+		})
+	}
+
+	if req.Status != nil && *req.Status != project.Status {
+		switch {
+		case *req.Status == "published":
+			recordProjectActivity(s.db, project.ID, userID, ActivityPublished, nil)
+		case project.Status == "published":
+			recordProjectActivity(s.db, project.ID, userID, ActivityUnpublished, map[string]interface{}{
+				"status": *req.Status,
+			})
+		}
+	}
+
+	if req.Status != nil && *req.Status == "published" && project.Status != "published" {
+		publishWebhookEvent(s.redisClient, project.UserID, WebhookEventProjectPublished, map[string]interface{}{
+			"project_id":   project.ID,
+			"name":         project.Name,
+			"is_public":    project.IsPublic,
+			"published_by": userID,
+		})
+	}
+
+	// Collaborators' cached copies expire on their own
+	s.InvalidateProjectCache(userID, projectID)
+	if project.UserID != userID {
+		s.InvalidateProjectCache(project.UserID, projectID)
+	}
+
 	// Reload project
 	s.db.First(&project, "id = ?", projectID)
+	if err := storage.LoadProjectHTML(s.storage, &project); err != nil {
+		return nil, err
+	}
 	return &project, nil
 }
 
-func (s *ProjectService) DeleteProject(userID, projectID uuid.UUID) error {
+func (s *ProjectService) DeleteProject(ctx context.Context, userID, projectID uuid.UUID) error {
+	defer s.InvalidateProjectCache(userID, projectID)
+
+	var htmlKeys []string
+
 	// Delete in transaction
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Project{}).Where("id = ? AND user_id = ? AND html_code_key IS NOT NULL", projectID, userID).Pluck("html_code_key", &htmlKeys).Error; err != nil {
+			return err
+		}
+
 		// Delete conversations first
 		if err := tx.Where("project_id = ?", projectID).Delete(&models.Conversation{}).Error; err != nil {
 			return err
 		}
 
+		if err := tx.Where("project_id = ? AND project_id IN (SELECT id FROM projects WHERE user_id = ?)", projectID, userID).Delete(&models.ProjectVersion{}).Error; err != nil {
+			return err
+		}
+
 		// Delete project
 		result := tx.Where("id = ? AND user_id = ?", projectID, userID).Delete(&models.Project{})
 		if result.Error != nil {
@@ -219,35 +674,40 @@ func (s *ProjectService) DeleteProject(userID, projectID uuid.UUID) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.deleteProjectHTML(htmlKeys)
+	return nil
+}
+
+// deleteProjectHTML removes externally stored HTML once the projects using it
+// are deleted. A failure only leaves an orphaned object, so it is logged
+// rather than failing the delete.
+func (s *ProjectService) deleteProjectHTML(keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(key); err != nil {
+			s.logger.Warn("Failed to delete project HTML", "key", key, "error", err)
+		}
+	}
 }
 
-func (s *ProjectService) DuplicateProject(userID, projectID uuid.UUID) (*models.Project, error) {
+func (s *ProjectService) DuplicateProject(ctx context.Context, userID, projectID uuid.UUID) (*models.Project, error) {
 	// Get original project
 	var original models.Project
 	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&original).Error; err != nil {
 		return nil, err
 	}
 
-	// Check project limit
-	var count int64
-	s.db.Model(&models.Project{}).Where("user_id = ?", userID).Count(&count)
-
-	var user models.User
-	s.db.First(&user, "id = ?", userID)
-
-	limits := map[string]int64{
-		"free":    5,
-		"pro":     50,
-		"premium": 500,
-	}
-
-	limit := limits[user.SubscriptionPlan]
-	if limit == 0 {
-		limit = limits["free"]
+	// Copy the HTML itself rather than sharing the storage object
+	if err := storage.LoadProjectHTML(s.storage, &original); err != nil {
+		return nil, err
 	}
 
-	if count >= limit {
-		return nil, fmt.Errorf("project limit reached")
+	// Check project limit
+	if err := s.checkProjectLimit(userID); err != nil {
+		return nil, err
 	}
 
 	// Create duplicate
@@ -261,29 +721,126 @@ func (s *ProjectService) DuplicateProject(userID, projectID uuid.UUID) (*models.
 		Tags:        original.Tags,
 	}
 
-	if err := s.db.Create(&duplicate).Error; err != nil {
+	if err := s.insertProject(s.db, &duplicate); err != nil {
 		return nil, err
 	}
 
+	enqueueSearchIndex(s.db, s.redisClient, s.storage, duplicate.ID)
+	recordProjectActivity(s.db, original.ID, userID, ActivityDuplicated, map[string]interface{}{
+		"duplicate_id":   duplicate.ID,
+		"duplicate_name": duplicate.Name,
+	})
+
 	return &duplicate, nil
 }
 
-func (s *ProjectService) GetConversations(userID, projectID uuid.UUID) ([]models.Conversation, error) {
-	// Verify project ownership
-	var project models.Project
-	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+func (s *ProjectService) ForkProject(ctx context.Context, forkingUserID, originalProjectID uuid.UUID) (*models.Project, error) {
+	// Public projects can be forked by anyone, private ones only by their owner
+	var original models.Project
+	if err := s.db.Where("id = ? AND (is_public = ? OR user_id = ?)", originalProjectID, true, forkingUserID).First(&original).Error; err != nil {
+		return nil, err
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, &original); err != nil {
+		return nil, err
+	}
+
+	// Check project limit
+	if err := s.checkProjectLimit(forkingUserID); err != nil {
+		return nil, err
+	}
+
+	// Create fork without the original conversation history
+	fork := models.Project{
+		UserID:       forkingUserID,
+		Name:         fmt.Sprintf("%s (Forked)", original.Name),
+		Description:  original.Description,
+		HTMLCode:     original.HTMLCode,
+		CSSCode:      original.CSSCode,
+		JSCode:       original.JSCode,
+		Tags:         original.Tags,
+		ForkedFromID: &original.ID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.insertProject(tx, &fork); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Project{}).Where("id = ?", original.ID).
+			Update("fork_count", gorm.Expr("fork_count + 1")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	enqueueSearchIndex(s.db, s.redisClient, s.storage, fork.ID)
+	recordProjectActivity(s.db, original.ID, forkingUserID, ActivityForked, map[string]interface{}{
+		"fork_id":   fork.ID,
+		"fork_name": fork.Name,
+	})
+
+	return &fork, nil
+}
+
+func (s *ProjectService) GetForks(ctx context.Context, userID, projectID uuid.UUID) ([]models.ProjectInfo, error) {
+	// The original must be visible to the caller
+	var original models.Project
+	if err := s.dbRouter.Reader().Where("id = ? AND (user_id = ? OR is_public = ?)", projectID, userID, true).First(&original).Error; err != nil {
+		return nil, err
+	}
+
+	var forks []models.Project
+	if err := s.dbRouter.Reader().Where("forked_from_id = ? AND (is_public = ? OR user_id = ?)", projectID, true, userID).
+		Order("created_at DESC").Find(&forks).Error; err != nil {
+		return nil, err
+	}
+
+	forkInfos := make([]models.ProjectInfo, len(forks))
+	for i, f := range forks {
+		forkInfos[i] = toProjectInfo(&f)
+	}
+
+	return forkInfos, nil
+}
+
+func (s *ProjectService) GetConversations(ctx context.Context, userID, projectID uuid.UUID) ([]models.Conversation, error) {
+	reader := s.dbRouter.Reader()
+
+	// Collaborators see the conversation history of the projects they can open
+	if _, err := s.findAccessibleProject(reader, userID, projectID, ProjectRoleEditor, ProjectRoleViewer); err != nil {
 		return nil, err
 	}
 
 	var conversations []models.Conversation
-	if err := s.db.Where("project_id = ?", projectID).Order("created_at ASC").Find(&conversations).Error; err != nil {
+	if err := reader.Where("project_id = ?", projectID).Order("created_at ASC").Find(&conversations).Error; err != nil {
 		return nil, err
 	}
 
 	return conversations, nil
 }
 
-func (s *ProjectService) SaveConversation(projectID, userID uuid.UUID, userMessage, aiResponse, generatedCode string, tokensUsed int, responseTime int64, modelUsed, messageType string) (*models.Conversation, error) {
+// RateConversation records the owner's 1-5 satisfaction rating of an AI response
+func (s *ProjectService) RateConversation(ctx context.Context, userID, projectID, conversationID uuid.UUID, rating int) error {
+	var project models.Project
+	if err := s.db.Select("id").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return err
+	}
+
+	result := s.db.Model(&models.Conversation{}).
+		Where("id = ? AND project_id = ?", conversationID, projectID).
+		Update("satisfaction_rating", rating)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("conversation not found")
+	}
+
+	return nil
+}
+
+func (s *ProjectService) SaveConversation(ctx context.Context, projectID, userID uuid.UUID, userMessage, aiResponse, generatedCode string, tokensUsed int, responseTime int64, modelUsed, promptVariant, messageType string, fromCache bool) (*models.Conversation, error) {
 	conversation := models.Conversation{
 		ProjectID:      projectID,
 		UserID:         userID,
@@ -294,11 +851,470 @@ func (s *ProjectService) SaveConversation(projectID, userID uuid.UUID, userMessa
 		ResponseTimeMS: func() *int { rt := int(responseTime); return &rt }(),
 		ModelUsed:      &modelUsed,
 		MessageType:    messageType,
+		FromCache:      fromCache,
+	}
+	if promptVariant != "" {
+		conversation.PromptVariant = &promptVariant
 	}
 
+	_, span := telemetry.Tracer.Start(ctx, "ProjectService.SaveConversation", trace.WithAttributes(
+		attribute.String("project.id", projectID.String()),
+		attribute.String("conversation.message_type", messageType),
+	))
+	defer span.End()
+
 	if err := s.db.Create(&conversation).Error; err != nil {
+		telemetry.RecordError(span, err)
 		return nil, err
 	}
 
+	publishWebhookEvent(s.redisClient, userID, WebhookEventGenerationCompleted, map[string]interface{}{
+		"project_id":      projectID,
+		"conversation_id": conversation.ID,
+		"message_type":    messageType,
+		"tokens_used":     tokensUsed,
+		"has_code":        generatedCode != "",
+	})
+
 	return &conversation, nil
 }
+
+func (s *ProjectService) ImportConversationHistory(ctx context.Context, userID, projectID uuid.UUID, data []byte, format string) ([]models.Conversation, error) {
+	if len(data) > maxConversationImportSize {
+		return nil, errors.New("import file exceeds 5 MB limit")
+	}
+
+	if !json.Valid(data) {
+		return nil, errors.New("import file is not valid JSON")
+	}
+
+	// Verify project ownership
+	var project models.Project
+	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = detectConversationFormat(data)
+	}
+
+	var entries []models.ConversationEntry
+	var err error
+	switch format {
+	case "chatgpt":
+		entries, err = parseChatGPTExport(data)
+	case "claude":
+		entries, err = parseClaudeExport(data)
+	default:
+		return nil, errors.New("unsupported conversation format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("no messages found in import file")
+	}
+
+	// Pair each user message with the assistant reply that follows it
+	var conversations []models.Conversation
+	for i := 0; i < len(entries); i++ {
+		conversation := models.Conversation{
+			ProjectID:   projectID,
+			UserID:      userID,
+			MessageType: "imported",
+		}
+
+		if entries[i].Role == "user" {
+			conversation.UserMessage = entries[i].Content
+			if i+1 < len(entries) && entries[i+1].Role == "assistant" {
+				conversation.AIResponse = entries[i+1].Content
+				i++
+			}
+		} else {
+			conversation.AIResponse = entries[i].Content
+		}
+
+		conversations = append(conversations, conversation)
+	}
+
+	if err := s.db.Create(&conversations).Error; err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+func detectConversationFormat(data []byte) string {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return ""
+	}
+
+	if _, ok := probe["mapping"]; ok {
+		return "chatgpt"
+	}
+	if _, ok := probe["messages"]; ok {
+		return "claude"
+	}
+
+	return ""
+}
+
+// parseChatGPTExport reads a single conversation from a ChatGPT export, which
+// stores messages as a tree of nodes keyed by ID inside "mapping".
+func parseChatGPTExport(data []byte) ([]models.ConversationEntry, error) {
+	var export struct {
+		CurrentNode string `json:"current_node"`
+		Mapping     map[string]struct {
+			Parent  *string `json:"parent"`
+			Message *struct {
+				Author struct {
+					Role string `json:"role"`
+				} `json:"author"`
+				Content struct {
+					Parts []json.RawMessage `json:"parts"`
+				} `json:"content"`
+				CreateTime *float64 `json:"create_time"`
+			} `json:"message"`
+		} `json:"mapping"`
+	}
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, errors.New("invalid ChatGPT export format")
+	}
+
+	if len(export.Mapping) == 0 {
+		return nil, errors.New("invalid ChatGPT export format")
+	}
+
+	// Follow the active branch from the current node back to the root when
+	// available, otherwise fall back to ordering every node by creation time
+	var nodeIDs []string
+	if _, ok := export.Mapping[export.CurrentNode]; ok {
+		// A crafted export can link parents in a loop, so stop on a repeated
+		// node; a valid branch never visits more nodes than there are
+		visited := make(map[string]bool)
+		for id := export.CurrentNode; id != ""; {
+			node, ok := export.Mapping[id]
+			if !ok {
+				break
+			}
+			if visited[id] || len(nodeIDs) >= len(export.Mapping) {
+				return nil, errors.New("invalid ChatGPT export: message tree contains a cycle")
+			}
+			visited[id] = true
+			nodeIDs = append(nodeIDs, id)
+			if node.Parent == nil {
+				break
+			}
+			id = *node.Parent
+		}
+
+		// Collected from the current node back, so reverse into root-first order
+		for i, j := 0, len(nodeIDs)-1; i < j; i, j = i+1, j-1 {
+			nodeIDs[i], nodeIDs[j] = nodeIDs[j], nodeIDs[i]
+		}
+	} else {
+		for id := range export.Mapping {
+			nodeIDs = append(nodeIDs, id)
+		}
+		sort.SliceStable(nodeIDs, func(i, j int) bool {
+			a, b := export.Mapping[nodeIDs[i]].Message, export.Mapping[nodeIDs[j]].Message
+			if a == nil || a.CreateTime == nil {
+				return b != nil && b.CreateTime != nil
+			}
+			if b == nil || b.CreateTime == nil {
+				return false
+			}
+			return *a.CreateTime < *b.CreateTime
+		})
+	}
+
+	var entries []models.ConversationEntry
+	for _, id := range nodeIDs {
+		message := export.Mapping[id].Message
+		if message == nil {
+			continue
+		}
+
+		role := message.Author.Role
+		if role != "user" && role != "assistant" {
+			continue
+		}
+
+		var parts []string
+		for _, raw := range message.Content.Parts {
+			var text string
+			if err := json.Unmarshal(raw, &text); err == nil && strings.TrimSpace(text) != "" {
+				parts = append(parts, text)
+			}
+		}
+
+		content := strings.TrimSpace(strings.Join(parts, "\n"))
+		if content == "" {
+			continue
+		}
+
+		entries = append(entries, models.ConversationEntry{Role: role, Content: content})
+	}
+
+	return entries, nil
+}
+
+// parseClaudeExport reads a Claude conversation export. Message content may be
+// either a plain string or a list of content blocks.
+func parseClaudeExport(data []byte) ([]models.ConversationEntry, error) {
+	var export struct {
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, errors.New("invalid Claude export format")
+	}
+
+	var entries []models.ConversationEntry
+	for _, message := range export.Messages {
+		role := message.Role
+		if role == "human" {
+			role = "user"
+		}
+		if role != "user" && role != "assistant" {
+			continue
+		}
+
+		var content string
+		if err := json.Unmarshal(message.Content, &content); err != nil {
+			var blocks []ContentBlock
+			if err := json.Unmarshal(message.Content, &blocks); err != nil {
+				return nil, errors.New("invalid Claude export format")
+			}
+
+			var parts []string
+			for _, block := range blocks {
+				if block.Type == "text" && block.Text != "" {
+					parts = append(parts, block.Text)
+				}
+			}
+			content = strings.Join(parts, "\n")
+		}
+
+		content = strings.TrimSpace(content)
+		if content == "" {
+			continue
+		}
+
+		entries = append(entries, models.ConversationEntry{Role: role, Content: content})
+	}
+
+	return entries, nil
+}
+
+func (s *ProjectService) GetUserLocation(ctx context.Context, userID uuid.UUID) *time.Location {
+	var user models.User
+	if err := s.dbRouter.Reader().Select("timezone").First(&user, "id = ?", userID).Error; err != nil || user.Timezone == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func (s *ProjectService) GetActivityHeatmap(ctx context.Context, userID, projectID uuid.UUID, days int, tz *time.Location) ([7][24]int, error) {
+	var matrix [7][24]int
+
+	// Verify project ownership
+	var project models.Project
+	if err := s.dbRouter.Reader().Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return matrix, err
+	}
+
+	if tz == nil || tz == time.Local {
+		tz = time.UTC
+	}
+
+	cacheKey := fmt.Sprintf("heatmap:%s:%d:%s", projectID.String(), days, tz.String())
+	if s.redisClient != nil {
+		if err := s.redisClient.Get(cacheKey, &matrix); err == nil {
+			return matrix, nil
+		}
+	}
+
+	var rows []struct {
+		Dow   int
+		Hour  int
+		Count int
+	}
+
+	// Activity is every conversation (prompts and AI generations), recorded
+	// view and export of the project
+	since := time.Now().AddDate(0, 0, -days)
+	if err := s.dbRouter.Reader().Raw(`
+		SELECT EXTRACT(DOW FROM created_at AT TIME ZONE ?)::int AS dow,
+		       EXTRACT(HOUR FROM created_at AT TIME ZONE ?)::int AS hour,
+		       COUNT(*) AS count
+		FROM (
+			SELECT created_at FROM conversations WHERE project_id = ? AND created_at >= ?
+			UNION ALL
+			SELECT created_at FROM project_views WHERE project_id = ? AND created_at >= ?
+			UNION ALL
+			SELECT created_at FROM export_records WHERE project_id = ? AND created_at >= ?
+		) AS activity
+		GROUP BY dow, hour`,
+		tz.String(), tz.String(), projectID, since, projectID, since, projectID, since,
+	).Scan(&rows).Error; err != nil {
+		return matrix, err
+	}
+
+	for _, row := range rows {
+		if row.Dow >= 0 && row.Dow < 7 && row.Hour >= 0 && row.Hour < 24 {
+			matrix[row.Dow][row.Hour] += row.Count
+		}
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, matrix, time.Hour) // Cache for 1 hour
+	}
+
+	return matrix, nil
+}
+
+// GetUserAnalytics aggregates activity across all of a user's projects. Project
+// totals are all-time; generation, spend, satisfaction and the daily view
+// series cover the last `days` days.
+func (s *ProjectService) GetUserAnalytics(ctx context.Context, userID uuid.UUID, days int) (*models.UserAnalytics, error) {
+	cacheKey := fmt.Sprintf("analytics:%s:%d", userID.String(), days)
+	if s.redisClient != nil {
+		var cached models.UserAnalytics
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	var row struct {
+		TotalProjects      int64
+		DraftCount         int64
+		PublishedCount     int64
+		ArchivedCount      int64
+		TotalViews         int64
+		TotalLikes         int64
+		TotalTokens        int64
+		AvgSatisfaction    *float64
+		MostViewedID       *uuid.UUID
+		MostViewedName     *string
+		MostViewedCount    *int64
+		MostGeneratedID    *uuid.UUID
+		MostGeneratedName  *string
+		MostGeneratedCount *int64
+		TimeSeries         string
+	}
+
+	since := time.Now().AddDate(0, 0, -(days - 1)).Truncate(24 * time.Hour)
+	if err := s.dbRouter.Reader().Raw(`
+		WITH user_projects AS (
+			SELECT id, name, status, view_count, like_count
+			FROM projects
+			WHERE user_id = @user_id AND deleted_at IS NULL
+		),
+		project_totals AS (
+			SELECT COUNT(*) AS total_projects,
+			       COUNT(*) FILTER (WHERE status = 'draft') AS draft_count,
+			       COUNT(*) FILTER (WHERE status = 'published') AS published_count,
+			       COUNT(*) FILTER (WHERE status = 'archived') AS archived_count,
+			       COALESCE(SUM(view_count), 0) AS total_views,
+			       COALESCE(SUM(like_count), 0) AS total_likes
+			FROM user_projects
+		),
+		period_conversations AS (
+			SELECT c.project_id, c.tokens_used, c.satisfaction_rating
+			FROM conversations c
+			JOIN user_projects p ON p.id = c.project_id
+			WHERE c.created_at >= @since
+		),
+		conversation_totals AS (
+			SELECT COALESCE(SUM(tokens_used), 0) AS total_tokens,
+			       AVG(satisfaction_rating)::float8 AS avg_satisfaction
+			FROM period_conversations
+		),
+		most_viewed AS (
+			SELECT id, name, view_count AS count
+			FROM user_projects
+			WHERE view_count > 0
+			ORDER BY view_count DESC
+			LIMIT 1
+		),
+		most_generated AS (
+			SELECT p.id, p.name, COUNT(*) AS count
+			FROM period_conversations pc
+			JOIN user_projects p ON p.id = pc.project_id
+			GROUP BY p.id, p.name
+			ORDER BY count DESC
+			LIMIT 1
+		),
+		daily_views AS (
+			SELECT json_agg(json_build_object('date', to_char(d.day, 'YYYY-MM-DD'), 'views', COALESCE(v.views, 0)) ORDER BY d.day) AS series
+			FROM generate_series(@since::date, CURRENT_DATE, interval '1 day') AS d(day)
+			LEFT JOIN (
+				SELECT pv.created_at::date AS day, COUNT(*) AS views
+				FROM project_views pv
+				JOIN user_projects p ON p.id = pv.project_id
+				WHERE pv.created_at >= @since
+				GROUP BY 1
+			) v ON v.day = d.day
+		)
+		SELECT pt.total_projects, pt.draft_count, pt.published_count, pt.archived_count,
+		       pt.total_views, pt.total_likes,
+		       ct.total_tokens, ct.avg_satisfaction,
+		       mv.id AS most_viewed_id, mv.name AS most_viewed_name, mv.count AS most_viewed_count,
+		       mg.id AS most_generated_id, mg.name AS most_generated_name, mg.count AS most_generated_count,
+		       COALESCE(dv.series, '[]'::json)::text AS time_series
+		FROM project_totals pt
+		CROSS JOIN conversation_totals ct
+		CROSS JOIN daily_views dv
+		LEFT JOIN most_viewed mv ON true
+		LEFT JOIN most_generated mg ON true`,
+		map[string]interface{}{"user_id": userID, "since": since},
+	).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	analytics := &models.UserAnalytics{
+		PeriodDays:    days,
+		TotalProjects: row.TotalProjects,
+		ProjectsByStatus: map[string]int64{
+			"draft":     row.DraftCount,
+			"published": row.PublishedCount,
+			"archived":  row.ArchivedCount,
+		},
+		TotalViewsAcrossProjects: row.TotalViews,
+		TotalLikes:               row.TotalLikes,
+		TotalTokensUsed:          row.TotalTokens,
+		TotalAISpendUSD:          math.Round(float64(row.TotalTokens)/1e6*estimatedCostPerMillionTokens*100) / 100,
+		AvgSatisfactionScore:     row.AvgSatisfaction,
+		TimeSeries:               []models.DailyViewCount{},
+	}
+
+	if row.MostViewedID != nil {
+		analytics.MostViewedProject = &models.AnalyticsProject{ID: *row.MostViewedID, Name: *row.MostViewedName, Count: *row.MostViewedCount}
+	}
+	if row.MostGeneratedID != nil {
+		analytics.MostGeneratedProject = &models.AnalyticsProject{ID: *row.MostGeneratedID, Name: *row.MostGeneratedName, Count: *row.MostGeneratedCount}
+	}
+
+	if err := json.Unmarshal([]byte(row.TimeSeries), &analytics.TimeSeries); err != nil {
+		return nil, fmt.Errorf("failed to parse view time series: %w", err)
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, analytics, 15*time.Minute) // Cache for 15 minutes
+	}
+
+	return analytics, nil
+}
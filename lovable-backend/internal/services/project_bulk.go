@@ -0,0 +1,114 @@
+// internal/services/project_bulk.go
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// Activity entries for a bulk status change, written with one statement.
+// Arguments: user ID, activity type, [new status,] project IDs.
+const (
+	bulkPublishedActivitySQL = `
+		INSERT INTO project_activities (project_id, user_id, activity_type, metadata, created_at)
+		SELECT id, ?, ?, '{}'::jsonb, NOW()
+		FROM projects
+		WHERE id IN ? AND status <> 'published'`
+	bulkUnpublishedActivitySQL = `
+		INSERT INTO project_activities (project_id, user_id, activity_type, metadata, created_at)
+		SELECT id, ?, ?, jsonb_build_object('status', ?::text), NOW()
+		FROM projects
+		WHERE id IN ? AND status = 'published'`
+)
+
+// BulkDeleteProjects deletes those of the given projects the user owns, with
+// their conversations and versions, in one transaction. Projects of other
+// users are skipped. It returns the number of projects deleted.
+func (s *ProjectService) BulkDeleteProjects(ctx context.Context, userID uuid.UUID, projectIDs []uuid.UUID) (int, error) {
+	var owned []uuid.UUID
+	var htmlKeys []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Project{}).Where("id IN ? AND user_id = ?", projectIDs, userID).Pluck("id", &owned).Error; err != nil {
+			return err
+		}
+		if len(owned) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&models.Project{}).Where("id IN ? AND html_code_key IS NOT NULL", owned).Pluck("html_code_key", &htmlKeys).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO project_activities (project_id, user_id, activity_type, metadata, created_at)
+			SELECT id, ?, ?, jsonb_build_object('name', name), NOW()
+			FROM projects
+			WHERE id IN ?`,
+			userID, ActivityDeleted, owned,
+		).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("project_id IN ?", owned).Delete(&models.Conversation{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("project_id IN ?", owned).Delete(&models.ProjectVersion{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ?", owned).Delete(&models.Project{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, projectID := range owned {
+		s.InvalidateProjectCache(userID, projectID)
+	}
+	s.deleteProjectHTML(htmlKeys)
+
+	return len(owned), nil
+}
+
+// BulkUpdateStatus sets the status of those of the given projects the user
+// owns, skipping projects of other users. Publishing and unpublishing are
+// recorded in the activity feed as UpdateProject does. It returns the number
+// of projects updated.
+func (s *ProjectService) BulkUpdateStatus(ctx context.Context, userID uuid.UUID, projectIDs []uuid.UUID, status string) (int, error) {
+	var owned []uuid.UUID
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Project{}).Where("id IN ? AND user_id = ?", projectIDs, userID).Pluck("id", &owned).Error; err != nil {
+			return err
+		}
+		if len(owned) == 0 {
+			return nil
+		}
+
+		// Recorded before the update, while the previous status is known
+		var err error
+		if status == "published" {
+			err = tx.Exec(bulkPublishedActivitySQL, userID, ActivityPublished, owned).Error
+		} else {
+			err = tx.Exec(bulkUnpublishedActivitySQL, userID, ActivityUnpublished, status, owned).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Project{}).Where("id IN ?", owned).Update("status", status).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, projectID := range owned {
+		s.InvalidateProjectCache(userID, projectID)
+	}
+
+	return len(owned), nil
+}
@@ -0,0 +1,288 @@
+// internal/services/project_import_url.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+
+	"lovable-backend/internal/models"
+)
+
+// Largest page accepted by URL import
+const maxImportPageSize = 2 << 20
+
+// Attributes holding a single URL that are made absolute in imported pages
+var importURLAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"poster": true,
+	"action": true,
+}
+
+// ImportFromURL creates a project from an existing web page. The page's
+// relative links are made absolute against its URL, and scripts from hosts
+// other than the page's own and the configured CDNs are removed.
+func (s *ProjectService) ImportFromURL(ctx context.Context, userID uuid.UUID, rawURL, name string) (*models.Project, error) {
+	if err := s.checkProjectLimit(userID); err != nil {
+		return nil, err
+	}
+
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Hostname() == "" {
+		return nil, errors.New("invalid import URL")
+	}
+	if s.importHostDisallowed(pageURL.Hostname()) {
+		return nil, errors.New("import host not allowed")
+	}
+
+	page, err := s.fetchImportPage(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedHTML, err)
+	}
+
+	base := pageURL
+	if href := findBaseHref(doc); href != "" {
+		if resolved, err := pageURL.Parse(href); err == nil {
+			base = resolved
+		}
+	}
+	s.normalizeImportedPage(doc, base, pageURL.Hostname())
+
+	var rendered bytes.Buffer
+	if err := html.Render(&rendered, doc); err != nil {
+		return nil, err
+	}
+	htmlCode := rendered.String()
+
+	if name == "" {
+		name = pageURL.Hostname()
+		if title := strings.TrimSpace(findTitle(doc)); title != "" {
+			name = title
+		}
+		if runes := []rune(name); len(runes) > 255 {
+			name = string(runes[:255])
+		}
+	}
+
+	return s.createProject(ctx, userID, &models.Project{
+		UserID:   userID,
+		Name:     name,
+		HTMLCode: &htmlCode,
+	}, ActivitySourceImportURL)
+}
+
+// fetchImportPage downloads an HTML page of at most maxImportPageSize. Every
+// connection, including redirects, is refused if it resolves to a private,
+// loopback or link-local address.
+func (s *ProjectService) fetchImportPage(ctx context.Context, pageURL *url.URL) ([]byte, error) {
+	dialer := publicOnlyDialer(5*time.Second, errors.New("import host not allowed"))
+
+	client := &http.Client{
+		Timeout:   time.Duration(s.urlImport.Timeout) * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			if s.importHostDisallowed(req.URL.Hostname()) {
+				return errors.New("import host not allowed")
+			}
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, errors.New("invalid import URL")
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if strings.Contains(err.Error(), "import host not allowed") {
+			return nil, errors.New("import host not allowed")
+		}
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch page: status %d", resp.StatusCode)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "text/html" {
+		return nil, errors.New("page is not HTML")
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxImportPageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	if len(page) > maxImportPageSize {
+		return nil, errors.New("page too large")
+	}
+
+	return page, nil
+}
+
+func (s *ProjectService) importHostDisallowed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, blocked := range s.urlImport.DisallowedHosts {
+		blocked = strings.ToLower(blocked)
+		if host == blocked || strings.HasSuffix(host, "."+blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicOnlyDialer returns a dialer that refuses, with refused, to connect
+// to any address that isn't publicIP. The check runs on the resolved address,
+// so it also covers host names that resolve to internal addresses.
+func publicOnlyDialer(timeout time.Duration, refused error) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return refused
+			}
+			return nil
+		},
+	}
+}
+
+// publicIP reports whether ip is routable on the internet, excluding RFC 1918
+// and other private, loopback, link-local and unspecified addresses
+func publicIP(ip net.IP) bool {
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast())
+}
+
+// normalizeImportedPage makes URL attributes absolute against base and drops
+// scripts loaded from third-party hosts that are not allowed
+func (s *ProjectService) normalizeImportedPage(n *html.Node, base *url.URL, pageHost string) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		if child.Type == html.ElementNode && child.Data == "script" {
+			if src := nodeAttr(child, "src"); src != "" {
+				resolved, err := base.Parse(strings.TrimSpace(src))
+				if err != nil || !s.importScriptAllowed(resolved.Hostname(), pageHost) {
+					n.RemoveChild(child)
+					child = next
+					continue
+				}
+			}
+		}
+
+		if child.Type == html.ElementNode {
+			for i, attr := range child.Attr {
+				switch {
+				case importURLAttributes[attr.Key]:
+					child.Attr[i].Val = absoluteURL(base, attr.Val)
+				case attr.Key == "srcset":
+					child.Attr[i].Val = absoluteSrcset(base, attr.Val)
+				}
+			}
+		}
+
+		s.normalizeImportedPage(child, base, pageHost)
+		child = next
+	}
+}
+
+func (s *ProjectService) importScriptAllowed(host, pageHost string) bool {
+	if strings.EqualFold(host, pageHost) {
+		return true
+	}
+	for _, allowed := range s.urlImport.AllowedScriptHosts {
+		if strings.EqualFold(host, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// absoluteURL resolves a relative reference against base. Fragment-only
+// links and non-fetchable schemes are left as they are.
+func absoluteURL(base *url.URL, value string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return value
+	}
+
+	ref, err := url.Parse(trimmed)
+	if err != nil || (ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https") {
+		return value
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// absoluteSrcset resolves each candidate URL in a srcset attribute
+func absoluteSrcset(base *url.URL, value string) string {
+	candidates := strings.Split(value, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = absoluteURL(base, fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+func nodeAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func findBaseHref(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "base" {
+		return nodeAttr(n, "href")
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if href := findBaseHref(child); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+func findTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "title" && n.FirstChild != nil {
+		return n.FirstChild.Data
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if title := findTitle(child); title != "" {
+			return title
+		}
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+// internal/services/project_import_zip.go
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+
+	"lovable-backend/internal/models"
+)
+
+// Largest total uncompressed size of an archive accepted by ZIP import. The
+// upload itself is limited to the same size by the handler.
+const MaxZIPImportSize = 5 << 20
+
+// ImportFromZIP creates a project from an archive laid out like a ZIP export:
+// index.html at the root or in a single top-level folder, with styles.css,
+// script.js and README.md read from beside it when present. The project is
+// named after nameOverride, the README's title or the page's <title>, in that
+// order, and described by the README's first paragraph.
+func (s *ProjectService) ImportFromZIP(ctx context.Context, userID uuid.UUID, zipData []byte, nameOverride string) (*models.Project, error) {
+	if err := s.checkProjectLimit(userID); err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, errors.New("invalid zip archive")
+	}
+
+	// Declared sizes are checked up front, and actual sizes as files are read,
+	// since an archive can understate them
+	var declared uint64
+	for _, file := range archive.File {
+		declared += file.UncompressedSize64
+	}
+	if declared > MaxZIPImportSize {
+		return nil, errors.New("archive too large")
+	}
+
+	index := findZIPIndex(archive)
+	if index == nil {
+		return nil, errors.New("index.html not found in archive")
+	}
+	dir := path.Dir(index.Name)
+
+	budget := int64(MaxZIPImportSize)
+	htmlCode, err := readZIPFile(index, &budget)
+	if err != nil {
+		return nil, err
+	}
+
+	project := &models.Project{
+		UserID:   userID,
+		HTMLCode: &htmlCode,
+	}
+
+	if file := findZIPFile(archive, dir, "styles.css"); file != nil {
+		cssCode, err := readZIPFile(file, &budget)
+		if err != nil {
+			return nil, err
+		}
+		project.CSSCode = &cssCode
+	}
+
+	if file := findZIPFile(archive, dir, "script.js"); file != nil {
+		jsCode, err := readZIPFile(file, &budget)
+		if err != nil {
+			return nil, err
+		}
+		project.JSCode = &jsCode
+	}
+
+	readmeTitle := ""
+	if file := findZIPFile(archive, dir, "README.md"); file != nil {
+		readme, err := readZIPFile(file, &budget)
+		if err != nil {
+			return nil, err
+		}
+		var description string
+		readmeTitle, description = parseReadme(readme)
+		if description != "" {
+			project.Description = &description
+		}
+	}
+
+	project.Name = nameOverride
+	if project.Name == "" {
+		project.Name = readmeTitle
+	}
+	if project.Name == "" {
+		if doc, err := html.Parse(strings.NewReader(htmlCode)); err == nil {
+			project.Name = strings.TrimSpace(findTitle(doc))
+		}
+	}
+	if project.Name == "" {
+		project.Name = "Imported project"
+	}
+	if runes := []rune(project.Name); len(runes) > 255 {
+		project.Name = string(runes[:255])
+	}
+
+	return s.createProject(ctx, userID, project, ActivitySourceImportZIP)
+}
+
+// findZIPIndex returns the archive's index.html at the root, or else the
+// first one directly inside a top-level folder. macOS resource forks are
+// ignored.
+func findZIPIndex(archive *zip.Reader) *zip.File {
+	if file := findZIPFile(archive, ".", "index.html"); file != nil {
+		return file
+	}
+
+	for _, file := range archive.File {
+		parts := strings.Split(file.Name, "/")
+		if len(parts) == 2 && parts[1] == "index.html" && parts[0] != "__MACOSX" {
+			return file
+		}
+	}
+	return nil
+}
+
+func findZIPFile(archive *zip.Reader, dir, name string) *zip.File {
+	for _, file := range archive.File {
+		if path.Dir(file.Name) == dir && path.Base(file.Name) == name && !file.FileInfo().IsDir() {
+			return file
+		}
+	}
+	return nil
+}
+
+// readZIPFile reads a file of the archive as text, taking its size from
+// budget and failing once the budget is spent
+func readZIPFile(file *zip.File, budget *int64) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", errors.New("invalid zip archive")
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, *budget+1))
+	if err != nil {
+		return "", errors.New("invalid zip archive")
+	}
+	if int64(len(data)) > *budget {
+		return "", errors.New("archive too large")
+	}
+	*budget -= int64(len(data))
+
+	return string(data), nil
+}
+
+// parseReadme returns a README's "# " title and its first paragraph of text,
+// such as the description written by ZIP export
+func parseReadme(readme string) (string, string) {
+	title := ""
+	var paragraph []string
+
+	for _, line := range strings.Split(readme, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(line, "#"):
+			if len(paragraph) > 0 {
+				return title, truncateDescription(strings.Join(paragraph, " "), 1000)
+			}
+			if title == "" && strings.HasPrefix(line, "# ") {
+				title = strings.TrimSpace(line[2:])
+			}
+		case line == "":
+			if len(paragraph) > 0 {
+				return title, truncateDescription(strings.Join(paragraph, " "), 1000)
+			}
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+
+	return title, truncateDescription(strings.Join(paragraph, " "), 1000)
+}
@@ -0,0 +1,158 @@
+// internal/services/project_stats.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+)
+
+// GetProjectStats aggregates views, exports, conversations, code size and
+// version history for a project the user owns or collaborates on. All-time
+// views come from the project's view counter; windowed views and the daily
+// chart from recorded views. Code kept in external storage is sized by the
+// latest version.
+func (s *ProjectService) GetProjectStats(ctx context.Context, userID, projectID uuid.UUID) (*models.ProjectStats, error) {
+	reader := s.dbRouter.Reader()
+
+	if _, err := s.findAccessibleProject(reader.Select("id"), userID, projectID, ProjectRoleEditor, ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("project_stats:%s", projectID.String())
+	if s.redisClient != nil {
+		var cached models.ProjectStats
+		if err := s.redisClient.Get(cacheKey, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	var row struct {
+		TotalViews             int64
+		Views7Days             int64
+		Views30Days            int64
+		ExportsByFormat        string
+		ConversationsByType    string
+		AvgTokensPerGeneration *float64
+		AvgSatisfaction        *float64
+		CodeSize               int64
+		VersionCount           int64
+		ChartData              string
+	}
+
+	now := time.Now()
+	since := now.AddDate(0, 0, -29).Truncate(24 * time.Hour)
+	if err := reader.Raw(`
+		WITH project AS (
+			SELECT id, view_count, html_code_key,
+			       COALESCE(octet_length(html_code), 0) + COALESCE(octet_length(css_code), 0) + COALESCE(octet_length(js_code), 0) AS code_size
+			FROM projects
+			WHERE id = @project_id
+		),
+		views AS (
+			SELECT COUNT(*) FILTER (WHERE created_at >= @week_ago) AS views_7_days,
+			       COUNT(*) AS views_30_days
+			FROM project_views
+			WHERE project_id = @project_id AND created_at >= @month_ago
+		),
+		exports AS (
+			SELECT json_object_agg(format, count) AS by_format
+			FROM (
+				SELECT format, COUNT(*) AS count
+				FROM export_records
+				WHERE project_id = @project_id
+				GROUP BY format
+			) e
+		),
+		conversation_totals AS (
+			SELECT AVG(tokens_used) FILTER (WHERE message_type = 'generation')::float8 AS avg_tokens_per_generation,
+			       AVG(satisfaction_rating)::float8 AS avg_satisfaction
+			FROM conversations
+			WHERE project_id = @project_id
+		),
+		conversation_types AS (
+			SELECT json_object_agg(message_type, count) AS by_type
+			FROM (
+				SELECT message_type, COUNT(*) AS count
+				FROM conversations
+				WHERE project_id = @project_id
+				GROUP BY message_type
+			) c
+		),
+		versions AS (
+			SELECT COUNT(*) AS version_count,
+			       (ARRAY_AGG(size_bytes ORDER BY version_number DESC))[1] AS latest_size
+			FROM project_versions
+			WHERE project_id = @project_id
+		),
+		daily_views AS (
+			SELECT json_agg(json_build_object('date', to_char(d.day, 'YYYY-MM-DD'), 'views', COALESCE(v.views, 0)) ORDER BY d.day) AS series
+			FROM generate_series(@since::date, CURRENT_DATE, interval '1 day') AS d(day)
+			LEFT JOIN (
+				SELECT created_at::date AS day, COUNT(*) AS views
+				FROM project_views
+				WHERE project_id = @project_id AND created_at >= @since
+				GROUP BY 1
+			) v ON v.day = d.day
+		)
+		SELECT p.view_count AS total_views,
+		       vw.views_7_days, vw.views_30_days,
+		       COALESCE(e.by_format, '{}'::json)::text AS exports_by_format,
+		       COALESCE(ct.by_type, '{}'::json)::text AS conversations_by_type,
+		       c.avg_tokens_per_generation, c.avg_satisfaction,
+		       CASE WHEN p.html_code_key IS NOT NULL THEN COALESCE(v.latest_size, p.code_size) ELSE p.code_size END AS code_size,
+		       v.version_count,
+		       COALESCE(dv.series, '[]'::json)::text AS chart_data
+		FROM project p
+		CROSS JOIN views vw
+		CROSS JOIN exports e
+		CROSS JOIN conversation_totals c
+		CROSS JOIN conversation_types ct
+		CROSS JOIN versions v
+		CROSS JOIN daily_views dv`,
+		map[string]interface{}{
+			"project_id": projectID,
+			"week_ago":   now.AddDate(0, 0, -7),
+			"month_ago":  now.AddDate(0, 0, -30),
+			"since":      since,
+		},
+	).Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &models.ProjectStats{
+		ProjectID: projectID,
+		Views: models.ProjectViewStats{
+			Total:      row.TotalViews,
+			Last7Days:  row.Views7Days,
+			Last30Days: row.Views30Days,
+		},
+		AvgTokensPerGeneration: row.AvgTokensPerGeneration,
+		AvgSatisfactionRating:  row.AvgSatisfaction,
+		CodeSizeBytes:          row.CodeSize,
+		VersionCount:           row.VersionCount,
+		ChartData:              []models.DailyViewCount{},
+		GeneratedAt:            now,
+	}
+
+	if err := json.Unmarshal([]byte(row.ExportsByFormat), &stats.ExportsByFormat); err != nil {
+		return nil, fmt.Errorf("failed to parse export counts: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.ConversationsByType), &stats.ConversationsByType); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation counts: %w", err)
+	}
+	if err := json.Unmarshal([]byte(row.ChartData), &stats.ChartData); err != nil {
+		return nil, fmt.Errorf("failed to parse view chart data: %w", err)
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(cacheKey, stats, 5*time.Minute) // Cache for 5 minutes
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,160 @@
+// internal/services/prompt_templates.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+)
+
+// Matches a {{variable}} placeholder, allowing spaces inside the braces
+var promptVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// PromptTemplateService stores users' reusable prompts and renders them
+type PromptTemplateService struct {
+	db       *gorm.DB // primary
+	dbRouter *database.DBRouter
+}
+
+func NewPromptTemplateService(dbRouter *database.DBRouter) *PromptTemplateService {
+	return &PromptTemplateService{
+		db:       dbRouter.Writer(),
+		dbRouter: dbRouter,
+	}
+}
+
+// promptVariables returns the distinct placeholder names in text, in order of
+// first appearance
+func promptVariables(text string) pq.StringArray {
+	variables := pq.StringArray{}
+	seen := make(map[string]bool)
+	for _, match := range promptVariablePattern.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+func (s *PromptTemplateService) CreateTemplate(ctx context.Context, userID uuid.UUID, req *models.CreatePromptTemplateRequest) (*models.PromptTemplate, error) {
+	template := models.PromptTemplate{
+		UserID:       userID,
+		Name:         req.Name,
+		TemplateText: req.TemplateText,
+		Variables:    promptVariables(req.TemplateText),
+		IsPublic:     req.IsPublic,
+	}
+
+	if err := s.db.Create(&template).Error; err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// ListTemplates returns the user's own templates and everyone's public ones,
+// newest first
+func (s *PromptTemplateService) ListTemplates(ctx context.Context, userID uuid.UUID, page, limit int) (*models.PromptTemplatesResponse, error) {
+	db := s.dbRouter.Reader().Model(&models.PromptTemplate{}).Where("user_id = ? OR is_public = ?", userID, true)
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	templates := []models.PromptTemplate{}
+	if err := db.Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	return &models.PromptTemplatesResponse{
+		Templates: templates,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: page < totalPages,
+			HasPrevPage: page > 1,
+		},
+	}, nil
+}
+
+func (s *PromptTemplateService) UpdateTemplate(ctx context.Context, userID, templateID uuid.UUID, req *models.UpdatePromptTemplateRequest) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	if err := s.db.Where("id = ? AND user_id = ?", templateID, userID).First(&template).Error; err != nil {
+		return nil, errors.New("prompt template not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.TemplateText != nil {
+		updates["template_text"] = *req.TemplateText
+		updates["variables"] = promptVariables(*req.TemplateText)
+	}
+	if req.IsPublic != nil {
+		updates["is_public"] = *req.IsPublic
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&template).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Reload template
+	if err := s.db.First(&template, "id = ?", templateID).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (s *PromptTemplateService) DeleteTemplate(ctx context.Context, userID, templateID uuid.UUID) error {
+	result := s.db.Where("id = ? AND user_id = ?", templateID, userID).Delete(&models.PromptTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("prompt template not found")
+	}
+
+	return nil
+}
+
+// RenderTemplate substitutes the given values into one of the user's own or a
+// public template and counts the use. Every placeholder needs a value.
+func (s *PromptTemplateService) RenderTemplate(ctx context.Context, userID, templateID uuid.UUID, vars map[string]string) (string, error) {
+	var template models.PromptTemplate
+	if err := s.db.Where("id = ? AND (user_id = ? OR is_public = ?)", templateID, userID, true).First(&template).Error; err != nil {
+		return "", errors.New("prompt template not found")
+	}
+
+	for _, variable := range template.Variables {
+		if vars[variable] == "" {
+			return "", errors.New("missing template variable")
+		}
+	}
+
+	rendered := promptVariablePattern.ReplaceAllStringFunc(template.TemplateText, func(placeholder string) string {
+		return vars[promptVariablePattern.FindStringSubmatch(placeholder)[1]]
+	})
+
+	s.db.Model(&template).UpdateColumn("usage_count", gorm.Expr("usage_count + 1"))
+
+	return rendered, nil
+}
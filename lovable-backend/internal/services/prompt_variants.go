@@ -0,0 +1,381 @@
+// internal/services/prompt_variants.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+// p-value below which variants' rating distributions are considered to differ
+const promptVariantSignificance = 0.05
+
+// PromptVariantService manages the system prompt variants A/B tested on
+// generations and compares them by satisfaction rating
+type PromptVariantService struct {
+	db         *gorm.DB // primary
+	dbRouter   *database.DBRouter
+	minSamples int
+}
+
+func NewPromptVariantService(dbRouter *database.DBRouter, minSamples int) *PromptVariantService {
+	return &PromptVariantService{
+		db:         dbRouter.Writer(),
+		dbRouter:   dbRouter,
+		minSamples: minSamples,
+	}
+}
+
+func (s *PromptVariantService) ListVariants(ctx context.Context) ([]models.PromptVariant, error) {
+	variants := []models.PromptVariant{}
+	if err := s.dbRouter.Reader().Order("name").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (s *PromptVariantService) CreateVariant(ctx context.Context, req *models.CreatePromptVariantRequest) (*models.PromptVariant, error) {
+	if err := s.checkNameAvailable(req.Name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	variant := models.PromptVariant{
+		Name:     req.Name,
+		Prompt:   req.Prompt,
+		Weight:   req.Weight,
+		IsActive: req.IsActive,
+	}
+
+	if err := s.db.Create(&variant).Error; err != nil {
+		return nil, err
+	}
+
+	return &variant, nil
+}
+
+func (s *PromptVariantService) UpdateVariant(ctx context.Context, variantID uuid.UUID, req *models.UpdatePromptVariantRequest) (*models.PromptVariant, error) {
+	var variant models.PromptVariant
+	if err := s.db.First(&variant, "id = ?", variantID).Error; err != nil {
+		return nil, errors.New("prompt variant not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil && *req.Name != variant.Name {
+		if err := s.checkNameAvailable(*req.Name, variantID); err != nil {
+			return nil, err
+		}
+		updates["name"] = *req.Name
+	}
+	if req.Prompt != nil {
+		updates["prompt"] = *req.Prompt
+	}
+	if req.Weight != nil {
+		updates["weight"] = *req.Weight
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&variant).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Reload variant
+	if err := s.db.First(&variant, "id = ?", variantID).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (s *PromptVariantService) DeleteVariant(ctx context.Context, variantID uuid.UUID) error {
+	result := s.db.Where("id = ?", variantID).Delete(&models.PromptVariant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("prompt variant not found")
+	}
+
+	return nil
+}
+
+// checkNameAvailable fails if another variant than exceptID has the name.
+// Generations record the variant by name, so names must stay unique.
+func (s *PromptVariantService) checkNameAvailable(name string, exceptID uuid.UUID) error {
+	var count int64
+	if err := s.db.Model(&models.PromptVariant{}).
+		Where("name = ? AND id <> ?", name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("prompt variant name already exists")
+	}
+	return nil
+}
+
+// AnalyseVariants summarizes the satisfaction ratings of each variant's
+// generations, stores each variant's win rate and sample count, and tests
+// whether the rating distributions differ between variants
+func (s *PromptVariantService) AnalyseVariants(ctx context.Context) (*models.PromptVariantAnalysis, error) {
+	var variants []models.PromptVariant
+	if err := s.db.Order("name").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Name   string
+		Rating int
+		Count  int64
+	}
+	if err := s.dbRouter.Reader().Table("conversations").
+		Select("prompt_variant AS name, satisfaction_rating AS rating, COUNT(*) AS count").
+		Where("prompt_variant IS NOT NULL AND satisfaction_rating BETWEEN 1 AND 5").
+		Group("prompt_variant, satisfaction_rating").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]*[5]int64)
+	for _, row := range rows {
+		if counts[row.Name] == nil {
+			counts[row.Name] = &[5]int64{}
+		}
+		counts[row.Name][row.Rating-1] = row.Count
+	}
+
+	analysis := &models.PromptVariantAnalysis{
+		Variants:   []models.PromptVariantStats{},
+		PValue:     1,
+		AnalysedAt: time.Now(),
+	}
+
+	var table [][5]int64
+	for _, variant := range variants {
+		stats := models.PromptVariantStats{
+			Name:    variant.Name,
+			Ratings: make(map[string]int64, 5),
+		}
+
+		var ratingSum, wins int64
+		ratings := counts[variant.Name]
+		for i := 0; i < 5; i++ {
+			var count int64
+			if ratings != nil {
+				count = ratings[i]
+			}
+			stats.Ratings[strconv.Itoa(i+1)] = count
+			stats.Samples += int(count)
+			ratingSum += int64(i+1) * count
+			if i+1 >= 4 {
+				wins += count
+			}
+		}
+
+		if stats.Samples > 0 {
+			stats.MeanRating = float64(ratingSum) / float64(stats.Samples)
+			stats.WinRate = float64(wins) / float64(stats.Samples)
+			table = append(table, *ratings)
+		}
+
+		if err := s.db.Model(&models.PromptVariant{}).Where("id = ?", variant.ID).
+			Updates(map[string]interface{}{
+				"win_rate":     stats.WinRate,
+				"sample_count": stats.Samples,
+			}).Error; err != nil {
+			return nil, err
+		}
+
+		analysis.Variants = append(analysis.Variants, stats)
+	}
+
+	analysis.ChiSquared, analysis.DegreesOfFreedom = chiSquaredIndependence(table)
+	if analysis.DegreesOfFreedom > 0 {
+		analysis.PValue = chiSquaredPValue(analysis.ChiSquared, analysis.DegreesOfFreedom)
+		analysis.Significant = analysis.PValue < promptVariantSignificance
+	}
+
+	return analysis, nil
+}
+
+// PromoteBestVariant analyses the variants and makes the one with the highest
+// mean rating, among those with at least the minimum number of rated
+// generations, the only active variant. It returns the promoted variant's
+// name, or an empty name when none qualifies or it already was the only
+// active variant.
+func (s *PromptVariantService) PromoteBestVariant(ctx context.Context) (string, error) {
+	analysis, err := s.AnalyseVariants(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var best *models.PromptVariantStats
+	for i, stats := range analysis.Variants {
+		if stats.Samples < s.minSamples {
+			continue
+		}
+		if best == nil || stats.MeanRating > best.MeanRating {
+			best = &analysis.Variants[i]
+		}
+	}
+	if best == nil {
+		return "", nil
+	}
+
+	changed := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		deactivated := tx.Model(&models.PromptVariant{}).
+			Where("name <> ? AND is_active = ?", best.Name, true).
+			Update("is_active", false)
+		if deactivated.Error != nil {
+			return deactivated.Error
+		}
+
+		// Selection skips variants without weight, so one promoted without
+		// any is given full weight
+		promoted := tx.Model(&models.PromptVariant{}).
+			Where("name = ? AND (is_active = ? OR weight = 0)", best.Name, false).
+			Updates(map[string]interface{}{
+				"is_active": true,
+				"weight":    gorm.Expr("CASE WHEN weight > 0 THEN weight ELSE 100 END"),
+			})
+		if promoted.Error != nil {
+			return promoted.Error
+		}
+
+		changed = deactivated.RowsAffected > 0 || promoted.RowsAffected > 0
+		return nil
+	})
+	if err != nil || !changed {
+		return "", err
+	}
+
+	return best.Name, nil
+}
+
+// StartPromotionWorker promotes the best rated prompt variant once an hour
+// until the context is cancelled.
+func (s *PromptVariantService) StartPromotionWorker(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if promoted, err := s.PromoteBestVariant(ctx); err != nil {
+			log.Error("Prompt variant promotion failed", "error", err)
+		} else if promoted != "" {
+			log.Info("Promoted prompt variant", "variant", promoted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// chiSquaredIndependence returns Pearson's chi-squared statistic and degrees
+// of freedom for a contingency table of variants by rating. Ratings no
+// variant received are left out, as their expected counts would be zero.
+func chiSquaredIndependence(table [][5]int64) (float64, int) {
+	var colTotals [5]int64
+	rowTotals := make([]int64, len(table))
+	var total int64
+	for i, row := range table {
+		for j, count := range row {
+			rowTotals[i] += count
+			colTotals[j] += count
+			total += count
+		}
+	}
+
+	cols := 0
+	for _, colTotal := range colTotals {
+		if colTotal > 0 {
+			cols++
+		}
+	}
+	if len(table) < 2 || cols < 2 {
+		return 0, 0
+	}
+
+	statistic := 0.0
+	for i, row := range table {
+		for j, count := range row {
+			if colTotals[j] == 0 {
+				continue
+			}
+			expected := float64(rowTotals[i]) * float64(colTotals[j]) / float64(total)
+			diff := float64(count) - expected
+			statistic += diff * diff / expected
+		}
+	}
+
+	return statistic, (len(table) - 1) * (cols - 1)
+}
+
+// chiSquaredPValue returns the probability of a chi-squared statistic at
+// least as large as x with df degrees of freedom, the regularized upper
+// incomplete gamma function Q(df/2, x/2)
+func chiSquaredPValue(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+
+	a, x := float64(df)/2, x/2
+	lgamma, _ := math.Lgamma(a)
+	scale := math.Exp(-x + a*math.Log(x) - lgamma)
+
+	const epsilon = 1e-14
+	const tiny = 1e-300
+
+	if x < a+1 {
+		// Series for the lower function P(a, x); Q = 1 - P
+		term := 1 / a
+		sum := term
+		for n := 1; n < 500; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*scale)
+	}
+
+	// Continued fraction for Q(a, x), by the modified Lentz method
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 500; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return math.Min(1, h*scale)
+}
@@ -0,0 +1,219 @@
+// internal/services/replay.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+const (
+	// Deadline for the generation of one replayed message
+	replayStepTimeout = 5 * time.Minute
+
+	// Replays that have made no progress for this long lost the server
+	// process running them
+	replayStaleAfter = 2 * replayStepTimeout
+)
+
+// ReplayConversations regenerates a project from scratch by replaying its
+// user messages, up to and including upToConvID when given, against a fresh
+// copy of the project. The first message is sent as a generation and each
+// following one as a refinement. Replay runs in the background and is tracked
+// on the returned job. Every step counts against the user's daily usage; the
+// job fails once the limit is reached.
+func (s *ProjectService) ReplayConversations(ctx context.Context, userID, projectID uuid.UUID, upToConvID *uuid.UUID) (*models.ReplayJob, error) {
+	if s.aiService == nil {
+		return nil, errors.New("AI service not available")
+	}
+
+	conversations, err := s.GetConversations(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	found := upToConvID == nil
+	for _, conversation := range conversations {
+		messages = append(messages, conversation.UserMessage)
+		if upToConvID != nil && conversation.ID == *upToConvID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, errors.New("conversation not found")
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("no conversations to replay")
+	}
+
+	replay, err := s.DuplicateProject(ctx, userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start from an empty project so the result only reflects the replayed turns
+	if err := s.db.Model(replay).Updates(map[string]interface{}{
+		"name":          fmt.Sprintf("%s (Replay)", strings.TrimSuffix(replay.Name, " (Copy)")),
+		"html_code":     nil,
+		"html_code_key": nil,
+		"css_code":      nil,
+		"js_code":       nil,
+	}).Error; err != nil {
+		return nil, err
+	}
+	// The duplicate's copy of the HTML is no longer referenced
+	if replay.HTMLCodeKey != nil {
+		s.deleteProjectHTML([]string{*replay.HTMLCodeKey})
+	}
+
+	job := models.ReplayJob{
+		UserID:          userID,
+		SourceProjectID: projectID,
+		ProjectID:       replay.ID,
+		Status:          "running",
+		TotalSteps:      len(messages),
+	}
+
+	if err := s.db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	// The replay outlives the request but stays part of its trace
+	go s.runReplay(context.WithoutCancel(ctx), job.ID, userID, replay.ID, messages)
+
+	return &job, nil
+}
+
+func (s *ProjectService) GetReplayJob(ctx context.Context, userID, jobID uuid.UUID) (*models.ReplayJob, error) {
+	var job models.ReplayJob
+	if err := s.db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// failInterruptedReplays marks replays that have made no progress for longer
+// than any step can take as failed. Replays run in the server process, so one
+// still running after a restart will never finish.
+func (s *ProjectService) failInterruptedReplays() (int64, error) {
+	result := s.db.Model(&models.ReplayJob{}).
+		Where("status = ? AND updated_at < ?", "running", time.Now().Add(-replayStaleAfter)).
+		Updates(map[string]interface{}{
+			"status":       "failed",
+			"error":        "replay interrupted by a server restart",
+			"completed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// StartReplaySweepWorker fails replays interrupted by a restart, once at
+// startup and then periodically for replays another instance was running
+func (s *ProjectService) StartReplaySweepWorker(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(replayStaleAfter)
+	defer ticker.Stop()
+
+	for {
+		if failed, err := s.failInterruptedReplays(); err != nil {
+			log.Error("Failed to fail interrupted replays", "error", err)
+		} else if failed > 0 {
+			log.Warn("Failed interrupted replays", "count", failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ProjectService) runReplay(ctx context.Context, jobID, userID, projectID uuid.UUID, messages []string) {
+	var currentCode string
+
+	for step, message := range messages {
+		allowed, _, err := s.authService.CheckUsageLimit(userID, "")
+		if err != nil {
+			errMessage := fmt.Sprintf("step %d failed: %v", step+1, err)
+			s.finishReplay(jobID, "failed", &errMessage)
+			return
+		}
+		if !allowed {
+			errMessage := fmt.Sprintf("step %d failed: daily usage limit exceeded", step+1)
+			s.finishReplay(jobID, "failed", &errMessage)
+			return
+		}
+
+		startTime := time.Now()
+
+		stepCtx, cancel := context.WithTimeout(ctx, replayStepTimeout)
+		var result *GenerationResult
+		messageType := "generation"
+		if step == 0 || currentCode == "" {
+			result, err = s.aiService.GenerateWebsite(stepCtx, message, nil, nil)
+		} else {
+			messageType = "refinement"
+			result, err = s.aiService.RefineWebsite(stepCtx, currentCode, message)
+		}
+		cancel()
+
+		if err != nil {
+			errMessage := fmt.Sprintf("step %d failed: %v", step+1, err)
+			s.finishReplay(jobID, "failed", &errMessage)
+			return
+		}
+
+		responseTime := time.Since(startTime).Milliseconds()
+		conversation, err := s.SaveConversation(ctx, projectID, userID, message,
+			result.ConversationalResponse, result.HTMLCode,
+			result.TokensUsed, responseTime, result.ModelUsed, result.PromptVariant, messageType, result.FromCache,
+		)
+		if err != nil {
+			errMessage := fmt.Sprintf("step %d failed: %v", step+1, err)
+			s.finishReplay(jobID, "failed", &errMessage)
+			return
+		}
+
+		if err := s.authService.IncrementUsage(userID); err != nil {
+			errMessage := fmt.Sprintf("step %d failed: %v", step+1, err)
+			s.finishReplay(jobID, "failed", &errMessage)
+			return
+		}
+
+		if result.HTMLCode != "" {
+			currentCode = result.HTMLCode
+			updateReq := &models.UpdateProjectRequest{
+				HTMLCode:       &result.HTMLCode,
+				ChangeSource:   ChangeSourceAIGeneration,
+				ConversationID: &conversation.ID,
+			}
+			if _, err := s.UpdateProject(ctx, userID, projectID, updateReq); err != nil {
+				errMessage := fmt.Sprintf("step %d failed: %v", step+1, err)
+				s.finishReplay(jobID, "failed", &errMessage)
+				return
+			}
+		}
+
+		s.db.Model(&models.ReplayJob{}).Where("id = ?", jobID).Update("completed_steps", step+1)
+	}
+
+	s.finishReplay(jobID, "completed", nil)
+}
+
+func (s *ProjectService) finishReplay(jobID uuid.UUID, status string, errMessage *string) {
+	s.db.Model(&models.ReplayJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"status":       status,
+		"error":        errMessage,
+		"completed_at": time.Now(),
+	})
+}
@@ -0,0 +1,299 @@
+// internal/services/saml.go
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+)
+
+// How long a login request may take before its assertion is rejected
+const samlRequestTTL = 10 * time.Minute
+
+var ErrSAMLNotConfigured = errors.New("SAML SSO is not configured")
+
+type SAMLService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	authService *AuthService
+	sp          *saml.ServiceProvider
+}
+
+// NewSAMLService builds the service provider from configuration and fetches
+// the identity provider metadata. When SAML is not configured the service is
+// returned in a disabled state.
+func NewSAMLService(ctx context.Context, cfg config.SAMLConfig, db *gorm.DB, redisClient *redis.Client, authService *AuthService) (*SAMLService, error) {
+	s := &SAMLService{
+		db:          db,
+		redisClient: redisClient,
+		authService: authService,
+	}
+
+	if cfg.MetadataURL == "" || cfg.EntityID == "" || cfg.AssertionConsumerServiceURL == "" {
+		return s, nil
+	}
+
+	keyPair, err := tls.X509KeyPair([]byte(cfg.Certificate), []byte(cfg.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML key pair: %w", err)
+	}
+
+	privateKey, ok := keyPair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("SAML private key must be RSA")
+	}
+
+	certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML certificate: %w", err)
+	}
+
+	metadataURL, err := url.Parse(cfg.MetadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML metadata URL: %w", err)
+	}
+
+	acsURL, err := url.Parse(cfg.AssertionConsumerServiceURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAML ACS URL: %w", err)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	idpMetadata, err := samlsp.FetchMetadata(fetchCtx, http.DefaultClient, *metadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IdP metadata: %w", err)
+	}
+
+	s.sp = &saml.ServiceProvider{
+		EntityID:          cfg.EntityID,
+		Key:               privateKey,
+		Certificate:       certificate,
+		AcsURL:            *acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: saml.EmailAddressNameIDFormat,
+		// Allow logins started from the identity provider's dashboard
+		AllowIDPInitiated: true,
+	}
+
+	return s, nil
+}
+
+func (s *SAMLService) Enabled() bool {
+	return s.sp != nil
+}
+
+func (s *SAMLService) Metadata() (*saml.EntityDescriptor, error) {
+	if !s.Enabled() {
+		return nil, ErrSAMLNotConfigured
+	}
+
+	return s.sp.Metadata(), nil
+}
+
+// LoginURL creates an authentication request and returns the identity
+// provider URL to redirect the user to. The request ID is remembered so the
+// assertion can be matched to it.
+func (s *SAMLService) LoginURL() (string, error) {
+	if !s.Enabled() {
+		return "", ErrSAMLNotConfigured
+	}
+
+	req, err := s.sp.MakeAuthenticationRequest(s.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", fmt.Errorf("failed to create SAML request: %w", err)
+	}
+
+	if s.redisClient != nil {
+		s.redisClient.Set(samlRequestKey(req.ID), true, samlRequestTTL)
+	}
+
+	redirectURL, err := req.Redirect(req.ID, s.sp)
+	if err != nil {
+		return "", fmt.Errorf("failed to create SAML redirect: %w", err)
+	}
+
+	return redirectURL.String(), nil
+}
+
+// ParseResponse validates the identity provider's POSTed response and returns
+// its assertion
+func (s *SAMLService) ParseResponse(req *http.Request) (*saml.Assertion, error) {
+	if !s.Enabled() {
+		return nil, ErrSAMLNotConfigured
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return nil, errors.New("invalid SAML response")
+	}
+
+	var possibleRequestIDs []string
+	if requestID := req.PostForm.Get("RelayState"); requestID != "" && s.redisClient != nil {
+		if s.redisClient.Exists(samlRequestKey(requestID)) {
+			possibleRequestIDs = append(possibleRequestIDs, requestID)
+			s.redisClient.Del(samlRequestKey(requestID))
+		}
+	}
+
+	assertion, err := s.sp.ParseResponse(req, possibleRequestIDs)
+	if err != nil {
+		return nil, errors.New("invalid SAML assertion")
+	}
+
+	return assertion, nil
+}
+
+// HandleAssertion signs in the user identified by the assertion's NameID,
+// creating the account on first login, and issues the standard token pair
+func (s *SAMLService) HandleAssertion(assertion *saml.Assertion) (*models.AuthResponse, error) {
+	if assertion.Subject == nil || assertion.Subject.NameID == nil {
+		return nil, errors.New("SAML assertion missing NameID")
+	}
+
+	nameID := strings.TrimSpace(assertion.Subject.NameID.Value)
+	email := strings.ToLower(nameID)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return nil, errors.New("SAML NameID is not an email address")
+	}
+
+	var user models.User
+	err := s.db.Where("saml_name_id = ?", nameID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.db.Where("email = ?", email).First(&user).Error
+	}
+
+	switch {
+	case err == nil:
+		// Link existing accounts to their SSO identity
+		if user.SAMLNameID == nil {
+			s.db.Model(&user).Update("saml_name_id", nameID)
+			user.SAMLNameID = &nameID
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		created, err := s.createSAMLUser(email, nameID, assertion)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	default:
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("account is disabled")
+	}
+
+	// The identity provider only proves the first factor; accounts with
+	// two-factor authentication finish logging in at /auth/mfa/verify
+	if s.authService.HasMFAEnabled(user.ID) {
+		return s.authService.mfaChallenge(user.ID)
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	s.db.Model(&user).Update("last_login_at", now)
+
+	accessToken, refreshToken, err := s.authService.generateTokenPair(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Message: "Login successful",
+		User: &models.UserInfo{
+			ID:               user.ID,
+			Email:            user.Email,
+			Name:             user.Name,
+			AvatarURL:        user.AvatarURL,
+			SubscriptionPlan: user.SubscriptionPlan,
+			EmailVerified:    user.EmailVerified,
+			CreatedAt:        user.CreatedAt,
+			LastLoginAt:      user.LastLoginAt,
+		},
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    "24h",
+	}, nil
+}
+
+func (s *SAMLService) createSAMLUser(email, nameID string, assertion *saml.Assertion) (*models.User, error) {
+	// SSO users never log in with a password, so store an unusable hash
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate credentials: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(randomBytes)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := models.User{
+		Email:         email,
+		PasswordHash:  string(hashedPassword),
+		SAMLNameID:    &nameID,
+		EmailVerified: true,
+		IsActive:      true,
+	}
+
+	if name := samlDisplayName(assertion); name != "" {
+		user.Name = &name
+	}
+
+	// Organisations with an SSO configuration can grant a plan to their members
+	var orgConfig models.SAMLConfig
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if err := s.db.Where("domain = ? AND is_active = ?", domain, true).First(&orgConfig).Error; err == nil && orgConfig.DefaultPlan != "" {
+		user.SubscriptionPlan = orgConfig.DefaultPlan
+	}
+
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// samlDisplayName reads a display name from common IdP attribute names
+func samlDisplayName(assertion *saml.Assertion) string {
+	names := map[string]bool{
+		"displayName": true,
+		"name":        true,
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name": true,
+		"http://schemas.microsoft.com/identity/claims/displayname":   true,
+		"urn:oid:2.16.840.1.113730.3.1.241":                          true,
+	}
+
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			if (names[attribute.Name] || names[attribute.FriendlyName]) && len(attribute.Values) > 0 {
+				return strings.TrimSpace(attribute.Values[0].Value)
+			}
+		}
+	}
+
+	return ""
+}
+
+func samlRequestKey(requestID string) string {
+	return fmt.Sprintf("saml_request:%s", requestID)
+}
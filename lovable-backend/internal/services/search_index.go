@@ -0,0 +1,242 @@
+// internal/services/search_index.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+	"lovable-backend/internal/storage"
+	"lovable-backend/pkg/logger"
+)
+
+const (
+	searchIndexStream    = "project:index:queue"
+	searchIndexGroup     = "search-indexer"
+	searchIndexBatchSize = 50
+	// How often entries left pending are looked for, and how long they must
+	// have been idle to be reclaimed
+	searchIndexReclaimInterval = 30 * time.Second
+	searchIndexReclaimIdle     = time.Minute
+	// Entries that have failed this many deliveries are moved to the dead
+	// letter list instead of being retried forever
+	searchIndexMaxDeliveries = 5
+	// Redis list of projects whose reindexing kept failing
+	searchIndexDeadLetterKey = "dead_letter:search_index"
+)
+
+const updateSearchVectorSQL = "UPDATE projects SET search_vector = " + database.SearchVectorSQL + " WHERE id = ANY(?)"
+
+const updateExternalSearchVectorSQL = "UPDATE projects SET search_vector = " + database.ExternalHTMLSearchVectorSQL + " WHERE id = ?"
+
+// SearchIndexWorker keeps projects.search_vector up to date from the index
+// queue so write paths don't pay for full-text indexing.
+type SearchIndexWorker struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	storage     storage.StorageBackend
+	logger      *logger.Logger
+}
+
+func NewSearchIndexWorker(db *gorm.DB, redisClient *redis.Client, storageBackend storage.StorageBackend, logger *logger.Logger) *SearchIndexWorker {
+	return &SearchIndexWorker{
+		db:          db,
+		redisClient: redisClient,
+		storage:     storageBackend,
+		logger:      logger,
+	}
+}
+
+// enqueueSearchIndex queues a project for search reindexing. Without Redis the
+// search vector is updated inline.
+func enqueueSearchIndex(db *gorm.DB, redisClient *redis.Client, backend storage.StorageBackend, projectID uuid.UUID) {
+	if redisClient != nil {
+		if err := redisClient.XAdd(searchIndexStream, map[string]interface{}{"project_id": projectID.String()}); err == nil {
+			return
+		}
+	}
+
+	updateSearchVectors(db, backend, []string{projectID.String()})
+}
+
+// updateSearchVectors rebuilds the projects' search vectors. HTML kept in
+// external storage is not in html_code, so it is loaded and indexed per
+// project.
+func updateSearchVectors(db *gorm.DB, backend storage.StorageBackend, ids []string) error {
+	if err := db.Exec(updateSearchVectorSQL, pq.Array(ids)).Error; err != nil {
+		return err
+	}
+	if backend == nil {
+		return nil
+	}
+
+	var external []models.Project
+	if err := db.Select("id", "html_code_key").
+		Where("id = ANY(?) AND html_code IS NULL AND html_code_key IS NOT NULL", pq.Array(ids)).
+		Find(&external).Error; err != nil {
+		return err
+	}
+
+	for _, project := range external {
+		html, err := backend.Get(*project.HTMLCodeKey)
+		if err != nil {
+			return fmt.Errorf("failed to load project HTML: %w", err)
+		}
+		if err := db.Exec(updateExternalSearchVectorSQL, html, project.ID).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *SearchIndexWorker) StartWorker(ctx context.Context) {
+	if w.redisClient == nil {
+		return
+	}
+
+	if err := w.redisClient.XGroupCreate(searchIndexStream, searchIndexGroup); err != nil {
+		w.logger.Error("Failed to create search index consumer group", "error", err)
+		return
+	}
+
+	consumer, _ := os.Hostname()
+	if consumer == "" {
+		consumer = "search-indexer"
+	}
+
+	// Entries left pending by a failed update or a consumer that died are
+	// reclaimed once they have been idle for a while
+	reclaim := time.NewTicker(searchIndexReclaimInterval)
+	defer reclaim.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reclaim.C:
+			messages, err := w.redisClient.XAutoClaim(ctx, searchIndexStream, searchIndexGroup, consumer, searchIndexReclaimIdle, searchIndexBatchSize)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				w.logger.Error("Failed to reclaim pending search index entries", "error", err)
+			} else if len(messages) > 0 {
+				w.indexBatch(ctx, messages)
+			}
+		default:
+		}
+
+		messages, err := w.redisClient.XReadGroup(ctx, searchIndexStream, searchIndexGroup, consumer, searchIndexBatchSize, 5*time.Second)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.logger.Error("Failed to read search index queue", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		w.indexBatch(ctx, messages)
+	}
+}
+
+// indexBatch reindexes the projects of the queue entries and acknowledges the
+// entries of every project that was indexed. When the batch fails each project
+// is retried on its own; the entries of those that still fail stay pending to
+// be reclaimed later, until they reach searchIndexMaxDeliveries.
+func (w *SearchIndexWorker) indexBatch(ctx context.Context, messages []goredis.XMessage) {
+	// A project may be queued several times in one batch; index it once
+	entries := make(map[string][]string, len(messages))
+	ids := make([]string, 0, len(messages))
+	var done []string
+	for _, message := range messages {
+		projectID, ok := message.Values["project_id"].(string)
+		if !ok {
+			done = append(done, message.ID)
+			continue
+		}
+		if _, err := uuid.Parse(projectID); err != nil {
+			done = append(done, message.ID)
+			continue
+		}
+		if _, queued := entries[projectID]; !queued {
+			ids = append(ids, projectID)
+		}
+		entries[projectID] = append(entries[projectID], message.ID)
+	}
+
+	if len(ids) > 0 {
+		if err := updateSearchVectors(w.db, w.storage, ids); err == nil {
+			for _, id := range ids {
+				done = append(done, entries[id]...)
+			}
+		} else {
+			w.logger.Warn("Failed to update search vectors, retrying projects one at a time", "count", len(ids), "error", err)
+
+			var failed []string
+			for _, id := range ids {
+				if err := updateSearchVectors(w.db, w.storage, []string{id}); err != nil {
+					w.logger.Error("Failed to update search vector", "projectId", id, "error", err)
+					failed = append(failed, entries[id]...)
+					continue
+				}
+				done = append(done, entries[id]...)
+			}
+			done = append(done, w.deadLetter(ctx, failed, messages)...)
+		}
+	}
+
+	if len(done) == 0 {
+		return
+	}
+	if err := w.redisClient.XAckDel(searchIndexStream, searchIndexGroup, done...); err != nil {
+		w.logger.Error("Failed to acknowledge search index entries", "error", err)
+	}
+}
+
+// deadLetter moves the failed entries that have used up their deliveries to
+// the dead letter list and returns their IDs, to be acknowledged
+func (w *SearchIndexWorker) deadLetter(ctx context.Context, failed []string, messages []goredis.XMessage) []string {
+	if len(failed) == 0 {
+		return nil
+	}
+
+	counts, err := w.redisClient.XDeliveryCounts(ctx, searchIndexStream, searchIndexGroup, failed...)
+	if err != nil {
+		w.logger.Error("Failed to read search index delivery counts", "error", err)
+		return nil
+	}
+
+	projects := make(map[string]string, len(messages))
+	for _, message := range messages {
+		projects[message.ID], _ = message.Values["project_id"].(string)
+	}
+
+	var dead []string
+	for _, entryID := range failed {
+		if counts[entryID] < searchIndexMaxDeliveries {
+			continue
+		}
+		if err := w.redisClient.RPush(searchIndexDeadLetterKey, projects[entryID]); err != nil {
+			w.logger.Error("Failed to dead-letter search index entry", "entryId", entryID, "error", err)
+			continue
+		}
+		w.logger.Warn("Dead-lettered search index entry", "entryId", entryID, "projectId", projects[entryID], "deliveries", counts[entryID])
+		dead = append(dead, entryID)
+	}
+
+	return dead
+}
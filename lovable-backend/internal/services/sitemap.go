@@ -0,0 +1,117 @@
+// internal/services/sitemap.go
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"lovable-backend/internal/models"
+)
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Sitemap 0.9 only accepts always, hourly, daily, weekly, monthly, yearly and
+// never; generated sites are edited often enough for weekly to be a fair guess
+const sitemapChangeFreq = "weekly"
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+}
+
+// GenerateSitemap builds a sitemap.xml listing the site root and every
+// internal page the project's HTML links to. Locations are prefixed with
+// baseURL, so an empty baseURL yields paths relative to the host the site is
+// deployed on.
+func (s *ExportService) GenerateSitemap(project *models.Project, baseURL string) ([]byte, error) {
+	paths := []string{"/"}
+	if project.HTMLCode != nil {
+		links, err := internalLinkPaths(*project.HTMLCode)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if link != "/" {
+				paths = append(paths, link)
+			}
+		}
+	}
+
+	baseURL = strings.TrimRight(baseURL, "/")
+	lastMod := project.UpdatedAt.UTC().Format(time.RFC3339)
+
+	urlSet := sitemapURLSet{Xmlns: sitemapNamespace}
+	for _, p := range paths {
+		urlSet.URLs = append(urlSet.URLs, sitemapURL{
+			Loc:        baseURL + (&url.URL{Path: p}).EscapedPath(),
+			LastMod:    lastMod,
+			ChangeFreq: sitemapChangeFreq,
+		})
+	}
+
+	data, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sitemap: %w", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// internalLinkPaths returns the distinct paths of the document's relative
+// anchor links, in order of first appearance. Links with a scheme or host,
+// and fragment-only links, are skipped.
+func internalLinkPaths(document string) ([]string, error) {
+	tokenizer := html.NewTokenizer(strings.NewReader(document))
+
+	var paths []string
+	seen := make(map[string]bool)
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("%w: %v", ErrMalformedHTML, err)
+			}
+			return paths, nil
+		}
+
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := tokenizer.Token()
+		if token.Data != "a" {
+			continue
+		}
+
+		href, err := url.Parse(strings.TrimSpace(tokenAttr(token, "href")))
+		if err != nil || href.Scheme != "" || href.Host != "" || href.Path == "" {
+			continue
+		}
+
+		p := href.Path
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		p = path.Clean(p)
+
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// internal/services/template_reviews.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"lovable-backend/internal/models"
+)
+
+type TemplateReviewQuery struct {
+	Sort  string // created_at or rating
+	Order string
+	Page  int
+	Limit int
+}
+
+// CreateReview records the user's rating of an approved template and
+// recalculates the template's average in the same transaction. The template
+// row is locked so concurrent reviews don't compute the average from a stale
+// set of reviews.
+func (s *TemplateService) CreateReview(ctx context.Context, userID, templateID uuid.UUID, req *models.CreateTemplateReviewRequest) (*models.TemplateReview, error) {
+	review := models.TemplateReview{
+		TemplateID: templateID,
+		UserID:     userID,
+		Rating:     req.Rating,
+		Comment:    req.Comment,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var template models.Template
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "created_by").
+			Where("id = ? AND publish_status = ?", templateID, TemplateApproved).
+			First(&template).Error; err != nil {
+			return errors.New("template not found")
+		}
+
+		if template.CreatedBy != nil && *template.CreatedBy == userID {
+			return errors.New("cannot review own template")
+		}
+
+		var existing int64
+		if err := tx.Model(&models.TemplateReview{}).Where("template_id = ? AND user_id = ?", templateID, userID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("template already reviewed")
+		}
+
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			UPDATE templates SET
+				rating = (SELECT COALESCE(AVG(rating), 0) FROM template_reviews WHERE template_id = ?),
+				review_count = (SELECT COUNT(*) FROM template_reviews WHERE template_id = ?)
+			WHERE id = ?`,
+			templateID, templateID, templateID,
+		).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// GetReviews returns a page of the reviews of a template the user can see
+func (s *TemplateService) GetReviews(ctx context.Context, userID, templateID uuid.UUID, query *TemplateReviewQuery) (*models.TemplateReviewsResponse, error) {
+	reader := s.dbRouter.Reader()
+
+	var template models.Template
+	if err := reader.Select("id").
+		Where("id = ? AND (publish_status = ? OR created_by = ?)", templateID, TemplateApproved, userID).
+		First(&template).Error; err != nil {
+		return nil, errors.New("template not found")
+	}
+
+	db := reader.Model(&models.TemplateReview{}).Where("template_id = ?", templateID)
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	reviews := []models.TemplateReview{}
+	if err := db.Order(fmt.Sprintf("%s %s, id %s", query.Sort, query.Order, query.Order)).
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(query.Limit)))
+
+	return &models.TemplateReviewsResponse{
+		Reviews: reviews,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: query.Page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: query.Page < totalPages,
+			HasPrevPage: query.Page > 1,
+		},
+	}, nil
+}
+
+// ratingDistribution counts a template's reviews per star rating, including
+// ratings nobody gave
+func (s *TemplateService) ratingDistribution(templateID uuid.UUID) (map[string]int64, error) {
+	var counts []struct {
+		Rating int
+		Count  int64
+	}
+	if err := s.dbRouter.Reader().Model(&models.TemplateReview{}).
+		Select("rating, COUNT(*) AS count").
+		Where("template_id = ?", templateID).
+		Group("rating").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	distribution := make(map[string]int64, 5)
+	for rating := 1; rating <= 5; rating++ {
+		distribution[strconv.Itoa(rating)] = 0
+	}
+	for _, count := range counts {
+		distribution[strconv.Itoa(count.Rating)] = count.Count
+	}
+
+	return distribution, nil
+}
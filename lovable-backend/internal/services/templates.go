@@ -0,0 +1,274 @@
+// internal/services/templates.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/database"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/storage"
+)
+
+// Template publish statuses
+const (
+	TemplateDraft         = "draft"
+	TemplatePendingReview = "pending_review"
+	TemplateApproved      = "approved"
+)
+
+// TemplateCategories are the categories templates are filed under, matching
+// the generation prompts of AIService
+var TemplateCategories = []string{
+	"portfolio", "landing", "blog", "ecommerce", "restaurant",
+	"business", "personal", "dashboard", "documentation",
+}
+
+type TemplateQuery struct {
+	Category  string
+	MinRating float64
+	Mine      bool // the caller's own templates in any status, instead of the approved ones
+	Page      int
+	Limit     int
+}
+
+// TemplateService stores website templates, both curated ones and those users
+// submit from their projects. Submissions are only listed publicly after an
+// admin approves them.
+type TemplateService struct {
+	db        *gorm.DB // primary
+	dbRouter  *database.DBRouter
+	storage   storage.StorageBackend
+	aiService *AIService
+}
+
+func NewTemplateService(dbRouter *database.DBRouter, storageBackend storage.StorageBackend, aiService *AIService) *TemplateService {
+	return &TemplateService{
+		db:        dbRouter.Writer(),
+		dbRouter:  dbRouter,
+		storage:   storageBackend,
+		aiService: aiService,
+	}
+}
+
+// GetTemplates returns a page of templates, most used first. Listings leave
+// out the code.
+func (s *TemplateService) GetTemplates(ctx context.Context, userID uuid.UUID, query *TemplateQuery) (*models.TemplatesResponse, error) {
+	db := s.dbRouter.Reader().Model(&models.Template{})
+	if query.Mine {
+		db = db.Where("created_by = ?", userID)
+	} else {
+		db = db.Where("publish_status = ?", TemplateApproved)
+	}
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+	if query.MinRating > 0 {
+		db = db.Where("rating >= ?", query.MinRating)
+	}
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	templates := []models.Template{}
+	if err := db.Omit("html_code", "css_code", "js_code").
+		Order("usage_count DESC, created_at DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range templates {
+		s.setVariables(&templates[i])
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(query.Limit)))
+
+	return &models.TemplatesResponse{
+		Templates:  templates,
+		Categories: TemplateCategories,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: query.Page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: query.Page < totalPages,
+			HasPrevPage: query.Page > 1,
+		},
+	}, nil
+}
+
+// GetTemplate loads an approved template, or one of the user's own. Fetching
+// an approved template counts as a use of it.
+func (s *TemplateService) GetTemplate(ctx context.Context, userID, templateID uuid.UUID) (*models.Template, error) {
+	var template models.Template
+	if err := s.dbRouter.Reader().
+		Where("id = ? AND (publish_status = ? OR created_by = ?)", templateID, TemplateApproved, userID).
+		First(&template).Error; err != nil {
+		return nil, errors.New("template not found")
+	}
+
+	if template.PublishStatus == TemplateApproved {
+		if err := s.db.Model(&template).UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error; err == nil {
+			template.UsageCount++
+		}
+	}
+
+	distribution, err := s.ratingDistribution(templateID)
+	if err != nil {
+		return nil, err
+	}
+	template.RatingDistribution = distribution
+
+	s.setVariables(&template)
+	return &template, nil
+}
+
+// CreateTemplate copies the current code of one of the user's projects into a
+// new template, as a draft or submitted for review
+func (s *TemplateService) CreateTemplate(ctx context.Context, userID uuid.UUID, req *models.CreateTemplateRequest) (*models.Template, error) {
+	project, err := s.loadSourceProject(userID, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := TemplateDraft
+	if req.SubmitForReview {
+		status = TemplatePendingReview
+	}
+
+	template := models.Template{
+		Name:            req.Name,
+		Description:     req.Description,
+		Category:        req.Category,
+		HTMLCode:        *project.HTMLCode,
+		CSSCode:         project.CSSCode,
+		JSCode:          project.JSCode,
+		Tags:            pq.StringArray(req.Tags),
+		CreatedBy:       &userID,
+		PublishStatus:   status,
+		SourceProjectID: &project.ID,
+	}
+
+	if err := s.db.Create(&template).Error; err != nil {
+		return nil, err
+	}
+
+	s.setVariables(&template)
+	return &template, nil
+}
+
+// UpdateTemplate edits one of the user's templates. Changing an approved
+// template sends it back for review.
+func (s *TemplateService) UpdateTemplate(ctx context.Context, userID, templateID uuid.UUID, req *models.UpdateTemplateRequest) (*models.Template, error) {
+	var template models.Template
+	if err := s.db.Where("id = ? AND created_by = ?", templateID, userID).First(&template).Error; err != nil {
+		return nil, errors.New("template not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.ProjectID != nil {
+		project, err := s.loadSourceProject(userID, *req.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		updates["html_code"] = *project.HTMLCode
+		updates["css_code"] = project.CSSCode
+		updates["js_code"] = project.JSCode
+		updates["source_project_id"] = project.ID
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Category != nil {
+		updates["category"] = *req.Category
+	}
+	if req.Tags != nil {
+		updates["tags"] = pq.StringArray(req.Tags)
+	}
+
+	if (len(updates) > 0 && template.PublishStatus == TemplateApproved) ||
+		(req.SubmitForReview && template.PublishStatus == TemplateDraft) {
+		updates["publish_status"] = TemplatePendingReview
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&template).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// Reload template
+	if err := s.db.First(&template, "id = ?", templateID).Error; err != nil {
+		return nil, err
+	}
+
+	s.setVariables(&template)
+	return &template, nil
+}
+
+func (s *TemplateService) DeleteTemplate(ctx context.Context, userID, templateID uuid.UUID) error {
+	result := s.db.Where("id = ? AND created_by = ?", templateID, userID).Delete(&models.Template{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("template not found")
+	}
+
+	return nil
+}
+
+// ApproveTemplate publishes a template submitted for review
+func (s *TemplateService) ApproveTemplate(ctx context.Context, templateID uuid.UUID) error {
+	result := s.db.Model(&models.Template{}).
+		Where("id = ? AND publish_status = ?", templateID, TemplatePendingReview).
+		Update("publish_status", TemplateApproved)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var count int64
+		s.db.Model(&models.Template{}).Where("id = ?", templateID).Count(&count)
+		if count == 0 {
+			return errors.New("template not found")
+		}
+		return errors.New("template is not pending review")
+	}
+
+	return nil
+}
+
+// loadSourceProject loads a project of the user with its HTML, for use as a
+// template's code
+func (s *TemplateService) loadSourceProject(userID, projectID uuid.UUID) (*models.Project, error) {
+	var project models.Project
+	if err := s.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		return nil, errors.New("project not found")
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, &project); err != nil {
+		return nil, err
+	}
+
+	if project.HTMLCode == nil || *project.HTMLCode == "" {
+		return nil, errors.New("project has no code")
+	}
+
+	return &project, nil
+}
+
+func (s *TemplateService) setVariables(template *models.Template) {
+	template.RequiredVars, template.OptionalVars = s.aiService.TemplateVariables(template.Category)
+}
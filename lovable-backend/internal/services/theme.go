@@ -0,0 +1,65 @@
+// internal/services/theme.go
+package services
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Custom property overrides applied to previews for each color scheme
+var themeOverrides = map[string]string{
+	"dark":  ":root{color-scheme:dark;--bg:#0f172a;--text:#f1f5f9;}",
+	"light": ":root{color-scheme:light;--bg:#ffffff;--text:#1a202c;}",
+}
+
+// colorSchemeDetectionPage is served for mode=auto. It reads the viewer's OS
+// preference, tells an embedding frame about it, remembers it in the
+// preview_mode cookie and reloads the preview with the detected mode.
+const colorSchemeDetectionPage = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Loading preview...</title>
+	<script>
+		(function () {
+			var scheme = window.matchMedia && window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light';
+			if (window.parent && window.parent !== window) {
+				window.parent.postMessage({ type: 'colorScheme', colorScheme: scheme }, '*');
+			}
+			document.cookie = 'preview_mode=' + scheme + '; path=/; max-age=31536000; SameSite=Lax';
+			var url = new URL(window.location.href);
+			url.searchParams.set('mode', scheme);
+			window.location.replace(url.toString());
+		})();
+	</script>
+</head>
+<body></body>
+</html>`
+
+// IsValidPreviewMode reports whether mode is a supported preview color scheme
+func IsValidPreviewMode(mode string) bool {
+	return mode == "dark" || mode == "light" || mode == "auto"
+}
+
+// ColorSchemeDetectionPage returns the wrapper page used for mode=auto
+func (s *ExportService) ColorSchemeDetectionPage() string {
+	return colorSchemeDetectionPage
+}
+
+// InjectThemeOverride adds a stylesheet overriding the --bg and --text custom
+// properties for the given mode. It is inserted before </head>, or prepended
+// when the document has no head. Unknown modes leave the HTML unchanged.
+func (s *ExportService) InjectThemeOverride(html, mode string) string {
+	css, ok := themeOverrides[mode]
+	if !ok {
+		return html
+	}
+
+	link := `<link rel="stylesheet" href="data:text/css,` + url.PathEscape(css) + `">`
+
+	if index := strings.Index(strings.ToLower(html), "</head>"); index >= 0 {
+		return html[:index] + link + html[index:]
+	}
+
+	return link + html
+}
@@ -0,0 +1,251 @@
+// internal/services/thumbnail.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+	"lovable-backend/internal/storage"
+	"lovable-backend/pkg/logger"
+)
+
+const (
+	thumbnailStream = "project:thumbnail:queue"
+	thumbnailGroup  = "thumbnailer"
+)
+
+// Minimum time between two thumbnail refreshes of the same project, so rapid
+// refinement sessions don't regenerate on every turn
+const thumbnailRefreshCooldown = 10 * time.Minute
+
+// How often entries left pending are looked for, and how long they must have
+// been idle to be reclaimed
+const (
+	thumbnailReclaimInterval = 30 * time.Second
+	thumbnailReclaimIdle     = time.Minute
+)
+
+// errThumbnailCooldown is returned for a changed project whose thumbnail was
+// refreshed within the cooldown; its queue entry is kept to retry later
+var errThumbnailCooldown = errors.New("thumbnail refreshed recently")
+
+// ThumbnailWorker regenerates project thumbnails from the thumbnail queue
+// whenever a project's HTML has changed meaningfully.
+type ThumbnailWorker struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	storage     storage.StorageBackend
+	palette     *ExportService
+	logger      *logger.Logger
+}
+
+func NewThumbnailWorker(db *gorm.DB, redisClient *redis.Client, storageBackend storage.StorageBackend, exportService *ExportService, logger *logger.Logger) *ThumbnailWorker {
+	return &ThumbnailWorker{
+		db:          db,
+		redisClient: redisClient,
+		storage:     storageBackend,
+		palette:     exportService,
+		logger:      logger,
+	}
+}
+
+// thumbnailCodeHash hashes HTML with whitespace collapsed so reformatting alone
+// doesn't count as a change.
+func thumbnailCodeHash(htmlCode string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(htmlCode), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// enqueueThumbnailRefresh queues a project for thumbnail regeneration
+func enqueueThumbnailRefresh(redisClient *redis.Client, projectID uuid.UUID) {
+	if redisClient == nil {
+		return
+	}
+
+	redisClient.XAdd(thumbnailStream, map[string]interface{}{"project_id": projectID.String()})
+}
+
+// ShouldRefresh reports whether a thumbnail rendered from storedHash is out of
+// date for newHash and the last refresh is outside the cooldown window.
+func (w *ThumbnailWorker) ShouldRefresh(newHash, storedHash string, refreshedAt *time.Time) bool {
+	if newHash == storedHash {
+		return false
+	}
+
+	return refreshedAt == nil || refreshedAt.Before(time.Now().Add(-thumbnailRefreshCooldown))
+}
+
+func (w *ThumbnailWorker) StartWorker(ctx context.Context) {
+	if w.redisClient == nil {
+		return
+	}
+
+	if err := w.redisClient.XGroupCreate(thumbnailStream, thumbnailGroup); err != nil {
+		w.logger.Error("Failed to create thumbnail consumer group", "error", err)
+		return
+	}
+
+	consumer, _ := os.Hostname()
+	if consumer == "" {
+		consumer = "thumbnailer"
+	}
+
+	// Entries left pending, by a failed refresh or a change made during the
+	// cooldown, are reclaimed once they have been idle for a while
+	reclaim := time.NewTicker(thumbnailReclaimInterval)
+	defer reclaim.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reclaim.C:
+			messages, err := w.redisClient.XAutoClaim(ctx, thumbnailStream, thumbnailGroup, consumer, thumbnailReclaimIdle, 10)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				w.logger.Error("Failed to reclaim pending thumbnail entries", "error", err)
+			} else {
+				w.process(messages)
+			}
+		default:
+		}
+
+		messages, err := w.redisClient.XReadGroup(ctx, thumbnailStream, thumbnailGroup, consumer, 10, 5*time.Second)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.logger.Error("Failed to read thumbnail queue", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		w.process(messages)
+	}
+}
+
+// process refreshes the thumbnails of the queue entries, acknowledging each
+// one that is done with. The others stay pending to be reclaimed later.
+func (w *ThumbnailWorker) process(messages []goredis.XMessage) {
+	for _, message := range messages {
+		projectID, err := uuid.Parse(fmt.Sprint(message.Values["project_id"]))
+		if err == nil {
+			if err := w.refresh(projectID); err != nil {
+				if err != errThumbnailCooldown {
+					w.logger.Error("Failed to refresh thumbnail", "projectId", projectID, "error", err)
+				}
+				continue
+			}
+		}
+
+		if err := w.redisClient.XAckDel(thumbnailStream, thumbnailGroup, message.ID); err != nil {
+			w.logger.Error("Failed to acknowledge thumbnail entry", "error", err)
+		}
+	}
+}
+
+func (w *ThumbnailWorker) refresh(projectID uuid.UUID) error {
+	var project models.Project
+	if err := w.db.Select("id", "name", "html_code", "html_code_key", "thumbnail_code_hash", "thumbnail_refreshed_at").
+		First(&project, "id = ?", projectID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if err := storage.LoadProjectHTML(w.storage, &project); err != nil {
+		return err
+	}
+	if project.HTMLCode == nil {
+		return nil
+	}
+
+	storedHash := ""
+	if project.ThumbnailCodeHash != nil {
+		storedHash = *project.ThumbnailCodeHash
+	}
+
+	newHash := thumbnailCodeHash(*project.HTMLCode)
+	if newHash == storedHash {
+		return nil
+	}
+	if !w.ShouldRefresh(newHash, storedHash, project.ThumbnailRefreshedAt) {
+		return errThumbnailCooldown
+	}
+
+	thumbnailURL := w.renderThumbnail(project.Name, *project.HTMLCode)
+
+	// Write URL, hash and timestamp together, guarding against another worker
+	// having refreshed the project in the meantime
+	cutoff := time.Now().Add(-thumbnailRefreshCooldown)
+	result := w.db.Model(&models.Project{}).
+		Where("id = ? AND (thumbnail_refreshed_at IS NULL OR thumbnail_refreshed_at < ?)", projectID, cutoff).
+		Updates(map[string]interface{}{
+			"thumbnail_url":          thumbnailURL,
+			"thumbnail_code_hash":    newHash,
+			"thumbnail_refreshed_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Another worker got there first, possibly with older HTML
+		return errThumbnailCooldown
+	}
+
+	return nil
+}
+
+// renderThumbnail draws a lightweight SVG preview from the page's palette and
+// returns it as a data URL.
+func (w *ThumbnailWorker) renderThumbnail(name, htmlCode string) string {
+	background, text := "#f9fafb", "#111827"
+	var accents []string
+	for _, swatch := range w.palette.ExtractColorPalette(htmlCode) {
+		switch {
+		case swatch.IsBackground && background == "#f9fafb":
+			background = swatch.Hex
+		case swatch.IsText && text == "#111827":
+			text = swatch.Hex
+		default:
+			accents = append(accents, swatch.Hex)
+		}
+	}
+	if len(accents) == 0 {
+		accents = []string{"#6366f1"}
+	}
+
+	var swatches strings.Builder
+	for i, accent := range accents {
+		if i == 5 {
+			break
+		}
+		fmt.Fprintf(&swatches, `<rect x="%d" y="200" width="40" height="40" rx="8" fill="%s"/>`, 32+i*52, accent)
+	}
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="400" height="300" viewBox="0 0 400 300">`+
+		`<rect width="400" height="300" fill="%s"/>`+
+		`<rect width="400" height="24" fill="%s" opacity="0.15"/>`+
+		`<text x="32" y="110" font-family="sans-serif" font-size="28" font-weight="600" fill="%s">%s</text>`+
+		`%s</svg>`,
+		background, text, text, html.EscapeString(truncateDescription(name, 22)), swatches.String())
+
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+}
@@ -0,0 +1,178 @@
+// internal/services/thumbnail_capture.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+	"lovable-backend/internal/storage"
+	"lovable-backend/internal/worker"
+	"lovable-backend/pkg/logger"
+)
+
+var (
+	ErrThumbnailServiceDisabled    = errors.New("thumbnail service is not enabled")
+	ErrThumbnailServiceUnavailable = errors.New("thumbnail service unavailable")
+)
+
+// JobTypeThumbnail is the background job type for thumbnail captures
+const JobTypeThumbnail = "thumbnail"
+
+// ThumbnailJob is the payload of a thumbnail background job
+type ThumbnailJob struct {
+	ProjectID uuid.UUID `json:"project_id"`
+}
+
+// ThumbnailService captures screenshot thumbnails of projects with an
+// external headless browser service. While the service is disabled, refreshes
+// go to the ThumbnailWorker, which draws a preview from the page's palette.
+type ThumbnailService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	storage     storage.StorageBackend
+	config      config.ThumbnailConfig
+	httpClient  *http.Client
+	logger      *logger.Logger
+}
+
+type thumbnailCaptureRequest struct {
+	ProjectID uuid.UUID `json:"projectId"`
+	URL       string    `json:"url"`
+}
+
+type thumbnailCaptureResponse struct {
+	URL string `json:"url"`
+}
+
+func NewThumbnailService(db *gorm.DB, redisClient *redis.Client, storageBackend storage.StorageBackend, config config.ThumbnailConfig, logger *logger.Logger) *ThumbnailService {
+	return &ThumbnailService{
+		db:          db,
+		redisClient: redisClient,
+		storage:     storageBackend,
+		config:      config,
+		httpClient:  &http.Client{Timeout: time.Duration(config.ServiceTimeout) * time.Second},
+		logger:      logger,
+	}
+}
+
+// Enabled reports whether thumbnails are captured by the external service
+func (s *ThumbnailService) Enabled() bool {
+	return s.config.ServiceEnabled && s.config.ServiceURL != ""
+}
+
+// QueueRefresh schedules a new thumbnail for the project with whichever
+// renderer is in use
+func (s *ThumbnailService) QueueRefresh(projectID uuid.UUID) {
+	if !s.Enabled() {
+		enqueueThumbnailRefresh(s.redisClient, projectID)
+		return
+	}
+
+	if _, err := worker.Enqueue(s.db, JobTypeThumbnail, ThumbnailJob{ProjectID: projectID}); err != nil {
+		s.logger.Error("Failed to queue thumbnail capture", "projectId", projectID, "error", err)
+	}
+}
+
+// GenerateThumbnail sends the page to the thumbnail service as a base64 data
+// URL and returns the URL of the captured image
+func (s *ThumbnailService) GenerateThumbnail(ctx context.Context, projectID uuid.UUID, htmlContent string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrThumbnailServiceDisabled
+	}
+
+	body, err := json.Marshal(thumbnailCaptureRequest{
+		ProjectID: projectID,
+		URL:       "data:text/html;base64," + base64.StdEncoding.EncodeToString([]byte(htmlContent)),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.ServiceURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrThumbnailServiceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status %d", ErrThumbnailServiceUnavailable, resp.StatusCode)
+	}
+
+	var captured thumbnailCaptureResponse
+	if err := json.NewDecoder(resp.Body).Decode(&captured); err != nil {
+		return "", fmt.Errorf("%w: invalid response: %v", ErrThumbnailServiceUnavailable, err)
+	}
+	if captured.URL == "" {
+		return "", fmt.Errorf("%w: no image URL in response", ErrThumbnailServiceUnavailable)
+	}
+
+	return captured.URL, nil
+}
+
+// HandleThumbnailJob captures and stores a project's thumbnail; it is
+// registered with the worker pool for JobTypeThumbnail
+func (s *ThumbnailService) HandleThumbnailJob(ctx context.Context, payload json.RawMessage) error {
+	var job ThumbnailJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+
+	var project models.Project
+	if err := s.db.Select("id", "html_code", "html_code_key").First(&project, "id = ?", job.ProjectID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := storage.LoadProjectHTML(s.storage, &project); err != nil {
+		return err
+	}
+	if project.HTMLCode == nil || *project.HTMLCode == "" {
+		return nil
+	}
+
+	thumbnailURL, err := s.GenerateThumbnail(ctx, project.ID, *project.HTMLCode)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Project{}).Where("id = ?", project.ID).Updates(map[string]interface{}{
+		"thumbnail_url":          thumbnailURL,
+		"thumbnail_code_hash":    thumbnailCodeHash(*project.HTMLCode),
+		"thumbnail_refreshed_at": time.Now(),
+	}).Error
+}
+
+// thumbnailPlaceholderURL draws a two-color gradient derived from the project
+// ID, so projects without a thumbnail still look distinct in listings
+func thumbnailPlaceholderURL(projectID uuid.UUID) string {
+	from := int(projectID[0]) * 360 / 256
+	to := (from + 40 + int(projectID[1])%80) % 360
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="400" height="300" viewBox="0 0 400 300">`+
+		`<defs><linearGradient id="g" x1="0" y1="0" x2="1" y2="1">`+
+		`<stop offset="0" stop-color="hsl(%d,70%%,60%%)"/><stop offset="1" stop-color="hsl(%d,70%%,45%%)"/>`+
+		`</linearGradient></defs><rect width="400" height="300" fill="url(#g)"/></svg>`,
+		from, to)
+
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(svg))
+}
@@ -0,0 +1,108 @@
+// internal/services/verification.go
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// How long an email verification link stays valid
+const verificationTokenTTL = 24 * time.Hour
+
+// hashEmailedToken returns the hex SHA-256 of a verification or password
+// reset token. Only the hash is stored, so a leaked database or backup doesn't
+// hand out working links.
+func hashEmailedToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// SendVerificationEmail issues a new verification token for the user and
+// emails it to them.
+func (s *AuthService) SendVerificationEmail(user *models.User) error {
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	tokenValue := hex.EncodeToString(randomBytes)
+
+	token := models.VerificationToken{
+		UserID:    user.ID,
+		TokenHash: hashEmailedToken(tokenValue),
+		ExpiresAt: time.Now().Add(verificationTokenTTL),
+	}
+	if err := s.db.Create(&token).Error; err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	return s.emailService.SendVerification(user.Email, tokenValue)
+}
+
+// VerifyEmail marks the token's user as verified. Tokens can be used once.
+func (s *AuthService) VerifyEmail(tokenValue string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var token models.VerificationToken
+		if err := tx.Where("token_hash = ?", hashEmailedToken(tokenValue)).First(&token).Error; err != nil {
+			return errors.New("invalid verification token")
+		}
+		if token.UsedAt != nil {
+			return errors.New("verification token already used")
+		}
+		if time.Now().After(token.ExpiresAt) {
+			return errors.New("verification token expired")
+		}
+
+		// Guard against the same token being redeemed concurrently
+		result := tx.Model(&models.VerificationToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("verification token already used")
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", token.UserID).Update("email_verified", true).Error
+	})
+}
+
+func (s *AuthService) ResendVerification(userID uuid.UUID) error {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	if user.IsGuest {
+		return errors.New("guest accounts cannot be verified")
+	}
+	if user.EmailVerified {
+		return errors.New("email already verified")
+	}
+
+	return s.SendVerificationEmail(&user)
+}
+
+// RequiresEmailVerification reports whether AI endpoints are limited to
+// verified accounts.
+func (s *AuthService) RequiresEmailVerification() bool {
+	return s.securityConfig.RequireEmailVerification
+}
+
+func (s *AuthService) IsEmailVerified(userID uuid.UUID) (bool, error) {
+	var user models.User
+	// Read from the primary so a just-verified account isn't blocked by replica lag
+	if err := s.db.Select("email_verified").First(&user, "id = ?", userID).Error; err != nil {
+		return false, err
+	}
+
+	return user.EmailVerified, nil
+}
@@ -0,0 +1,189 @@
+// internal/services/versions.go
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/diff"
+	"lovable-backend/pkg/logger"
+)
+
+// Sources recorded on project version snapshots
+const (
+	ChangeSourceManualEdit   = "manual_edit"
+	ChangeSourceAIGeneration = "ai_generation"
+	ChangeSourceImport       = "import"
+	ChangeSourceRestore      = "restore"
+)
+
+// createProjectVersion stores version as the project's next version number,
+// dropping the oldest versions beyond maxVersions. It must run in the
+// transaction that updated the project row, whose row lock serializes
+// concurrent writers so version numbers stay monotonic.
+func createProjectVersion(tx *gorm.DB, version *models.ProjectVersion, maxVersions int) error {
+	var next int
+	if err := tx.Model(&models.ProjectVersion{}).
+		Where("project_id = ?", version.ProjectID).
+		Select("COALESCE(MAX(version_number), 0) + 1").
+		Scan(&next).Error; err != nil {
+		return err
+	}
+
+	version.VersionNumber = next
+	version.SizeBytes = codeSize(version.HTMLCode) + codeSize(version.CSSCode) + codeSize(version.JSCode)
+	if err := tx.Create(version).Error; err != nil {
+		return err
+	}
+
+	if maxVersions > 0 {
+		return tx.Where("project_id = ? AND version_number <= ?", version.ProjectID, next-maxVersions).
+			Delete(&models.ProjectVersion{}).Error
+	}
+	return nil
+}
+
+func codeSize(code *string) int {
+	if code == nil {
+		return 0
+	}
+	return len(*code)
+}
+
+// PruneVersions deletes version snapshots created before cutoff, and those
+// beyond the newest MaxPerProject of their project. The latest version of
+// each project is always kept.
+func (s *ProjectService) PruneVersions(cutoff time.Time) (int64, error) {
+	latest := "(SELECT MAX(latest.version_number) FROM project_versions latest WHERE latest.project_id = project_versions.project_id)"
+
+	db := s.db.Where("version_number < "+latest).Where("created_at < ?", cutoff)
+	if s.versions.MaxPerProject > 0 {
+		db = db.Or("version_number <= "+latest+" - ?", s.versions.MaxPerProject)
+	}
+
+	result := db.Delete(&models.ProjectVersion{})
+	return result.RowsAffected, result.Error
+}
+
+// StartVersionPruneWorker prunes version snapshots past the retention period
+// once a day until the context is cancelled.
+func (s *ProjectService) StartVersionPruneWorker(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().AddDate(0, 0, -s.versions.RetentionDays)
+		if pruned, err := s.PruneVersions(cutoff); err != nil {
+			log.Error("Version prune failed", "error", err)
+		} else if pruned > 0 {
+			log.Info("Pruned project versions", "count", pruned)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func coalesceCode(updated, current *string) *string {
+	if updated != nil {
+		return updated
+	}
+	return current
+}
+
+// GetProjectVersions lists version metadata for a project, newest first,
+// without the code itself.
+func (s *ProjectService) GetProjectVersions(ctx context.Context, userID, projectID uuid.UUID, page, limit int) (*models.ProjectVersionsResponse, error) {
+	reader := s.dbRouter.Reader()
+
+	if _, err := s.findAccessibleProject(reader.Select("id"), userID, projectID, ProjectRoleEditor, ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	db := reader.Model(&models.ProjectVersion{}).Where("project_id = ?", projectID)
+
+	var totalCount int64
+	if err := db.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var versions []models.ProjectVersionInfo
+	if err := db.Select("id, version_number, conversation_id, size_bytes, changed_by, change_source, created_at").
+		Order("version_number DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Scan(&versions).Error; err != nil {
+		return nil, err
+	}
+
+	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+
+	return &models.ProjectVersionsResponse{
+		Versions: versions,
+		Pagination: &models.PaginationResponse{
+			CurrentPage: page,
+			TotalPages:  &totalPages,
+			TotalCount:  &totalCount,
+			HasNextPage: page < totalPages,
+			HasPrevPage: page > 1,
+		},
+	}, nil
+}
+
+func (s *ProjectService) GetProjectVersion(ctx context.Context, userID, projectID uuid.UUID, versionNumber int) (*models.ProjectVersion, error) {
+	if _, err := s.findAccessibleProject(s.db.Select("id"), userID, projectID, ProjectRoleEditor, ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	var version models.ProjectVersion
+	if err := s.db.Where("project_id = ? AND version_number = ?", projectID, versionNumber).First(&version).Error; err != nil {
+		return nil, errors.New("version not found")
+	}
+
+	return &version, nil
+}
+
+// DiffProjectVersions computes a line diff of the HTML between two of a
+// project's versions
+func (s *ProjectService) DiffProjectVersions(ctx context.Context, userID, projectID uuid.UUID, fromVersion, toVersion int) (*diff.Result, error) {
+	from, err := s.GetProjectVersion(ctx, userID, projectID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.GetProjectVersion(ctx, userID, projectID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := ""
+	return diff.Text(*coalesceCode(from.HTMLCode, &empty), *coalesceCode(to.HTMLCode, &empty)), nil
+}
+
+// RestoreProjectVersion copies a snapshot's code back onto the project. The
+// restore is recorded as a new version rather than rewriting history.
+func (s *ProjectService) RestoreProjectVersion(ctx context.Context, userID, projectID uuid.UUID, versionNumber int) (*models.Project, error) {
+	version, err := s.GetProjectVersion(ctx, userID, projectID, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := ""
+	req := &models.UpdateProjectRequest{
+		HTMLCode:     coalesceCode(version.HTMLCode, &empty),
+		CSSCode:      coalesceCode(version.CSSCode, &empty),
+		JSCode:       coalesceCode(version.JSCode, &empty),
+		ChangeSource: ChangeSourceRestore,
+	}
+
+	return s.UpdateProject(ctx, userID, projectID, req)
+}
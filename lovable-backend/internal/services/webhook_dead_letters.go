@@ -0,0 +1,227 @@
+// internal/services/webhook_dead_letters.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"lovable-backend/internal/models"
+)
+
+const (
+	// Redis list of events that failed every delivery attempt, oldest first
+	webhookDeadLetterKey = "dead_letter:webhooks"
+
+	// Most dead letters requeued by one retry request
+	webhookRetryBatchSize = 100
+
+	// Dead letters older than this are purged by the nightly job
+	webhookDeadLetterRetention = 30 * 24 * time.Hour
+)
+
+// webhookDeadLetter is an event that could not be delivered to a webhook
+type webhookDeadLetter struct {
+	WebhookID uuid.UUID       `json:"webhook_id"`
+	EventID   uuid.UUID       `json:"event_id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+// deadLetter keeps an event that failed every attempt so it can be retried
+func (d *WebhookDispatcher) deadLetter(webhook *models.Webhook, eventID uuid.UUID, event string, body []byte, attempts int) {
+	if d.redisClient == nil {
+		return
+	}
+
+	entry, err := json.Marshal(webhookDeadLetter{
+		WebhookID: webhook.ID,
+		EventID:   eventID,
+		Event:     event,
+		Payload:   body,
+		Attempts:  attempts,
+		FailedAt:  time.Now(),
+	})
+	if err != nil {
+		return
+	}
+
+	if err := d.redisClient.RPush(webhookDeadLetterKey, string(entry)); err != nil {
+		d.logger.Error("Failed to dead-letter webhook event", "webhookId", webhook.ID, "eventId", eventID, "error", err)
+	}
+}
+
+// RetryDeadLetters moves up to webhookRetryBatchSize dead letters, optionally
+// only those of one webhook, back onto the delivery queue, where each is
+// delivered again starting from the first attempt. It returns how many were
+// requeued.
+func (d *WebhookDispatcher) RetryDeadLetters(ctx context.Context, webhookID *uuid.UUID) (int, error) {
+	if d.redisClient == nil {
+		return 0, errors.New("webhook queue unavailable")
+	}
+
+	entries, err := d.redisClient.LRange(webhookDeadLetterKey, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, raw := range entries {
+		if requeued >= webhookRetryBatchSize {
+			break
+		}
+
+		var entry webhookDeadLetter
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			continue
+		}
+		if webhookID != nil && entry.WebhookID != *webhookID {
+			continue
+		}
+
+		// Removing the entry first means a concurrent retry can't requeue it too
+		removed, err := d.redisClient.LRem(webhookDeadLetterKey, 1, raw)
+		if err != nil {
+			return requeued, err
+		}
+		if removed == 0 {
+			continue
+		}
+
+		if err := d.redisClient.XAdd(webhookStream, map[string]interface{}{
+			"webhook_id": entry.WebhookID.String(),
+			"event_id":   entry.EventID.String(),
+			"event":      entry.Event,
+			"payload":    string(entry.Payload),
+		}); err != nil {
+			d.redisClient.RPush(webhookDeadLetterKey, raw)
+			return requeued, fmt.Errorf("failed to requeue webhook event: %w", err)
+		}
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+// redeliver delivers a requeued dead letter to its webhook, unless the
+// webhook has since been deleted or disabled
+func (d *WebhookDispatcher) redeliver(ctx context.Context, values map[string]interface{}) {
+	webhookID, err := uuid.Parse(fmt.Sprint(values["webhook_id"]))
+	if err != nil {
+		return
+	}
+	eventID, err := uuid.Parse(fmt.Sprint(values["event_id"]))
+	if err != nil {
+		return
+	}
+	event, _ := values["event"].(string)
+	payload, _ := values["payload"].(string)
+
+	var webhook models.Webhook
+	if err := d.db.Where("id = ? AND is_active = ?", webhookID, true).First(&webhook).Error; err != nil {
+		d.logger.Warn("Dropped webhook retry for missing or inactive webhook", "webhookId", webhookID, "eventId", eventID)
+		return
+	}
+
+	go d.deliver(context.WithoutCancel(ctx), &webhook, eventID, event, []byte(payload))
+}
+
+// PurgeDeadLetters deletes dead letters that failed before cutoff and returns
+// how many were deleted
+func (d *WebhookDispatcher) PurgeDeadLetters(cutoff time.Time) (int, error) {
+	if d.redisClient == nil {
+		return 0, nil
+	}
+
+	entries, err := d.redisClient.LRange(webhookDeadLetterKey, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, raw := range entries {
+		var entry webhookDeadLetter
+		// Entries that can't be read can never be retried either
+		if err := json.Unmarshal([]byte(raw), &entry); err == nil && !entry.FailedAt.Before(cutoff) {
+			continue
+		}
+
+		removed, err := d.redisClient.LRem(webhookDeadLetterKey, 1, raw)
+		if err != nil {
+			return purged, err
+		}
+		purged += int(removed)
+	}
+
+	return purged, nil
+}
+
+// StartDeadLetterPurgeWorker purges expired dead letters at startup and then
+// nightly
+func (d *WebhookDispatcher) StartDeadLetterPurgeWorker(ctx context.Context) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := d.PurgeDeadLetters(time.Now().Add(-webhookDeadLetterRetention)); err != nil {
+			d.logger.Error("Webhook dead-letter purge failed", "error", err)
+		} else if purged > 0 {
+			d.logger.Info("Purged webhook dead letters", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetStats summarizes webhooks, their queue and the last day's deliveries
+func (d *WebhookDispatcher) GetStats(ctx context.Context) (*models.WebhookStats, error) {
+	stats := &models.WebhookStats{}
+
+	if err := d.db.Model(&models.Webhook{}).Count(&stats.TotalWebhooks).Error; err != nil {
+		return nil, err
+	}
+	if err := d.db.Model(&models.Webhook{}).Where("is_active = ?", true).Count(&stats.Active).Error; err != nil {
+		return nil, err
+	}
+	stats.Inactive = stats.TotalWebhooks - stats.Active
+
+	since := time.Now().Add(-24 * time.Hour)
+	var deliveries struct {
+		Total     int64
+		Succeeded int64
+	}
+	if err := d.db.Model(&models.WebhookDelivery{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE success) AS succeeded").
+		Where("created_at > ?", since).
+		Scan(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	stats.TotalDeliveriesLast24h = deliveries.Total
+	if deliveries.Total > 0 {
+		stats.SuccessRatePct = math.Round(float64(deliveries.Succeeded)/float64(deliveries.Total)*1000) / 10
+	}
+
+	if d.redisClient != nil {
+		// Acknowledged entries are deleted, so the stream holds only events
+		// still waiting for or being dispatched
+		if pending, err := d.redisClient.XLen(webhookStream); err == nil {
+			stats.PendingDelivery = pending
+		}
+		if deadLetters, err := d.redisClient.LLen(webhookDeadLetterKey); err == nil {
+			stats.DeadLetterCount = deadLetters
+		}
+	}
+
+	return stats, nil
+}
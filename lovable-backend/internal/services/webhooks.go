@@ -0,0 +1,515 @@
+// internal/services/webhooks.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/internal/redis"
+	"lovable-backend/pkg/logger"
+)
+
+// Events webhooks can subscribe to
+const (
+	WebhookEventGenerationCompleted = "generation.completed"
+	WebhookEventProjectPublished    = "project.published"
+	WebhookEventExportCompleted     = "export.completed"
+
+	// Sent by the test endpoint regardless of subscriptions
+	webhookEventPing = "ping"
+)
+
+var webhookEvents = map[string]bool{
+	WebhookEventGenerationCompleted: true,
+	WebhookEventProjectPublished:    true,
+	WebhookEventExportCompleted:     true,
+}
+
+const (
+	webhookStream = "webhook:event:queue"
+	webhookGroup  = "webhook-dispatcher"
+
+	// Maximum number of webhooks per user
+	maxWebhooksPerUser = 10
+
+	// Retries after the first attempt, waiting webhookRetryBaseDelay and
+	// doubling before each
+	webhookMaxRetries     = 3
+	webhookRetryBaseDelay = 2 * time.Second
+
+	// Consecutive failed deliveries after which a webhook is disabled
+	webhookMaxFailures = 10
+
+	webhookTimeout = 10 * time.Second
+
+	// Redirects followed on a single delivery
+	webhookMaxRedirects = 3
+)
+
+// Returned when a webhook URL points at, or resolves or redirects to, a
+// private, loopback or link-local address
+var errWebhookHostNotAllowed = errors.New("webhook host not allowed")
+
+// webhookPayload is the JSON body POSTed to webhook URLs
+type webhookPayload struct {
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// publishWebhookEvent queues an event for delivery to the user's webhooks.
+// Without Redis events are dropped.
+func publishWebhookEvent(redisClient *redis.Client, userID uuid.UUID, event string, data interface{}) {
+	if redisClient == nil {
+		return
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	redisClient.XAdd(webhookStream, map[string]interface{}{
+		"user_id": userID.String(),
+		"event":   event,
+		"data":    string(encoded),
+	})
+}
+
+// WebhookDispatcher delivers queued events to every active webhook of the
+// user subscribed to them, signing each payload with the webhook's secret.
+type WebhookDispatcher struct {
+	db           *gorm.DB
+	redisClient  *redis.Client
+	emailService *EmailService
+	client       *http.Client
+	logger       *logger.Logger
+}
+
+func NewWebhookDispatcher(db *gorm.DB, redisClient *redis.Client, emailService *EmailService, logger *logger.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:           db,
+		redisClient:  redisClient,
+		emailService: emailService,
+		client:       newWebhookClient(),
+		logger:       logger,
+	}
+}
+
+// newWebhookClient returns the client deliveries are made with. Webhook URLs
+// are user supplied, so connections to internal addresses are refused when
+// dialing, which also covers redirects and DNS answers that change after the
+// URL was saved.
+func newWebhookClient() *http.Client {
+	dialer := publicOnlyDialer(5*time.Second, errWebhookHostNotAllowed)
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= webhookMaxRedirects {
+				return errors.New("too many redirects")
+			}
+			return validateWebhookURL(req.Context(), req.URL.String())
+		},
+	}
+}
+
+func (d *WebhookDispatcher) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := d.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (d *WebhookDispatcher) GetWebhook(ctx context.Context, userID, webhookID uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := d.db.Where("id = ? AND user_id = ?", webhookID, userID).First(&webhook).Error; err != nil {
+		return nil, errors.New("webhook not found")
+	}
+
+	return &webhook, nil
+}
+
+func (d *WebhookDispatcher) CreateWebhook(ctx context.Context, userID uuid.UUID, req *models.CreateWebhookRequest) (*models.CreateWebhookResponse, error) {
+	if err := validateWebhookURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := d.db.Model(&models.Webhook{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, errors.New("webhook limit reached")
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		randomBytes := make([]byte, 32)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = "whsec_" + hex.EncodeToString(randomBytes)
+	}
+
+	webhook := models.Webhook{
+		UserID:   userID,
+		URL:      req.URL,
+		Secret:   secret,
+		Events:   req.Events,
+		IsActive: true,
+	}
+
+	if err := d.db.Create(&webhook).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.CreateWebhookResponse{
+		Secret:  secret,
+		Webhook: &webhook,
+	}, nil
+}
+
+func (d *WebhookDispatcher) UpdateWebhook(ctx context.Context, userID, webhookID uuid.UUID, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	webhook, err := d.GetWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.URL != nil {
+		if err := validateWebhookURL(ctx, *req.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = *req.URL
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+		updates["events"] = pq.StringArray(req.Events)
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+		// Re-enabling starts the failure count over
+		if *req.IsActive {
+			updates["failure_count"] = 0
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := d.db.Model(webhook).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return webhook, nil
+}
+
+func (d *WebhookDispatcher) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", webhookID, userID).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("webhook not found")
+		}
+
+		return tx.Where("webhook_id = ?", webhookID).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// TestWebhook sends a ping event to the webhook once, without retries, and
+// returns the recorded attempt
+func (d *WebhookDispatcher) TestWebhook(ctx context.Context, userID, webhookID uuid.UUID) (*models.WebhookDelivery, error) {
+	webhook, err := d.GetWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := uuid.New()
+	body, err := json.Marshal(webhookPayload{
+		ID:        eventID,
+		Event:     webhookEventPing,
+		CreatedAt: time.Now(),
+		Data:      map[string]interface{}{"webhook_id": webhook.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d.attempt(ctx, webhook, eventID, webhookEventPing, body, 1), nil
+}
+
+// validateWebhookURL checks that raw is an http(s) URL whose host resolves
+// only to public addresses
+func validateWebhookURL(ctx context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Hostname() == "" {
+		return errors.New("webhook URL must be an http or https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return errors.New("webhook host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return errWebhookHostNotAllowed
+		}
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, event := range events {
+		if !webhookEvents[event] {
+			return errors.New("unknown webhook event")
+		}
+	}
+	return nil
+}
+
+// signWebhookPayload returns the X-Lovable-Signature value for a body
+func signWebhookPayload(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *WebhookDispatcher) StartWorker(ctx context.Context) {
+	if d.redisClient == nil {
+		return
+	}
+
+	if err := d.redisClient.XGroupCreate(webhookStream, webhookGroup); err != nil {
+		d.logger.Error("Failed to create webhook consumer group", "error", err)
+		return
+	}
+
+	consumer, _ := os.Hostname()
+	if consumer == "" {
+		consumer = "webhook-dispatcher"
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		messages, err := d.redisClient.XReadGroup(ctx, webhookStream, webhookGroup, consumer, 20, 5*time.Second)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.logger.Error("Failed to read webhook queue", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		entryIDs := make([]string, 0, len(messages))
+		for _, message := range messages {
+			entryIDs = append(entryIDs, message.ID)
+
+			// Dead letters requeued by an admin go back to their one webhook
+			if _, ok := message.Values["webhook_id"]; ok {
+				d.redeliver(ctx, message.Values)
+				continue
+			}
+
+			userID, err := uuid.Parse(fmt.Sprint(message.Values["user_id"]))
+			if err != nil {
+				continue
+			}
+			event, _ := message.Values["event"].(string)
+			data, _ := message.Values["data"].(string)
+
+			d.dispatch(ctx, userID, event, json.RawMessage(data))
+		}
+
+		if len(entryIDs) > 0 {
+			if err := d.redisClient.XAckDel(webhookStream, webhookGroup, entryIDs...); err != nil {
+				d.logger.Error("Failed to acknowledge webhook entries", "error", err)
+			}
+		}
+	}
+}
+
+// dispatch starts a delivery to each of the user's active webhooks subscribed
+// to the event. Deliveries retry in the background so one slow endpoint does
+// not hold up the queue.
+func (d *WebhookDispatcher) dispatch(ctx context.Context, userID uuid.UUID, event string, data json.RawMessage) {
+	var webhooks []models.Webhook
+	if err := d.db.Where("user_id = ? AND is_active = ? AND ? = ANY(events)", userID, true, event).Find(&webhooks).Error; err != nil {
+		d.logger.Error("Failed to load webhooks", "userId", userID, "event", event, "error", err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	eventID := uuid.New()
+	body, err := json.Marshal(webhookPayload{
+		ID:        eventID,
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return
+	}
+
+	for i := range webhooks {
+		go d.deliver(context.WithoutCancel(ctx), &webhooks[i], eventID, event, body)
+	}
+}
+
+// deliver POSTs the payload, retrying non-2xx responses with exponential
+// backoff, and updates the webhook's failure count with the outcome. Events
+// that still fail are kept in the dead-letter list for an admin to retry.
+func (d *WebhookDispatcher) deliver(ctx context.Context, webhook *models.Webhook, eventID uuid.UUID, event string, body []byte) {
+	delay := webhookRetryBaseDelay
+	for attempt := 1; attempt <= webhookMaxRetries+1; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		if d.attempt(ctx, webhook, eventID, event, body, attempt).Success {
+			return
+		}
+	}
+
+	d.deadLetter(webhook, eventID, event, body, webhookMaxRetries+1)
+
+	// Counted in SQL so concurrent deliveries don't lose failures
+	var failures int
+	if err := d.db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).
+		Update("failure_count", gorm.Expr("failure_count + 1")).Error; err != nil {
+		d.logger.Error("Failed to record webhook failure", "webhookId", webhook.ID, "error", err)
+		return
+	}
+	d.db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).Pluck("failure_count", &failures)
+
+	if failures < webhookMaxFailures {
+		return
+	}
+
+	result := d.db.Model(&models.Webhook{}).Where("id = ? AND is_active = ?", webhook.ID, true).Update("is_active", false)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	d.logger.Warn("Webhook disabled after repeated failures", "webhookId", webhook.ID, "userId", webhook.UserID, "failures", failures)
+	d.notifyDisabled(webhook, failures)
+}
+
+// attempt makes a single signed POST and records it as a WebhookDelivery
+func (d *WebhookDispatcher) attempt(ctx context.Context, webhook *models.Webhook, eventID uuid.UUID, event string, body []byte, attempt int) *models.WebhookDelivery {
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   eventID,
+		Event:     event,
+		Payload:   body,
+		Attempt:   attempt,
+	}
+
+	startTime := time.Now()
+	statusCode, err := d.post(ctx, webhook, event, body)
+	delivery.DurationMS = int(time.Since(startTime).Milliseconds())
+
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+	}
+	if err != nil {
+		message := err.Error()
+		delivery.Error = &message
+	} else {
+		delivery.Success = true
+	}
+
+	if err := d.db.Create(&delivery).Error; err != nil {
+		d.logger.Error("Failed to record webhook delivery", "webhookId", webhook.ID, "error", err)
+	}
+
+	if delivery.Success {
+		d.db.Model(&models.Webhook{}).Where("id = ?", webhook.ID).Updates(map[string]interface{}{
+			"last_delivery_at": time.Now(),
+			"failure_count":    0,
+		})
+	}
+
+	return &delivery
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, webhook *models.Webhook, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Lovable-Webhooks/1.0")
+	req.Header.Set("X-Lovable-Event", event)
+	req.Header.Set("X-Lovable-Signature", signWebhookPayload(body, webhook.Secret))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if errors.Is(err, errWebhookHostNotAllowed) {
+			return 0, errWebhookHostNotAllowed
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The response body is never recorded: deliveries are shown to the
+	// webhook's owner, who shouldn't be able to read what a URL returns
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *WebhookDispatcher) notifyDisabled(webhook *models.Webhook, failures int) {
+	var user models.User
+	if err := d.db.Select("email").First(&user, "id = ?", webhook.UserID).Error; err != nil {
+		return
+	}
+
+	body := fmt.Sprintf("Your webhook for %s failed %d deliveries in a row and has been disabled.\n\n"+
+		"Fix the endpoint, then re-enable the webhook from your account settings.\n\nAI Website Builder",
+		webhook.URL, failures)
+	if err := d.emailService.Send(user.Email, "Webhook disabled", body); err != nil {
+		d.logger.Error("Failed to send webhook disabled email", "webhookId", webhook.ID, "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+// internal/services/webhooks_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{"ftp://example.com/hook", "webhook URL must be an http or https URL"},
+		{"https:///hook", "webhook URL must be an http or https URL"},
+		{"http://127.0.0.1:8080/hook", "webhook host not allowed"},
+		{"http://localhost/hook", "webhook host not allowed"},
+		{"http://10.0.0.5/hook", "webhook host not allowed"},
+		{"http://192.168.1.1/hook", "webhook host not allowed"},
+		{"http://169.254.169.254/latest/meta-data/", "webhook host not allowed"},
+		{"http://[::1]/hook", "webhook host not allowed"},
+		{"https://93.184.216.34/hook", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := validateWebhookURL(context.Background(), tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("webhook client connected to a loopback address")
+	}))
+	defer server.Close()
+
+	resp, err := newWebhookClient().Post(server.URL, "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the connection to be refused")
+	}
+	if !errors.Is(err, errWebhookHostNotAllowed) {
+		t.Errorf("error = %v, want %v", err, errWebhookHostNotAllowed)
+	}
+}
@@ -0,0 +1,172 @@
+// internal/shutdown/monitor.go
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"lovable-backend/pkg/logger"
+)
+
+// Interval between drain progress log lines
+const progressInterval = 5 * time.Second
+
+// ShutdownMonitor tracks WebSocket connections and in-flight AI generations
+// that http.Server.Shutdown cannot see, since hijacked connections are not
+// waited on.
+type ShutdownMonitor struct {
+	logger *logger.Logger
+
+	mu          sync.Mutex
+	draining    bool
+	connections map[*websocket.Conn]struct{}
+	generations int
+	idle        chan struct{} // closed when the last generation finishes while draining
+}
+
+type DrainSummary struct {
+	WSConnectionsClosed int `json:"ws_connections_closed"`
+	PendingJobsDrained  int `json:"pending_jobs_drained"`
+	ForceKilled         int `json:"force_killed"`
+}
+
+func NewShutdownMonitor(logger *logger.Logger) *ShutdownMonitor {
+	return &ShutdownMonitor{
+		logger:      logger,
+		connections: make(map[*websocket.Conn]struct{}),
+	}
+}
+
+func (m *ShutdownMonitor) Draining() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.draining
+}
+
+// TrackConnection registers an upgraded WebSocket connection. It returns
+// false once draining has started, in which case the caller should close the
+// connection.
+func (m *ShutdownMonitor) TrackConnection(conn *websocket.Conn) (func(), bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.draining {
+		return func() {}, false
+	}
+
+	m.connections[conn] = struct{}{}
+	return func() {
+		m.mu.Lock()
+		delete(m.connections, conn)
+		m.mu.Unlock()
+	}, true
+}
+
+// BeginGeneration registers an in-flight AI generation. It returns false once
+// draining has started; otherwise the returned function must be called when
+// the generation finishes.
+func (m *ShutdownMonitor) BeginGeneration() (func(), bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.draining {
+		return func() {}, false
+	}
+
+	m.generations++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.generations--
+			if m.generations == 0 && m.idle != nil {
+				close(m.idle)
+				m.idle = nil
+			}
+		})
+	}, true
+}
+
+// Drain stops accepting new WebSocket connections and generations, waits for
+// in-flight generations until the context expires, then closes the remaining
+// WebSocket connections with a 1001 Going Away frame.
+func (m *ShutdownMonitor) Drain(ctx context.Context) DrainSummary {
+	m.mu.Lock()
+	m.draining = true
+	pending := m.generations
+	idle := make(chan struct{})
+	if pending == 0 {
+		close(idle)
+	} else {
+		m.idle = idle
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("Draining in-flight work", "pending_jobs", pending, "ws_connections", m.connectionCount())
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	forceKilled := 0
+wait:
+	for {
+		select {
+		case <-idle:
+			break wait
+		case <-ticker.C:
+			m.logger.Info("Waiting for in-flight generations", "pending_jobs", m.pendingGenerations(), "ws_connections", m.connectionCount())
+		case <-ctx.Done():
+			forceKilled = m.pendingGenerations()
+			m.logger.Warn("Drain timed out with generations still running", "pending_jobs", forceKilled)
+			break wait
+		}
+	}
+
+	summary := DrainSummary{
+		WSConnectionsClosed: m.closeConnections(),
+		PendingJobsDrained:  pending - forceKilled,
+		ForceKilled:         forceKilled,
+	}
+
+	m.logger.Info("Drain complete",
+		"ws_connections_closed", summary.WSConnectionsClosed,
+		"pending_jobs_drained", summary.PendingJobsDrained,
+		"force_killed", summary.ForceKilled,
+	)
+
+	return summary
+}
+
+func (m *ShutdownMonitor) closeConnections() int {
+	m.mu.Lock()
+	connections := make([]*websocket.Conn, 0, len(m.connections))
+	for conn := range m.connections {
+		connections = append(connections, conn)
+	}
+	m.connections = make(map[*websocket.Conn]struct{})
+	m.mu.Unlock()
+
+	closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, conn := range connections {
+		conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(time.Second))
+		conn.Close()
+	}
+
+	return len(connections)
+}
+
+func (m *ShutdownMonitor) pendingGenerations() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.generations
+}
+
+func (m *ShutdownMonitor) connectionCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.connections)
+}
@@ -0,0 +1,65 @@
+// internal/storage/postgres.go
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/models"
+)
+
+// PostgresStorage keeps HTML inline in the projects.html_code column. Keys are
+// project object keys as returned by ProjectHTMLKey.
+type PostgresStorage struct {
+	db *gorm.DB
+}
+
+func NewPostgresStorage(db *gorm.DB) *PostgresStorage {
+	return &PostgresStorage{db: db}
+}
+
+func (s *PostgresStorage) Put(key, data string) error {
+	projectID, err := projectIDFromKey(key)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Project{}).Where("id = ?", projectID).Update("html_code", data).Error
+}
+
+func (s *PostgresStorage) Get(key string) (string, error) {
+	projectID, err := projectIDFromKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	var project models.Project
+	if err := s.db.Select("html_code").Where("id = ?", projectID).First(&project).Error; err != nil {
+		return "", err
+	}
+
+	if project.HTMLCode == nil {
+		return "", errors.New("no HTML stored for this key")
+	}
+
+	return *project.HTMLCode, nil
+}
+
+func (s *PostgresStorage) Delete(key string) error {
+	projectID, err := projectIDFromKey(key)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Project{}).Where("id = ?", projectID).Update("html_code", nil).Error
+}
+
+func projectIDFromKey(key string) (string, error) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[0] != "projects" || parts[1] == "" {
+		return "", errors.New("invalid storage key")
+	}
+	return parts[1], nil
+}
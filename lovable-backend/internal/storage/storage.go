@@ -0,0 +1,58 @@
+// internal/storage/storage.go
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"lovable-backend/internal/config"
+	"lovable-backend/internal/models"
+)
+
+const (
+	ModePostgres = "postgres"
+	ModeS3       = "s3"
+)
+
+// StorageBackend stores generated project code by key
+type StorageBackend interface {
+	Put(key, data string) error
+	Get(key string) (string, error)
+	Delete(key string) error
+}
+
+// New returns the backend selected by the configured storage mode
+func New(ctx context.Context, cfg *config.Config, db *gorm.DB) (StorageBackend, error) {
+	switch cfg.StorageMode {
+	case ModeS3:
+		return NewS3Storage(ctx, cfg.S3)
+	case ModePostgres, "":
+		return NewPostgresStorage(db), nil
+	default:
+		return nil, fmt.Errorf("unknown storage mode %q", cfg.StorageMode)
+	}
+}
+
+// ProjectHTMLKey returns the object key used for a project's HTML
+func ProjectHTMLKey(projectID uuid.UUID) string {
+	return fmt.Sprintf("projects/%s/index.html", projectID.String())
+}
+
+// LoadProjectHTML fills in HTMLCode for projects whose HTML lives in external
+// storage. Projects with inline HTML are left untouched.
+func LoadProjectHTML(backend StorageBackend, project *models.Project) error {
+	if backend == nil || project.HTMLCode != nil || project.HTMLCodeKey == nil {
+		return nil
+	}
+
+	html, err := backend.Get(*project.HTMLCodeKey)
+	if err != nil {
+		return fmt.Errorf("failed to load project HTML: %w", err)
+	}
+
+	project.HTMLCode = &html
+	return nil
+}
@@ -0,0 +1,287 @@
+// internal/worker/pool.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"lovable-backend/internal/models"
+	"lovable-backend/pkg/logger"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusDead      = "dead"
+)
+
+const (
+	// How long an idle worker waits before checking the queue again
+	pollInterval = 2 * time.Second
+
+	// Deadline for one run of a job's handler
+	jobTimeout = 5 * time.Minute
+
+	// Jobs still running after this long lost their worker and are requeued
+	staleAfter = 2 * jobTimeout
+
+	// Completed jobs are deleted after this long; dead ones are kept for
+	// inspection
+	completedRetention = 7 * 24 * time.Hour
+
+	// Attempts allowed when the job doesn't set its own limit
+	defaultMaxAttempts = 5
+
+	// Delay before the first retry, doubled after each further failure
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// Handler runs one job of a registered type. A returned error fails the
+// attempt and the job is retried until it runs out of attempts.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Pool runs jobs from the jobs table on a fixed number of workers, which
+// claim jobs with SELECT ... FOR UPDATE SKIP LOCKED so any number of
+// instances can share the queue.
+type Pool struct {
+	db          *gorm.DB
+	concurrency int
+	logger      *logger.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	wg sync.WaitGroup
+}
+
+func NewPool(db *gorm.DB, concurrency int, logger *logger.Logger) *Pool {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &Pool{
+		db:          db,
+		concurrency: concurrency,
+		logger:      logger,
+		handlers:    make(map[string]Handler),
+	}
+}
+
+// Register sets the handler for jobs of the given type
+func (p *Pool) Register(jobType string, handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[jobType] = handler
+}
+
+// Enqueue stores a job to be run as soon as a worker is free. db may be a
+// transaction, so the job is only queued if the surrounding work commits.
+func Enqueue(db *gorm.DB, jobType string, payload interface{}) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.Job{
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusQueued,
+		MaxAttempts: defaultMaxAttempts,
+		RunAfter:    time.Now(),
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Start runs the workers until ctx is cancelled. Jobs already running are
+// allowed to finish; Drain waits for them.
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.concurrency; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.runWorker(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		p.sweep()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain waits for running jobs to finish after the pool's context has been
+// cancelled, giving up when ctx expires
+func (p *Pool) Drain(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.logger.Info("Job workers drained")
+	case <-ctx.Done():
+		p.logger.Warn("Job workers did not drain before the shutdown deadline")
+	}
+}
+
+func (p *Pool) sweep() {
+	if err := p.db.Where("status = ? AND completed_at < ?", StatusCompleted, time.Now().Add(-completedRetention)).Delete(&models.Job{}).Error; err != nil {
+		p.logger.Error("Failed to delete completed jobs", "error", err)
+	}
+
+	result := p.db.Model(&models.Job{}).
+		Where("status = ? AND started_at < ?", StatusRunning, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{"status": StatusQueued, "started_at": nil})
+	if result.Error != nil {
+		p.logger.Error("Failed to requeue stale jobs", "error", result.Error)
+	} else if result.RowsAffected > 0 {
+		p.logger.Warn("Requeued stale jobs", "count", result.RowsAffected)
+	}
+}
+
+func (p *Pool) runWorker(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := p.claimJob()
+		if err != nil {
+			p.logger.Error("Failed to claim job", "error", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		p.process(job)
+	}
+}
+
+// claimJob marks the oldest due job as running and returns it, or nil when
+// no job is due
+func (p *Pool) claimJob() (*models.Job, error) {
+	var job models.Job
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_after <= ?", StatusQueued, time.Now()).
+			Order("run_after").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = StatusRunning
+		job.StartedAt = &now
+		job.Attempts++
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":     StatusRunning,
+			"started_at": now,
+			"attempts":   job.Attempts,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// process runs the job's handler. Its context is not tied to the pool's, so a
+// job that has started finishes during shutdown.
+func (p *Pool) process(job *models.Job) {
+	p.mu.RLock()
+	handler, ok := p.handlers[job.Type]
+	p.mu.RUnlock()
+
+	if !ok {
+		p.bury(job, fmt.Errorf("no handler registered for job type %q", job.Type))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+	defer cancel()
+
+	if err := handler(ctx, job.Payload); err != nil {
+		p.fail(job, err)
+		return
+	}
+
+	if err := p.db.Model(job).Updates(map[string]interface{}{
+		"status":       StatusCompleted,
+		"last_error":   nil,
+		"completed_at": time.Now(),
+	}).Error; err != nil {
+		p.logger.Error("Failed to mark job as completed", "jobId", job.ID, "error", err)
+	}
+}
+
+// fail requeues the job with exponential backoff, or moves it to the dead
+// letter queue once it has used all its attempts
+func (p *Pool) fail(job *models.Job, jobErr error) {
+	if job.Attempts >= job.MaxAttempts {
+		p.bury(job, jobErr)
+		return
+	}
+
+	delay := retryBaseDelay << (job.Attempts - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+
+	p.logger.Error("Job failed, retrying", "jobId", job.ID, "type", job.Type, "attempt", job.Attempts, "retryIn", delay, "error", jobErr)
+
+	if err := p.db.Model(job).Updates(map[string]interface{}{
+		"status":     StatusQueued,
+		"last_error": jobErr.Error(),
+		"run_after":  time.Now().Add(delay),
+		"started_at": nil,
+	}).Error; err != nil {
+		p.logger.Error("Failed to requeue job", "jobId", job.ID, "error", err)
+	}
+}
+
+// bury marks the job dead so it is no longer retried
+func (p *Pool) bury(job *models.Job, jobErr error) {
+	p.logger.Warn("Job moved to dead letter queue", "jobId", job.ID, "type", job.Type, "attempts", job.Attempts, "error", jobErr)
+
+	if err := p.db.Model(job).Updates(map[string]interface{}{
+		"status":       StatusDead,
+		"last_error":   jobErr.Error(),
+		"completed_at": time.Now(),
+	}).Error; err != nil {
+		p.logger.Error("Failed to mark job as dead", "jobId", job.ID, "error", err)
+	}
+}
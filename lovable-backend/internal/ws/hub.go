@@ -0,0 +1,204 @@
+// internal/ws/hub.go
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+
+	"lovable-backend/internal/redis"
+)
+
+// Time allowed to write a message before the peer is considered gone
+const writeWait = 10 * time.Second
+
+// Redis channels carrying broadcasts between server instances, one per user
+const userChannelPrefix = "ws:user:"
+
+var ErrHubClosed = errors.New("websocket hub is shut down")
+
+// Conn wraps a WebSocket connection so several goroutines can write to it;
+// gorilla connections support only one concurrent writer.
+type Conn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func NewConn(conn *websocket.Conn) *Conn {
+	return &Conn{conn: conn}
+}
+
+func (c *Conn) WriteJSON(msg interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *Conn) close() {
+	closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	c.conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(time.Second))
+	c.conn.Close()
+}
+
+// WSHub tracks each user's open WebSocket connections so events can reach
+// every tab the user has open.
+type WSHub struct {
+	mu     sync.RWMutex
+	conns  map[uuid.UUID][]*Conn
+	closed bool
+
+	pubsub      *redis.RedisPubSub
+	unsubscribe func()
+}
+
+// publishedMessage is a broadcast as sent between server instances
+type publishedMessage struct {
+	UserID  uuid.UUID       `json:"userId"`
+	Message json.RawMessage `json:"message"`
+}
+
+func NewWSHub() *WSHub {
+	return &WSHub{
+		conns: make(map[uuid.UUID][]*Conn),
+	}
+}
+
+// Register adds a connection for the user. It fails once Shutdown has been
+// called, in which case the caller should close the connection.
+func (h *WSHub) Register(userID uuid.UUID, conn *Conn) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrHubClosed
+	}
+
+	h.conns[userID] = append(h.conns[userID], conn)
+	return nil
+}
+
+func (h *WSHub) Unregister(userID uuid.UUID, conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	conns := h.conns[userID]
+	for i, c := range conns {
+		if c == conn {
+			conns = append(conns[:i:i], conns[i+1:]...)
+			break
+		}
+	}
+
+	if len(conns) == 0 {
+		delete(h.conns, userID)
+	} else {
+		h.conns[userID] = conns
+	}
+}
+
+// UsePubSub routes broadcasts through Redis so they reach the user's
+// connections on every server instance, not only this one
+func (h *WSHub) UsePubSub(pubsub *redis.RedisPubSub) error {
+	unsubscribe, err := pubsub.Subscribe(userChannelPrefix+"*", h.deliverPublished)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.pubsub = pubsub
+	h.unsubscribe = unsubscribe
+	h.mu.Unlock()
+	return nil
+}
+
+// Broadcast sends msg to every connection the user has open. With pub/sub
+// enabled it is published for every instance to deliver, and delivered
+// locally only if publishing fails.
+func (h *WSHub) Broadcast(userID uuid.UUID, msg interface{}) error {
+	h.mu.RLock()
+	pubsub := h.pubsub
+	h.mu.RUnlock()
+
+	if pubsub == nil {
+		return h.deliver(userID, msg)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	err = pubsub.Publish(userChannelPrefix+userID.String(), publishedMessage{UserID: userID, Message: data})
+	if err != nil {
+		return errors.Join(err, h.deliver(userID, json.RawMessage(data)))
+	}
+	return nil
+}
+
+func (h *WSHub) deliverPublished(payload []byte) {
+	var published publishedMessage
+	if err := json.Unmarshal(payload, &published); err != nil {
+		return
+	}
+
+	h.deliver(published.UserID, published.Message)
+}
+
+// deliver writes msg to the user's connections on this instance. Delivery to
+// the remaining connections continues when one fails; the failures are
+// returned together.
+func (h *WSHub) deliver(userID uuid.UUID, msg interface{}) error {
+	h.mu.RLock()
+	conns := append([]*Conn(nil), h.conns[userID]...)
+	h.mu.RUnlock()
+
+	var errs []error
+	for _, conn := range conns {
+		if err := conn.WriteJSON(msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ConnectionCounts returns the number of open connections per user
+func (h *WSHub) ConnectionCounts() map[uuid.UUID]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	counts := make(map[uuid.UUID]int, len(h.conns))
+	for userID, conns := range h.conns {
+		counts[userID] = len(conns)
+	}
+	return counts
+}
+
+// Shutdown closes every registered connection with a 1001 Going Away frame
+// and rejects further registrations.
+func (h *WSHub) Shutdown() {
+	h.mu.Lock()
+	h.closed = true
+	all := h.conns
+	h.conns = make(map[uuid.UUID][]*Conn)
+	unsubscribe := h.unsubscribe
+	h.pubsub = nil
+	h.unsubscribe = nil
+	h.mu.Unlock()
+
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+
+	for _, conns := range all {
+		for _, conn := range conns {
+			conn.close()
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// pkg/diff/diff.go
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Line types in a diff
+const (
+	LineContext = "context"
+	LineAdded   = "added"
+	LineRemoved = "removed"
+)
+
+// Number of unchanged lines kept around each change in a hunk
+const contextLines = 3
+
+// Line is a single line of a hunk
+type Line struct {
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+// Hunk is a run of changes with its surrounding context. OldStart and
+// NewStart are the 1-based line numbers of its first line in each text.
+type Hunk struct {
+	OldStart int    `json:"old_start"`
+	OldLines int    `json:"old_lines"`
+	NewStart int    `json:"new_start"`
+	NewLines int    `json:"new_lines"`
+	Lines    []Line `json:"lines"`
+}
+
+// Result is a unified line diff of two texts
+type Result struct {
+	Added     int    `json:"added"`
+	Removed   int    `json:"removed"`
+	Unchanged int    `json:"unchanged"`
+	Hunks     []Hunk `json:"hunks"`
+}
+
+// Text computes a unified diff between the lines of two texts using Myers'
+// algorithm in linear space
+func Text(from, to string) *Result {
+	a, b := splitLines(from), splitLines(to)
+
+	// Lines are compared as integers, interned by content
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+
+	d := &differ{
+		a:         intern(a),
+		b:         intern(b),
+		removedAt: make([]bool, len(a)),
+		addedAt:   make([]bool, len(b)),
+	}
+	d.compare(0, len(a), 0, len(b))
+
+	return d.result(a, b)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+type differ struct {
+	a, b      []int
+	removedAt []bool
+	addedAt   []bool
+}
+
+// compare marks the lines of a[aLo:aHi] and b[bLo:bHi] that are not part of
+// their longest common subsequence
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		aLo++
+		bLo++
+	}
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+	}
+
+	switch {
+	case aLo == aHi:
+		for i := bLo; i < bHi; i++ {
+			d.addedAt[i] = true
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.removedAt[i] = true
+		}
+	default:
+		x, y, ok := d.middleSnake(aLo, aHi, bLo, bHi)
+		if !ok {
+			for i := aLo; i < aHi; i++ {
+				d.removedAt[i] = true
+			}
+			for i := bLo; i < bHi; i++ {
+				d.addedAt[i] = true
+			}
+			return
+		}
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	}
+}
+
+// middleSnake runs the forward and reverse searches of Myers' algorithm
+// until they overlap and returns the point where they meet, which splits the
+// edit script in two
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+
+	forward := make([]int, size)
+	reverse := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		reverse[i] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+
+	delta := n - m
+	odd := delta%2 != 0
+
+	// Diagonals that ran off the edge of the grid are skipped in later rounds
+	var fStart, fEnd, rStart, rEnd int
+
+	for step := 0; step < maxD; step++ {
+		for k := -step + fStart; k <= step-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[i] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				j := offset + delta - k
+				if j >= 0 && j < size && reverse[j] != -1 && x >= n-reverse[j] {
+					return aLo + x, bLo + y, true
+				}
+			}
+		}
+
+		for k := -step + rStart; k <= step-rEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -step || (k != step && reverse[i-1] < reverse[i+1]) {
+				x = reverse[i+1]
+			} else {
+				x = reverse[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			reverse[i] = x
+
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					fy := fx - (j - offset)
+					if fx >= n-x {
+						return aLo + fx, bLo + fy, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+type op struct {
+	kind    string
+	content string
+	oldLine int
+	newLine int
+}
+
+// result walks both texts in step, emitting the marked lines as removals and
+// additions, and groups the changes into hunks
+func (d *differ) result(a, b []string) *Result {
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && d.removedAt[i]:
+			ops = append(ops, op{LineRemoved, a[i], i, j})
+			i++
+		case j < len(b) && d.addedAt[j]:
+			ops = append(ops, op{LineAdded, b[j], i, j})
+			j++
+		default:
+			ops = append(ops, op{LineContext, a[i], i, j})
+			i++
+			j++
+		}
+	}
+
+	result := &Result{Hunks: []Hunk{}}
+	for _, o := range ops {
+		switch o.kind {
+		case LineAdded:
+			result.Added++
+		case LineRemoved:
+			result.Removed++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	for start := 0; start < len(ops); {
+		if ops[start].kind == LineContext {
+			start++
+			continue
+		}
+
+		// Extend the hunk until a run of unchanged lines is long enough to
+		// separate it from the next change
+		end := start
+		for k := start; k < len(ops) && k-end <= 2*contextLines; k++ {
+			if ops[k].kind != LineContext {
+				end = k
+			}
+		}
+
+		from := max(start-contextLines, 0)
+		to := min(end+contextLines+1, len(ops))
+
+		hunk := Hunk{
+			OldStart: ops[from].oldLine + 1,
+			NewStart: ops[from].newLine + 1,
+		}
+		for _, o := range ops[from:to] {
+			hunk.Lines = append(hunk.Lines, Line{Type: o.kind, Content: o.content})
+			if o.kind != LineAdded {
+				hunk.OldLines++
+			}
+			if o.kind != LineRemoved {
+				hunk.NewLines++
+			}
+		}
+		result.Hunks = append(result.Hunks, hunk)
+
+		start = to
+	}
+
+	return result
+}
+
+// HTML renders the diff as a self-contained fragment with inline colours,
+// for embedding in a page
+func (r *Result) HTML() string {
+	var sb strings.Builder
+
+	sb.WriteString(`<div class="diff" style="font-family:monospace;font-size:13px;border:1px solid #d0d7de;border-radius:6px;overflow:auto">`)
+	fmt.Fprintf(&sb, `<div class="diff-summary" style="padding:6px 10px;background:#f6f8fa;border-bottom:1px solid #d0d7de"><span style="color:#1a7f37">+%d</span> <span style="color:#cf222e">-%d</span></div>`, r.Added, r.Removed)
+
+	for _, hunk := range r.Hunks {
+		fmt.Fprintf(&sb, `<div class="diff-hunk-header" style="padding:2px 10px;background:#ddf4ff;color:#57606a">@@ -%d,%d +%d,%d @@</div>`,
+			hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+
+		for _, line := range hunk.Lines {
+			prefix, class, style := " ", "diff-context", ""
+			switch line.Type {
+			case LineAdded:
+				prefix, class, style = "+", "diff-added", "background:#e6ffec;"
+			case LineRemoved:
+				prefix, class, style = "-", "diff-removed", "background:#ffebe9;"
+			}
+			fmt.Fprintf(&sb, `<div class="diff-line %s" style="%swhite-space:pre;padding:0 10px">%s%s</div>`,
+				class, style, prefix, html.EscapeString(line.Content))
+		}
+	}
+
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
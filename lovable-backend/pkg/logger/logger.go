@@ -2,10 +2,13 @@
 package logger
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"os"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Logger struct {
@@ -39,10 +42,53 @@ func New(environment string) *Logger {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	logger := slog.New(handler)
+	logger := slog.New(traceHandler{handler})
 	return &Logger{Logger: logger}
 }
 
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the HTTP request
+// being served
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored by WithRequestID, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// traceHandler adds the trace ID of the active span and the request ID to
+// records logged with a context, e.g. through InfoContext
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			r.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+		}
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			r.AddAttrs(slog.String("requestID", requestID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
+
 func (l *Logger) Write(p []byte) (n int, err error) {
 	// Implement io.Writer interface for Gin logging
 	l.Info(string(p))
@@ -50,13 +96,14 @@ func (l *Logger) Write(p []byte) (n int, err error) {
 }
 
 // Convenience methods with structured logging
-func (l *Logger) LogAPICall(method, url string, statusCode, responseTime int, userID string) {
+func (l *Logger) LogAPICall(method, url string, statusCode, responseTime int, userID, requestID string) {
 	l.Info("API Call",
 		"method", method,
 		"url", url,
 		"statusCode", statusCode,
 		"responseTime", responseTime,
 		"userID", userID,
+		"requestID", requestID,
 	)
 }
 
@@ -77,9 +124,10 @@ func (l *Logger) LogDatabaseOperation(operation, table string, duration int, use
 	)
 }
 
-func (l *Logger) LogAIGeneration(userID, prompt string, tokensUsed, responseTime int, success bool) {
+func (l *Logger) LogAIGeneration(userID, requestID, prompt string, tokensUsed, responseTime int, success bool) {
 	l.Info("AI Generation",
 		"userID", userID,
+		"requestID", requestID,
 		"promptLength", len(prompt),
 		"tokensUsed", tokensUsed,
 		"responseTime", responseTime,